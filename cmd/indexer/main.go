@@ -6,19 +6,34 @@
 //	indexer --project=myproject --full  # Full reindex
 //	indexer --all                       # Index all projects
 //	indexer --all --full                # Full reindex all projects
+//	indexer --project=myproject --modified-after=2024-01-01T00:00:00Z  # Skip hashing older cached files
+//	indexer --project=myproject --diff  # Report index drift without indexing
+//	indexer --purge-deleted             # Hard-delete expired soft-delete tombstones
+//	indexer --project=myproject --slowest-files=10  # Report the 10 slowest files
+//	indexer --list-projects [--json]    # List configured projects
+//	indexer --stats [--project=myproject]  # Summarize what's in the vector DB
+//	indexer --migrate-payloads [--project=myproject]  # Upgrade stored payloads to the current schema
 package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/iasik/project-indexer/internal/config"
 	"github.com/iasik/project-indexer/internal/embedder"
+	"github.com/iasik/project-indexer/internal/health"
 	"github.com/iasik/project-indexer/internal/indexer"
 	"github.com/iasik/project-indexer/internal/vectordb"
 )
@@ -28,18 +43,40 @@ func main() {
 	projectID := flag.String("project", "", "Project ID to index")
 	fullIndex := flag.Bool("full", false, "Perform full reindex (clear existing)")
 	indexAll := flag.Bool("all", false, "Index all configured projects")
+	modifiedAfter := flag.String("modified-after", "", "Skip hashing cached files not modified after this RFC3339 timestamp (the whole tree is still walked)")
+	diffOnly := flag.Bool("diff", false, "Report added/deleted/modified files vs the index cache without indexing")
+	purgeDeleted := flag.Bool("purge-deleted", false, "Hard-delete soft-deleted chunks older than vectordb.soft_delete.retention")
+	slowest := flag.Int("slowest-files", 0, "Report the N slowest files (path, duration, chunk count) at the end of the run")
+	listProjects := flag.Bool("list-projects", false, "List configured projects (ID, name, source path, team, tags)")
+	jsonOutput := flag.Bool("json", false, "Print --list-projects output as JSON")
+	showStats := flag.Bool("stats", false, "Print vector counts per project, language and symbol type (scoped by --project)")
+	migratePayloads := flag.Bool("migrate-payloads", false, "Back-fill fields of stored points written with an older payload schema (scoped by --project)")
 	flag.Parse()
 
 	// Validate flags
-	if *projectID == "" && !*indexAll {
+	if *projectID == "" && !*indexAll && !*purgeDeleted && !*listProjects && !*showStats && !*migratePayloads {
 		fmt.Fprintln(os.Stderr, "Error: --project or --all is required")
 		fmt.Fprintln(os.Stderr, "Usage:")
 		fmt.Fprintln(os.Stderr, "  indexer --project=myproject         # Incremental index")
 		fmt.Fprintln(os.Stderr, "  indexer --project=myproject --full  # Full reindex")
 		fmt.Fprintln(os.Stderr, "  indexer --all                       # Index all projects")
+		fmt.Fprintln(os.Stderr, "  indexer --purge-deleted             # Purge expired tombstones")
+		fmt.Fprintln(os.Stderr, "  indexer --list-projects [--json]    # List configured projects")
+		fmt.Fprintln(os.Stderr, "  indexer --stats [--project=myproject]  # Summarize the vector DB")
+		fmt.Fprintln(os.Stderr, "  indexer --migrate-payloads [--project=myproject]  # Upgrade stored payloads")
 		os.Exit(1)
 	}
 
+	var modifiedAfterTime time.Time
+	if *modifiedAfter != "" {
+		t, err := time.Parse(time.RFC3339, *modifiedAfter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --modified-after timestamp: %v\n", err)
+			os.Exit(1)
+		}
+		modifiedAfterTime = t
+	}
+
 	// Setup logger
 	logLevel := slog.LevelInfo
 	if os.Getenv("DEBUG") != "" {
@@ -57,10 +94,42 @@ func main() {
 	}
 	cfg := cfgManager.Get()
 
+	// Listing only reads project configs; keep stdout free of log lines
+	if *listProjects {
+		os.Exit(runListProjects(os.Stdout, os.Stderr, cfg.Projects.ConfigDir, *jsonOutput))
+	}
+
 	logger.Info("configuration loaded",
 		"embedding_provider", cfg.Embedding.Provider,
 		"vectordb_provider", cfg.VectorDB.Provider)
 
+	// Diff only reads the source tree and cache; no providers needed
+	if *diffOnly {
+		os.Exit(runDiff(cfg, logger, *projectID, *indexAll))
+	}
+
+	// Purge only talks to the vector database; no embedder needed
+	if *purgeDeleted {
+		os.Exit(runPurge(cfg, logger))
+	}
+
+	// Migration rewrites payloads in place; no embedder needed
+	if *migratePayloads {
+		os.Exit(runMigrate(cfg, logger, *projectID))
+	}
+
+	// Stats only read the vector database
+	if *showStats {
+		vdb, err := vectordb.NewProvider(cfg.VectorDB)
+		if err != nil {
+			logger.Error("failed to create vectordb", "error", err)
+			os.Exit(1)
+		}
+		code := runStats(context.Background(), os.Stdout, os.Stderr, vdb, *projectID)
+		vdb.Close()
+		os.Exit(code)
+	}
+
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -82,18 +151,6 @@ func main() {
 	}
 	defer emb.Close()
 
-	// Check embedder health
-	if err := emb.Health(ctx); err != nil {
-		logger.Error("embedder health check failed", "error", err)
-		logger.Info("hint: ensure embedding model is available",
-			"model", cfg.Embedding.Model,
-			"endpoint", cfg.Embedding.Endpoint)
-		os.Exit(1)
-	}
-	logger.Info("embedder connected",
-		"provider", cfg.Embedding.Provider,
-		"model", cfg.Embedding.Model)
-
 	// Initialize vector database
 	vdb, err := vectordb.NewProvider(cfg.VectorDB)
 	if err != nil {
@@ -102,17 +159,37 @@ func main() {
 	}
 	defer vdb.Close()
 
-	// Check vectordb health
-	if err := vdb.Health(ctx); err != nil {
-		logger.Error("vectordb health check failed", "error", err)
+	// Check embedder and vectordb health concurrently
+	if err := health.WaitAll(ctx, 1, 0,
+		health.Dependency{Name: "embedder", Checker: emb},
+		health.Dependency{Name: "vectordb", Checker: vdb},
+	); err != nil {
+		var depErr *health.DependencyError
+		if errors.As(err, &depErr) {
+			logger.Error("dependency health check failed", "dependency", depErr.Name, "error", depErr.Err)
+			if depErr.Name == "embedder" {
+				logger.Info("hint: ensure embedding model is available",
+					"model", cfg.Embedding.Model,
+					"endpoint", cfg.Embedding.Endpoint)
+			}
+		} else {
+			logger.Error("dependency health check failed", "error", err)
+		}
 		os.Exit(1)
 	}
+	logger.Info("embedder connected",
+		"provider", cfg.Embedding.Provider,
+		"model", cfg.Embedding.Model,
+		"headers", config.RedactHeaders(cfg.Embedding.Headers))
 	logger.Info("vectordb connected",
 		"provider", cfg.VectorDB.Provider,
-		"collection", cfg.VectorDB.CollectionName)
+		"collection", cfg.VectorDB.CollectionName,
+		"headers", config.RedactHeaders(cfg.VectorDB.Headers))
 
 	// Create indexer
 	idx := indexer.NewIndexer(cfg, emb, vdb, logger)
+	idx.SetModifiedAfter(modifiedAfterTime)
+	idx.SetSlowestFiles(*slowest)
 
 	// Ensure collection exists
 	if err := idx.EnsureCollection(ctx); err != nil {
@@ -132,6 +209,8 @@ func main() {
 		fmt.Println("\n=== Indexing Summary ===")
 		totalFiles := 0
 		totalChunks := 0
+		totalTokens := 0
+		totalCost := 0.0
 		hasErrors := false
 
 		for projectID, result := range results {
@@ -139,6 +218,8 @@ func main() {
 			fmt.Printf("  Files indexed: %d\n", result.FilesIndexed)
 			fmt.Printf("  Chunks created: %d\n", result.ChunksCreated)
 			fmt.Printf("  Duration: %s\n", result.Duration)
+			printEmbeddingUsage("  ", result)
+			printSlowestFiles("  ", result)
 
 			if len(result.Errors) > 0 {
 				hasErrors = true
@@ -150,10 +231,13 @@ func main() {
 
 			totalFiles += result.FilesIndexed
 			totalChunks += result.ChunksCreated
+			totalTokens += result.EmbeddingTokens
+			totalCost += result.EstimatedCost
 		}
 
 		fmt.Printf("\nTotal: %d files, %d chunks across %d projects\n",
 			totalFiles, totalChunks, len(results))
+		printEmbeddingUsage("", &indexer.IndexResult{EmbeddingTokens: totalTokens, EstimatedCost: totalCost})
 
 		if hasErrors {
 			os.Exit(1)
@@ -181,11 +265,25 @@ func main() {
 		fmt.Printf("Files deleted: %d\n", result.FilesDeleted)
 		fmt.Printf("Chunks created: %d\n", result.ChunksCreated)
 		fmt.Printf("Chunks deleted: %d\n", result.ChunksDeleted)
+		if result.ChunksSkipped > 0 {
+			fmt.Printf("Chunks skipped (empty): %d\n", result.ChunksSkipped)
+		}
+		if result.ChunksExcluded > 0 {
+			fmt.Printf("Chunks excluded (exclude_symbols): %d\n", result.ChunksExcluded)
+		}
+		if result.ChunksRelocated > 0 {
+			fmt.Printf("Chunks relocated (line numbers updated): %d\n", result.ChunksRelocated)
+		}
+		if result.ChunksSplit > 0 {
+			fmt.Printf("Chunks split (over token limit): %d\n", result.ChunksSplit)
+		}
 		fmt.Printf("Duration: %s\n", result.Duration)
+		printEmbeddingUsage("", result)
+		printSlowestFiles("", result)
 
 		if len(result.OversizedChunks) > 0 {
-			fmt.Printf("Oversized chunks: %d (see data/index-cache/reports/%s-oversized.json)\n", 
-				len(result.OversizedChunks), result.ProjectID)
+			fmt.Printf("Oversized chunks: %d (see %s)\n",
+				len(result.OversizedChunks), result.OversizedReportPath)
 		}
 
 		if len(result.Errors) > 0 {
@@ -199,3 +297,275 @@ func main() {
 
 	logger.Info("indexing completed successfully")
 }
+
+// runDiff prints index drift for one or all projects and returns the exit code
+// (0 = in sync, 2 = drift found, 1 = error).
+func runDiff(cfg *config.Config, logger *slog.Logger, projectID string, all bool) int {
+	idx := indexer.NewIndexer(cfg, nil, nil, logger)
+	ctx := context.Background()
+
+	var projects []*config.ProjectConfig
+	if all {
+		loaded, err := config.LoadAllProjects(cfg.Projects.ConfigDir)
+		if err != nil {
+			logger.Error("failed to load projects", "error", err)
+			return 1
+		}
+		for _, p := range loaded {
+			projects = append(projects, p)
+		}
+		sort.Slice(projects, func(i, j int) bool { return projects[i].ProjectID < projects[j].ProjectID })
+	} else {
+		projectCfg, err := config.GetProject(cfg.Projects.ConfigDir, projectID)
+		if err != nil {
+			logger.Error("failed to load project config", "project", projectID, "error", err)
+			return 1
+		}
+		projects = append(projects, projectCfg)
+	}
+
+	exitCode := 0
+	for _, projectCfg := range projects {
+		diff, err := idx.Diff(ctx, projectCfg)
+		if err != nil {
+			logger.Error("diff failed", "project", projectCfg.ProjectID, "error", err)
+			return 1
+		}
+
+		fmt.Printf("\n=== Index Diff: %s ===\n", diff.ProjectID)
+		printPaths := func(label, marker string, paths []string) {
+			fmt.Printf("%s: %d\n", label, len(paths))
+			for _, p := range paths {
+				fmt.Printf("  %s %s\n", marker, p)
+			}
+		}
+		printPaths("Added (not indexed)", "+", diff.Added)
+		printPaths("Deleted (still indexed)", "-", diff.Deleted)
+		printPaths("Modified (stale)", "~", diff.Modified)
+
+		if diff.HasDrift() {
+			exitCode = 2
+		}
+	}
+	return exitCode
+}
+
+// projectListing is one row of --list-projects output.
+type projectListing struct {
+	ProjectID   string   `json:"project_id"`
+	DisplayName string   `json:"display_name"`
+	SourcePath  string   `json:"source_path"`
+	Team        string   `json:"team,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// runListProjects prints all configured projects as a table or JSON and
+// returns the exit code. Files that fail to load are reported on errOut
+// without hiding the others (exit code 1).
+func runListProjects(out, errOut io.Writer, configDir string, asJSON bool) int {
+	loaded, err := config.LoadAllProjects(configDir)
+	exitCode := 0
+	if err != nil {
+		// Per-file failures are joined; anything else (unreadable dir) is fatal
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			return 1
+		}
+		for _, fileErr := range joined.Unwrap() {
+			fmt.Fprintf(errOut, "Error: %v\n", fileErr)
+		}
+		exitCode = 1
+	}
+
+	listing := make([]projectListing, 0, len(loaded))
+	for _, p := range loaded {
+		listing = append(listing, projectListing{
+			ProjectID:   p.ProjectID,
+			DisplayName: p.DisplayName,
+			SourcePath:  p.SourcePath,
+			Team:        p.Metadata.Team,
+			Tags:        p.Metadata.Tags,
+		})
+	}
+	sort.Slice(listing, func(i, j int) bool { return listing[i].ProjectID < listing[j].ProjectID })
+
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(listing); err != nil {
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			return 1
+		}
+		return exitCode
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT ID\tDISPLAY NAME\tSOURCE PATH\tTEAM\tTAGS")
+	for _, p := range listing {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p.ProjectID, p.DisplayName, p.SourcePath, p.Team, strings.Join(p.Tags, ","))
+	}
+	tw.Flush()
+	return exitCode
+}
+
+// statsCount is one row of a --stats breakdown.
+type statsCount struct {
+	Name  string
+	Count int
+}
+
+// runStats prints the total vector count and per-project, language and
+// symbol-type breakdowns, optionally scoped to one project, and returns the
+// exit code.
+func runStats(ctx context.Context, out, errOut io.Writer, vdb vectordb.Provider, projectID string) int {
+	caps := vdb.Capabilities()
+	if !caps.Count || !caps.Scroll {
+		fmt.Fprintln(errOut, "Error: vector database provider does not support counting and scrolling points")
+		return 1
+	}
+
+	filter := vectordb.Filter{ProjectID: projectID}
+	total, err := vdb.Count(ctx, filter)
+	if err != nil {
+		fmt.Fprintf(errOut, "Error: count vectors: %v\n", err)
+		return 1
+	}
+
+	byProject := make(map[string]int)
+	byLanguage := make(map[string]int)
+	bySymbolType := make(map[string]int)
+	if total > 0 {
+		// Page through the breakdown fields only; content is never loaded
+		query := vectordb.ScrollQuery{Filter: filter, Fields: []string{"project_id", "language", "symbol_type"}}
+		err := vectordb.ScrollAll(ctx, vdb, query, func(points []vectordb.SearchResult) error {
+			for _, p := range points {
+				byProject[p.Payload.ProjectID]++
+				byLanguage[p.Payload.Language]++
+				bySymbolType[p.Payload.SymbolType]++
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(errOut, "Error: scroll vectors: %v\n", err)
+			return 1
+		}
+	}
+
+	title := "all projects"
+	if projectID != "" {
+		title = projectID
+	}
+	fmt.Fprintf(out, "=== Vector DB Stats: %s ===\n", title)
+	fmt.Fprintf(out, "Total vectors: %d\n", total)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	printBreakdown := func(label string, counts map[string]int) {
+		fmt.Fprintf(tw, "\n%s:\n", label)
+		for _, c := range sortedCounts(counts) {
+			fmt.Fprintf(tw, "  %s\t%d\n", c.Name, c.Count)
+		}
+	}
+	if projectID == "" {
+		printBreakdown("Projects", byProject)
+	}
+	printBreakdown("Languages", byLanguage)
+	printBreakdown("Symbol types", bySymbolType)
+	tw.Flush()
+	return 0
+}
+
+// sortedCounts returns counts largest first (ties by name); empty names are
+// shown as "(none)".
+func sortedCounts(counts map[string]int) []statsCount {
+	rows := make([]statsCount, 0, len(counts))
+	for name, count := range counts {
+		if name == "" {
+			name = "(none)"
+		}
+		rows = append(rows, statsCount{Name: name, Count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// runMigrate upgrades stored payloads to the current schema version and
+// returns the exit code.
+func runMigrate(cfg *config.Config, logger *slog.Logger, projectID string) int {
+	ctx := context.Background()
+
+	vdb, err := vectordb.NewProvider(cfg.VectorDB)
+	if err != nil {
+		logger.Error("failed to create vectordb", "error", err)
+		return 1
+	}
+	defer vdb.Close()
+
+	idx := indexer.NewIndexer(cfg, nil, vdb, logger)
+	result, err := idx.MigratePayloads(ctx, projectID)
+	if err != nil {
+		logger.Error("payload migration failed", "error", err)
+		return 1
+	}
+
+	logger.Info("payload migration complete",
+		"project", projectID,
+		"payload_version", vectordb.PayloadVersion,
+		"scanned", result.Scanned,
+		"migrated", result.Migrated,
+		"skipped", result.Skipped)
+	return 0
+}
+
+// runPurge hard-deletes soft-delete tombstones older than the configured
+// retention period and returns the exit code.
+func runPurge(cfg *config.Config, logger *slog.Logger) int {
+	ctx := context.Background()
+
+	vdb, err := vectordb.NewProvider(cfg.VectorDB)
+	if err != nil {
+		logger.Error("failed to create vectordb", "error", err)
+		return 1
+	}
+	defer vdb.Close()
+
+	retention := cfg.VectorDB.SoftDelete.GetRetention()
+	cutoff := time.Now().Add(-retention)
+	if err := vdb.PurgeDeleted(ctx, cutoff); err != nil {
+		logger.Error("purge failed", "error", err)
+		return 1
+	}
+
+	logger.Info("purged soft-deleted chunks",
+		"retention", retention.String(),
+		"deleted_before", cutoff.UTC().Format(time.RFC3339))
+	return 0
+}
+
+// printEmbeddingUsage prints embedding token usage and, when a price is
+// configured, the estimated cost. Nothing is printed if no usage was reported.
+func printEmbeddingUsage(indent string, result *indexer.IndexResult) {
+	if result.EmbeddingTokens == 0 {
+		return
+	}
+	fmt.Printf("%sEmbedding tokens: %d\n", indent, result.EmbeddingTokens)
+	if result.EstimatedCost > 0 {
+		fmt.Printf("%sEstimated embedding cost: $%.4f\n", indent, result.EstimatedCost)
+	}
+}
+
+// printSlowestFiles prints the slowest files recorded with --slowest-files.
+func printSlowestFiles(indent string, result *indexer.IndexResult) {
+	if len(result.SlowestFiles) == 0 {
+		return
+	}
+	fmt.Printf("%sSlowest files:\n", indent)
+	for _, f := range result.SlowestFiles {
+		fmt.Printf("%s  %s  %s (%d chunks)\n", indent, f.Duration.Round(time.Millisecond), f.FilePath, f.Chunks)
+	}
+}
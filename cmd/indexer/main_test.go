@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+func writeProjectFixtures(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"crm.yaml": `project_id: crm-backend
+display_name: CRM Backend
+source_path: crm
+include_extensions: [".go"]
+metadata:
+  team: sales
+  tags: [api, billing]
+`,
+		"docs.yaml": `project_id: docs
+display_name: Docs
+source_path: docs
+include_extensions: [".md"]
+`,
+		"broken.yaml": "project_id: Not Valid\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRunListProjects_Table(t *testing.T) {
+	dir := writeProjectFixtures(t)
+
+	var out, errOut bytes.Buffer
+	if code := runListProjects(&out, &errOut, dir, false); code != 1 {
+		t.Errorf("exit code = %d, want 1 (broken.yaml)", code)
+	}
+
+	for _, want := range []string{"PROJECT ID", "crm-backend", "CRM Backend", "crm", "sales", "api,billing", "docs"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("table missing %q:\n%s", want, out.String())
+		}
+	}
+	if !strings.Contains(errOut.String(), "broken.yaml") {
+		t.Errorf("expected per-file error for broken.yaml, got %q", errOut.String())
+	}
+}
+
+func TestRunListProjects_JSON(t *testing.T) {
+	dir := writeProjectFixtures(t)
+	os.Remove(filepath.Join(dir, "broken.yaml"))
+
+	var out, errOut bytes.Buffer
+	if code := runListProjects(&out, &errOut, dir, true); code != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", code, errOut.String())
+	}
+
+	var listing []projectListing
+	if err := json.Unmarshal(out.Bytes(), &listing); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(listing) != 2 {
+		t.Fatalf("got %d projects, want 2", len(listing))
+	}
+	crm := listing[0]
+	if crm.ProjectID != "crm-backend" || crm.DisplayName != "CRM Backend" || crm.SourcePath != "crm" ||
+		crm.Team != "sales" || strings.Join(crm.Tags, ",") != "api,billing" {
+		t.Errorf("unexpected listing: %+v", crm)
+	}
+	if listing[1].ProjectID != "docs" {
+		t.Errorf("listing not sorted by ID: %+v", listing)
+	}
+}
+
+func seedStatsDB(t *testing.T) *vectordb.MemoryProvider {
+	t.Helper()
+	seed := []struct {
+		project, language, symbolType string
+		n                             int
+	}{
+		{"crm-backend", "go", "function", 5},
+		{"crm-backend", "go", "method", 3},
+		{"crm-backend", "sql", "table", 2},
+		{"docs", "markdown", "section", 4},
+	}
+	var points []vectordb.Point
+	for _, s := range seed {
+		for i := 0; i < s.n; i++ {
+			points = append(points, vectordb.Point{
+				ID:     fmt.Sprintf("%s-%s-%d", s.project, s.symbolType, i),
+				Vector: []float32{1, 0},
+				Payload: vectordb.Payload{
+					ProjectID:  s.project,
+					FilePath:   fmt.Sprintf("f%d", i),
+					Language:   s.language,
+					SymbolType: s.symbolType,
+				},
+			})
+		}
+	}
+	vdb := vectordb.NewMemoryProvider()
+	if err := vdb.Upsert(context.Background(), points); err != nil {
+		t.Fatal(err)
+	}
+	return vdb
+}
+
+// statsLines returns the output lines with runs of spaces collapsed.
+func statsLines(out string) []string {
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	return lines
+}
+
+func TestRunStats_AllProjects(t *testing.T) {
+	vdb := seedStatsDB(t)
+
+	var out, errOut bytes.Buffer
+	if code := runStats(context.Background(), &out, &errOut, vdb, ""); code != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", code, errOut.String())
+	}
+
+	got := strings.Join(statsLines(out.String()), "\n")
+	want := `=== Vector DB Stats: all projects ===
+Total vectors: 14
+
+Projects:
+crm-backend 10
+docs 4
+
+Languages:
+go 8
+markdown 4
+sql 2
+
+Symbol types:
+function 5
+section 4
+method 3
+table 2
+`
+	if got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRunStats_Project(t *testing.T) {
+	vdb := seedStatsDB(t)
+
+	var out, errOut bytes.Buffer
+	if code := runStats(context.Background(), &out, &errOut, vdb, "docs"); code != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", code, errOut.String())
+	}
+
+	lines := statsLines(out.String())
+	for _, want := range []string{"=== Vector DB Stats: docs ===", "Total vectors: 4", "markdown 4", "section 4"} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Projects:") || strings.Contains(out.String(), "crm-backend") {
+		t.Errorf("expected output scoped to docs:\n%s", out.String())
+	}
+}
+
+// scrollRecorder records the scroll queries made against a provider.
+type scrollRecorder struct {
+	*vectordb.MemoryProvider
+	queries []vectordb.ScrollQuery
+}
+
+func (r *scrollRecorder) Scroll(ctx context.Context, filter vectordb.Filter, limit int) ([]vectordb.SearchResult, error) {
+	r.queries = append(r.queries, vectordb.ScrollQuery{Filter: filter, Limit: limit})
+	return r.MemoryProvider.Scroll(ctx, filter, limit)
+}
+
+func (r *scrollRecorder) ScrollPage(ctx context.Context, query vectordb.ScrollQuery) ([]vectordb.SearchResult, string, error) {
+	r.queries = append(r.queries, query)
+	return r.MemoryProvider.ScrollPage(ctx, query)
+}
+
+func TestRunStats_PagesBreakdownFields(t *testing.T) {
+	vdb := &scrollRecorder{MemoryProvider: seedStatsDB(t)}
+
+	var out, errOut bytes.Buffer
+	if code := runStats(context.Background(), &out, &errOut, vdb, ""); code != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", code, errOut.String())
+	}
+
+	if len(vdb.queries) == 0 {
+		t.Fatal("Expected the breakdowns to be scrolled")
+	}
+	for _, q := range vdb.queries {
+		if q.Limit <= 0 {
+			t.Errorf("Expected a bounded page, got %+v", q)
+		}
+		if !slices.Equal(q.Fields, []string{"project_id", "language", "symbol_type"}) {
+			t.Errorf("Expected only the breakdown fields to be fetched, got %v", q.Fields)
+		}
+	}
+}
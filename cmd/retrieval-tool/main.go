@@ -15,14 +15,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/iasik/project-indexer/internal/api"
 	"github.com/iasik/project-indexer/internal/config"
 	"github.com/iasik/project-indexer/internal/embedder"
+	"github.com/iasik/project-indexer/internal/health"
+	"github.com/iasik/project-indexer/internal/reranker"
 	"github.com/iasik/project-indexer/internal/vectordb"
 )
 
@@ -77,31 +81,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Check embedder health (with retries for startup)
-	logger.Info("waiting for embedder...", "endpoint", cfg.Embedding.Endpoint)
-	for i := 0; i < 30; i++ {
-		if err := emb.Health(ctx); err == nil {
-			break
-		}
-		if i == 29 {
-			logger.Error("embedder health check failed after retries", "error", err)
-			os.Exit(1)
-		}
-		select {
-		case <-ctx.Done():
-			os.Exit(0)
-		case <-make(chan struct{}):
-		default:
-			// Sleep 1 second between retries
-			sleepCtx, sleepCancel := context.WithTimeout(ctx, 1e9)
-			<-sleepCtx.Done()
-			sleepCancel()
-		}
-	}
-	logger.Info("embedder connected",
-		"provider", cfg.Embedding.Provider,
-		"model", cfg.Embedding.Model)
-
 	// Initialize vector database
 	vdb, err := vectordb.NewProvider(cfg.VectorDB)
 	if err != nil {
@@ -109,28 +88,43 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Check vectordb health (with retries for startup)
-	logger.Info("waiting for vectordb...", "endpoint", cfg.VectorDB.Endpoint)
-	for i := 0; i < 30; i++ {
-		if err := vdb.Health(ctx); err == nil {
-			break
-		}
-		if i == 29 {
-			logger.Error("vectordb health check failed after retries", "error", err)
-			os.Exit(1)
-		}
-		select {
-		case <-ctx.Done():
+	// Initialize the optional reranker (no-op when unconfigured)
+	rr, err := reranker.NewReranker(cfg.Rerank)
+	if err != nil {
+		logger.Error("failed to create reranker", "error", err)
+		os.Exit(1)
+	}
+
+	// Wait for both dependencies concurrently (with retries for startup)
+	logger.Info("waiting for dependencies...",
+		"embedder_endpoint", cfg.Embedding.Endpoint,
+		"vectordb_endpoint", cfg.VectorDB.Endpoint)
+	if err := health.WaitAll(ctx, 30, time.Second,
+		health.Dependency{Name: "embedder", Checker: emb},
+		health.Dependency{Name: "vectordb", Checker: vdb},
+	); err != nil {
+		if ctx.Err() != nil {
 			os.Exit(0)
-		default:
-			sleepCtx, sleepCancel := context.WithTimeout(ctx, 1e9)
-			<-sleepCtx.Done()
-			sleepCancel()
 		}
+		var depErr *health.DependencyError
+		if errors.As(err, &depErr) {
+			logger.Error("dependency health check failed",
+				"dependency", depErr.Name,
+				"attempts", depErr.Attempts,
+				"error", depErr.Err)
+		} else {
+			logger.Error("dependency health check failed", "error", err)
+		}
+		os.Exit(1)
 	}
+	logger.Info("embedder connected",
+		"provider", cfg.Embedding.Provider,
+		"model", cfg.Embedding.Model,
+		"headers", config.RedactHeaders(cfg.Embedding.Headers))
 	logger.Info("vectordb connected",
 		"provider", cfg.VectorDB.Provider,
-		"collection", cfg.VectorDB.CollectionName)
+		"collection", cfg.VectorDB.CollectionName,
+		"headers", config.RedactHeaders(cfg.VectorDB.Headers))
 
 	// Ensure collection exists
 	if err := vdb.EnsureCollection(ctx, cfg.Embedding.Dimensions); err != nil {
@@ -139,6 +133,10 @@ func main() {
 
 	// Create and start server
 	server := api.NewServer(cfgManager, emb, vdb, logger)
+	server.SetReranker(rr)
+	if cfg.Rerank.Provider != "" {
+		logger.Info("reranker enabled", "provider", cfg.Rerank.Provider, "top_n", rr.TopN())
+	}
 
 	if err := server.Start(ctx); err != nil {
 		logger.Error("server error", "error", err)
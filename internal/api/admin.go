@@ -0,0 +1,99 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// MaintenanceRequest is the request body for POST /admin/maintenance.
+type MaintenanceRequest struct {
+	// Enabled turns maintenance mode on or off
+	Enabled bool `json:"enabled"`
+}
+
+// MaintenanceResponse is the response body for POST /admin/maintenance.
+type MaintenanceResponse struct {
+	Maintenance bool `json:"maintenance"`
+}
+
+// InvalidateCacheRequest is the request body for POST /admin/cache/invalidate.
+type InvalidateCacheRequest struct {
+	// ProjectID is the reindexed project whose cached results are dropped
+	ProjectID string `json:"project_id"`
+}
+
+// InvalidateCacheResponse is the response body for POST /admin/cache/invalidate.
+type InvalidateCacheResponse struct {
+	ProjectID string `json:"project_id"`
+
+	// Generation is the project's new result cache generation (0 when the
+	// result cache is disabled)
+	Generation uint64 `json:"generation"`
+}
+
+// requireAdmin wraps a handler with bearer-token authentication.
+// Admin endpoints are disabled (403) when no admin token is configured.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serverCfg := s.cfg.Get().Server
+		token := serverCfg.GetAdminToken()
+		if token == "" {
+			s.writeErrorWithCode(w, http.StatusForbidden, "admin endpoints are disabled", ErrCodeUnauthorized)
+			return
+		}
+
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			s.writeErrorWithCode(w, http.StatusUnauthorized, "invalid admin token", ErrCodeUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// handleMaintenance handles POST /admin/maintenance requests.
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErrorWithCode(w, http.StatusBadRequest, "invalid request body: "+err.Error(), ErrCodeInvalidRequest)
+		return
+	}
+
+	if s.maintenance.Swap(req.Enabled) != req.Enabled {
+		s.logger.Info("maintenance mode changed", "enabled", req.Enabled)
+	}
+
+	s.writeJSON(w, http.StatusOK, MaintenanceResponse{Maintenance: req.Enabled})
+}
+
+// handleLivez handles GET /livez requests. It only reports that the process
+// is serving HTTP, independent of dependencies and maintenance mode.
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "alive"})
+}
+
+// handleInvalidateCache handles POST /admin/cache/invalidate requests.
+// Index runs invalidate a project's results on their own; this forces it,
+// e.g. after the vector DB was changed outside the indexer.
+func (s *Server) handleInvalidateCache(w http.ResponseWriter, r *http.Request) {
+	var req InvalidateCacheRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErrorWithCode(w, http.StatusBadRequest, "invalid request body: "+err.Error(), ErrCodeInvalidRequest)
+		return
+	}
+	if req.ProjectID == "" {
+		s.writeErrorWithCode(w, http.StatusBadRequest, "project_id is required", ErrCodeMissingField)
+		return
+	}
+
+	resp := InvalidateCacheResponse{ProjectID: req.ProjectID}
+	if s.results != nil {
+		resp.Generation = s.results.invalidate(req.ProjectID)
+		s.logger.Info("result cache invalidated", "project", req.ProjectID, "generation", resp.Generation)
+	}
+
+	s.writeJSON(w, http.StatusOK, resp)
+}
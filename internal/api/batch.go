@@ -0,0 +1,186 @@
+// Package api provides batch retrieval for multiple queries in one request.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+// maxBatchQueries bounds the number of sub-queries per batch request.
+const maxBatchQueries = 20
+
+// BatchRetrieveRequest is the request body for POST /retrieve/batch.
+// Shared options apply to every sub-query.
+type BatchRetrieveRequest struct {
+	// ProjectID is the project to search in (required)
+	ProjectID string `json:"project_id"`
+
+	// Queries are the natural language sub-queries (required, max 20)
+	Queries []string `json:"queries"`
+
+	// TopK, ScoreThreshold, Filters, ResolveContent, IncludeNeighbors,
+	// Expand, DedupThreshold, Diversity, DebugScores, Highlight and
+	// ContextLines behave as in RetrieveRequest
+	TopK             int              `json:"top_k,omitempty"`
+	ScoreThreshold   *float32         `json:"score_threshold,omitempty"`
+	Filters          *RetrieveFilters `json:"filters,omitempty"`
+	ResolveContent   bool             `json:"resolve_content,omitempty"`
+	IncludeNeighbors bool             `json:"include_neighbors,omitempty"`
+	Expand           string           `json:"expand,omitempty"`
+	DedupThreshold   float32          `json:"dedup_threshold,omitempty"`
+	Diversity        float32          `json:"diversity,omitempty"`
+	DebugScores      bool             `json:"debug_scores,omitempty"`
+	Highlight        bool             `json:"highlight,omitempty"`
+	ContextLines     int              `json:"context_lines,omitempty"`
+
+	// MinBestScore marks a sub-query as no_match (with no results) when its
+	// best result scores below this floor (0 = disabled)
+	MinBestScore float32 `json:"min_best_score,omitempty"`
+}
+
+// BatchRetrieveResponse is the response body for POST /retrieve/batch.
+type BatchRetrieveResponse struct {
+	// Results holds one entry per sub-query, in request order
+	Results []BatchQueryResult `json:"results"`
+
+	// QueryTimeMs is the total execution time in milliseconds
+	QueryTimeMs int64 `json:"query_time_ms"`
+
+	// ProjectNotIndexed is set when the project is configured but has no
+	// indexed chunks (only with server.validate_projects)
+	ProjectNotIndexed bool `json:"project_not_indexed,omitempty"`
+}
+
+// BatchQueryResult is the outcome of a single sub-query.
+type BatchQueryResult struct {
+	// Query is the sub-query text
+	Query string `json:"query"`
+
+	// Results contains the retrieved chunks (empty when NoMatch)
+	Results []RetrieveResult `json:"results"`
+
+	// NoMatch is set when the best result scored below min_best_score
+	NoMatch bool `json:"no_match,omitempty"`
+
+	// Intent is the detected query intent when intent routing applied
+	Intent string `json:"intent,omitempty"`
+
+	// EmbeddingTokens is the query embedding token usage, if reported
+	EmbeddingTokens int `json:"embedding_tokens,omitempty"`
+}
+
+// handleRetrieveBatch handles POST /retrieve/batch requests.
+func (s *Server) handleRetrieveBatch(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+
+	if s.maintenance.Load() {
+		s.writeErrorWithCode(w, http.StatusServiceUnavailable, "server is in maintenance mode", ErrCodeMaintenance)
+		return
+	}
+
+	var req BatchRetrieveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErrorWithCode(w, http.StatusBadRequest, "invalid request body: "+err.Error(), ErrCodeInvalidRequest)
+		return
+	}
+
+	if req.ProjectID == "" {
+		s.writeErrorWithCode(w, http.StatusBadRequest, "project_id is required", ErrCodeMissingField)
+		return
+	}
+	if len(req.Queries) == 0 {
+		s.writeErrorWithCode(w, http.StatusBadRequest, "queries is required", ErrCodeMissingField)
+		return
+	}
+	if len(req.Queries) > maxBatchQueries {
+		s.writeErrorWithCode(w, http.StatusBadRequest,
+			fmt.Sprintf("at most %d queries per batch", maxBatchQueries), ErrCodeInvalidRequest)
+		return
+	}
+	for i, q := range req.Queries {
+		if q == "" {
+			s.writeErrorWithCode(w, http.StatusBadRequest, fmt.Sprintf("queries[%d] is empty", i), ErrCodeMissingField)
+			return
+		}
+	}
+
+	validateProjects := s.cfg.Get().Server.ValidateProjects
+	if validateProjects {
+		if rerr := s.checkProjectKnown(req.ProjectID); rerr != nil {
+			s.writeErrorWithCode(w, rerr.status, rerr.message, rerr.code)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	// Embed every sub-query, then run all searches in one provider call
+	subRequests := make([]*RetrieveRequest, len(req.Queries))
+	plans := make([]*retrievePlan, len(req.Queries))
+	queries := make([]vectordb.SearchQuery, len(req.Queries))
+	for i, query := range req.Queries {
+		subRequests[i] = &RetrieveRequest{
+			ProjectID:        req.ProjectID,
+			Query:            query,
+			TopK:             req.TopK,
+			ScoreThreshold:   req.ScoreThreshold,
+			Filters:          req.Filters,
+			ResolveContent:   req.ResolveContent,
+			IncludeNeighbors: req.IncludeNeighbors,
+			Expand:           req.Expand,
+			DedupThreshold:   req.DedupThreshold,
+			Diversity:        req.Diversity,
+			DebugScores:      req.DebugScores,
+			Highlight:        req.Highlight,
+			ContextLines:     req.ContextLines,
+		}
+		plan, rerr := s.planRetrieve(ctx, subRequests[i])
+		if rerr != nil {
+			s.writeErrorWithCode(w, rerr.status, fmt.Sprintf("queries[%d]: %s", i, rerr.message), rerr.code)
+			return
+		}
+		plans[i] = plan
+		queries[i] = plan.query
+	}
+
+	_, vdb, release := s.acquireProviders()
+	defer release()
+	searchResults, err := vdb.SearchBatch(ctx, queries)
+	if err != nil {
+		s.logger.Error("batch search failed", "error", err)
+		s.writeErrorWithCode(w, http.StatusInternalServerError, "search failed", ErrCodeSearchFailed)
+		return
+	}
+
+	response := BatchRetrieveResponse{Results: make([]BatchQueryResult, len(req.Queries))}
+	anyResults := false
+	for i, query := range req.Queries {
+		sub := s.finishRetrieve(ctx, subRequests[i], plans[i], searchResults[i])
+		anyResults = anyResults || len(sub.Results) > 0
+
+		result := BatchQueryResult{
+			Query:           query,
+			Results:         sub.Results,
+			Intent:          sub.Intent,
+			EmbeddingTokens: sub.EmbeddingTokens,
+		}
+		// Results are sorted, so the first one is the best match
+		if req.MinBestScore > 0 && (len(sub.Results) == 0 || sub.Results[0].Score < req.MinBestScore) {
+			result.Results = []RetrieveResult{}
+			result.NoMatch = true
+		}
+		response.Results[i] = result
+	}
+	if validateProjects && !anyResults {
+		response.ProjectNotIndexed = s.projectNotIndexed(ctx, vdb, req.ProjectID)
+	}
+	response.QueryTimeMs = time.Since(startTime).Milliseconds()
+
+	s.writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iasik/project-indexer/internal/indexer"
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+// maxContextLines caps context_lines to bound response size.
+const maxContextLines = 50
+
+// attachContextLines sets ContextBefore/ContextAfter on results to up to n
+// source lines around each chunk. Results are left without context when the
+// file is gone or changed since indexing: its hash must match the index
+// cache, or, for files the cache doesn't know, the chunk's lines must still
+// hash to the indexed chunk content.
+func (s *Server) attachContextLines(projectID string, searchResults []vectordb.SearchResult, results []RetrieveResult, n int) {
+	cfg := s.cfg.Get()
+
+	projectCfg, err := s.projects.Get(cfg.Projects.ConfigDir, projectID)
+	if err != nil {
+		s.logger.Warn("cannot attach context lines, project config not found", "project", projectID, "error", err)
+		return
+	}
+	sourceRoot := projectCfg.GetPathRoot(projectCfg.GetFullSourcePath(cfg.Projects.SourceBasePath))
+
+	cache, err := indexer.NewCache(cfg.Cache.Dir, projectID)
+	if err != nil {
+		s.logger.Warn("failed to read index cache, checking chunk hashes only", "project", projectID, "error", err)
+	}
+
+	// Read each file once; nil lines mark files that are gone or changed
+	files := make(map[string][]string)
+	fileLines := func(relPath string) []string {
+		if lines, ok := files[relPath]; ok {
+			return lines
+		}
+		var lines []string
+		data, err := readSourceFile(sourceRoot, relPath)
+		if err != nil {
+			s.logger.Debug("no context lines, source file unreadable", "source", relPath, "error", err)
+		} else if entry, ok := cacheEntry(cache, relPath); ok && entry.ContentHash != fmt.Sprintf("%x", sha256.Sum256(data)) {
+			s.logger.Debug("no context lines, source file changed since indexing", "source", relPath)
+		} else {
+			lines = strings.Split(string(data), "\n")
+		}
+		files[relPath] = lines
+		return lines
+	}
+
+	for i, sr := range searchResults {
+		p := sr.Payload
+		if p.StartLine < 1 || p.EndLine < p.StartLine {
+			continue
+		}
+		lines := fileLines(p.FilePath)
+		if p.EndLine > len(lines) {
+			continue
+		}
+		if _, cached := cacheEntry(cache, p.FilePath); !cached && !chunkLinesMatch(p, lines[p.StartLine-1:p.EndLine]) {
+			continue
+		}
+
+		before := lines[max(0, p.StartLine-1-n) : p.StartLine-1]
+		after := lines[p.EndLine:min(len(lines), p.EndLine+n)]
+		results[i].ContextBefore = strings.Join(before, "\n")
+		results[i].ContextAfter = strings.Join(after, "\n")
+	}
+}
+
+// cacheEntry looks up a file in the index cache, if one could be read.
+func cacheEntry(cache *indexer.Cache, relPath string) (indexer.CacheEntry, bool) {
+	if cache == nil {
+		return indexer.CacheEntry{}, false
+	}
+	return cache.Get(relPath)
+}
+
+// chunkLinesMatch reports whether lines hash to the indexed chunk content.
+func chunkLinesMatch(p vectordb.Payload, lines []string) bool {
+	indexed := p.ExactHash
+	if indexed == "" {
+		indexed = p.ContentHash
+	}
+	return indexed != "" && indexed == fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(lines, "\n"))))
+}
+
+// readSourceFile reads a file under root. Paths escaping root are rejected.
+func readSourceFile(root, relPath string) ([]byte, error) {
+	absPath, err := sourceFilePath(root, relPath)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(absPath)
+}
@@ -0,0 +1,91 @@
+// Package api provides the query embedding debug endpoint.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/iasik/project-indexer/internal/embedder"
+)
+
+// maxEmbedInputChars bounds the text accepted by /embed.
+const maxEmbedInputChars = 8192
+
+// EmbedRequest is the request body for POST /embed. GET /embed takes the
+// text as the "text" query parameter instead.
+type EmbedRequest struct {
+	// Text is embedded exactly as a /retrieve query would be (required)
+	Text string `json:"text"`
+}
+
+// EmbedResponse is the response body for /embed.
+type EmbedResponse struct {
+	// Vector is the raw query embedding
+	Vector []float32 `json:"vector"`
+
+	// Dimensions is the length of Vector
+	Dimensions int `json:"dimensions"`
+
+	// Model describes the embedder that produced the vector
+	Model EmbedModel `json:"model"`
+
+	// EmbeddingTokens is the token usage, if the provider reports it
+	EmbeddingTokens int `json:"embedding_tokens,omitempty"`
+}
+
+// EmbedModel is the embedding model metadata returned by /embed.
+type EmbedModel struct {
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+}
+
+// handleEmbed handles GET/POST /embed requests. It returns the query vector
+// the server would search with, without running a search.
+func (s *Server) handleEmbed(w http.ResponseWriter, r *http.Request) {
+	var req EmbedRequest
+	if r.Method == http.MethodGet {
+		req.Text = r.URL.Query().Get("text")
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErrorWithCode(w, http.StatusBadRequest, "invalid request body: "+err.Error(), ErrCodeInvalidRequest)
+		return
+	}
+
+	if req.Text == "" {
+		s.writeErrorWithCode(w, http.StatusBadRequest, "text is required", ErrCodeMissingField)
+		return
+	}
+	if len(req.Text) > maxEmbedInputChars {
+		s.writeErrorWithCode(w, http.StatusBadRequest,
+			fmt.Sprintf("text exceeds %d characters", maxEmbedInputChars), ErrCodeInvalidRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	emb, _, release := s.acquireProviders()
+	defer release()
+	usage := &embedder.Usage{}
+	vector, err := emb.Embed(embedder.WithUsage(ctx, usage), req.Text)
+	if err != nil {
+		s.logger.Error("embedding failed", "error", err)
+		s.writeErrorWithCode(w, http.StatusInternalServerError, "failed to embed text", ErrCodeEmbeddingFailed)
+		return
+	}
+
+	info := emb.ModelInfo()
+	s.writeJSON(w, http.StatusOK, EmbedResponse{
+		Vector:     vector,
+		Dimensions: len(vector),
+		Model: EmbedModel{
+			Provider:   info.Provider,
+			Model:      info.Model,
+			Dimensions: info.Dimensions,
+		},
+		EmbeddingTokens: usage.Tokens(),
+	})
+}
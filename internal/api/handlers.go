@@ -4,9 +4,16 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/iasik/project-indexer/internal/config"
+	"github.com/iasik/project-indexer/internal/embedder"
+	"github.com/iasik/project-indexer/internal/indexer"
 	"github.com/iasik/project-indexer/internal/vectordb"
 )
 
@@ -18,11 +25,44 @@ type RetrieveRequest struct {
 	// Query is the natural language search query
 	Query string `json:"query"`
 
-	// TopK is the number of results to return (default: 5, max: 20)
+	// TopK is the number of results to return (default: project/server default, max: 20)
 	TopK int `json:"top_k,omitempty"`
 
+	// ScoreThreshold is the minimum similarity score (default: project/server default)
+	ScoreThreshold *float32 `json:"score_threshold,omitempty"`
+
 	// Filters for narrowing search results
 	Filters *RetrieveFilters `json:"filters,omitempty"`
+
+	// ResolveContent reads missing content from the project source by file:line
+	// (used when the index is configured with vectordb.store_content: false)
+	ResolveContent bool `json:"resolve_content,omitempty"`
+
+	// IncludeNeighbors attaches the preceding and following chunk from the same file
+	IncludeNeighbors bool `json:"include_neighbors,omitempty"`
+
+	// Expand "class" attaches the enclosing class chunk to method results
+	Expand string `json:"expand,omitempty"`
+
+	// DedupThreshold drops results whose vector has at least this cosine
+	// similarity to a higher-ranked result (0 = disabled)
+	DedupThreshold float32 `json:"dedup_threshold,omitempty"`
+
+	// Diversity re-ranks candidates with Maximal Marginal Relevance: the
+	// weight (0-1) given to dissimilarity from already selected results
+	// over query relevance (0 = disabled)
+	Diversity float32 `json:"diversity,omitempty"`
+
+	// DebugScores adds raw_score (the provider similarity before boosting
+	// and reranking) to each result
+	DebugScores bool `json:"debug_scores,omitempty"`
+
+	// Highlight adds the ranges of content where query terms matched
+	Highlight bool `json:"highlight,omitempty"`
+
+	// ContextLines adds up to this many source lines before and after each
+	// result, read from the project source (0 = disabled, max 50)
+	ContextLines int `json:"context_lines,omitempty"`
 }
 
 // RetrieveFilters contains optional filters for search.
@@ -35,6 +75,21 @@ type RetrieveFilters struct {
 
 	// SymbolType filters by symbol type (function, struct, etc.)
 	SymbolType string `json:"symbol_type,omitempty"`
+
+	// FilePath filters by exact file path, or by directory when it ends
+	// with "/" (e.g. "src/auth/" matches every file under src/auth)
+	FilePath string `json:"file_path,omitempty"`
+
+	// Owner filters by file owner (requires ownership capture at index time)
+	Owner string `json:"owner,omitempty"`
+
+	// Custom filters by project custom metadata; every key/value must match
+	Custom map[string]string `json:"custom,omitempty"`
+
+	// MinLines and MaxLines keep only chunks spanning that many lines
+	// (end_line - start_line + 1); 0 means unbounded
+	MinLines int `json:"min_lines,omitempty"`
+	MaxLines int `json:"max_lines,omitempty"`
 }
 
 // RetrieveResponse is the response body for POST /retrieve.
@@ -44,6 +99,19 @@ type RetrieveResponse struct {
 
 	// QueryTimeMs is the query execution time in milliseconds
 	QueryTimeMs int64 `json:"query_time_ms"`
+
+	// Intent is the detected query intent (docs | code) when intent routing applied
+	Intent string `json:"intent,omitempty"`
+
+	// EmbeddingTokens is the query embedding token usage, if the provider reports it
+	EmbeddingTokens int `json:"embedding_tokens,omitempty"`
+
+	// Cached is set when the response was served from the result cache
+	Cached bool `json:"cached,omitempty"`
+
+	// ProjectNotIndexed is set when the project is configured but has no
+	// indexed chunks (only with server.validate_projects)
+	ProjectNotIndexed bool `json:"project_not_indexed,omitempty"`
 }
 
 // RetrieveResult is a single search result.
@@ -54,6 +122,10 @@ type RetrieveResult struct {
 	// Source is the file path
 	Source string `json:"source"`
 
+	// Sources lists every file of a chunk merged from sibling files
+	// (chunking.merge_sibling_files); Source is the first
+	Sources []string `json:"sources,omitempty"`
+
 	// Symbol is the function/struct/heading name
 	Symbol string `json:"symbol,omitempty"`
 
@@ -75,58 +147,224 @@ type RetrieveResult struct {
 	// EndLine is the ending line number in the file
 	EndLine int `json:"end_line,omitempty"`
 
+	// Owner is the file owner, if captured at index time
+	Owner string `json:"owner,omitempty"`
+
+	// ParentSymbol is the enclosing class/type of a method result
+	ParentSymbol string `json:"parent_symbol,omitempty"`
+
+	// LastModified is the file modification time when the chunk was indexed
+	LastModified string `json:"last_modified,omitempty"`
+
+	// Custom is the project custom metadata stored with the chunk
+	Custom map[string]string `json:"custom,omitempty"`
+
+	// GitRef is the commit checked out when the chunk was indexed, if known
+	GitRef string `json:"git_ref,omitempty"`
+
+	// URL links to the source lines (only when the project sets source_url)
+	URL string `json:"url,omitempty"`
+
+	// Imports and References are the symbol's used import paths and called
+	// functions (Go, when chunking.extract_relationships is enabled)
+	Imports    []string `json:"imports,omitempty"`
+	References []string `json:"references,omitempty"`
+
 	// Score is the similarity score (0.0 to 1.0)
 	Score float32 `json:"score"`
+
+	// RawScore is the unmodified provider similarity (debug_scores only)
+	RawScore *float32 `json:"raw_score,omitempty"`
+
+	// Highlights are the code point ranges of content matching query terms
+	// (highlight only)
+	Highlights []Highlight `json:"highlights,omitempty"`
+
+	// ContextBefore and ContextAfter are the source lines around the chunk
+	// (context_lines only; omitted when the file changed since indexing)
+	ContextBefore string `json:"context_before,omitempty"`
+	ContextAfter  string `json:"context_after,omitempty"`
+
+	// Neighbors holds the adjacent chunks in the same file (include_neighbors only)
+	Neighbors *ResultNeighbors `json:"neighbors,omitempty"`
+
+	// Parent is the enclosing class chunk of a method result (expand: "class" only)
+	Parent *RetrieveResult `json:"parent,omitempty"`
+}
+
+// expandClass is the Expand value that attaches enclosing class chunks.
+const expandClass = "class"
+
+// ResultNeighbors holds the chunks immediately before and after a result
+// in the same file. Either side is nil at file edges.
+type ResultNeighbors struct {
+	Previous *RetrieveResult `json:"previous,omitempty"`
+	Next     *RetrieveResult `json:"next,omitempty"`
 }
 
 // HealthResponse is the response body for GET /health.
 type HealthResponse struct {
-	Status     string            `json:"status"`
-	Components map[string]string `json:"components"`
-	Version    string            `json:"version"`
+	Status       string                         `json:"status"`
+	Mode         string                         `json:"mode"`
+	Components   map[string]string              `json:"components"`
+	Capabilities *vectordb.ProviderCapabilities `json:"capabilities,omitempty"`
+	Version      string                         `json:"version"`
 }
 
 // handleRetrieve handles POST /retrieve requests.
 func (s *Server) handleRetrieve(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
+	s.metrics.retrieveRequests.Inc()
+
+	if s.maintenance.Load() {
+		s.writeErrorWithCode(w, http.StatusServiceUnavailable, "server is in maintenance mode", ErrCodeMaintenance)
+		return
+	}
 
 	// Parse request body
 	var req RetrieveRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorWithCode(w, http.StatusBadRequest, "invalid request body: "+err.Error(), ErrCodeInvalidRequest)
+		s.writeErrorWithCode(w, http.StatusBadRequest, "invalid request body: "+err.Error(), ErrCodeInvalidRequest)
 		return
 	}
 
 	// Validate required fields
 	if req.ProjectID == "" {
-		writeErrorWithCode(w, http.StatusBadRequest, "project_id is required", ErrCodeMissingField)
+		s.writeErrorWithCode(w, http.StatusBadRequest, "project_id is required", ErrCodeMissingField)
 		return
 	}
 	if req.Query == "" {
-		writeErrorWithCode(w, http.StatusBadRequest, "query is required", ErrCodeMissingField)
+		s.writeErrorWithCode(w, http.StatusBadRequest, "query is required", ErrCodeMissingField)
 		return
 	}
 
-	// Apply defaults
-	if req.TopK <= 0 {
-		req.TopK = 5
+	// Serve repeated identical requests from the result cache
+	var cacheKey string
+	if s.results != nil {
+		cacheKey = s.results.key(&req, indexer.CacheStamp(s.cfg.Get().Cache.Dir, req.ProjectID))
+		if cached, ok := s.results.get(cacheKey); ok {
+			cached.Cached = true
+			cached.EmbeddingTokens = 0
+			cached.QueryTimeMs = time.Since(startTime).Milliseconds()
+			s.metrics.observeQueryTime(time.Since(startTime))
+			s.writeJSON(w, http.StatusOK, cached)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	response, rerr := s.retrieve(ctx, &req)
+	if rerr != nil {
+		s.writeErrorWithCode(w, rerr.status, rerr.message, rerr.code)
+		return
+	}
+	if s.results != nil {
+		s.results.put(cacheKey, response)
+	}
+	response.QueryTimeMs = time.Since(startTime).Milliseconds()
+	s.metrics.observeQueryTime(time.Since(startTime))
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// retrieveError is a retrieval failure mapped to an HTTP error response.
+type retrieveError struct {
+	status  int
+	message string
+	code    ErrorCode
+}
+
+// retrieve runs a validated retrieve request: embed, search, rank and
+// enrich results. QueryTimeMs is left for the caller to set.
+func (s *Server) retrieve(ctx context.Context, req *RetrieveRequest) (*RetrieveResponse, *retrieveError) {
+	validateProjects := s.cfg.Get().Server.ValidateProjects
+	if validateProjects {
+		if rerr := s.checkProjectKnown(req.ProjectID); rerr != nil {
+			return nil, rerr
+		}
+	}
+
+	plan, rerr := s.planRetrieve(ctx, req)
+	if rerr != nil {
+		return nil, rerr
+	}
+
+	_, vdb, release := s.acquireProviders()
+	defer release()
+	searchResults, err := vdb.Search(ctx, plan.query)
+	if err != nil {
+		s.logger.Error("search failed", "error", err)
+		return nil, &retrieveError{http.StatusInternalServerError, "search failed", ErrCodeSearchFailed}
+	}
+
+	response := s.finishRetrieve(ctx, req, plan, searchResults)
+	if validateProjects && len(response.Results) == 0 {
+		response.ProjectNotIndexed = s.projectNotIndexed(ctx, vdb, req.ProjectID)
+	}
+	return response, nil
+}
+
+// retrievePlan is a validated and embedded retrieve request, ready to search.
+type retrievePlan struct {
+	query              vectordb.SearchQuery
+	topK               int
+	minLines, maxLines int
+	intent             string
+	usage              *embedder.Usage
+}
+
+// planRetrieve validates a retrieve request, embeds its query and builds the
+// vector search to run.
+func (s *Server) planRetrieve(ctx context.Context, req *RetrieveRequest) (*retrievePlan, *retrieveError) {
+	// Apply defaults (request > project > server)
+	topK, scoreThreshold := s.effectiveRetrieveParams(req)
+
+	var minLines, maxLines int
+	if req.Filters != nil {
+		minLines, maxLines = req.Filters.MinLines, req.Filters.MaxLines
+	}
+	if minLines < 0 || maxLines < 0 || (maxLines > 0 && minLines > maxLines) {
+		return nil, &retrieveError{http.StatusBadRequest, "invalid min_lines/max_lines range", ErrCodeInvalidRequest}
+	}
+	if req.Filters != nil {
+		for key := range req.Filters.Custom {
+			if err := config.ValidateCustomKey(key); err != nil {
+				return nil, &retrieveError{http.StatusBadRequest, err.Error(), ErrCodeInvalidRequest}
+			}
+		}
+	}
+	if req.DedupThreshold < 0 || req.DedupThreshold > 1 {
+		return nil, &retrieveError{http.StatusBadRequest, "dedup_threshold must be between 0 and 1", ErrCodeInvalidRequest}
+	}
+	if req.Diversity < 0 || req.Diversity > 1 {
+		return nil, &retrieveError{http.StatusBadRequest, "diversity must be between 0 and 1", ErrCodeInvalidRequest}
 	}
-	if req.TopK > 20 {
-		req.TopK = 20
+	if req.ContextLines < 0 || req.ContextLines > maxContextLines {
+		return nil, &retrieveError{http.StatusBadRequest, fmt.Sprintf("context_lines must be between 0 and %d", maxContextLines), ErrCodeInvalidRequest}
+	}
+	if req.Expand != "" && req.Expand != expandClass {
+		return nil, &retrieveError{http.StatusBadRequest, "expand must be \"class\"", ErrCodeInvalidRequest}
 	}
 
 	// Get providers
-	emb, vdb := s.getProviders()
+	emb, vdb, release := s.acquireProviders()
+	defer release()
 
-	// Generate query embedding
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+	if req.IncludeNeighbors && !vdb.Capabilities().Scroll {
+		return nil, &retrieveError{http.StatusNotImplemented, "include_neighbors is not supported by the vectordb provider", ErrCodeNotSupported}
+	}
+	if req.Expand != "" && !vdb.Capabilities().Scroll {
+		return nil, &retrieveError{http.StatusNotImplemented, "expand is not supported by the vectordb provider", ErrCodeNotSupported}
+	}
 
-	queryVector, err := emb.Embed(ctx, req.Query)
+	// Generate query embedding
+	usage := &embedder.Usage{}
+	queryVector, err := emb.Embed(embedder.WithUsage(ctx, usage), req.Query)
 	if err != nil {
 		s.logger.Error("embedding failed", "error", err)
-		writeErrorWithCode(w, http.StatusInternalServerError, "failed to process query", ErrCodeEmbeddingFailed)
-		return
+		return nil, &retrieveError{http.StatusInternalServerError, "failed to process query", ErrCodeEmbeddingFailed}
 	}
 
 	// Build search filter
@@ -137,47 +375,382 @@ func (s *Server) handleRetrieve(w http.ResponseWriter, r *http.Request) {
 		filter.Module = req.Filters.Module
 		filter.Language = req.Filters.Language
 		filter.SymbolType = req.Filters.SymbolType
+		filter.FilePath = req.Filters.FilePath
+		filter.Owner = req.Filters.Owner
+		filter.Custom = req.Filters.Custom
 	}
 
-	// Perform vector search
-	searchResults, err := vdb.Search(ctx, vectordb.SearchQuery{
-		Vector: queryVector,
-		TopK:   req.TopK,
-		Filter: filter,
-	})
-	if err != nil {
-		s.logger.Error("search failed", "error", err)
-		writeErrorWithCode(w, http.StatusInternalServerError, "search failed", ErrCodeSearchFailed)
-		return
+	// Detect query intent unless the client pinned a symbol type
+	serverCfg := s.cfg.Get().Server
+	intent := ""
+	if serverCfg.IntentRouting && filter.SymbolType == "" {
+		intent = classifyQueryIntent(req.Query)
+	}
+
+	// Fetch extra candidates when post-retrieval boosting, recency decay or
+	// reranking may reorder results, or the line range filter, dedup or
+	// diversity may drop some
+	needVectors := req.DedupThreshold > 0 || req.Diversity > 0
+	reorders := (serverCfg.ExactSymbolBoost > 0 && serverCfg.ExactSymbolBoost != 1) || intent != "" ||
+		serverCfg.GetRecencyHalfLife() > 0 || s.currentReranker().TopN() > 0
+	searchTopK := topK
+	if reorders || minLines > 0 || maxLines > 0 || needVectors {
+		searchTopK = topK * candidateMultiplier
+	}
+
+	return &retrievePlan{
+		query: vectordb.SearchQuery{
+			Vector:         queryVector,
+			TopK:           searchTopK,
+			Filter:         filter,
+			ScoreThreshold: scoreThreshold,
+			WithVectors:    needVectors,
+		},
+		topK:     topK,
+		minLines: minLines,
+		maxLines: maxLines,
+		intent:   intent,
+		usage:    usage,
+	}, nil
+}
+
+// finishRetrieve ranks, truncates and enriches the search results of a plan.
+func (s *Server) finishRetrieve(ctx context.Context, req *RetrieveRequest, plan *retrievePlan, searchResults []vectordb.SearchResult) *RetrieveResponse {
+	serverCfg := s.cfg.Get().Server
+	_, vdb, release := s.acquireProviders()
+	defer release()
+
+	// Remember provider similarities before boosts change them
+	var rawScores map[string]float32
+	if req.DebugScores {
+		rawScores = make(map[string]float32, len(searchResults))
+		for _, sr := range searchResults {
+			rawScores[sr.ID] = sr.Score
+		}
 	}
 
-	// Check if project exists (no results might mean project not indexed)
-	// Check if project exists (no results might mean project not indexed)
-	// For now, return empty results (could query Qdrant for project existence)
+	// Post-retrieval filtering and ranking adjustments
+	searchResults = filterByLineCount(searchResults, plan.minLines, plan.maxLines)
+	applyExactSymbolBoost(req.Query, searchResults, serverCfg.ExactSymbolBoost)
+	applyIntentBoost(plan.intent, searchResults)
+	applyRecencyDecay(searchResults, serverCfg.GetRecencyHalfLife(), time.Now())
+	searchResults = dedupBySimilarity(searchResults, req.DedupThreshold)
+	s.rerank(ctx, req.Query, searchResults)
+	searchResults = selectByMMR(searchResults, plan.topK, req.Diversity)
+	if len(searchResults) > plan.topK {
+		searchResults = searchResults[:plan.topK]
+	}
 
 	// Convert to response format
 	results := make([]RetrieveResult, len(searchResults))
 	for i, sr := range searchResults {
-		results[i] = RetrieveResult{
-			Content:    sr.Payload.Content,
-			Source:     sr.Payload.FilePath,
-			Symbol:     sr.Payload.Symbol,
-			SymbolType: sr.Payload.SymbolType,
-			ProjectID:  sr.Payload.ProjectID,
-			Module:     sr.Payload.Module,
-			Language:   sr.Payload.Language,
-			StartLine:  sr.Payload.StartLine,
-			EndLine:    sr.Payload.EndLine,
-			Score:      sr.Score,
+		results[i] = toRetrieveResult(sr)
+		if raw, ok := rawScores[sr.ID]; ok {
+			results[i].RawScore = &raw
+		}
+	}
+
+	// Attach adjacent chunks from the same file
+	if req.IncludeNeighbors {
+		s.attachNeighbors(ctx, vdb, req.ProjectID, searchResults, results)
+	}
+
+	// Attach the enclosing class of method results
+	if req.Expand == expandClass {
+		s.attachParents(ctx, vdb, req.ProjectID, searchResults, results)
+	}
+
+	// Resolve content from source for metadata-only indexes
+	if req.ResolveContent {
+		s.resolveResultContent(req.ProjectID, results)
+	}
+
+	// Read the source lines around each chunk
+	if req.ContextLines > 0 {
+		s.attachContextLines(req.ProjectID, searchResults, results, req.ContextLines)
+	}
+
+	// Highlight query terms once the final content is known
+	if req.Highlight {
+		attachHighlights(req.Query, results)
+	}
+
+	s.attachSourceURLs(req.ProjectID, results)
+
+	return &RetrieveResponse{
+		Results:         results,
+		Intent:          plan.intent,
+		EmbeddingTokens: plan.usage.Tokens(),
+	}
+}
+
+// rerank reorders the top candidates by cross-encoder relevance when a
+// reranker is configured. Reranker failures keep the vector order.
+func (s *Server) rerank(ctx context.Context, query string, results []vectordb.SearchResult) {
+	rr := s.currentReranker()
+	n := min(rr.TopN(), len(results))
+	if n <= 1 {
+		return
+	}
+
+	documents := make([]string, n)
+	for i := range documents {
+		documents[i] = rerankDocument(results[i])
+	}
+	scores, err := rr.Rerank(ctx, query, documents)
+	if err != nil {
+		s.logger.Warn("rerank failed, keeping vector order", "error", err)
+		return
+	}
+	applyRerankScores(results, scores)
+}
+
+// toRetrieveResult converts a vector search result into the response format.
+func toRetrieveResult(sr vectordb.SearchResult) RetrieveResult {
+	return RetrieveResult{
+		Content:    sr.Payload.Content,
+		Source:     sr.Payload.FilePath,
+		Sources:    sr.Payload.FilePaths,
+		Symbol:     sr.Payload.Symbol,
+		SymbolType: sr.Payload.SymbolType,
+		ProjectID:  sr.Payload.ProjectID,
+		Module:     sr.Payload.Module,
+		Language:   sr.Payload.Language,
+		StartLine:  sr.Payload.StartLine,
+		EndLine:    sr.Payload.EndLine,
+		Owner:      sr.Payload.Owner,
+		Custom:     sr.Payload.Custom,
+		GitRef:     sr.Payload.GitRef,
+		Imports:    sr.Payload.Imports,
+		References: sr.Payload.References,
+		Score:      sr.Score,
+
+		ParentSymbol: sr.Payload.ParentSymbol,
+		LastModified: sr.Payload.LastModified,
+	}
+}
+
+// maxNeighborScan caps how many chunks of a single file are scrolled
+// when looking up neighbors.
+const maxNeighborScan = 512
+
+// attachNeighbors sets Neighbors on each result to the chunks directly
+// before and after it in the same file, ordered by start_line.
+// Files are scrolled once per request; lookup failures are logged and skipped.
+func (s *Server) attachNeighbors(ctx context.Context, vdb vectordb.Provider, projectID string, hits []vectordb.SearchResult, results []RetrieveResult) {
+	fileChunks := make(map[string][]vectordb.SearchResult)
+
+	for i, hit := range hits {
+		path := hit.Payload.FilePath
+		chunks, ok := fileChunks[path]
+		if !ok {
+			var err error
+			chunks, err = vdb.Scroll(ctx, vectordb.Filter{ProjectID: projectID, FilePath: path}, maxNeighborScan)
+			if err != nil {
+				s.logger.Warn("neighbor lookup failed", "file", path, "error", err)
+				continue
+			}
+			sort.SliceStable(chunks, func(a, b int) bool {
+				return chunks[a].Payload.StartLine < chunks[b].Payload.StartLine
+			})
+			fileChunks[path] = chunks
+		}
+
+		pos := -1
+		for j, c := range chunks {
+			if c.ID == hit.ID || (c.Payload.StartLine == hit.Payload.StartLine && c.Payload.EndLine == hit.Payload.EndLine) {
+				pos = j
+				break
+			}
+		}
+		if pos < 0 {
+			continue
+		}
+
+		neighbors := &ResultNeighbors{}
+		if pos > 0 {
+			prev := toRetrieveResult(chunks[pos-1])
+			neighbors.Previous = &prev
+		}
+		if pos < len(chunks)-1 {
+			next := toRetrieveResult(chunks[pos+1])
+			neighbors.Next = &next
+		}
+		results[i].Neighbors = neighbors
+	}
+}
+
+// attachParents sets Parent on method results to the chunk of their
+// enclosing class in the same file. Each class is looked up once per
+// request; lookup failures are logged and skipped.
+func (s *Server) attachParents(ctx context.Context, vdb vectordb.Provider, projectID string, hits []vectordb.SearchResult, results []RetrieveResult) {
+	type classKey struct{ file, symbol string }
+	classes := make(map[classKey]*vectordb.SearchResult)
+
+	for i, hit := range hits {
+		if hit.Payload.ParentSymbol == "" {
+			continue
+		}
+		key := classKey{hit.Payload.FilePath, hit.Payload.ParentSymbol}
+		class, ok := classes[key]
+		if !ok {
+			var err error
+			class, err = findClassChunk(ctx, vdb, projectID, key.file, key.symbol)
+			if err != nil {
+				s.logger.Warn("class lookup failed", "file", key.file, "symbol", key.symbol, "error", err)
+				continue
+			}
+			classes[key] = class
+		}
+		if class != nil {
+			parent := toRetrieveResult(*class)
+			results[i].Parent = &parent
+		}
+	}
+}
+
+// findClassChunk returns the chunk of a class symbol in a file, or nil if
+// it isn't indexed. A class split for size is returned as its header,
+// the first part (symbol#1).
+func findClassChunk(ctx context.Context, vdb vectordb.Provider, projectID, file, symbol string) (*vectordb.SearchResult, error) {
+	for _, candidate := range []string{symbol, symbol + "#1"} {
+		chunks, err := vdb.Scroll(ctx, vectordb.Filter{ProjectID: projectID, FilePath: file, Symbol: candidate}, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunks) > 0 {
+			return &chunks[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// maxTopK is the upper bound on results returned per request.
+const maxTopK = 20
+
+// effectiveRetrieveParams resolves top_k and score_threshold for a request,
+// falling back to the project's retrieval defaults and then server defaults.
+func (s *Server) effectiveRetrieveParams(req *RetrieveRequest) (int, float32) {
+	cfg := s.cfg.Get()
+	topK := cfg.Server.DefaultTopK
+	scoreThreshold := cfg.Server.DefaultScoreThreshold
+
+	if req.TopK <= 0 || req.ScoreThreshold == nil {
+		if projectCfg, err := s.projects.Get(cfg.Projects.ConfigDir, req.ProjectID); err == nil {
+			if projectCfg.Retrieval.DefaultTopK > 0 {
+				topK = projectCfg.Retrieval.DefaultTopK
+			}
+			if t := projectCfg.Retrieval.DefaultScoreThreshold; t != nil {
+				scoreThreshold = *t
+			}
+		} else {
+			s.logger.Debug("project config not found, using server defaults", "project", req.ProjectID)
+		}
+	}
+
+	if req.TopK > 0 {
+		topK = req.TopK
+	}
+	if req.ScoreThreshold != nil {
+		scoreThreshold = *req.ScoreThreshold
+	}
+
+	if topK <= 0 {
+		topK = 5
+	}
+	if topK > maxTopK {
+		topK = maxTopK
+	}
+	return topK, scoreThreshold
+}
+
+// resolveResultContent fills empty result content by reading the referenced
+// line range from the project's source tree.
+func (s *Server) resolveResultContent(projectID string, results []RetrieveResult) {
+	cfg := s.cfg.Get()
+
+	projectCfg, err := s.projects.Get(cfg.Projects.ConfigDir, projectID)
+	if err != nil {
+		s.logger.Warn("cannot resolve content, project config not found", "project", projectID, "error", err)
+		return
+	}
+	sourceRoot := projectCfg.GetPathRoot(projectCfg.GetFullSourcePath(cfg.Projects.SourceBasePath))
+
+	resolve := func(r *RetrieveResult) {
+		if r == nil || r.Content != "" {
+			return
+		}
+		content, err := readSourceLines(sourceRoot, r.Source, r.StartLine, r.EndLine)
+		if err != nil {
+			s.logger.Warn("failed to resolve content", "source", r.Source, "error", err)
+			return
+		}
+		r.Content = content
+	}
+
+	for i := range results {
+		resolve(&results[i])
+		if results[i].Neighbors != nil {
+			resolve(results[i].Neighbors.Previous)
+			resolve(results[i].Neighbors.Next)
 		}
 	}
+}
+
+// attachSourceURLs sets URL on results (and their neighbors) from the
+// project's source_url template. Nothing is set when no template is configured.
+func (s *Server) attachSourceURLs(projectID string, results []RetrieveResult) {
+	cfg := s.cfg.Get()
+
+	projectCfg, err := s.projects.Get(cfg.Projects.ConfigDir, projectID)
+	if err != nil || projectCfg.SourceURL.Template == "" {
+		return
+	}
 
-	response := RetrieveResponse{
-		Results:     results,
-		QueryTimeMs: time.Since(startTime).Milliseconds(),
+	link := func(r *RetrieveResult) {
+		if r != nil {
+			r.URL = projectCfg.SourceURL.Expand(r.GitRef, r.Source, r.StartLine, r.EndLine)
+		}
 	}
 
-	writeJSON(w, http.StatusOK, response)
+	for i := range results {
+		link(&results[i])
+		if results[i].Neighbors != nil {
+			link(results[i].Neighbors.Previous)
+			link(results[i].Neighbors.Next)
+		}
+	}
+}
+
+// readSourceLines reads lines [start, end] (1-indexed, inclusive) of a file
+// under root. Paths escaping root are rejected.
+func readSourceLines(root, relPath string, start, end int) (string, error) {
+	data, err := readSourceFile(root, relPath)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if start < 1 {
+		start = 1
+	}
+	if end <= 0 || end > len(lines) {
+		end = len(lines)
+	}
+	if start > end {
+		return "", fmt.Errorf("invalid line range %d-%d", start, end)
+	}
+	return strings.Join(lines[start-1:end], "\n"), nil
+}
+
+// sourceFilePath joins a file path onto a source root, rejecting paths that
+// escape it.
+func sourceFilePath(root, relPath string) (string, error) {
+	absPath := filepath.Join(root, relPath)
+	if rel, err := filepath.Rel(root, absPath); err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("path outside source root: %s", relPath)
+	}
+	return absPath, nil
 }
 
 // handleHealth handles GET /health requests.
@@ -185,31 +758,45 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	emb, vdb := s.getProviders()
+	emb, vdb, release := s.acquireProviders()
+	defer release()
 
 	components := make(map[string]string)
 	status := "healthy"
 
 	// Check embedder
-	if err := emb.Health(ctx); err != nil {
-		components["embedder"] = "error: " + err.Error()
+	embErr := emb.Health(ctx)
+	s.metrics.setDependencyUp("embedder", embErr)
+	if embErr != nil {
+		components["embedder"] = "error: " + embErr.Error()
 		status = "degraded"
 	} else {
 		components["embedder"] = "ok"
 	}
 
 	// Check vector DB
-	if err := vdb.Health(ctx); err != nil {
-		components["vectordb"] = "error: " + err.Error()
+	vdbErr := vdb.Health(ctx)
+	s.metrics.setDependencyUp("vectordb", vdbErr)
+	if vdbErr != nil {
+		components["vectordb"] = "error: " + vdbErr.Error()
 		status = "degraded"
 	} else {
 		components["vectordb"] = "ok"
 	}
 
+	mode := "normal"
+	if s.maintenance.Load() {
+		mode = "maintenance"
+		status = "maintenance"
+	}
+
+	caps := vdb.Capabilities()
 	response := HealthResponse{
-		Status:     status,
-		Components: components,
-		Version:    s.version,
+		Status:       status,
+		Mode:         mode,
+		Components:   components,
+		Capabilities: &caps,
+		Version:      s.version,
 	}
 
 	statusCode := http.StatusOK
@@ -217,7 +804,7 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		statusCode = http.StatusServiceUnavailable
 	}
 
-	writeJSON(w, statusCode, response)
+	s.writeJSON(w, statusCode, response)
 }
 
 // handleRoot handles GET / requests.
@@ -227,12 +814,18 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
 		"name":    "project-indexer-retrieval-tool",
 		"version": s.version,
 		"endpoints": []string{
 			"POST /retrieve",
+			"POST /retrieve/batch",
 			"GET /health",
+			"GET /livez",
+			"GET /projects",
+			"GET /metrics",
+			"GET|POST /embed",
+			"POST /admin/maintenance",
 		},
 	})
 }
@@ -0,0 +1,1754 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iasik/project-indexer/internal/config"
+	"github.com/iasik/project-indexer/internal/embedder"
+	"github.com/iasik/project-indexer/internal/indexer"
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+// fakeEmbedder returns a fixed vector for every query.
+type fakeEmbedder struct{}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func (f *fakeEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{0.1, 0.2, 0.3}
+	}
+	return vectors, nil
+}
+
+func (f *fakeEmbedder) ModelInfo() embedder.ModelInfo {
+	return embedder.ModelInfo{Provider: "fake", Model: "fake-model", Dimensions: 3}
+}
+
+func (f *fakeEmbedder) Health(ctx context.Context) error { return nil }
+func (f *fakeEmbedder) Close() error                     { return nil }
+
+// fakeVectorDB returns canned search results and records the last query.
+type fakeVectorDB struct {
+	results   []vectordb.SearchResult
+	lastQuery vectordb.SearchQuery
+	caps      vectordb.ProviderCapabilities
+
+	// chunks are returned by Scroll, filtered by file path and symbol,
+	// and counted per project by Count
+	chunks []vectordb.SearchResult
+
+	// countErr is returned by Count when set
+	countErr error
+
+	// Number of Search and SearchBatch calls
+	searchCalls, batchCalls int
+}
+
+func (f *fakeVectorDB) Upsert(ctx context.Context, points []vectordb.Point) error { return nil }
+
+func (f *fakeVectorDB) Search(ctx context.Context, query vectordb.SearchQuery) ([]vectordb.SearchResult, error) {
+	f.searchCalls++
+	return f.search(query), nil
+}
+
+func (f *fakeVectorDB) SearchBatch(ctx context.Context, queries []vectordb.SearchQuery) ([][]vectordb.SearchResult, error) {
+	f.batchCalls++
+	results := make([][]vectordb.SearchResult, len(queries))
+	for i, query := range queries {
+		results[i] = f.search(query)
+	}
+	return results, nil
+}
+
+func (f *fakeVectorDB) search(query vectordb.SearchQuery) []vectordb.SearchResult {
+	f.lastQuery = query
+	results := f.results
+	if query.TopK > 0 && len(results) > query.TopK {
+		results = results[:query.TopK]
+	}
+	// Return a copy like a real provider; ranking reorders results in place
+	return append([]vectordb.SearchResult(nil), results...)
+}
+
+func (f *fakeVectorDB) Delete(ctx context.Context, ids []string) error                   { return nil }
+func (f *fakeVectorDB) DeleteByFilter(ctx context.Context, filter vectordb.Filter) error { return nil }
+func (f *fakeVectorDB) PurgeDeleted(ctx context.Context, olderThan time.Time) error      { return nil }
+func (f *fakeVectorDB) EnsureCollection(ctx context.Context, dimensions int) error       { return nil }
+func (f *fakeVectorDB) Health(ctx context.Context) error                                 { return nil }
+func (f *fakeVectorDB) Close() error                                                     { return nil }
+
+func (f *fakeVectorDB) Capabilities() vectordb.ProviderCapabilities { return f.caps }
+
+func (f *fakeVectorDB) Scroll(ctx context.Context, filter vectordb.Filter, limit int) ([]vectordb.SearchResult, error) {
+	var out []vectordb.SearchResult
+	for _, c := range f.chunks {
+		if (filter.FilePath == "" || c.Payload.FilePath == filter.FilePath) &&
+			(filter.Symbol == "" || c.Payload.Symbol == filter.Symbol) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeVectorDB) ScrollPage(ctx context.Context, query vectordb.ScrollQuery) ([]vectordb.SearchResult, string, error) {
+	results, err := f.Scroll(ctx, query.Filter, query.Limit)
+	return results, "", err
+}
+
+func (f *fakeVectorDB) UpdatePayloads(ctx context.Context, points []vectordb.Point) error {
+	return nil
+}
+
+func (f *fakeVectorDB) UpdatePayload(ctx context.Context, id string, fields map[string]interface{}) error {
+	return nil
+}
+
+func (f *fakeVectorDB) Count(ctx context.Context, filter vectordb.Filter) (int, error) {
+	if f.countErr != nil {
+		return 0, f.countErr
+	}
+	n := 0
+	for _, c := range f.chunks {
+		if filter.ProjectID == "" || c.Payload.ProjectID == filter.ProjectID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// newTestServer writes configYAML to a temp dir and builds a server around fakes.
+// The config may reference {{dir}}, which is replaced with the temp dir.
+func newTestServer(t *testing.T, configYAML string, vdb *fakeVectorDB) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	configYAML = string(bytes.ReplaceAll([]byte(configYAML), []byte("{{dir}}"), []byte(dir)))
+
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	manager := config.NewManager(configPath)
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewServer(manager, &fakeEmbedder{}, vdb, logger), dir
+}
+
+// doRetrieve posts a retrieve request and decodes the response.
+func doRetrieve(t *testing.T, s *Server, req RetrieveRequest) (*httptest.ResponseRecorder, RetrieveResponse) {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	s.handleRetrieve(rec, httptest.NewRequest(http.MethodPost, "/retrieve", bytes.NewReader(body)))
+
+	var resp RetrieveResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+	return rec, resp
+}
+
+// writeProjectConfig writes a project config into {{dir}}/projects.
+func writeProjectConfig(t *testing.T, dir, projectID, extraYAML string) {
+	t.Helper()
+	projectsDir := filepath.Join(dir, "projects")
+	if err := os.MkdirAll(projectsDir, 0755); err != nil {
+		t.Fatalf("Failed to create projects dir: %v", err)
+	}
+	content := "project_id: \"" + projectID + "\"\nsource_path: \"" + projectID + "\"\ninclude_extensions: [\".go\"]\n" + extraYAML
+	if err := os.WriteFile(filepath.Join(projectsDir, projectID+".yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+}
+
+const testServerConfig = `
+projects:
+  config_dir: "{{dir}}/projects"
+  source_base_path: "{{dir}}/sources"
+`
+
+func TestHandleRetrieve_MetadataOnlyPayload(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{{
+		ID:    "1",
+		Score: 0.9,
+		Payload: vectordb.Payload{
+			ProjectID:  "proj",
+			FilePath:   "main.go",
+			Symbol:     "main",
+			SymbolType: "function",
+			StartLine:  2,
+			EndLine:    3,
+		},
+	}}}
+	s, dir := newTestServer(t, `
+vectordb:
+  store_content: false
+projects:
+  config_dir: "{{dir}}/projects"
+  source_base_path: "{{dir}}/sources"
+`, vdb)
+
+	// Without resolve_content, metadata is returned as-is
+	rec, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "main"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(resp.Results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(resp.Results))
+	}
+	r := resp.Results[0]
+	if r.Symbol != "main" || r.StartLine != 2 || r.EndLine != 3 || r.Content != "" {
+		t.Errorf("Unexpected result: %+v", r)
+	}
+
+	// With resolve_content, content is read from the source tree
+	os.MkdirAll(filepath.Join(dir, "projects"), 0755)
+	os.MkdirAll(filepath.Join(dir, "sources", "proj"), 0755)
+	os.WriteFile(filepath.Join(dir, "projects", "proj.yaml"), []byte(`
+project_id: "proj"
+source_path: "proj"
+include_extensions: [".go"]
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "sources", "proj", "main.go"),
+		[]byte("package main\nfunc main() {\n}\n"), 0644)
+
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "main", ResolveContent: true})
+	if got := resp.Results[0].Content; got != "func main() {\n}" {
+		t.Errorf("Expected resolved content, got %q", got)
+	}
+}
+
+func TestHandleRetrieve_PerProjectDefaults(t *testing.T) {
+	vdb := &fakeVectorDB{}
+	s, dir := newTestServer(t, testServerConfig+`
+server:
+  default_top_k: 7
+  default_score_threshold: 0.1
+`, vdb)
+	writeProjectConfig(t, dir, "dense", `
+retrieval:
+  default_top_k: 3
+  default_score_threshold: 0.6
+`)
+	writeProjectConfig(t, dir, "sparse", `
+retrieval:
+  default_top_k: 12
+  default_score_threshold: 0.2
+`)
+	writeProjectConfig(t, dir, "plain", "")
+	writeProjectConfig(t, dir, "unfiltered", `
+retrieval:
+  default_score_threshold: 0
+`)
+
+	tests := []struct {
+		projectID string
+		topK      int
+		threshold float32
+	}{
+		{"dense", 3, 0.6},
+		{"sparse", 12, 0.2},
+		{"plain", 7, 0.1},
+		{"unfiltered", 7, 0},
+		{"unknown", 7, 0.1},
+	}
+
+	for _, tt := range tests {
+		rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: tt.projectID, Query: "q"})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tt.projectID, rec.Code)
+		}
+		if vdb.lastQuery.TopK != tt.topK {
+			t.Errorf("%s: expected top_k %d, got %d", tt.projectID, tt.topK, vdb.lastQuery.TopK)
+		}
+		if vdb.lastQuery.ScoreThreshold != tt.threshold {
+			t.Errorf("%s: expected threshold %v, got %v", tt.projectID, tt.threshold, vdb.lastQuery.ScoreThreshold)
+		}
+	}
+
+	// Explicit request values win over project defaults
+	threshold := float32(0.9)
+	doRetrieve(t, s, RetrieveRequest{ProjectID: "dense", Query: "q", TopK: 4, ScoreThreshold: &threshold})
+	if vdb.lastQuery.TopK != 4 || vdb.lastQuery.ScoreThreshold != 0.9 {
+		t.Errorf("Expected request values to win, got top_k=%d threshold=%v", vdb.lastQuery.TopK, vdb.lastQuery.ScoreThreshold)
+	}
+}
+
+func TestHandleRetrieve_ExactSymbolBoost(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "1", Score: 0.90, Payload: vectordb.Payload{Symbol: "loggingMiddleware"}},
+		{ID: "2", Score: 0.85, Payload: vectordb.Payload{Symbol: "writeJSON"}},
+		{ID: "3", Score: 0.70, Payload: vectordb.Payload{Symbol: "Server.handleRetrieve"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig+`
+server:
+  exact_symbol_boost: 1.5
+`, vdb)
+
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "how does handleRetrieve validate input?", TopK: 2})
+
+	if vdb.lastQuery.TopK != 2*candidateMultiplier {
+		t.Errorf("Expected %d candidates to be fetched, got %d", 2*candidateMultiplier, vdb.lastQuery.TopK)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(resp.Results))
+	}
+	if resp.Results[0].Symbol != "Server.handleRetrieve" {
+		t.Errorf("Expected exact symbol match to be promoted, got %s first", resp.Results[0].Symbol)
+	}
+	if resp.Results[1].Symbol != "loggingMiddleware" {
+		t.Errorf("Expected loggingMiddleware second, got %s", resp.Results[1].Symbol)
+	}
+}
+
+func TestHandleRetrieve_DebugScores(t *testing.T) {
+	newVDB := func() *fakeVectorDB {
+		return &fakeVectorDB{results: []vectordb.SearchResult{
+			{ID: "1", Score: 0.90, Payload: vectordb.Payload{Symbol: "loggingMiddleware"}},
+			{ID: "2", Score: 0.60, Payload: vectordb.Payload{Symbol: "Server.handleRetrieve"}},
+		}}
+	}
+	serverConfig := testServerConfig + `
+server:
+  exact_symbol_boost: 1.5
+`
+	query := "how does handleRetrieve validate input?"
+
+	s, _ := newTestServer(t, serverConfig, newVDB())
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: query, DebugScores: true})
+	if len(resp.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(resp.Results))
+	}
+
+	boosted := resp.Results[0]
+	if boosted.Symbol != "Server.handleRetrieve" || boosted.RawScore == nil {
+		t.Fatalf("Expected boosted result first with raw_score, got %+v", boosted)
+	}
+	if *boosted.RawScore != 0.60 || math.Abs(float64(boosted.Score)-0.90) > 1e-6 {
+		t.Errorf("Expected raw_score 0.60 and boosted score 0.90, got raw=%v score=%v", *boosted.RawScore, boosted.Score)
+	}
+	if other := resp.Results[1]; other.RawScore == nil || *other.RawScore != other.Score {
+		t.Errorf("Expected unboosted raw_score to equal score, got %+v", other)
+	}
+
+	// Without debug_scores the field is omitted
+	s, _ = newTestServer(t, serverConfig, newVDB())
+	rec, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: query})
+	if resp.Results[0].RawScore != nil || strings.Contains(rec.Body.String(), "raw_score") {
+		t.Errorf("Expected no raw_score without debug_scores, got %s", rec.Body.String())
+	}
+}
+
+func TestSymbolMatchesQuery(t *testing.T) {
+	tokens := queryTokens("where is Server.handleRetrieve and App\\Http\\UserController used")
+
+	tests := []struct {
+		symbol string
+		want   bool
+	}{
+		{"handleRetrieve", true},
+		{"Server.handleRetrieve", true},
+		{"Server.handleRetrieve+2", true},
+		{"UserController", true},
+		{"helper+handleRetrieve", true},
+		{"handle", false},
+		{"Retrieve", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := symbolMatchesQuery(tt.symbol, tokens); got != tt.want {
+			t.Errorf("symbolMatchesQuery(%q) = %v, want %v", tt.symbol, got, tt.want)
+		}
+	}
+}
+
+func TestWriteJSON_CompactAndIndented(t *testing.T) {
+	tests := []struct {
+		name       string
+		configYAML string
+		indented   bool
+	}{
+		{"default", testServerConfig, false},
+		{"compact", testServerConfig + "server:\n  compact_json: true\n", false},
+		{"indented", testServerConfig + "server:\n  compact_json: false\n", true},
+	}
+
+	for _, tt := range tests {
+		s, _ := newTestServer(t, tt.configYAML, &fakeVectorDB{})
+
+		rec := httptest.NewRecorder()
+		s.handleRoot(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		body := rec.Body.String()
+		hasIndent := bytes.Contains(rec.Body.Bytes(), []byte("\n  \""))
+		if hasIndent != tt.indented {
+			t.Errorf("%s: expected indented=%v, got body %q", tt.name, tt.indented, body)
+		}
+		if got, want := rec.Header().Get("Content-Length"), strconv.Itoa(len(body)); got != want {
+			t.Errorf("%s: expected Content-Length %s, got %s", tt.name, want, got)
+		}
+		if !json.Valid(rec.Body.Bytes()) {
+			t.Errorf("%s: response is not valid JSON", tt.name)
+		}
+	}
+}
+
+func TestClassifyQueryIntent(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"how does authentication work?", IntentDocs},
+		{"explain the deployment process", IntentDocs},
+		{"handleRetrieve", IntentCode},
+		{"where is parse_config called", IntentCode},
+		{"config.Load error handling", IntentCode},
+		{"NewServer()", IntentCode},
+		{"login", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := classifyQueryIntent(tt.query); got != tt.want {
+			t.Errorf("classifyQueryIntent(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestHandleRetrieve_IntentRouting(t *testing.T) {
+	newResults := func() []vectordb.SearchResult {
+		return []vectordb.SearchResult{
+			{ID: "code", Score: 0.80, Payload: vectordb.Payload{Symbol: "Login", SymbolType: "function", Language: "go"}},
+			{ID: "doc", Score: 0.75, Payload: vectordb.Payload{Symbol: "Authentication", SymbolType: "heading", Language: "markdown"}},
+		}
+	}
+	vdb := &fakeVectorDB{}
+	s, _ := newTestServer(t, testServerConfig+"server:\n  intent_routing: true\n", vdb)
+
+	// Prose query promotes documentation
+	vdb.results = newResults()
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "how does the login flow work?"})
+	if resp.Intent != IntentDocs || resp.Results[0].SymbolType != "heading" {
+		t.Errorf("Expected docs intent with heading first, got intent=%q first=%s", resp.Intent, resp.Results[0].SymbolType)
+	}
+
+	// Identifier query promotes code
+	vdb.results = []vectordb.SearchResult{newResults()[1], newResults()[0]}
+	vdb.results[0].Score, vdb.results[1].Score = 0.80, 0.75
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "validateToken usage"})
+	if resp.Intent != IntentCode || resp.Results[0].SymbolType != "function" {
+		t.Errorf("Expected code intent with function first, got intent=%q first=%s", resp.Intent, resp.Results[0].SymbolType)
+	}
+
+	// Explicit symbol_type filter disables routing
+	vdb.results = newResults()
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "how does login work?",
+		Filters: &RetrieveFilters{SymbolType: "function"}})
+	if resp.Intent != "" {
+		t.Errorf("Expected no intent routing with explicit filter, got intent=%q", resp.Intent)
+	}
+}
+
+func TestWithBackpressure_SaturatedQueue(t *testing.T) {
+	s, _ := newTestServer(t, testServerConfig+`
+server:
+  max_in_flight: 1
+  queue_depth: 1
+  queue_timeout: "2s"
+`, &fakeVectorDB{})
+
+	entered := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+	handler := s.withBackpressure(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/retrieve", nil))
+		return rec
+	}
+
+	// First request occupies the only slot, second waits in the queue
+	codes := make(chan int, 2)
+	go func() { codes <- serve().Code }()
+	<-entered
+	go func() { codes <- serve().Code }()
+	deadline := time.Now().Add(time.Second)
+	for s.limiter.waiting.Load() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Second request never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Third request exceeds queue depth
+	rec := serve()
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", rec.Code)
+	}
+	var errResp ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &errResp)
+	if errResp.Code != ErrCodeOverloaded {
+		t.Errorf("Expected code %s, got %s", ErrCodeOverloaded, errResp.Code)
+	}
+
+	// Freeing capacity lets the queued request and new ones through
+	close(unblock)
+	for i := 0; i < 2; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("Expected queued request to succeed, got %d", code)
+		}
+	}
+	if rec := serve(); rec.Code != http.StatusOK {
+		t.Errorf("Expected recovery after capacity freed, got %d", rec.Code)
+	}
+}
+
+func TestWithBackpressure_QueueDepthDefault(t *testing.T) {
+	tests := []struct {
+		yaml  string
+		depth int64
+	}{
+		{"  max_in_flight: 1\n", 32},
+		{"  max_in_flight: 1\n  queue_depth: 0\n", 0},
+	}
+	for _, tt := range tests {
+		s, _ := newTestServer(t, testServerConfig+"\nserver:\n"+tt.yaml, &fakeVectorDB{})
+		if s.limiter.queueDepth != tt.depth {
+			t.Errorf("%q: expected queue depth %d, got %d", tt.yaml, tt.depth, s.limiter.queueDepth)
+		}
+	}
+}
+
+func TestRequestLimiter_QueueTimeout(t *testing.T) {
+	l := newRequestLimiter(1, 1, 20*time.Millisecond)
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("First acquire failed: %v", err)
+	}
+	if err := l.acquire(context.Background()); err != errOverloaded {
+		t.Errorf("Expected errOverloaded after queue timeout, got %v", err)
+	}
+	l.release()
+	if err := l.acquire(context.Background()); err != nil {
+		t.Errorf("Expected acquire to succeed after release, got %v", err)
+	}
+
+	if newRequestLimiter(0, 10, time.Second) != nil {
+		t.Error("Expected nil limiter when max_in_flight is 0")
+	}
+}
+
+func TestHandleHealth_AdvertisesCapabilities(t *testing.T) {
+	vdb := &fakeVectorDB{caps: vectordb.ProviderCapabilities{Scroll: true, Count: true}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	rec := httptest.NewRecorder()
+	s.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var resp HealthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Capabilities == nil || *resp.Capabilities != vdb.caps {
+		t.Errorf("Expected capabilities %+v, got %+v", vdb.caps, resp.Capabilities)
+	}
+}
+
+func TestHandleRetrieve_IncludeNeighbors(t *testing.T) {
+	chunk := func(id, file string, start, end int) vectordb.SearchResult {
+		return vectordb.SearchResult{ID: id, Payload: vectordb.Payload{
+			ProjectID: "proj", FilePath: file, Symbol: id, StartLine: start, EndLine: end,
+		}}
+	}
+	// Scroll order differs from line order to ensure sorting by start_line
+	vdb := &fakeVectorDB{
+		caps: vectordb.ProviderCapabilities{Scroll: true},
+		chunks: []vectordb.SearchResult{
+			chunk("c", "a.go", 21, 30),
+			chunk("a", "a.go", 1, 10),
+			chunk("b", "a.go", 11, 20),
+			chunk("other", "b.go", 1, 5),
+		},
+	}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	hit := func(id string, start, end int) vectordb.SearchResult {
+		r := chunk(id, "a.go", start, end)
+		r.Score = 0.9
+		return r
+	}
+	vdb.results = []vectordb.SearchResult{hit("b", 11, 20), hit("a", 1, 10), hit("c", 21, 30)}
+
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q", IncludeNeighbors: true})
+	if len(resp.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(resp.Results))
+	}
+
+	// Middle chunk has both neighbors
+	middle := resp.Results[0].Neighbors
+	if middle == nil || middle.Previous == nil || middle.Next == nil ||
+		middle.Previous.Symbol != "a" || middle.Next.Symbol != "c" {
+		t.Errorf("Expected neighbors a/c for middle chunk, got %+v", middle)
+	}
+
+	// File edges have only one neighbor
+	first := resp.Results[1].Neighbors
+	if first == nil || first.Previous != nil || first.Next == nil || first.Next.Symbol != "b" {
+		t.Errorf("Expected only next neighbor b for first chunk, got %+v", first)
+	}
+	last := resp.Results[2].Neighbors
+	if last == nil || last.Next != nil || last.Previous == nil || last.Previous.Symbol != "b" {
+		t.Errorf("Expected only previous neighbor b for last chunk, got %+v", last)
+	}
+
+	// Without the flag no neighbors are attached
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q"})
+	if resp.Results[0].Neighbors != nil {
+		t.Error("Expected no neighbors when include_neighbors is false")
+	}
+}
+
+func TestHandleRetrieve_IncludeNeighborsUnsupported(t *testing.T) {
+	s, _ := newTestServer(t, testServerConfig, &fakeVectorDB{})
+
+	rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q", IncludeNeighbors: true})
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without scroll support, got %d", rec.Code)
+	}
+}
+
+func TestHandleRetrieve_ExpandClass(t *testing.T) {
+	chunk := func(file, symbol, symbolType, parent string, start int) vectordb.SearchResult {
+		return vectordb.SearchResult{ID: file + ":" + symbol, Score: 0.9, Payload: vectordb.Payload{
+			ProjectID: "proj", FilePath: file, Symbol: symbol, SymbolType: symbolType,
+			ParentSymbol: parent, StartLine: start,
+		}}
+	}
+	vdb := &fakeVectorDB{
+		caps: vectordb.ProviderCapabilities{Scroll: true},
+		chunks: []vectordb.SearchResult{
+			chunk("user.py", "User", "class", "", 1),
+			chunk("user.py", "User.save", "method", "User", 10),
+			// An oversized class is stored as split parts; #1 is the header
+			chunk("order.py", "Order#1", "class", "", 1),
+			chunk("order.py", "Order#2", "class", "", 40),
+		},
+	}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+	vdb.results = []vectordb.SearchResult{
+		chunk("user.py", "User.save", "method", "User", 10),
+		chunk("order.py", "Order.total", "method", "Order", 80),
+		chunk("util.py", "helper", "function", "", 1),
+	}
+
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q", Expand: "class"})
+	if len(resp.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(resp.Results))
+	}
+	if p := resp.Results[0].Parent; p == nil || p.Symbol != "User" || p.SymbolType != "class" {
+		t.Errorf("Expected class User attached to User.save, got %+v", p)
+	}
+	if resp.Results[0].ParentSymbol != "User" {
+		t.Errorf("Expected parent_symbol User, got %q", resp.Results[0].ParentSymbol)
+	}
+	if p := resp.Results[1].Parent; p == nil || p.Symbol != "Order#1" {
+		t.Errorf("Expected split class header Order#1, got %+v", p)
+	}
+	if resp.Results[2].Parent != nil {
+		t.Errorf("Expected no parent for a top-level function, got %+v", resp.Results[2].Parent)
+	}
+
+	// Without expand no parents are attached
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q"})
+	if resp.Results[0].Parent != nil {
+		t.Error("Expected no parent without expand")
+	}
+
+	rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q", Expand: "module"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown expand value, got %d", rec.Code)
+	}
+}
+
+func TestMaintenanceMode_Toggle(t *testing.T) {
+	t.Setenv("TEST_ADMIN_TOKEN", "secret")
+	s, _ := newTestServer(t, testServerConfig+`
+server:
+  admin_token_env: "TEST_ADMIN_TOKEN"
+`, &fakeVectorDB{})
+
+	toggle := func(token string, enabled bool) int {
+		body, _ := json.Marshal(MaintenanceRequest{Enabled: enabled})
+		req := httptest.NewRequest(http.MethodPost, "/admin/maintenance", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		s.requireAdmin(s.handleMaintenance)(rec, req)
+		return rec.Code
+	}
+	health := func() (int, HealthResponse) {
+		rec := httptest.NewRecorder()
+		s.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var resp HealthResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	if code := toggle("wrong", true); code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 with wrong token, got %d", code)
+	}
+	if code := toggle("secret", true); code != http.StatusOK {
+		t.Fatalf("Expected 200 enabling maintenance, got %d", code)
+	}
+
+	rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q"})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 in maintenance, got %d", rec.Code)
+	}
+	var errResp ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &errResp)
+	if errResp.Code != ErrCodeMaintenance {
+		t.Errorf("Expected code %s, got %s", ErrCodeMaintenance, errResp.Code)
+	}
+
+	if code, resp := health(); code != http.StatusServiceUnavailable || resp.Mode != "maintenance" {
+		t.Errorf("Expected health 503 with mode maintenance, got %d %q", code, resp.Mode)
+	}
+
+	livez := httptest.NewRecorder()
+	s.handleLivez(livez, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if livez.Code != http.StatusOK {
+		t.Errorf("Expected livez 200 in maintenance, got %d", livez.Code)
+	}
+
+	// Turning maintenance off restores normal serving
+	toggle("secret", false)
+	if rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q"}); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 after leaving maintenance, got %d", rec.Code)
+	}
+	if code, resp := health(); code != http.StatusOK || resp.Mode != "normal" {
+		t.Errorf("Expected healthy normal mode, got %d %q", code, resp.Mode)
+	}
+}
+
+func TestHandleRetrieve_ResultCache(t *testing.T) {
+	t.Setenv("TEST_ADMIN_TOKEN", "secret")
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "1", Score: 0.9, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig+`
+server:
+  admin_token_env: "TEST_ADMIN_TOKEN"
+  result_cache_size: 8
+  result_cache_ttl: "1m"
+`, vdb)
+
+	req := RetrieveRequest{ProjectID: "proj", Query: "login handler"}
+	if _, resp := doRetrieve(t, s, req); resp.Cached || len(resp.Results) != 1 {
+		t.Fatalf("Expected a fresh response on first request, got %+v", resp)
+	}
+
+	// Identical request is served from the cache without searching
+	_, resp := doRetrieve(t, s, req)
+	if !resp.Cached || len(resp.Results) != 1 || vdb.searchCalls != 1 {
+		t.Errorf("Expected a cache hit with one search, got cached=%v searches=%d", resp.Cached, vdb.searchCalls)
+	}
+
+	// A different filter is a different key
+	doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "login handler", Filters: &RetrieveFilters{Language: "go"}})
+	if vdb.searchCalls != 2 {
+		t.Errorf("Expected a filtered request to miss the cache, got %d searches", vdb.searchCalls)
+	}
+
+	// Reindexing the project invalidates its cached results
+	body, _ := json.Marshal(InvalidateCacheRequest{ProjectID: "proj"})
+	httpReq := httptest.NewRequest(http.MethodPost, "/admin/cache/invalidate", bytes.NewReader(body))
+	httpReq.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.requireAdmin(s.handleInvalidateCache)(rec, httpReq)
+	var invalidated InvalidateCacheResponse
+	json.Unmarshal(rec.Body.Bytes(), &invalidated)
+	if rec.Code != http.StatusOK || invalidated.Generation != 1 {
+		t.Fatalf("Expected 200 with generation 1, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if _, resp := doRetrieve(t, s, req); resp.Cached || vdb.searchCalls != 3 {
+		t.Errorf("Expected a cache miss after invalidation, got cached=%v searches=%d", resp.Cached, vdb.searchCalls)
+	}
+	if _, resp := doRetrieve(t, s, req); !resp.Cached {
+		t.Errorf("Expected the new generation to be cached again")
+	}
+}
+
+func TestHandleRetrieve_ResultCacheInvalidatedByReindex(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "1", Score: 0.9, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "main.go"}},
+	}}
+	s, dir := newTestServer(t, testServerConfig+`
+cache:
+  dir: "{{dir}}/cache"
+server:
+  result_cache_size: 8
+  result_cache_ttl: "1m"
+`, vdb)
+	writeProjectConfig(t, dir, "proj", "")
+	sourceDir := filepath.Join(dir, "sources", "proj")
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatalf("Failed to create source dir: %v", err)
+	}
+
+	reindex := func(source string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(sourceDir, "main.go"), []byte(source), 0644); err != nil {
+			t.Fatalf("Failed to write source: %v", err)
+		}
+		projectCfg, err := config.GetProject(filepath.Join(dir, "projects"), "proj")
+		if err != nil {
+			t.Fatalf("GetProject failed: %v", err)
+		}
+		idx := indexer.NewIndexer(s.cfg.Get(), &fakeEmbedder{}, vdb, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+			t.Fatalf("IndexProject failed: %v", err)
+		}
+	}
+
+	reindex("package main\n\nfunc main() {}\n")
+	req := RetrieveRequest{ProjectID: "proj", Query: "entry point"}
+	doRetrieve(t, s, req)
+	if _, resp := doRetrieve(t, s, req); !resp.Cached || vdb.searchCalls != 1 {
+		t.Fatalf("Expected a cache hit with one search, got cached=%v searches=%d", resp.Cached, vdb.searchCalls)
+	}
+
+	// An index run that changes the project drops its cached results
+	reindex("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	if _, resp := doRetrieve(t, s, req); resp.Cached || vdb.searchCalls != 2 {
+		t.Errorf("Expected a cache miss after reindex, got cached=%v searches=%d", resp.Cached, vdb.searchCalls)
+	}
+	if _, resp := doRetrieve(t, s, req); !resp.Cached {
+		t.Errorf("Expected the reindexed results to be cached again")
+	}
+}
+
+func TestResultCache_EvictsAndExpires(t *testing.T) {
+	c := newResultCache(2, time.Minute)
+	for _, key := range []string{"a", "b", "c"} {
+		c.put(key, &RetrieveResponse{Intent: key})
+	}
+	if _, ok := c.get("a"); ok {
+		t.Errorf("Expected the least recently used entry to be evicted")
+	}
+	if resp, ok := c.get("c"); !ok || resp.Intent != "c" {
+		t.Errorf("Expected c to be cached, got %+v", resp)
+	}
+
+	expired := newResultCache(2, -time.Second)
+	expired.put("a", &RetrieveResponse{})
+	if _, ok := expired.get("a"); ok {
+		t.Errorf("Expected expired entry to be dropped")
+	}
+
+	if newResultCache(0, time.Minute) != nil {
+		t.Errorf("Expected size 0 to disable the cache")
+	}
+}
+
+func TestMaintenanceMode_AdminDisabledWithoutToken(t *testing.T) {
+	s, _ := newTestServer(t, testServerConfig+`
+server:
+  maintenance: true
+`, &fakeVectorDB{})
+
+	if rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "q"}); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected config flag to start in maintenance, got %d", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/maintenance", bytes.NewReader([]byte(`{"enabled":false}`)))
+	s.requireAdmin(s.handleMaintenance)(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without configured admin token, got %d", rec.Code)
+	}
+}
+
+func TestHandleRetrieve_OwnerFilter(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{{
+		ID:      "1",
+		Score:   0.8,
+		Payload: vectordb.Payload{ProjectID: "proj", FilePath: "auth/login.go", Owner: "@org/identity"},
+	}}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	rec, resp := doRetrieve(t, s, RetrieveRequest{
+		ProjectID: "proj",
+		Query:     "login",
+		Filters:   &RetrieveFilters{Owner: "@org/identity"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if vdb.lastQuery.Filter.Owner != "@org/identity" {
+		t.Errorf("Expected owner filter forwarded, got %q", vdb.lastQuery.Filter.Owner)
+	}
+	if resp.Results[0].Owner != "@org/identity" {
+		t.Errorf("Expected owner in result, got %q", resp.Results[0].Owner)
+	}
+}
+
+func TestHandleRetrieve_FilePathFilter(t *testing.T) {
+	vdb := &fakeVectorDB{}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	for _, path := range []string{"src/auth/login.go", "src/auth/"} {
+		rec, _ := doRetrieve(t, s, RetrieveRequest{
+			ProjectID: "proj",
+			Query:     "login",
+			Filters:   &RetrieveFilters{FilePath: path},
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		if got := vdb.lastQuery.Filter.FilePath; got != path {
+			t.Errorf("Expected file_path filter %q forwarded, got %q", path, got)
+		}
+	}
+}
+
+func TestHandleRetrieve_CustomMetadataFilter(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{{
+		ID:      "1",
+		Score:   0.8,
+		Payload: vectordb.Payload{ProjectID: "proj", FilePath: "pay.go", Custom: map[string]string{"service": "billing"}},
+	}}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	rec, resp := doRetrieve(t, s, RetrieveRequest{
+		ProjectID: "proj",
+		Query:     "invoice",
+		Filters:   &RetrieveFilters{Custom: map[string]string{"service": "billing"}},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if got := vdb.lastQuery.Filter.Custom["service"]; got != "billing" {
+		t.Errorf("Expected custom filter forwarded, got %v", vdb.lastQuery.Filter.Custom)
+	}
+	if resp.Results[0].Custom["service"] != "billing" {
+		t.Errorf("Expected custom metadata in result, got %v", resp.Results[0].Custom)
+	}
+
+	rec, _ = doRetrieve(t, s, RetrieveRequest{
+		ProjectID: "proj",
+		Query:     "invoice",
+		Filters:   &RetrieveFilters{Custom: map[string]string{"Service Name": "billing"}},
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid custom key, got %d", rec.Code)
+	}
+}
+
+func TestHandleRetrieveBatch_MinBestScore(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "1", Score: 0.42, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go"}},
+		{ID: "2", Score: 0.31, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "b.go"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	doBatch := func(req BatchRetrieveRequest) BatchRetrieveResponse {
+		t.Helper()
+		body, _ := json.Marshal(req)
+		rec := httptest.NewRecorder()
+		s.handleRetrieveBatch(rec, httptest.NewRequest(http.MethodPost, "/retrieve/batch", bytes.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp BatchRetrieveResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// Best score 0.42 is below the floor: no useful match
+	resp := doBatch(BatchRetrieveRequest{
+		ProjectID:    "proj",
+		Queries:      []string{"quantum flux capacitor", "another weak query"},
+		MinBestScore: 0.5,
+	})
+	if len(resp.Results) != 2 {
+		t.Fatalf("Expected 2 sub-query results, got %d", len(resp.Results))
+	}
+	for _, r := range resp.Results {
+		if !r.NoMatch || len(r.Results) != 0 {
+			t.Errorf("Expected no_match with empty results for %q, got %+v", r.Query, r)
+		}
+	}
+
+	// Floor below the best score keeps the hits
+	resp = doBatch(BatchRetrieveRequest{ProjectID: "proj", Queries: []string{"login"}, MinBestScore: 0.4})
+	if resp.Results[0].NoMatch || len(resp.Results[0].Results) != 2 {
+		t.Errorf("Expected hits to be kept above the floor, got %+v", resp.Results[0])
+	}
+}
+
+func TestHandleRetrieveBatch_SingleProviderCall(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "1", Score: 0.9, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	body, _ := json.Marshal(BatchRetrieveRequest{ProjectID: "proj", Queries: []string{"login", "logout", "session"}})
+	rec := httptest.NewRecorder()
+	s.handleRetrieveBatch(rec, httptest.NewRequest(http.MethodPost, "/retrieve/batch", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if vdb.batchCalls != 1 || vdb.searchCalls != 0 {
+		t.Errorf("Expected one SearchBatch call and no Search calls, got %d and %d", vdb.batchCalls, vdb.searchCalls)
+	}
+	var resp BatchRetrieveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Results) != 3 || resp.Results[2].Query != "session" || len(resp.Results[2].Results) != 1 {
+		t.Errorf("Expected 3 sub-query results in request order, got %+v", resp.Results)
+	}
+}
+
+func TestHandleRetrieveBatch_Validation(t *testing.T) {
+	s, _ := newTestServer(t, testServerConfig, &fakeVectorDB{})
+
+	tooMany := make([]string, maxBatchQueries+1)
+	for i := range tooMany {
+		tooMany[i] = "q"
+	}
+	for name, req := range map[string]BatchRetrieveRequest{
+		"missing project": {Queries: []string{"q"}},
+		"no queries":      {ProjectID: "proj"},
+		"empty query":     {ProjectID: "proj", Queries: []string{"q", ""}},
+		"too many":        {ProjectID: "proj", Queries: tooMany},
+	} {
+		body, _ := json.Marshal(req)
+		rec := httptest.NewRecorder()
+		s.handleRetrieveBatch(rec, httptest.NewRequest(http.MethodPost, "/retrieve/batch", bytes.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestHandleRetrieve_SourceURL(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "1", Score: 0.9, Payload: vectordb.Payload{ProjectID: "linked", FilePath: "auth/login.go", StartLine: 10, EndLine: 24, GitRef: "3f9c2ab"}},
+		{ID: "2", Score: 0.8, Payload: vectordb.Payload{ProjectID: "linked", FilePath: "main.go", StartLine: 1, EndLine: 5}},
+	}}
+	s, dir := newTestServer(t, testServerConfig, vdb)
+	writeProjectConfig(t, dir, "linked", `
+source_url:
+  template: "https://git.example.com/{repo}/blob/{ref}/{path}#L{start}-L{end}"
+  repo: "team/linked"
+  ref: "main"
+`)
+	writeProjectConfig(t, dir, "plain", "")
+
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "linked", Query: "login"})
+	if got, want := resp.Results[0].URL, "https://git.example.com/team/linked/blob/3f9c2ab/auth/login.go#L10-L24"; got != want {
+		t.Errorf("Expected URL %q, got %q", want, got)
+	}
+	// Chunks without a stored git_ref fall back to the configured ref
+	if got, want := resp.Results[1].URL, "https://git.example.com/team/linked/blob/main/main.go#L1-L5"; got != want {
+		t.Errorf("Expected fallback URL %q, got %q", want, got)
+	}
+
+	// No template: url is omitted from the response
+	rec, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "plain", Query: "login"})
+	if resp.Results[0].URL != "" {
+		t.Errorf("Expected no URL without a template, got %q", resp.Results[0].URL)
+	}
+	if bytes.Contains(rec.Body.Bytes(), []byte(`"url"`)) {
+		t.Errorf("Expected url field omitted, got %s", rec.Body.String())
+	}
+}
+
+func TestHandleRetrieve_LineRangeFilter(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "stub", Score: 0.9, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go", Symbol: "Stub", StartLine: 3, EndLine: 3}},
+		{ID: "impl", Score: 0.8, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go", Symbol: "Impl", StartLine: 10, EndLine: 40}},
+		{ID: "huge", Score: 0.7, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "b.go", Symbol: "Huge", StartLine: 1, EndLine: 500}},
+		{ID: "small", Score: 0.6, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "c.go", Symbol: "Small", StartLine: 5, EndLine: 8}},
+	}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	rec, resp := doRetrieve(t, s, RetrieveRequest{
+		ProjectID: "proj",
+		Query:     "implementation",
+		TopK:      2,
+		Filters:   &RetrieveFilters{MinLines: 5},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if vdb.lastQuery.TopK != 2*candidateMultiplier {
+		t.Errorf("Expected %d candidates to be fetched, got %d", 2*candidateMultiplier, vdb.lastQuery.TopK)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Symbol != "Impl" || resp.Results[1].Symbol != "Huge" {
+		t.Errorf("Expected tiny chunks excluded, got %+v", resp.Results)
+	}
+
+	_, resp = doRetrieve(t, s, RetrieveRequest{
+		ProjectID: "proj",
+		Query:     "implementation",
+		Filters:   &RetrieveFilters{MinLines: 2, MaxLines: 100},
+	})
+	if len(resp.Results) != 2 || resp.Results[0].Symbol != "Impl" || resp.Results[1].Symbol != "Small" {
+		t.Errorf("Expected only chunks within 2-100 lines, got %+v", resp.Results)
+	}
+
+	rec, _ = doRetrieve(t, s, RetrieveRequest{
+		ProjectID: "proj",
+		Query:     "implementation",
+		Filters:   &RetrieveFilters{MinLines: 50, MaxLines: 10},
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an inverted range, got %d", rec.Code)
+	}
+}
+
+func TestHandleRetrieve_DedupThreshold(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "a", Score: 0.9, Vector: []float32{1, 0, 0}, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go", Symbol: "Parse"}},
+		{ID: "a-copy", Score: 0.89, Vector: []float32{0.99, 0.05, 0}, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "b.go", Symbol: "Parse"}},
+		{ID: "other", Score: 0.7, Vector: []float32{0, 1, 0}, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "c.go", Symbol: "Render"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	rec, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", TopK: 2, DedupThreshold: 0.98})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if !vdb.lastQuery.WithVectors || vdb.lastQuery.TopK != 2*candidateMultiplier {
+		t.Errorf("Expected vectors and %d candidates to be requested, got %+v", 2*candidateMultiplier, vdb.lastQuery)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Source != "a.go" || resp.Results[1].Source != "c.go" {
+		t.Errorf("Expected the near-duplicate to be dropped, got %+v", resp.Results)
+	}
+
+	// Below the pair's similarity nothing is dropped
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", TopK: 3, DedupThreshold: 0.9999})
+	if len(resp.Results) != 3 {
+		t.Errorf("Expected all results above a stricter threshold, got %+v", resp.Results)
+	}
+
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", TopK: 3})
+	if vdb.lastQuery.WithVectors || len(resp.Results) != 3 {
+		t.Errorf("Expected dedup to be disabled by default, got %d results", len(resp.Results))
+	}
+
+	rec, _ = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", DedupThreshold: 1.5})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an out-of-range threshold, got %d", rec.Code)
+	}
+}
+
+// reverseReranker scores documents by their position so the reranked order
+// is the reverse of the vector order.
+type reverseReranker struct {
+	topN      int
+	err       error
+	documents []string
+}
+
+func (r *reverseReranker) Rerank(ctx context.Context, query string, documents []string) ([]float32, error) {
+	r.documents = documents
+	if r.err != nil {
+		return nil, r.err
+	}
+	scores := make([]float32, len(documents))
+	for i := range documents {
+		scores[i] = float32(i)
+	}
+	return scores, nil
+}
+
+func (r *reverseReranker) TopN() int { return r.topN }
+
+func TestHandleRetrieve_Rerank(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "a", Score: 0.9, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go", Content: "func A() {}"}},
+		{ID: "b", Score: 0.8, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "b.go", Content: "func B() {}"}},
+		{ID: "c", Score: 0.7, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "c.go", Symbol: "C"}},
+		{ID: "d", Score: 0.6, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "d.go", Content: "func D() {}"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+	stub := &reverseReranker{topN: 3}
+	s.SetReranker(stub)
+
+	rec, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "func", TopK: 2, DebugScores: true})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if vdb.lastQuery.TopK != 2*candidateMultiplier {
+		t.Errorf("Expected %d candidates to be requested, got %d", 2*candidateMultiplier, vdb.lastQuery.TopK)
+	}
+	wantDocs := []string{"func A() {}", "func B() {}", "c.go C"}
+	if strings.Join(stub.documents, "|") != strings.Join(wantDocs, "|") {
+		t.Errorf("Expected the top 3 contents to be reranked, got %q", stub.documents)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Source != "c.go" || resp.Results[1].Source != "b.go" {
+		t.Fatalf("Expected reranked order c, b, got %+v", resp.Results)
+	}
+	if resp.Results[0].Score != 2 || resp.Results[0].RawScore == nil || *resp.Results[0].RawScore != 0.7 {
+		t.Errorf("Expected reranker score with the raw similarity, got %+v", resp.Results[0])
+	}
+
+	// A failing reranker keeps the vector order
+	s.SetReranker(&reverseReranker{topN: 3, err: errors.New("reranker down")})
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "func", TopK: 2})
+	if len(resp.Results) != 2 || resp.Results[0].Source != "a.go" || resp.Results[1].Source != "b.go" {
+		t.Errorf("Expected vector order on reranker failure, got %+v", resp.Results)
+	}
+
+	// Without a reranker the candidate pool is not widened
+	s.SetReranker(nil)
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "func", TopK: 2})
+	if vdb.lastQuery.TopK != 2 || resp.Results[0].Source != "a.go" {
+		t.Errorf("Expected plain vector search without reranker, got top_k %d and %+v", vdb.lastQuery.TopK, resp.Results)
+	}
+}
+
+func TestHighlightRanges(t *testing.T) {
+	content := "// ParseToken parses a token.\nfunc ParseToken(s string) (Token, error)"
+	got := highlightRanges(content, highlightTerms("how to parse token"))
+	want := []Highlight{{3, 13}, {14, 19}, {23, 28}, {35, 45}, {57, 62}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("highlightRanges = %v, want %v", got, want)
+	}
+
+	// Overlapping and adjacent matches merge; offsets count code points, not bytes
+	got = highlightRanges("çağrı ParseToken", highlightTerms("ParseToken token"))
+	if want := []Highlight{{6, 16}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("highlightRanges = %v, want %v", got, want)
+	}
+
+	if got := highlightRanges("func Render()", highlightTerms("a")); got != nil {
+		t.Errorf("Expected no highlights for single-character terms, got %v", got)
+	}
+}
+
+func TestHandleRetrieve_Highlight(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "a", Score: 0.9, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "auth.go", Content: "func Login(user string) error"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "user login", Highlight: true})
+	if len(resp.Results) != 1 {
+		t.Fatalf("Expected 1 result, got %+v", resp.Results)
+	}
+	want := []Highlight{{5, 10}, {11, 15}}
+	if !reflect.DeepEqual(resp.Results[0].Highlights, want) {
+		t.Errorf("Highlights = %v, want %v", resp.Results[0].Highlights, want)
+	}
+
+	_, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "user login"})
+	if resp.Results[0].Highlights != nil {
+		t.Errorf("Expected no highlights by default, got %v", resp.Results[0].Highlights)
+	}
+}
+
+func TestSelectByMMR(t *testing.T) {
+	// Three near-identical copies of one chunk outrank two distinct chunks
+	candidates := []vectordb.SearchResult{
+		{ID: "parse-1", Score: 0.95, Vector: []float32{1, 0, 0}},
+		{ID: "parse-2", Score: 0.94, Vector: []float32{0.99, 0.1, 0}},
+		{ID: "parse-3", Score: 0.93, Vector: []float32{0.98, 0.15, 0}},
+		{ID: "render", Score: 0.80, Vector: []float32{0, 1, 0}},
+		{ID: "config", Score: 0.75, Vector: []float32{0, 0, 1}},
+	}
+	ids := func(results []vectordb.SearchResult) string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := ids(selectByMMR(candidates, 3, 0.5)); got != "parse-1,render,config" {
+		t.Errorf("Expected diverse selection, got %s", got)
+	}
+	// A small weight only breaks near-ties, so relevance still dominates
+	if got := ids(selectByMMR(candidates, 3, 0.05)); got != "parse-1,parse-2,parse-3" {
+		t.Errorf("Expected relevance order with low diversity, got %s", got)
+	}
+	if got := ids(selectByMMR(candidates, 3, 0)); got != ids(candidates) {
+		t.Errorf("Expected results unchanged when disabled, got %s", got)
+	}
+	if candidates[1].ID != "parse-2" {
+		t.Error("Expected the input slice to be left untouched")
+	}
+}
+
+func TestHandleRetrieve_Diversity(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "a", Score: 0.9, Vector: []float32{1, 0}, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go"}},
+		{ID: "a-copy", Score: 0.89, Vector: []float32{1, 0.05}, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "vendor/a.go"}},
+		{ID: "b", Score: 0.7, Vector: []float32{0, 1}, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "b.go"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", TopK: 2, Diversity: 0.5})
+	if !vdb.lastQuery.WithVectors || vdb.lastQuery.TopK != 2*candidateMultiplier {
+		t.Errorf("Expected vectors and %d candidates to be requested, got %+v", 2*candidateMultiplier, vdb.lastQuery)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Source != "a.go" || resp.Results[1].Source != "b.go" {
+		t.Errorf("Expected the near-duplicate to be passed over, got %+v", resp.Results)
+	}
+
+	rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", Diversity: -0.1})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an out-of-range diversity, got %d", rec.Code)
+	}
+}
+
+func TestHandleProjects(t *testing.T) {
+	vdb := &fakeVectorDB{chunks: []vectordb.SearchResult{
+		{ID: "1", Payload: vectordb.Payload{ProjectID: "alpha"}},
+		{ID: "2", Payload: vectordb.Payload{ProjectID: "alpha"}},
+		{ID: "3", Payload: vectordb.Payload{ProjectID: "beta"}},
+	}}
+	s, dir := newTestServer(t, testServerConfig+`
+cache:
+  dir: "{{dir}}/cache"
+`, vdb)
+	writeProjectConfig(t, dir, "beta", "")
+	writeProjectConfig(t, dir, "alpha", "display_name: \"Alpha Service\"\n")
+
+	indexedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache, err := indexer.NewCache(filepath.Join(dir, "cache"), "alpha")
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	cache.Set("main.go", indexer.CacheEntry{ChunkIDs: []string{"1", "2", "3"}, IndexedAt: indexedAt})
+	if err := cache.Save("alpha"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	get := func() []ProjectInfo {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleProjects(rec, httptest.NewRequest(http.MethodGet, "/projects", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var projects []ProjectInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &projects); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return projects
+	}
+
+	projects := get()
+	if len(projects) != 2 || projects[0].ProjectID != "alpha" || projects[1].ProjectID != "beta" {
+		t.Fatalf("Expected alpha and beta sorted by ID, got %+v", projects)
+	}
+	alpha, beta := projects[0], projects[1]
+	if alpha.DisplayName != "Alpha Service" || alpha.ChunkCount != 2 || alpha.FileCount != 1 {
+		t.Errorf("Unexpected alpha entry %+v", alpha)
+	}
+	if alpha.LastIndexedAt == nil || !alpha.LastIndexedAt.Equal(indexedAt) {
+		t.Errorf("Expected alpha last indexed at %v, got %v", indexedAt, alpha.LastIndexedAt)
+	}
+	if beta.DisplayName != "beta" || beta.ChunkCount != 1 || beta.LastIndexedAt != nil {
+		t.Errorf("Expected beta without cache stats, got %+v", beta)
+	}
+
+	// Counts fall back to the index cache when the vector DB fails
+	vdb.countErr = errors.New("vectordb down")
+	if projects := get(); projects[0].ChunkCount != 3 || projects[1].ChunkCount != 0 {
+		t.Errorf("Expected cache chunk counts, got %+v", projects)
+	}
+}
+
+func TestApplyRecencyDecay(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	candidates := func() []vectordb.SearchResult {
+		return []vectordb.SearchResult{
+			{ID: "old", Score: 0.8, Payload: vectordb.Payload{LastModified: "2023-06-01T00:00:00Z"}},
+			{ID: "new", Score: 0.8, Payload: vectordb.Payload{LastModified: "2024-05-31T00:00:00Z"}},
+			{ID: "legacy", Score: 0.5, Payload: vectordb.Payload{IndexedAt: "2024-06-01T00:00:00Z"}},
+		}
+	}
+
+	results := candidates()
+	applyRecencyDecay(results, 7*24*time.Hour, now)
+	if results[0].ID != "new" || results[1].ID != "legacy" || results[2].ID != "old" {
+		t.Fatalf("Expected newer chunks first under a short half-life, got %+v", results)
+	}
+	// One day old with a 7 day half-life: 0.8 * 2^(-1/7)
+	if want := float32(0.8 * math.Exp2(-1.0/7)); math.Abs(float64(results[0].Score-want)) > 1e-6 {
+		t.Errorf("Expected decayed score %f, got %f", want, results[0].Score)
+	}
+	if results[1].Score != 0.5 {
+		t.Errorf("Expected indexed_at fallback with no age to keep its score, got %f", results[1].Score)
+	}
+
+	results = candidates()
+	applyRecencyDecay(results, 0, now)
+	if results[0].ID != "old" || results[1].ID != "new" || results[0].Score != 0.8 {
+		t.Errorf("Expected order and scores unchanged with decay off, got %+v", results)
+	}
+}
+
+func TestHandleRetrieve_RecencyHalfLife(t *testing.T) {
+	now := time.Now().UTC()
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "old", Score: 0.8, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "old.go", LastModified: now.AddDate(-1, 0, 0).Format(time.RFC3339)}},
+		{ID: "new", Score: 0.8, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "new.go", LastModified: now.Format(time.RFC3339)}},
+	}}
+	s, _ := newTestServer(t, testServerConfig+`
+server:
+  recency_half_life: "168h"
+`, vdb)
+
+	_, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", TopK: 1})
+	if vdb.lastQuery.TopK != candidateMultiplier {
+		t.Errorf("Expected %d candidates to be requested, got %d", candidateMultiplier, vdb.lastQuery.TopK)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Source != "new.go" || resp.Results[0].LastModified == "" {
+		t.Errorf("Expected the recently modified chunk, got %+v", resp.Results)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		{ID: "a", Score: 0.9, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "a.go"}},
+	}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+
+	if rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse"}); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if rec, _ := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without query, got %d", rec.Code)
+	}
+	s.handleHealth(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	rec := httptest.NewRecorder()
+	s.metrics.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"retrieval_retrieve_requests_total 2",
+		`retrieval_errors_total{code="` + string(ErrCodeMissingField) + `"} 1`,
+		"retrieval_query_time_ms_count 1",
+		`retrieval_dependency_up{dependency="embedder"} 1`,
+		`retrieval_dependency_up{dependency="vectordb"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q", want)
+		}
+	}
+}
+
+func TestHandleRetrieve_ValidateProjects(t *testing.T) {
+	vdb := &fakeVectorDB{}
+	s, dir := newTestServer(t, testServerConfig+`
+server:
+  validate_projects: true
+`, vdb)
+	writeProjectConfig(t, dir, "empty", "")
+	writeProjectConfig(t, dir, "proj", "")
+
+	// Known but never indexed: 200 with no results, flagged
+	rec, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "empty", Query: "parse"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a known project, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(resp.Results) != 0 || !resp.ProjectNotIndexed {
+		t.Errorf("Expected an empty response flagged project_not_indexed, got %+v", resp)
+	}
+
+	// Known and indexed: results, not flagged
+	vdb.results = []vectordb.SearchResult{{ID: "1", Score: 0.9, Payload: vectordb.Payload{ProjectID: "proj", FilePath: "main.go", Content: "func Parse() {}"}}}
+	vdb.chunks = vdb.results
+	rec, resp = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(resp.Results) != 1 || resp.ProjectNotIndexed {
+		t.Errorf("Expected one unflagged result, got %+v", resp)
+	}
+
+	// Unknown: 404 before anything is searched
+	vdb.lastQuery = vectordb.SearchQuery{}
+	rec, _ = doRetrieve(t, s, RetrieveRequest{ProjectID: "missing", Query: "parse"})
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), string(ErrCodeProjectNotFound)) {
+		t.Errorf("Expected 404 PROJECT_NOT_FOUND for an unknown project, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if vdb.lastQuery.TopK != 0 {
+		t.Error("Expected no search for an unknown project")
+	}
+}
+
+func TestHandleRetrieveBatch_ValidateProjects(t *testing.T) {
+	vdb := &fakeVectorDB{}
+	s, dir := newTestServer(t, testServerConfig+`
+server:
+  validate_projects: true
+`, vdb)
+	writeProjectConfig(t, dir, "empty", "")
+
+	doBatch := func(projectID string) (*httptest.ResponseRecorder, BatchRetrieveResponse) {
+		t.Helper()
+		body, _ := json.Marshal(BatchRetrieveRequest{ProjectID: projectID, Queries: []string{"parse", "render"}})
+		rec := httptest.NewRecorder()
+		s.handleRetrieveBatch(rec, httptest.NewRequest(http.MethodPost, "/retrieve/batch", bytes.NewReader(body)))
+		var resp BatchRetrieveResponse
+		if rec.Code == http.StatusOK {
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+		}
+		return rec, resp
+	}
+
+	// Known but never indexed: 200, flagged
+	if rec, resp := doBatch("empty"); rec.Code != http.StatusOK || !resp.ProjectNotIndexed {
+		t.Errorf("Expected 200 flagged project_not_indexed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Unknown: 404 like /retrieve, before anything is searched
+	rec, _ := doBatch("missing")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), string(ErrCodeProjectNotFound)) {
+		t.Errorf("Expected 404 PROJECT_NOT_FOUND for an unknown project, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if vdb.batchCalls != 1 {
+		t.Errorf("Expected no search for an unknown project, got %d batch calls", vdb.batchCalls)
+	}
+}
+
+func TestHandleRetrieve_UnknownProjectWithoutValidation(t *testing.T) {
+	s, _ := newTestServer(t, testServerConfig, &fakeVectorDB{})
+
+	rec, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "missing", Query: "parse"})
+	if rec.Code != http.StatusOK || resp.ProjectNotIndexed {
+		t.Errorf("Expected a plain empty 200 with validate_projects off, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleRetrieve_ContextLines(t *testing.T) {
+	lines := make([]string, 10)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	source := strings.Join(lines, "\n") + "\n"
+	sha := func(text string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(text))) }
+	chunk := func(file string, start, end int, exactHash string) vectordb.SearchResult {
+		return vectordb.SearchResult{ID: file, Score: 0.9, Payload: vectordb.Payload{
+			ProjectID: "proj", FilePath: file, StartLine: start, EndLine: end,
+			Content: "chunk", ContentHash: exactHash,
+		}}
+	}
+
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{
+		chunk("main.go", 4, 5, sha("line 4\nline 5")),
+		chunk("edge.go", 1, 2, sha("line 1\nline 2")),
+		chunk("gone.go", 4, 5, sha("line 4\nline 5")),
+		chunk("edited.go", 4, 5, sha("old 4\nold 5")),
+		chunk("cached.go", 4, 5, ""),
+		chunk("stale.go", 4, 5, sha("line 4\nline 5")),
+	}}
+	s, dir := newTestServer(t, testServerConfig+`
+cache:
+  dir: "{{dir}}/cache"
+`, vdb)
+	writeProjectConfig(t, dir, "proj", "")
+	sourceDir := filepath.Join(dir, "sources", "proj")
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatalf("Failed to create source dir: %v", err)
+	}
+	for _, name := range []string{"main.go", "edge.go", "edited.go", "cached.go", "stale.go"} {
+		if err := os.WriteFile(filepath.Join(sourceDir, name), []byte(source), 0644); err != nil {
+			t.Fatalf("Failed to write source: %v", err)
+		}
+	}
+
+	// The index cache vouches for cached.go's file hash and rejects stale.go
+	cache, err := indexer.NewCache(filepath.Join(dir, "cache"), "proj")
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	cache.Set("cached.go", indexer.CacheEntry{ContentHash: sha(source)})
+	cache.Set("stale.go", indexer.CacheEntry{ContentHash: sha("older source")})
+	if err := cache.Save("proj"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	rec, resp := doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", TopK: 10, ContextLines: 2})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(resp.Results) != 6 {
+		t.Fatalf("Expected 6 results, got %d", len(resp.Results))
+	}
+
+	want := map[string][2]string{
+		"main.go":   {"line 2\nline 3", "line 6\nline 7"},
+		"edge.go":   {"", "line 3\nline 4"},
+		"gone.go":   {"", ""},
+		"edited.go": {"", ""},
+		"cached.go": {"line 2\nline 3", "line 6\nline 7"},
+		"stale.go":  {"", ""},
+	}
+	for _, r := range resp.Results {
+		if got := [2]string{r.ContextBefore, r.ContextAfter}; got != want[r.Source] {
+			t.Errorf("%s: context = %q, want %q", r.Source, got, want[r.Source])
+		}
+		if r.Content != "chunk" {
+			t.Errorf("%s: expected chunk content unchanged, got %q", r.Source, r.Content)
+		}
+	}
+
+	rec, _ = doRetrieve(t, s, RetrieveRequest{ProjectID: "proj", Query: "parse", ContextLines: maxContextLines + 1})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for context_lines above the cap, got %d", rec.Code)
+	}
+}
+
+func TestHandleEmbed(t *testing.T) {
+	t.Setenv("TEST_ADMIN_TOKEN", "secret")
+	s, _ := newTestServer(t, testServerConfig+`
+server:
+  admin_token_env: "TEST_ADMIN_TOKEN"
+`, &fakeVectorDB{})
+
+	embed := func(req *http.Request) *httptest.ResponseRecorder {
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		s.requireAdmin(s.handleEmbed)(rec, req)
+		return rec
+	}
+
+	for name, req := range map[string]*http.Request{
+		"GET":  httptest.NewRequest(http.MethodGet, "/embed?text=authentication+flow", nil),
+		"POST": httptest.NewRequest(http.MethodPost, "/embed", bytes.NewReader([]byte(`{"text":"authentication flow"}`))),
+	} {
+		rec := embed(req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+		var resp EmbedResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", name, err)
+		}
+		if len(resp.Vector) != 3 || resp.Dimensions != 3 || resp.Model.Dimensions != 3 {
+			t.Errorf("%s: expected a 3-dimensional vector, got %+v", name, resp)
+		}
+		if resp.Model.Provider != "fake" || resp.Model.Model != "fake-model" {
+			t.Errorf("%s: expected model metadata, got %+v", name, resp.Model)
+		}
+	}
+
+	tooLong := strings.Repeat("a", maxEmbedInputChars+1)
+	body, _ := json.Marshal(EmbedRequest{Text: tooLong})
+	if rec := embed(httptest.NewRequest(http.MethodPost, "/embed", bytes.NewReader(body))); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for oversized text, got %d", rec.Code)
+	}
+	if rec := embed(httptest.NewRequest(http.MethodGet, "/embed", nil)); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without text, got %d", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	s.requireAdmin(s.handleEmbed)(rec, httptest.NewRequest(http.MethodGet, "/embed?text=q", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+}
+
+// slowEmbedder blocks Embed until release is closed and records Close.
+type slowEmbedder struct {
+	fakeEmbedder
+	started chan struct{}
+	release chan struct{}
+	closed  atomic.Bool
+}
+
+func (e *slowEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	close(e.started)
+	<-e.release
+	if e.closed.Load() {
+		return nil, errors.New("embedder closed")
+	}
+	return e.fakeEmbedder.Embed(ctx, text)
+}
+
+func (e *slowEmbedder) Close() error {
+	e.closed.Store(true)
+	return nil
+}
+
+func TestUpdateProviders_DrainsInFlightRequests(t *testing.T) {
+	vdb := &fakeVectorDB{results: []vectordb.SearchResult{{ID: "1", Score: 0.9}}}
+	s, _ := newTestServer(t, testServerConfig, vdb)
+	old := &slowEmbedder{started: make(chan struct{}), release: make(chan struct{})}
+	s.UpdateProviders(old, vdb)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		body, _ := json.Marshal(RetrieveRequest{ProjectID: "proj", Query: "main"})
+		rec := httptest.NewRecorder()
+		s.handleRetrieve(rec, httptest.NewRequest(http.MethodPost, "/retrieve", bytes.NewReader(body)))
+		done <- rec
+	}()
+
+	// Swap providers while the request is blocked inside the old embedder
+	<-old.started
+	s.UpdateProviders(&fakeEmbedder{}, &fakeVectorDB{results: vdb.results})
+	time.Sleep(20 * time.Millisecond)
+	if old.closed.Load() {
+		t.Fatal("old embedder closed while a request was still using it")
+	}
+
+	close(old.release)
+	if rec := <-done; rec.Code != http.StatusOK {
+		t.Fatalf("Expected in-flight request to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Once drained, the old embedder is closed
+	deadline := time.Now().Add(time.Second)
+	for !old.closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("old embedder was not closed after the request finished")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxHighlights caps the ranges returned per result to bound response size.
+const maxHighlights = 50
+
+// Highlight is a matched range in a result's content, in Unicode code point
+// offsets: Start is inclusive, End exclusive.
+type Highlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// highlightTerms returns the lower-cased query terms worth highlighting:
+// identifier-like tokens and their qualified-name parts, minus single
+// characters and question words.
+func highlightTerms(query string) []string {
+	var terms []string
+	for token := range queryTokens(query) {
+		term := strings.ToLower(token)
+		if utf8.RuneCountInString(term) < 2 || questionWords[term] {
+			continue
+		}
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// highlightRanges finds case-insensitive occurrences of terms in content and
+// returns them as sorted code point ranges, merging overlapping and adjacent
+// matches (at most maxHighlights).
+func highlightRanges(content string, terms []string) []Highlight {
+	if content == "" || len(terms) == 0 {
+		return nil
+	}
+
+	// Lower-case rune by rune so offsets stay aligned with content
+	text := []rune(content)
+	for i, r := range text {
+		text[i] = unicode.ToLower(r)
+	}
+
+	var ranges []Highlight
+	for _, term := range terms {
+		pattern := []rune(term)
+		for i := 0; i+len(pattern) <= len(text); i++ {
+			if runesEqual(text[i:i+len(pattern)], pattern) {
+				ranges = append(ranges, Highlight{Start: i, End: i + len(pattern)})
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].Start != ranges[j].Start {
+			return ranges[i].Start < ranges[j].Start
+		}
+		return ranges[i].End > ranges[j].End
+	})
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		if len(merged) == maxHighlights {
+			break
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// runesEqual reports whether a and b hold the same runes.
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// attachHighlights sets the matched query term ranges on each result's content.
+func attachHighlights(query string, results []RetrieveResult) {
+	terms := highlightTerms(query)
+	for i := range results {
+		results[i].Highlights = highlightRanges(results[i].Content, terms)
+	}
+}
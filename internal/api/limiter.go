@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// errOverloaded is returned when no slot frees up within the queue timeout
+// or the queue is already full.
+var errOverloaded = errors.New("server overloaded")
+
+// requestLimiter bounds concurrent requests with a fixed number of in-flight
+// slots and a bounded wait queue in front of them.
+type requestLimiter struct {
+	slots      chan struct{}
+	queueDepth int64
+	waiting    atomic.Int64
+	timeout    time.Duration
+}
+
+// newRequestLimiter creates a limiter. Returns nil when maxInFlight is 0,
+// which disables limiting.
+func newRequestLimiter(maxInFlight, queueDepth int, timeout time.Duration) *requestLimiter {
+	if maxInFlight <= 0 {
+		return nil
+	}
+	return &requestLimiter{
+		slots:      make(chan struct{}, maxInFlight),
+		queueDepth: int64(queueDepth),
+		timeout:    timeout,
+	}
+}
+
+// acquire takes an in-flight slot, waiting in the queue up to the timeout.
+func (l *requestLimiter) acquire(ctx context.Context) error {
+	// Fast path: free slot
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if l.waiting.Add(1) > l.queueDepth {
+		l.waiting.Add(-1)
+		return errOverloaded
+	}
+	defer l.waiting.Add(-1)
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return errOverloaded
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees an in-flight slot.
+func (l *requestLimiter) release() {
+	<-l.slots
+}
+
+// withBackpressure wraps a handler with the server's request limiter.
+// Requests beyond capacity get 503 with ErrCodeOverloaded.
+func (s *Server) withBackpressure(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.limiter == nil {
+			next(w, r)
+			return
+		}
+
+		if err := s.limiter.acquire(r.Context()); err != nil {
+			s.logger.Warn("request rejected", "path", r.URL.Path, "reason", err)
+			w.Header().Set("Retry-After", "1")
+			s.writeErrorWithCode(w, http.StatusServiceUnavailable, "server overloaded, retry later", ErrCodeOverloaded)
+			return
+		}
+		defer s.limiter.release()
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,73 @@
+// Package api provides Prometheus metrics for the retrieval server.
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// serverMetrics holds the metrics exposed on GET /metrics. Each server has
+// its own registry, so several servers (e.g. in tests) don't collide.
+type serverMetrics struct {
+	registry         *prometheus.Registry
+	retrieveRequests prometheus.Counter
+	errors           *prometheus.CounterVec
+	queryTime        prometheus.Histogram
+	dependencyUp     *prometheus.GaugeVec
+}
+
+// newServerMetrics creates and registers the server metrics.
+func newServerMetrics() *serverMetrics {
+	m := &serverMetrics{
+		registry: prometheus.NewRegistry(),
+		retrieveRequests: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "retrieval_retrieve_requests_total",
+			Help: "Total POST /retrieve requests.",
+		}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retrieval_errors_total",
+			Help: "Error responses by error code.",
+		}, []string{"code"}),
+		queryTime: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "retrieval_query_time_ms",
+			Help:    "Successful POST /retrieve latency (query_time_ms) in milliseconds.",
+			Buckets: []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+		}),
+		dependencyUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "retrieval_dependency_up",
+			Help: "Whether a dependency passed its last health check (1) or not (0).",
+		}, []string{"dependency"}),
+	}
+	m.registry.MustRegister(
+		m.retrieveRequests,
+		m.errors,
+		m.queryTime,
+		m.dependencyUp,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// observeQueryTime records the latency of a successful retrieve request.
+func (m *serverMetrics) observeQueryTime(d time.Duration) {
+	m.queryTime.Observe(float64(d.Milliseconds()))
+}
+
+// setDependencyUp records the result of a dependency health check.
+func (m *serverMetrics) setDependencyUp(dependency string, err error) {
+	up := 1.0
+	if err != nil {
+		up = 0
+	}
+	m.dependencyUp.WithLabelValues(dependency).Set(up)
+}
+
+// handler serves the registry in the Prometheus exposition format.
+func (m *serverMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
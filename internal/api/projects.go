@@ -0,0 +1,112 @@
+// Package api provides the indexed project listing endpoint.
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/iasik/project-indexer/internal/config"
+	"github.com/iasik/project-indexer/internal/indexer"
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+// ProjectInfo is one entry of the GET /projects response.
+type ProjectInfo struct {
+	ProjectID   string `json:"project_id"`
+	DisplayName string `json:"display_name"`
+
+	// ChunkCount is the number of chunks stored in the vector database
+	// (the index cache's count when the database can't be queried)
+	ChunkCount int `json:"chunk_count"`
+
+	// FileCount is the number of indexed files recorded in the index cache
+	FileCount int `json:"file_count"`
+
+	// LastIndexedAt is when a file of the project was last indexed, from the
+	// index cache (omitted when the project has not been indexed)
+	LastIndexedAt *time.Time `json:"last_indexed_at,omitempty"`
+}
+
+// handleProjects handles GET /projects requests. It lists every configured
+// project with its chunk count and last indexing time, sorted by project ID.
+// Project files that fail to load are logged and left out.
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	cfg := s.cfg.Get()
+
+	loaded, err := config.LoadAllProjects(cfg.Projects.ConfigDir)
+	if err != nil {
+		// Per-file failures are joined; anything else (unreadable dir) is fatal
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			s.logger.Error("failed to load project configs", "error", err)
+			s.writeError(w, http.StatusInternalServerError, "failed to load project configs")
+			return
+		}
+		for _, fileErr := range joined.Unwrap() {
+			s.logger.Warn("skipping project config", "error", fileErr)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	_, vdb, release := s.acquireProviders()
+	defer release()
+
+	projects := make([]ProjectInfo, 0, len(loaded))
+	for _, p := range loaded {
+		info := ProjectInfo{ProjectID: p.ProjectID, DisplayName: p.DisplayName}
+
+		cacheChunks := 0
+		if cache, err := indexer.NewCache(cfg.Cache.Dir, p.ProjectID); err != nil {
+			s.logger.Warn("failed to read index cache", "project_id", p.ProjectID, "error", err)
+		} else {
+			stats := cache.Stats()
+			info.FileCount = stats.FileCount
+			cacheChunks = stats.ChunkCount
+			if !stats.LastIndexed.IsZero() {
+				info.LastIndexedAt = &stats.LastIndexed
+			}
+		}
+
+		info.ChunkCount, err = vdb.Count(ctx, vectordb.Filter{ProjectID: p.ProjectID})
+		if err != nil {
+			s.logger.Warn("failed to count project chunks, using index cache", "project_id", p.ProjectID, "error", err)
+			info.ChunkCount = cacheChunks
+		}
+
+		projects = append(projects, info)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ProjectID < projects[j].ProjectID })
+
+	s.writeJSON(w, http.StatusOK, projects)
+}
+
+// checkProjectKnown rejects retrieval for a project ID no project config
+// declares. Other config errors are logged and don't block the request.
+func (s *Server) checkProjectKnown(projectID string) *retrieveError {
+	cfg := s.cfg.Get()
+
+	_, err := s.projects.Get(cfg.Projects.ConfigDir, projectID)
+	if errors.Is(err, config.ErrProjectNotFound) {
+		return &retrieveError{http.StatusNotFound, "project not found: " + projectID, ErrCodeProjectNotFound}
+	}
+	if err != nil {
+		s.logger.Warn("failed to check project config", "project_id", projectID, "error", err)
+	}
+	return nil
+}
+
+// projectNotIndexed reports whether a project has no chunks in the vector
+// database. A failed count is logged and treated as indexed.
+func (s *Server) projectNotIndexed(ctx context.Context, vdb vectordb.Provider, projectID string) bool {
+	n, err := vdb.Count(ctx, vectordb.Filter{ProjectID: projectID})
+	if err != nil {
+		s.logger.Warn("failed to count project chunks", "project_id", projectID, "error", err)
+		return false
+	}
+	return n == 0
+}
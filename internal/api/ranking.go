@@ -0,0 +1,321 @@
+// Package api provides post-retrieval ranking adjustments for search results.
+package api
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+// candidateMultiplier is how many extra candidates are fetched when
+// post-retrieval boosting may reorder results.
+const candidateMultiplier = 3
+
+// applyExactSymbolBoost multiplies the score of results whose symbol appears
+// verbatim as a query token, then re-sorts by score (stable on ties).
+func applyExactSymbolBoost(query string, results []vectordb.SearchResult, boost float32) {
+	if boost <= 0 || boost == 1 || len(results) == 0 {
+		return
+	}
+
+	tokens := queryTokens(query)
+	for i := range results {
+		if symbolMatchesQuery(results[i].Payload.Symbol, tokens) {
+			results[i].Score *= boost
+		}
+	}
+
+	sortByScore(results)
+}
+
+// queryTokens splits a query into identifier-like tokens. Qualified names
+// such as Server.handleRetrieve also contribute their individual parts.
+func queryTokens(query string) map[string]bool {
+	tokens := make(map[string]bool)
+	fields := strings.FieldsFunc(query, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '\\')
+	})
+	for _, f := range fields {
+		f = strings.Trim(f, ".\\")
+		if f == "" {
+			continue
+		}
+		tokens[f] = true
+		for _, part := range splitQualified(f) {
+			tokens[part] = true
+		}
+	}
+	return tokens
+}
+
+// symbolMatchesQuery reports whether a chunk symbol (or any name merged into
+// it, e.g. "Foo+Bar" or "Server.handleRetrieve+2") appears in the query tokens.
+func symbolMatchesQuery(symbol string, tokens map[string]bool) bool {
+	if symbol == "" {
+		return false
+	}
+	if tokens[symbol] {
+		return true
+	}
+	for _, name := range strings.Split(symbol, "+") {
+		if name == "" || isDigits(name) {
+			continue
+		}
+		if tokens[name] {
+			return true
+		}
+		// Method/namespace qualified names match on the final segment
+		parts := splitQualified(name)
+		if len(parts) > 1 && tokens[parts[len(parts)-1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitQualified splits a Go/PHP qualified name on '.' and '\' separators.
+func splitQualified(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return r == '.' || r == '\\'
+	})
+}
+
+// isDigits reports whether s consists only of ASCII digits.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// filterByLineCount drops results spanning fewer than minLines or more than
+// maxLines lines. A bound of 0 is ignored.
+func filterByLineCount(results []vectordb.SearchResult, minLines, maxLines int) []vectordb.SearchResult {
+	if minLines <= 0 && maxLines <= 0 {
+		return results
+	}
+	kept := make([]vectordb.SearchResult, 0, len(results))
+	for _, r := range results {
+		lines := r.Payload.EndLine - r.Payload.StartLine + 1
+		if minLines > 0 && lines < minLines {
+			continue
+		}
+		if maxLines > 0 && lines > maxLines {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// dedupBySimilarity greedily keeps results in rank order, dropping any whose
+// vector has at least threshold cosine similarity to an already kept result.
+// Results without a vector are always kept. A threshold of 0 disables dedup.
+func dedupBySimilarity(results []vectordb.SearchResult, threshold float32) []vectordb.SearchResult {
+	if threshold <= 0 || len(results) < 2 {
+		return results
+	}
+	kept := make([]vectordb.SearchResult, 0, len(results))
+	for _, r := range results {
+		duplicate := false
+		for _, k := range kept {
+			if len(r.Vector) > 0 && vectordb.CosineSimilarity(r.Vector, k.Vector) >= threshold {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// selectByMMR greedily picks up to topK results by Maximal Marginal
+// Relevance: each step takes the candidate maximizing
+//
+//	(1-diversity)*score - diversity*max cosine similarity to the picked results
+//
+// so near-duplicates of already picked results lose out to less similar ones.
+// Results without a vector count as dissimilar to everything. A diversity of
+// 0 disables selection and returns results unchanged.
+func selectByMMR(results []vectordb.SearchResult, topK int, diversity float32) []vectordb.SearchResult {
+	if diversity <= 0 || len(results) < 2 || topK <= 0 {
+		return results
+	}
+
+	remaining := append([]vectordb.SearchResult(nil), results...)
+	selected := make([]vectordb.SearchResult, 0, min(topK, len(results)))
+	// maxSim[i] is the highest similarity of remaining[i] to a selected result
+	maxSim := make([]float32, len(remaining))
+	for len(selected) < topK && len(remaining) > 0 {
+		best := 0
+		bestScore := float32(math.Inf(-1))
+		for i, r := range remaining {
+			mmr := (1-diversity)*r.Score - diversity*maxSim[i]
+			if mmr > bestScore {
+				best, bestScore = i, mmr
+			}
+		}
+
+		picked := remaining[best]
+		selected = append(selected, picked)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		maxSim = append(maxSim[:best], maxSim[best+1:]...)
+
+		if len(picked.Vector) == 0 {
+			continue
+		}
+		for i, r := range remaining {
+			if len(r.Vector) == 0 {
+				continue
+			}
+			if sim := vectordb.CosineSimilarity(r.Vector, picked.Vector); sim > maxSim[i] {
+				maxSim[i] = sim
+			}
+		}
+	}
+	return selected
+}
+
+// sortByScore sorts results by descending score, keeping original order on ties.
+func sortByScore(results []vectordb.SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+}
+
+// Query intents detected by classifyQueryIntent.
+const (
+	IntentDocs = "docs"
+	IntentCode = "code"
+)
+
+// intentBoost is the score multiplier applied to results matching the query intent.
+const intentBoost = 1.15
+
+// questionWords mark prose/documentation-style queries.
+var questionWords = map[string]bool{
+	"how": true, "what": true, "why": true, "where": true, "when": true,
+	"which": true, "who": true, "explain": true, "describe": true,
+	"overview": true, "guide": true, "nasil": true, "nedir": true, "neden": true,
+}
+
+// classifyQueryIntent guesses whether a query targets documentation or code.
+// Identifier-like tokens (camelCase, snake_case, qualified names, calls) mean
+// code; question words, a trailing '?' or plain multi-word prose mean docs.
+// Returns "" when there is no clear signal.
+func classifyQueryIntent(query string) string {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return ""
+	}
+
+	for _, w := range words {
+		if isIdentifierLike(strings.Trim(w, ",;:!?\"'`")) {
+			return IntentCode
+		}
+	}
+
+	first := strings.ToLower(strings.Trim(words[0], ",;:!?"))
+	if questionWords[first] || strings.HasSuffix(strings.TrimSpace(query), "?") || len(words) >= 4 {
+		return IntentDocs
+	}
+	return ""
+}
+
+// isIdentifierLike reports whether a word looks like a code identifier.
+func isIdentifierLike(w string) bool {
+	if w == "" {
+		return false
+	}
+	if strings.HasSuffix(w, "()") || strings.Contains(w, "_") || strings.Contains(w, "::") {
+		return true
+	}
+	// Qualified names like pkg.Func (but not sentence punctuation)
+	if i := strings.Index(w, "."); i > 0 && i < len(w)-1 {
+		return true
+	}
+	// camelCase / PascalCase with an inner upper-case letter
+	runes := []rune(w)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDocResult reports whether a result is documentation rather than code.
+func isDocResult(r vectordb.SearchResult) bool {
+	switch r.Payload.SymbolType {
+	case "heading", "document":
+		return true
+	}
+	return r.Payload.Language == "markdown"
+}
+
+// applyRecencyDecay multiplies each result's score by 0.5^(age/halfLife),
+// where age is measured from the chunk's file modification time (indexed_at
+// for chunks indexed before it was stored), then re-sorts. Results without a
+// parseable time keep their score. A halfLife of 0 disables the decay.
+func applyRecencyDecay(results []vectordb.SearchResult, halfLife time.Duration, now time.Time) {
+	if halfLife <= 0 || len(results) == 0 {
+		return
+	}
+	for i := range results {
+		stamp := results[i].Payload.LastModified
+		if stamp == "" {
+			stamp = results[i].Payload.IndexedAt
+		}
+		modified, err := time.Parse(time.RFC3339, stamp)
+		if err != nil {
+			continue
+		}
+		age := max(now.Sub(modified), 0)
+		results[i].Score *= float32(math.Exp2(-float64(age) / float64(halfLife)))
+	}
+	sortByScore(results)
+}
+
+// rerankDocument returns the text a reranker scores for a result; chunks of
+// metadata-only indexes fall back to their path and symbol.
+func rerankDocument(r vectordb.SearchResult) string {
+	if r.Payload.Content != "" {
+		return r.Payload.Content
+	}
+	return strings.TrimSpace(r.Payload.FilePath + " " + r.Payload.Symbol)
+}
+
+// applyRerankScores replaces the scores of the leading len(scores) results
+// with reranker scores and re-sorts them; the remaining results keep their
+// order behind the reranked ones.
+func applyRerankScores(results []vectordb.SearchResult, scores []float32) {
+	if len(scores) == 0 || len(scores) > len(results) {
+		return
+	}
+	head := results[:len(scores)]
+	for i := range head {
+		head[i].Score = scores[i]
+	}
+	sortByScore(head)
+}
+
+// applyIntentBoost boosts results matching the query intent and re-sorts.
+func applyIntentBoost(intent string, results []vectordb.SearchResult) {
+	if intent == "" || len(results) == 0 {
+		return
+	}
+	for i := range results {
+		if isDocResult(results[i]) == (intent == IntentDocs) {
+			results[i].Score *= intentBoost
+		}
+	}
+	sortByScore(results)
+}
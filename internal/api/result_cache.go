@@ -0,0 +1,112 @@
+package api
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// resultCache is an LRU cache of full retrieve responses with a TTL.
+// Keys include the project's index cache stamp, so a reindex makes every
+// older entry of that project unreachable; those entries age out through the
+// LRU order and TTL. A per-project generation, bumped by
+// POST /admin/cache/invalidate, does the same on demand.
+type resultCache struct {
+	mu          sync.Mutex
+	maxEntries  int
+	ttl         time.Duration
+	order       *list.List // front = most recently used
+	entries     map[string]*list.Element
+	generations map[string]uint64
+}
+
+// resultCacheEntry is a cached response and its expiry.
+type resultCacheEntry struct {
+	key      string
+	response RetrieveResponse
+	expires  time.Time
+}
+
+// newResultCache creates a result cache. Returns nil when maxEntries is 0,
+// which disables caching.
+func newResultCache(maxEntries int, ttl time.Duration) *resultCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	return &resultCache{
+		maxEntries:  maxEntries,
+		ttl:         ttl,
+		order:       list.New(),
+		entries:     make(map[string]*list.Element),
+		generations: make(map[string]uint64),
+	}
+}
+
+// key hashes every request field that affects the response together with the
+// project's index cache stamp (indexer.CacheStamp) and current generation.
+func (c *resultCache) key(req *RetrieveRequest, indexStamp string) string {
+	c.mu.Lock()
+	generation := c.generations[req.ProjectID]
+	c.mu.Unlock()
+
+	data, _ := json.Marshal(struct {
+		IndexStamp string           `json:"index_stamp"`
+		Generation uint64           `json:"generation"`
+		Request    *RetrieveRequest `json:"request"`
+	}{indexStamp, generation, req})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns a copy of the cached response for key, if present and fresh.
+func (c *resultCache) get(key string) (*RetrieveResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	response := entry.response
+	return &response, true
+}
+
+// put stores a response, evicting the least recently used entries beyond
+// the size limit.
+func (c *resultCache) put(key string, response *RetrieveResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &resultCacheEntry{key: key, response: *response, expires: time.Now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*resultCacheEntry).key)
+	}
+}
+
+// invalidate bumps a project's generation so its cached results are no
+// longer served, and returns the new generation.
+func (c *resultCache) invalidate(projectID string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[projectID]++
+	return c.generations[projectID]
+}
@@ -2,6 +2,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -11,24 +12,32 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/iasik/project-indexer/internal/config"
 	"github.com/iasik/project-indexer/internal/embedder"
+	"github.com/iasik/project-indexer/internal/reranker"
 	"github.com/iasik/project-indexer/internal/vectordb"
 )
 
 // Server represents the HTTP API server.
 type Server struct {
-	cfg           *config.Manager
-	embedder      embedder.Provider
-	vectorDB      vectordb.Provider
-	logger        *slog.Logger
-	httpServer    *http.Server
-	mu            sync.RWMutex
-	version       string
+	cfg         *config.Manager
+	providers   *providerSet
+	logger      *slog.Logger
+	httpServer  *http.Server
+	limiter     *requestLimiter
+	results     *resultCache
+	projects    *config.ProjectCache
+	reranker    reranker.Reranker
+	metrics     *serverMetrics
+	maintenance atomic.Bool
+	mu          sync.RWMutex
+	version     string
 }
 
 // NewServer creates a new API server.
@@ -38,13 +47,20 @@ func NewServer(
 	vdb vectordb.Provider,
 	logger *slog.Logger,
 ) *Server {
-	return &Server{
-		cfg:      cfg,
-		embedder: emb,
-		vectorDB: vdb,
-		logger:   logger,
-		version:  "1.0.0",
+	serverCfg := cfg.Get().Server
+	s := &Server{
+		cfg:       cfg,
+		providers: &providerSet{embedder: emb, vectorDB: vdb},
+		logger:    logger,
+		limiter:   newRequestLimiter(serverCfg.MaxInFlight, serverCfg.GetQueueDepth(), serverCfg.GetQueueTimeout()),
+		results:   newResultCache(serverCfg.ResultCacheSize, serverCfg.GetResultCacheTTL()),
+		projects:  config.NewProjectCache(),
+		reranker:  reranker.Noop{},
+		metrics:   newServerMetrics(),
+		version:   "1.0.0",
 	}
+	s.maintenance.Store(serverCfg.Maintenance)
+	return s
 }
 
 // Start starts the HTTP server with graceful shutdown.
@@ -52,8 +68,16 @@ func (s *Server) Start(ctx context.Context) error {
 	cfg := s.cfg.Get()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /retrieve", s.handleRetrieve)
+	mux.HandleFunc("POST /retrieve", s.withBackpressure(s.handleRetrieve))
+	mux.HandleFunc("POST /retrieve/batch", s.withBackpressure(s.handleRetrieveBatch))
 	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /livez", s.handleLivez)
+	mux.HandleFunc("GET /projects", s.handleProjects)
+	mux.Handle("GET /metrics", s.metrics.handler())
+	mux.HandleFunc("GET /embed", s.requireAdmin(s.withBackpressure(s.handleEmbed)))
+	mux.HandleFunc("POST /embed", s.requireAdmin(s.withBackpressure(s.handleEmbed)))
+	mux.HandleFunc("POST /admin/maintenance", s.requireAdmin(s.handleMaintenance))
+	mux.HandleFunc("POST /admin/cache/invalidate", s.requireAdmin(s.handleInvalidateCache))
 	mux.HandleFunc("GET /", s.handleRoot)
 
 	s.httpServer = &http.Server{
@@ -99,12 +123,10 @@ func (s *Server) shutdown() error {
 	}
 
 	// Close providers
-	if err := s.embedder.Close(); err != nil {
-		s.logger.Warn("embedder close error", "error", err)
-	}
-	if err := s.vectorDB.Close(); err != nil {
-		s.logger.Warn("vectordb close error", "error", err)
-	}
+	s.mu.RLock()
+	providers := s.providers
+	s.mu.RUnlock()
+	providers.closeWhenIdle(s.logger)
 
 	s.logger.Info("server stopped")
 	return nil
@@ -127,28 +149,74 @@ func (s *Server) setupHotReload() {
 	}()
 }
 
+// providerSet is one generation of providers. Requests hold a reference
+// while using it, so a replaced set is closed only after they complete.
+type providerSet struct {
+	embedder embedder.Provider
+	vectorDB vectordb.Provider
+	inFlight sync.WaitGroup
+}
+
+// closeWhenIdle waits for every request holding the set to release it, then
+// closes both providers.
+func (p *providerSet) closeWhenIdle(logger *slog.Logger) {
+	p.inFlight.Wait()
+
+	if p.embedder != nil {
+		if err := p.embedder.Close(); err != nil {
+			logger.Warn("embedder close error", "error", err)
+		}
+	}
+	if p.vectorDB != nil {
+		if err := p.vectorDB.Close(); err != nil {
+			logger.Warn("vectordb close error", "error", err)
+		}
+	}
+}
+
 // UpdateProviders updates the embedding and vectordb providers (for hot reload).
+// The old providers are closed in the background once in-flight requests
+// using them have finished.
 func (s *Server) UpdateProviders(emb embedder.Provider, vdb vectordb.Provider) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Close old providers
-	if s.embedder != nil {
-		s.embedder.Close()
+	old := s.providers
+	s.providers = &providerSet{embedder: emb, vectorDB: vdb}
+	s.mu.Unlock()
+
+	// No new references to old can be taken past this point, so waiting on
+	// it only covers requests that already acquired it
+	if old != nil {
+		go old.closeWhenIdle(s.logger)
 	}
-	if s.vectorDB != nil {
-		s.vectorDB.Close()
+}
+
+// SetReranker sets the reranker applied to retrieval candidates.
+// A nil reranker disables reranking.
+func (s *Server) SetReranker(r reranker.Reranker) {
+	if r == nil {
+		r = reranker.Noop{}
 	}
+	s.mu.Lock()
+	s.reranker = r
+	s.mu.Unlock()
+}
 
-	s.embedder = emb
-	s.vectorDB = vdb
+// currentReranker returns the configured reranker.
+func (s *Server) currentReranker() reranker.Reranker {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.reranker
 }
 
-// getProviders returns thread-safe access to providers.
-func (s *Server) getProviders() (embedder.Provider, vectordb.Provider) {
+// acquireProviders returns the current providers and a release func the
+// caller must invoke once done with them; until then they are not closed
+// by a concurrent UpdateProviders.
+func (s *Server) acquireProviders() (embedder.Provider, vectordb.Provider, func()) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.embedder, s.vectorDB
+	p := s.providers
+	p.inFlight.Add(1)
+	return p.embedder, p.vectorDB, p.inFlight.Done
 }
 
 // loggingMiddleware logs all HTTP requests.
@@ -180,24 +248,41 @@ func (w *statusResponseWriter) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(status)
 }
 
-// writeJSON writes a JSON response.
-func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+// writeJSON writes a buffered JSON response with Content-Length set.
+// Output is compact unless server.compact_json is disabled.
+func (s *Server) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if !s.cfg.Get().Server.IsCompactJSON() {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(data); err != nil {
+		s.logger.Error("failed to encode response", "error", err)
+		http.Error(w, `{"error":"failed to encode response","code":"INTERNAL_ERROR"}`, http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+	w.Write(buf.Bytes())
 }
 
 // ErrorCode represents machine-parseable error codes.
 type ErrorCode string
 
 const (
-	ErrCodeInvalidRequest   ErrorCode = "INVALID_REQUEST"
-	ErrCodeMissingField     ErrorCode = "MISSING_REQUIRED_FIELD"
-	ErrCodeProjectNotFound  ErrorCode = "PROJECT_NOT_FOUND"
-	ErrCodeEmbeddingFailed  ErrorCode = "EMBEDDING_FAILED"
-	ErrCodeSearchFailed     ErrorCode = "SEARCH_FAILED"
-	ErrCodeInternalError    ErrorCode = "INTERNAL_ERROR"
-	ErrCodeServiceDegraded  ErrorCode = "SERVICE_DEGRADED"
+	ErrCodeInvalidRequest  ErrorCode = "INVALID_REQUEST"
+	ErrCodeMissingField    ErrorCode = "MISSING_REQUIRED_FIELD"
+	ErrCodeProjectNotFound ErrorCode = "PROJECT_NOT_FOUND"
+	ErrCodeEmbeddingFailed ErrorCode = "EMBEDDING_FAILED"
+	ErrCodeSearchFailed    ErrorCode = "SEARCH_FAILED"
+	ErrCodeInternalError   ErrorCode = "INTERNAL_ERROR"
+	ErrCodeServiceDegraded ErrorCode = "SERVICE_DEGRADED"
+	ErrCodeOverloaded      ErrorCode = "OVERLOADED"
+	ErrCodeNotSupported    ErrorCode = "NOT_SUPPORTED"
+	ErrCodeMaintenance     ErrorCode = "MAINTENANCE"
+	ErrCodeUnauthorized    ErrorCode = "UNAUTHORIZED"
 )
 
 // ErrorResponse is the standard error response format.
@@ -215,15 +300,16 @@ func generateRequestID() string {
 }
 
 // writeError writes an error response with code and request ID.
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeErrorWithCode(w, status, message, ErrCodeInternalError)
+func (s *Server) writeError(w http.ResponseWriter, status int, message string) {
+	s.writeErrorWithCode(w, status, message, ErrCodeInternalError)
 }
 
 // writeErrorWithCode writes an error response with a specific error code.
-func writeErrorWithCode(w http.ResponseWriter, status int, message string, code ErrorCode) {
+func (s *Server) writeErrorWithCode(w http.ResponseWriter, status int, message string, code ErrorCode) {
+	s.metrics.errors.WithLabelValues(string(code)).Inc()
 	requestID := generateRequestID()
 	w.Header().Set("X-Request-ID", requestID)
-	writeJSON(w, status, ErrorResponse{
+	s.writeJSON(w, status, ErrorResponse{
 		Error:     message,
 		Code:      code,
 		RequestID: requestID,
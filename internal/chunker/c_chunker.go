@@ -0,0 +1,430 @@
+// Package chunker provides C/C++ code chunking using brace matching.
+// It extracts function definitions and struct/class/enum/union definitions.
+package chunker
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CChunker implements function/type-level chunking for C and C++.
+type CChunker struct {
+	config ChunkingConfig
+}
+
+// NewCChunker creates a new C/C++ chunker.
+func NewCChunker(cfg ChunkingConfig) *CChunker {
+	return &CChunker{config: cfg}
+}
+
+// Name returns the chunker strategy name.
+func (c *CChunker) Name() string {
+	return "c"
+}
+
+// cSymbol represents an extracted C/C++ symbol.
+type cSymbol struct {
+	name       string
+	symbolType string
+	startLine  int
+	endLine    int
+	content    string
+	tokens     int
+}
+
+// cLineState is the lexer state of a single line.
+type cLineState struct {
+	code       bool // line starts outside comments and raw strings and is not a preprocessor line
+	depthStart int  // brace depth at line start
+	depthEnd   int  // brace depth at line end
+	depthMax   int  // highest brace depth reached on the line
+}
+
+// Regex patterns for C/C++ symbol extraction
+var (
+	// Scopes whose contents are treated as top level: namespace foo, extern "C"
+	cScopePattern = regexp.MustCompile(`^\s*(?:(?:inline\s+)?namespace\b|extern\s+"C(?:\+\+)?"\s*$)`)
+
+	// Type definition: [typedef] struct|class|union|enum [class] [name]
+	cTypePattern = regexp.MustCompile(`^\s*(?:typedef\s+)?(struct|class|union|enum)\b(?:\s+(?:class|struct)\b)?(?:\s+(\w+))?`)
+
+	// Trailing typedef name: } point_t;
+	cTypedefNamePattern = regexp.MustCompile(`}\s*(\w+)\s*;\s*$`)
+
+	// Function name before its parameter list: name(, Foo::bar(, ~Foo(, operator==(
+	cFuncNamePattern = regexp.MustCompile(`((?:\w+::)*(?:~?\w+|operator\s*(?:\(\)|[^\s(]+)))\s*\(`)
+)
+
+// cNonFunctionNames are words the function pattern could mistake for a name.
+var cNonFunctionNames = map[string]bool{
+	"__attribute__": true, "__declspec": true, "alignas": true, "decltype": true,
+	"sizeof": true, "if": true, "for": true, "while": true, "switch": true, "return": true,
+}
+
+// Chunk splits C/C++ source code into function and type chunks.
+func (c *CChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error) {
+	contentStr := string(content)
+	lines := strings.Split(contentStr, "\n")
+
+	language := metadata.Language
+	if language != "c" && language != "cpp" {
+		language = "c"
+	}
+
+	symbols := c.extractSymbols(lines, cScanLines(lines))
+	if len(symbols) == 0 {
+		return c.chunkAsFile(content, metadata, language), nil
+	}
+
+	if c.config.MergeSmallChunks {
+		symbols = c.mergeSmallSymbols(symbols)
+	}
+
+	chunks := make([]Chunk, 0, len(symbols))
+	for _, sym := range symbols {
+		contentHash := c.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
+		chunks = append(chunks, Chunk{
+			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
+			Content:     sym.content,
+			Symbol:      sym.name,
+			SymbolType:  sym.symbolType,
+			StartLine:   sym.startLine,
+			EndLine:     sym.endLine,
+			TokenCount:  sym.tokens,
+			ContentHash: contentHash,
+			ExactHash:   exactHash,
+			FilePath:    metadata.FilePath,
+			Language:    language,
+			Module:      metadata.Module,
+			ProjectID:   metadata.ProjectID,
+		})
+	}
+
+	return chunks, nil
+}
+
+// extractSymbols walks top-level declarations (including those inside
+// namespace and extern "C" blocks). A declaration whose header opens a brace
+// is a function definition if the header has a parameter list, or a type
+// definition if it starts with struct/class/union/enum. Declarations ending
+// with ';' (prototypes, forward declarations, variables) are skipped.
+func (c *CChunker) extractSymbols(lines []string, states []cLineState) []cSymbol {
+	var symbols []cSymbol
+	var scopeEnds []int // closing lines of enclosing namespace/extern blocks
+
+	for i := 0; i < len(lines); i++ {
+		for len(scopeEnds) > 0 && scopeEnds[len(scopeEnds)-1] <= i {
+			scopeEnds = scopeEnds[:len(scopeEnds)-1]
+		}
+		top := len(scopeEnds)
+
+		trimmed := strings.TrimSpace(lines[i])
+		if !states[i].code || states[i].depthStart != top || trimmed == "" ||
+			strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || trimmed == "}" {
+			continue
+		}
+
+		// Gather the declaration header up to its opening brace or ';'
+		headerEnd := -1
+		for j := i; j < len(lines); j++ {
+			if states[j].depthMax > top {
+				headerEnd = j
+				break
+			}
+			if strings.HasSuffix(strings.TrimSpace(lines[j]), ";") {
+				break
+			}
+		}
+		if headerEnd < 0 {
+			continue
+		}
+
+		header := strings.Join(lines[i:headerEnd+1], "\n")
+		if k := strings.Index(header, "{"); k >= 0 {
+			header = header[:k]
+		}
+		end := c.findBraceEnd(states, i+1, top)
+
+		if cScopePattern.MatchString(header) {
+			// Descend into the block
+			scopeEnds = append(scopeEnds, end-1)
+			i = headerEnd
+			continue
+		}
+
+		if name, symbolType := c.classify(header, lines, end); name != "" {
+			start := c.findPrecedingComment(lines, i+1)
+			symbols = append(symbols, c.newSymbol(lines, name, symbolType, start, end))
+		}
+		i = end - 1
+	}
+
+	sort.SliceStable(symbols, func(a, b int) bool {
+		return symbols[a].startLine < symbols[b].startLine
+	})
+	return symbols
+}
+
+// classify returns the name and symbol type of a braced declaration, or ""
+// when it is neither a function nor a type definition (e.g. an initializer).
+func (c *CChunker) classify(header string, lines []string, end int) (string, string) {
+	decl := cStripTemplate(header)
+
+	// Initializers (int table[] = {...}, struct point p = {...}) are not definitions
+	withoutOperators := strings.NewReplacer("operator=", "", "==", "", "!=", "", "<=", "", ">=", "").Replace(decl)
+	if strings.Contains(withoutOperators, "=") {
+		return "", ""
+	}
+
+	if m := cTypePattern.FindStringSubmatch(decl); m != nil && !strings.Contains(decl, "(") {
+		name := m[2]
+		if name == "" {
+			// Anonymous typedef: typedef struct { ... } point_t;
+			if t := cTypedefNamePattern.FindStringSubmatch(lines[end-1]); t != nil {
+				name = t[1]
+			}
+		}
+		if name == "" {
+			return "", ""
+		}
+		return name, m[1]
+	}
+
+	for _, m := range cFuncNamePattern.FindAllStringSubmatch(decl, -1) {
+		name := m[1]
+		if cNonFunctionNames[name] {
+			continue
+		}
+		return name, "function"
+	}
+	return "", ""
+}
+
+// cStripTemplate removes a leading template<...> clause, honoring nested brackets.
+func cStripTemplate(header string) string {
+	s := strings.TrimSpace(header)
+	if !strings.HasPrefix(s, "template") {
+		return s
+	}
+	depth := 0
+	for k := len("template"); k < len(s); k++ {
+		switch s[k] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(s[k+1:])
+			}
+		}
+	}
+	return s
+}
+
+// findPrecedingComment finds line and block comments directly before a declaration.
+func (c *CChunker) findPrecedingComment(lines []string, symbolLine int) int {
+	startLine := symbolLine
+
+	for i := symbolLine - 2; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+
+		// Block comment end
+		if strings.HasSuffix(line, "*/") {
+			for k := i; k >= 0; k-- {
+				if strings.Contains(lines[k], "/*") {
+					startLine = k + 1
+					i = k
+					break
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "//") {
+			startLine = i + 1
+			continue
+		}
+
+		break
+	}
+
+	return startLine
+}
+
+// findBraceEnd returns the line closing the block opened at or after startLine.
+func (c *CChunker) findBraceEnd(states []cLineState, startLine, base int) int {
+	opened := false
+	for i := startLine - 1; i < len(states); i++ {
+		if states[i].depthMax > base {
+			opened = true
+		}
+		if opened && states[i].depthEnd <= base {
+			return i + 1
+		}
+	}
+	return len(states)
+}
+
+// newSymbol builds a symbol from a 1-indexed inclusive line range.
+func (c *CChunker) newSymbol(lines []string, name, symbolType string, start, end int) cSymbol {
+	if end < start {
+		end = start
+	}
+	content := extractLines(lines, start, end)
+	return cSymbol{
+		name:       name,
+		symbolType: symbolType,
+		startLine:  start,
+		endLine:    end,
+		content:    content,
+		tokens:     c.config.CountTokens(content),
+	}
+}
+
+// cScanLines tracks brace depth per line, ignoring braces inside comments,
+// string and char literals, C++ raw strings and preprocessor directives
+// (including their backslash continuations).
+func cScanLines(lines []string) []cLineState {
+	states := make([]cLineState, len(lines))
+	depth := 0
+	inBlockComment := false
+	rawEnd := "" // closing delimiter of an open raw string, e.g. )sql"
+	inDirective := false
+
+	for i, line := range lines {
+		directive := inDirective || (!inBlockComment && rawEnd == "" && strings.HasPrefix(strings.TrimSpace(line), "#"))
+		inDirective = directive && strings.HasSuffix(line, "\\")
+
+		st := cLineState{code: !inBlockComment && rawEnd == "" && !directive, depthStart: depth, depthMax: depth}
+		if directive {
+			st.depthEnd = depth
+			states[i] = st
+			continue
+		}
+
+		for k := 0; k < len(line); k++ {
+			switch {
+			case inBlockComment:
+				if strings.HasPrefix(line[k:], "*/") {
+					inBlockComment = false
+					k++
+				}
+			case rawEnd != "":
+				if strings.HasPrefix(line[k:], rawEnd) {
+					k += len(rawEnd) - 1
+					rawEnd = ""
+				}
+			case strings.HasPrefix(line[k:], "//"):
+				k = len(line)
+			case strings.HasPrefix(line[k:], "/*"):
+				inBlockComment = true
+				k++
+			case strings.HasPrefix(line[k:], `R"`) && (k == 0 || !isIdentByte(line[k-1]) || line[k-1] == '8' || line[k-1] == 'u' || line[k-1] == 'U' || line[k-1] == 'L'):
+				// Raw string: R"delim( ... )delim"
+				if open := strings.IndexByte(line[k+2:], '('); open >= 0 {
+					rawEnd = ")" + line[k+2:k+2+open] + `"`
+					k += 2 + open
+				}
+			case line[k] == '\'' && k > 0 && line[k-1] >= '0' && line[k-1] <= '9' && !strings.HasSuffix(line[:k], "u8"):
+				// Digit separator: 1'000'000
+			case line[k] == '"' || line[k] == '\'':
+				quote := line[k]
+				for k++; k < len(line) && line[k] != quote; k++ {
+					if line[k] == '\\' {
+						k++
+					}
+				}
+			case line[k] == '{':
+				depth++
+				if depth > st.depthMax {
+					st.depthMax = depth
+				}
+			case line[k] == '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+
+		st.depthEnd = depth
+		states[i] = st
+	}
+	return states
+}
+
+// mergeSmallSymbols merges small symbols into adjacent larger ones.
+func (c *CChunker) mergeSmallSymbols(symbols []cSymbol) []cSymbol {
+	if len(symbols) <= 1 {
+		return symbols
+	}
+
+	result := make([]cSymbol, 0, len(symbols))
+	var pending *cSymbol
+
+	for i := range symbols {
+		sym := symbols[i]
+
+		if sym.tokens < c.config.MinTokens {
+			if pending == nil {
+				pending = &sym
+			} else {
+				pending.content += "\n\n" + sym.content
+				pending.endLine = sym.endLine
+				pending.tokens = c.config.CountTokens(pending.content)
+				pending.name = pending.name + "+" + sym.name
+			}
+		} else {
+			if pending != nil {
+				if pending.tokens+sym.tokens <= c.config.MaxTokensFor(sym.symbolType) {
+					sym.content = pending.content + "\n\n" + sym.content
+					sym.startLine = pending.startLine
+					sym.tokens = c.config.CountTokens(sym.content)
+				} else {
+					result = append(result, *pending)
+				}
+				pending = nil
+			}
+			result = append(result, sym)
+		}
+	}
+
+	// Attach trailing small symbols to the previous chunk if it fits
+	if pending != nil {
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= c.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = c.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
+	}
+
+	return result
+}
+
+// chunkAsFile creates a single chunk for the entire file.
+func (c *CChunker) chunkAsFile(content []byte, metadata FileMetadata, language string) []Chunk {
+	contentStr := string(content)
+	contentHash := c.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
+	symbol := metadata.FilePath
+
+	return []Chunk{{
+		ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, symbol, contentHash),
+		Content:     contentStr,
+		Symbol:      symbol,
+		SymbolType:  "file",
+		StartLine:   1,
+		EndLine:     strings.Count(contentStr, "\n") + 1,
+		TokenCount:  c.config.CountTokens(contentStr),
+		ContentHash: contentHash,
+		ExactHash:   exactHash,
+		FilePath:    metadata.FilePath,
+		Language:    language,
+		Module:      metadata.Module,
+		ProjectID:   metadata.ProjectID,
+	}}
+}
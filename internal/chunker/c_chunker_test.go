@@ -0,0 +1,139 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCChunker_HeaderStructs(t *testing.T) {
+	chunks := chunkSource(t, NewCChunker(testChunkingConfig), "include/list.h", "c", `#ifndef LIST_H
+#define LIST_H
+
+#define LIST_MAX(a, b) ((a) > (b) ? (a) : (b))
+#define LIST_FOREACH(node, list) \
+    for ((node) = (list)->head; (node); (node) = (node)->next) {
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+struct list_node;
+
+/*
+ * A doubly linked list node.
+ */
+struct list_node {
+    struct list_node *prev;
+    struct list_node *next;
+    const char *label; /* e.g. "{" */
+};
+
+// Iteration order.
+typedef enum {
+    LIST_FORWARD,
+    LIST_BACKWARD,
+} list_order_t;
+
+static const int list_sizes[] = { 1, 2, 4 };
+
+struct list_node *list_push(struct list_node *head, const char *label);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
+`)
+
+	if findChunk(chunks, "LIST_MAX") != nil || findChunk(chunks, "LIST_FOREACH") != nil {
+		t.Fatalf("Expected #define macros not to become functions, got %+v", chunks)
+	}
+	if findChunk(chunks, "list_push") != nil || findChunk(chunks, "list_sizes") != nil {
+		t.Errorf("Expected prototypes and initializers to be skipped, got %+v", chunks)
+	}
+
+	node := findChunk(chunks, "list_node")
+	if node == nil || node.SymbolType != "struct" {
+		t.Fatalf("Expected list_node struct chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(node.Content, "/*\n * A doubly linked list node.") || !strings.HasSuffix(node.Content, "};") {
+		t.Errorf("Expected struct with its block comment, got:\n%s", node.Content)
+	}
+	if node.StartLine != 14 || node.EndLine != 21 || node.Language != "c" {
+		t.Errorf("Expected list_node at lines 14-21, got %d-%d (%s)", node.StartLine, node.EndLine, node.Language)
+	}
+
+	order := findChunk(chunks, "list_order_t")
+	if order == nil || order.SymbolType != "enum" || !strings.HasPrefix(order.Content, "// Iteration order.") {
+		t.Errorf("Expected anonymous typedef enum named list_order_t, got %+v", chunks)
+	}
+}
+
+func TestCChunker_CppFunctions(t *testing.T) {
+	chunks := chunkSource(t, NewCChunker(testChunkingConfig), "src/engine.cpp", "cpp", `#include <string>
+#include "engine.hpp"
+
+namespace engine {
+
+template <typename T, typename A = std::allocator<T>>
+class Buffer : public Base<T> {
+public:
+    void clear() { size_ = 0; }
+private:
+    int size_;
+};
+
+// Builds the startup query.
+std::string Engine::query(int limit) const {
+    const char *open = "{";
+    char close = '}';
+    return R"sql(
+SELECT * FROM t WHERE x = '}'
+)sql" + std::to_string(limit * 1'000);
+}
+
+/* Tears down the engine. */
+Engine::~Engine()
+{
+    if (handle_) {
+        release(handle_);
+    }
+}
+
+}  // namespace engine
+`)
+
+	buffer := findChunk(chunks, "Buffer")
+	if buffer == nil || buffer.SymbolType != "class" {
+		t.Fatalf("Expected Buffer class inside the namespace, got %+v", chunks)
+	}
+	if !strings.HasPrefix(buffer.Content, "template <typename T") || findChunk(chunks, "clear") != nil {
+		t.Errorf("Expected Buffer class with its template clause kept whole, got:\n%s", buffer.Content)
+	}
+
+	query := findChunk(chunks, "Engine::query")
+	if query == nil || query.SymbolType != "function" {
+		t.Fatalf("Expected Engine::query function chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(query.Content, "// Builds the startup query.") || !strings.HasSuffix(query.Content, "1'000);\n}") {
+		t.Errorf("Expected query to span its full body despite literals, got:\n%s", query.Content)
+	}
+
+	dtor := findChunk(chunks, "Engine::~Engine")
+	if dtor == nil {
+		t.Fatalf("Expected Engine::~Engine chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(dtor.Content, "/* Tears down the engine. */") || !strings.HasSuffix(dtor.Content, "    }\n}") {
+		t.Errorf("Expected destructor with its comment and body, got:\n%s", dtor.Content)
+	}
+	if dtor.Language != "cpp" {
+		t.Errorf("Expected language cpp, got %s", dtor.Language)
+	}
+}
+
+func TestCChunker_FallbackToFile(t *testing.T) {
+	chunks := chunkSource(t, NewCChunker(testChunkingConfig), "include/version.h", "c", "#pragma once\n#define VERSION \"1.0\"\n")
+	if len(chunks) != 1 || chunks[0].SymbolType != "file" {
+		t.Errorf("Expected a single file chunk, got %+v", chunks)
+	}
+}
@@ -0,0 +1,430 @@
+// Package chunker provides C# code chunking using brace matching.
+// It extracts classes, interfaces, structs, records and enums and their methods.
+package chunker
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CSharpChunker implements class/method-level chunking for C#.
+type CSharpChunker struct {
+	config ChunkingConfig
+}
+
+// NewCSharpChunker creates a new C# chunker.
+func NewCSharpChunker(cfg ChunkingConfig) *CSharpChunker {
+	return &CSharpChunker{config: cfg}
+}
+
+// Name returns the chunker strategy name.
+func (c *CSharpChunker) Name() string {
+	return "csharp"
+}
+
+// csSymbol represents an extracted C# symbol.
+type csSymbol struct {
+	name       string
+	symbolType string
+	startLine  int
+	endLine    int
+	content    string
+	tokens     int
+}
+
+// csLineState is the lexer state of a single line.
+type csLineState struct {
+	code       bool // line starts outside block comments and multi-line strings
+	depthStart int  // brace depth at line start
+	depthEnd   int  // brace depth at line end
+	depthMax   int  // highest brace depth reached on the line
+}
+
+// Regex patterns for C# symbol extraction
+var (
+	// Namespace: namespace Acme.Web { ... } or file-scoped namespace Acme.Web;
+	csNamespacePattern = regexp.MustCompile(`^\s*namespace\s+([\w.]+)\s*(;)?`)
+
+	// Type: [Attr] public sealed partial class Foo, record struct Point, interface IBar<T>
+	csTypePattern = regexp.MustCompile(`^\s*(?:\[.*?\]\s*)*(?:(?:public|internal|protected|private|abstract|sealed|static|partial|readonly|unsafe|new|file|ref)\s+)*(class|interface|struct|record(?:\s+(?:class|struct))?|enum)\s+(\w+)`)
+
+	// Method or constructor: attributes, modifiers, return type, name, type parameters, "("
+	csMethodPattern = regexp.MustCompile(`^\s*(?:\[.*?\]\s*)*(?:(?:public|private|protected|internal|static|virtual|override|abstract|sealed|async|extern|unsafe|new|partial|readonly)\s+)*([\w.]+(?:<.*>)?(?:\[\])*\??\s+)?(\w+)\s*(?:<[^>]*>)?\s*\(`)
+)
+
+// csKeywords are words the method pattern could mistake for a name.
+var csKeywords = map[string]bool{
+	"if": true, "for": true, "foreach": true, "while": true, "switch": true,
+	"catch": true, "using": true, "lock": true, "return": true, "new": true,
+	"throw": true, "else": true, "do": true, "try": true, "await": true,
+	"nameof": true, "typeof": true, "sizeof": true, "base": true, "this": true,
+}
+
+// csTypeKinds maps declaration keywords to symbol types.
+var csTypeKinds = map[string]string{
+	"class":     "class",
+	"interface": "interface",
+	"struct":    "struct",
+	"record":    "record",
+	"enum":      "enum",
+}
+
+// Chunk splits C# source code into type and method chunks.
+func (c *CSharpChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error) {
+	contentStr := string(content)
+	lines := strings.Split(contentStr, "\n")
+	states := csScanLines(lines)
+
+	module := metadata.Module
+	typeDepth := 0
+	for i, line := range lines {
+		if !states[i].code || states[i].depthStart != 0 {
+			continue
+		}
+		if m := csNamespacePattern.FindStringSubmatch(line); m != nil {
+			module = m[1]
+			if m[2] == "" {
+				// Types live inside the namespace block
+				typeDepth = 1
+			}
+			break
+		}
+	}
+
+	symbols := c.extractSymbols(lines, states, typeDepth)
+	if len(symbols) == 0 {
+		return c.chunkAsFile(content, metadata, module), nil
+	}
+
+	if c.config.MergeSmallChunks {
+		symbols = c.mergeSmallSymbols(symbols)
+	}
+
+	chunks := make([]Chunk, 0, len(symbols))
+	for _, sym := range symbols {
+		contentHash := c.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
+		chunks = append(chunks, Chunk{
+			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
+			Content:     sym.content,
+			Symbol:      sym.name,
+			SymbolType:  sym.symbolType,
+			StartLine:   sym.startLine,
+			EndLine:     sym.endLine,
+			TokenCount:  sym.tokens,
+			ContentHash: contentHash,
+			ExactHash:   exactHash,
+			FilePath:    metadata.FilePath,
+			Language:    "csharp",
+			Module:      module,
+			ProjectID:   metadata.ProjectID,
+		})
+	}
+
+	return chunks, nil
+}
+
+// extractSymbols finds the types declared at typeDepth (0 for file-scoped
+// namespaces, 1 inside a namespace block) and their methods. A type chunk
+// covers its header up to the first method; each method runs until the next
+// method or the type's closing brace. Interfaces and enums are kept whole.
+func (c *CSharpChunker) extractSymbols(lines []string, states []csLineState, typeDepth int) []csSymbol {
+	var symbols []csSymbol
+
+	for i := 0; i < len(lines); i++ {
+		if !states[i].code || states[i].depthStart != typeDepth {
+			continue
+		}
+		m := csTypePattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		kind, name := csTypeKinds[strings.Fields(m[1])[0]], m[2]
+
+		start := c.findPrecedingComment(lines, i+1)
+		end := c.findBraceEnd(lines, states, i+1)
+
+		var methods []csSymbol
+		if kind != "interface" && kind != "enum" {
+			methods = c.findMethods(lines, states, name, i+1, end)
+		}
+
+		typeEnd := end
+		if len(methods) > 0 {
+			typeEnd = trimTrailingBlankLines(lines, start, methods[0].startLine-1)
+		}
+		symbols = append(symbols, c.newSymbol(lines, name, kind, start, typeEnd))
+		symbols = append(symbols, methods...)
+
+		i = end - 1
+	}
+
+	sort.SliceStable(symbols, func(a, b int) bool {
+		return symbols[a].startLine < symbols[b].startLine
+	})
+	return symbols
+}
+
+// findMethods returns the methods and constructors directly inside a type
+// declared at typeLine and closed at typeEnd.
+func (c *CSharpChunker) findMethods(lines []string, states []csLineState, typeName string, typeLine, typeEnd int) []csSymbol {
+	type methodMatch struct {
+		name       string
+		symbolType string
+		start      int
+	}
+	var matches []methodMatch
+
+	// Members sit one level below the type's opening brace
+	memberDepth := states[typeLine-1].depthStart + 1
+
+	for i := typeLine; i < typeEnd-1; i++ {
+		if !states[i].code || states[i].depthStart != memberDepth {
+			continue
+		}
+		m := csMethodPattern.FindStringSubmatch(lines[i])
+		if m == nil || csKeywords[m[2]] {
+			continue
+		}
+		symbolType := "method"
+		if m[1] == "" {
+			// Without a return type only a constructor is a declaration
+			if m[2] != typeName {
+				continue
+			}
+			symbolType = "constructor"
+		}
+		matches = append(matches, methodMatch{
+			name:       typeName + "." + m[2],
+			symbolType: symbolType,
+			start:      c.findPrecedingComment(lines, i+1),
+		})
+	}
+
+	methods := make([]csSymbol, 0, len(matches))
+	for i, m := range matches {
+		// Stop before the type's closing brace
+		end := typeEnd - 1
+		if i < len(matches)-1 {
+			end = matches[i+1].start - 1
+		}
+		end = trimTrailingBlankLines(lines, m.start, end)
+		methods = append(methods, c.newSymbol(lines, m.name, m.symbolType, m.start, end))
+	}
+	return methods
+}
+
+// findPrecedingComment finds XML doc comments, line comments and attributes
+// before a declaration.
+func (c *CSharpChunker) findPrecedingComment(lines []string, symbolLine int) int {
+	startLine := symbolLine
+
+	for i := symbolLine - 2; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+
+		// Block comment end
+		if strings.HasSuffix(line, "*/") {
+			for k := i; k >= 0; k-- {
+				if strings.Contains(lines[k], "/*") {
+					startLine = k + 1
+					i = k
+					break
+				}
+			}
+			continue
+		}
+
+		// XML doc comment, line comment or attribute
+		if strings.HasPrefix(line, "//") || strings.HasPrefix(line, "[") {
+			startLine = i + 1
+			continue
+		}
+
+		break
+	}
+
+	return startLine
+}
+
+// findBraceEnd returns the line closing the block opened at or after
+// startLine, or the line ending a body-less declaration with ';'
+// (abstract and expression-bodied members, positional records).
+func (c *CSharpChunker) findBraceEnd(lines []string, states []csLineState, startLine int) int {
+	base := states[startLine-1].depthStart
+	opened := false
+
+	for i := startLine - 1; i < len(lines); i++ {
+		if states[i].depthMax > base {
+			opened = true
+		}
+		if opened && states[i].depthEnd <= base {
+			return i + 1
+		}
+		if !opened && strings.HasSuffix(strings.TrimSpace(lines[i]), ";") {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// newSymbol builds a symbol from a 1-indexed inclusive line range.
+func (c *CSharpChunker) newSymbol(lines []string, name, symbolType string, start, end int) csSymbol {
+	if end < start {
+		end = start
+	}
+	content := extractLines(lines, start, end)
+	return csSymbol{
+		name:       name,
+		symbolType: symbolType,
+		startLine:  start,
+		endLine:    end,
+		content:    content,
+		tokens:     c.config.CountTokens(content),
+	}
+}
+
+// csScanLines tracks brace depth per line, ignoring braces inside comments,
+// char literals and regular, verbatim (@"...") and raw ("""...""") strings.
+func csScanLines(lines []string) []csLineState {
+	states := make([]csLineState, len(lines))
+	depth := 0
+	inBlockComment := false
+	inVerbatim := false
+	rawQuotes := "" // closing delimiter of an open raw string literal
+
+	for i, line := range lines {
+		st := csLineState{code: !inBlockComment && !inVerbatim && rawQuotes == "", depthStart: depth, depthMax: depth}
+
+		for k := 0; k < len(line); k++ {
+			switch {
+			case inBlockComment:
+				if strings.HasPrefix(line[k:], "*/") {
+					inBlockComment = false
+					k++
+				}
+			case inVerbatim:
+				if strings.HasPrefix(line[k:], `""`) {
+					k++
+				} else if line[k] == '"' {
+					inVerbatim = false
+				}
+			case rawQuotes != "":
+				if strings.HasPrefix(line[k:], rawQuotes) {
+					k += len(rawQuotes) - 1
+					rawQuotes = ""
+				}
+			case strings.HasPrefix(line[k:], "//"):
+				k = len(line)
+			case strings.HasPrefix(line[k:], "/*"):
+				inBlockComment = true
+				k++
+			case strings.HasPrefix(line[k:], `"""`):
+				n := 3
+				for k+n < len(line) && line[k+n] == '"' {
+					n++
+				}
+				rawQuotes = strings.Repeat(`"`, n)
+				k += n - 1
+			case strings.HasPrefix(line[k:], `@"`), strings.HasPrefix(line[k:], `$@"`), strings.HasPrefix(line[k:], `@$"`):
+				inVerbatim = true
+				k = strings.IndexByte(line[k:], '"') + k
+			case line[k] == '"' || line[k] == '\'':
+				quote := line[k]
+				for k++; k < len(line) && line[k] != quote; k++ {
+					if line[k] == '\\' {
+						k++
+					}
+				}
+			case line[k] == '{':
+				depth++
+				if depth > st.depthMax {
+					st.depthMax = depth
+				}
+			case line[k] == '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+
+		st.depthEnd = depth
+		states[i] = st
+	}
+	return states
+}
+
+// mergeSmallSymbols merges small symbols into adjacent larger ones.
+func (c *CSharpChunker) mergeSmallSymbols(symbols []csSymbol) []csSymbol {
+	if len(symbols) <= 1 {
+		return symbols
+	}
+
+	result := make([]csSymbol, 0, len(symbols))
+	var pending *csSymbol
+
+	for i := range symbols {
+		sym := symbols[i]
+
+		if sym.tokens < c.config.MinTokens {
+			if pending == nil {
+				pending = &sym
+			} else {
+				pending.content += "\n\n" + sym.content
+				pending.endLine = sym.endLine
+				pending.tokens = c.config.CountTokens(pending.content)
+				pending.name = pending.name + "+" + sym.name
+			}
+		} else {
+			if pending != nil {
+				if pending.tokens+sym.tokens <= c.config.MaxTokensFor(sym.symbolType) {
+					sym.content = pending.content + "\n\n" + sym.content
+					sym.startLine = pending.startLine
+					sym.tokens = c.config.CountTokens(sym.content)
+				} else {
+					result = append(result, *pending)
+				}
+				pending = nil
+			}
+			result = append(result, sym)
+		}
+	}
+
+	// Attach trailing small symbols to the previous chunk if it fits
+	if pending != nil {
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= c.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = c.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
+	}
+
+	return result
+}
+
+// chunkAsFile creates a single chunk for the entire file.
+func (c *CSharpChunker) chunkAsFile(content []byte, metadata FileMetadata, module string) []Chunk {
+	contentStr := string(content)
+	contentHash := c.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
+	symbol := metadata.FilePath
+
+	return []Chunk{{
+		ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, symbol, contentHash),
+		Content:     contentStr,
+		Symbol:      symbol,
+		SymbolType:  "file",
+		StartLine:   1,
+		EndLine:     strings.Count(contentStr, "\n") + 1,
+		TokenCount:  c.config.CountTokens(contentStr),
+		ContentHash: contentHash,
+		ExactHash:   exactHash,
+		FilePath:    metadata.FilePath,
+		Language:    "csharp",
+		Module:      module,
+		ProjectID:   metadata.ProjectID,
+	}}
+}
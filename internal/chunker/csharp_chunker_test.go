@@ -0,0 +1,142 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSharpChunker_ControllerActions(t *testing.T) {
+	chunks := chunkSource(t, NewCSharpChunker(testChunkingConfig), "Api/Controllers/UsersController.cs", "csharp", `using Microsoft.AspNetCore.Mvc;
+
+namespace Acme.Api.Controllers
+{
+    /// <summary>
+    /// Manages users.
+    /// </summary>
+    [ApiController]
+    [Route("api/[controller]")]
+    public class UsersController : ControllerBase
+    {
+        private readonly IUserService _users;
+
+        public UsersController(IUserService users)
+        {
+            _users = users;
+        }
+
+        /// <summary>Lists users.</summary>
+        [HttpGet]
+        public async Task<ActionResult<IEnumerable<UserDto>>> GetAll()
+        {
+            var template = @"{
+  ""page"": 1
+}";
+            return Ok(await _users.ListAsync(template));
+        }
+
+        [HttpGet("{id}")]
+        [ProducesResponseType(404)]
+        public async Task<IActionResult> Get(int id)
+        {
+            if (id <= 0) { return BadRequest('{'); }
+            var user = await _users.FindAsync(id);
+            return user == null ? NotFound() : Ok(user);
+        }
+
+        [HttpDelete("{id}")] public IActionResult Delete(int id) => NoContent();
+    }
+}
+`)
+
+	controller := findChunk(chunks, "UsersController")
+	if controller == nil || controller.SymbolType != "class" {
+		t.Fatalf("Expected UsersController class chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(controller.Content, "    /// <summary>") || !strings.Contains(controller.Content, `[Route("api/[controller]")]`) {
+		t.Errorf("Expected class doc comment and attributes, got:\n%s", controller.Content)
+	}
+	if controller.Module != "Acme.Api.Controllers" {
+		t.Errorf("Expected module from namespace, got %q", controller.Module)
+	}
+
+	if ctor := findChunk(chunks, "UsersController.UsersController"); ctor == nil || ctor.SymbolType != "constructor" {
+		t.Errorf("Expected constructor chunk, got %+v", chunks)
+	}
+
+	getAll := findChunk(chunks, "UsersController.GetAll")
+	if getAll == nil || getAll.SymbolType != "method" {
+		t.Fatalf("Expected GetAll action chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(getAll.Content, "        /// <summary>Lists users.</summary>\n        [HttpGet]") {
+		t.Errorf("Expected GetAll with its doc comment and attribute, got:\n%s", getAll.Content)
+	}
+	// Braces in the verbatim string must not end the method early
+	if !strings.HasSuffix(getAll.Content, "ListAsync(template));\n        }") {
+		t.Errorf("Expected GetAll to span its full body, got:\n%s", getAll.Content)
+	}
+
+	get := findChunk(chunks, "UsersController.Get")
+	if get == nil {
+		t.Fatalf("Expected Get action chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(get.Content), `[HttpGet("{id}")]`) || !strings.Contains(get.Content, "[ProducesResponseType(404)]") {
+		t.Errorf("Expected Get to carry its attributes, got:\n%s", get.Content)
+	}
+	if strings.Contains(get.Content, "HttpDelete") {
+		t.Errorf("Expected Delete's attribute to belong to Delete, got:\n%s", get.Content)
+	}
+
+	del := findChunk(chunks, "UsersController.Delete")
+	if del == nil || !strings.Contains(del.Content, `[HttpDelete("{id}")]`) || del.Module != "Acme.Api.Controllers" {
+		t.Errorf("Expected expression-bodied Delete with its inline attribute, got %+v", del)
+	}
+}
+
+func TestCSharpChunker_FileScopedNamespaceTypes(t *testing.T) {
+	chunks := chunkSource(t, NewCSharpChunker(testChunkingConfig), "Api/Controllers/UsersController.cs", "csharp", `namespace Acme.Domain;
+
+public interface IUserService
+{
+    Task<User?> FindAsync(int id);
+}
+
+public record UserDto(int Id, string Name);
+
+public readonly record struct Point(int X, int Y)
+{
+    public double Length() => Math.Sqrt(X * X + Y * Y);
+}
+
+internal struct Range
+{
+    public int Start;
+}
+
+public enum Role { Admin, User }
+`)
+
+	want := map[string]string{
+		"IUserService": "interface",
+		"UserDto":      "record",
+		"Point":        "record",
+		"Point.Length": "method",
+		"Range":        "struct",
+		"Role":         "enum",
+	}
+	for symbol, symbolType := range want {
+		c := findChunk(chunks, symbol)
+		if c == nil || c.SymbolType != symbolType {
+			t.Errorf("Expected %s %s chunk, got %+v", symbolType, symbol, c)
+			continue
+		}
+		if c.Module != "Acme.Domain" {
+			t.Errorf("Expected module Acme.Domain for %s, got %q", symbol, c.Module)
+		}
+	}
+	if findChunk(chunks, "IUserService.FindAsync") != nil {
+		t.Errorf("Expected interface members to stay in the interface chunk")
+	}
+	if dto := findChunk(chunks, "UserDto"); dto != nil && dto.StartLine != dto.EndLine {
+		t.Errorf("Expected positional record on one line, got %d-%d", dto.StartLine, dto.EndLine)
+	}
+}
@@ -10,31 +10,61 @@ import (
 
 // Factory creates chunkers based on file type and configuration.
 type Factory struct {
+	config            ChunkingConfig
 	goChunker         *GoChunker
 	typescriptChunker *TypeScriptChunker
 	phpChunker        *PHPChunker
+	pythonChunker     *PythonChunker
+	javaChunker       *JavaChunker
+	rustChunker       *RustChunker
+	csharpChunker     *CSharpChunker
+	rubyChunker       *RubyChunker
+	cChunker          *CChunker
+	sqlChunker        *SQLChunker
 	markdownChunker   *MarkdownChunker
 	genericChunker    *GenericChunker
 }
 
-// NewFactory creates a new chunker factory.
-func NewFactory(cfg config.ChunkingConfig) *Factory {
+// NewFactory creates a new chunker factory. A nil tokenizer uses the
+// default cl100k_base tokenizer.
+func NewFactory(cfg config.ChunkingConfig, tokenizer Tokenizer) *Factory {
 	chunkCfg := ChunkingConfig{
 		MinTokens:        cfg.MinTokens,
 		IdealTokens:      cfg.IdealTokens,
 		MaxTokens:        cfg.MaxTokens,
+		MaxTokensByType:  cfg.MaxTokensByType,
 		MergeSmallChunks: cfg.MergeSmallChunks,
+
+		ExtractRelationships: cfg.ExtractRelationships,
+		NormalizeHash:        cfg.NormalizeHash,
+		OverlapTokens:        cfg.OverlapTokens,
+		Tokenizer:            tokenizer,
+		ReceiverNaming:       cfg.ReceiverNaming,
 	}
 
 	return &Factory{
+		config:            chunkCfg,
 		goChunker:         NewGoChunker(chunkCfg),
 		typescriptChunker: NewTypeScriptChunker(chunkCfg),
 		phpChunker:        NewPHPChunker(chunkCfg),
+		pythonChunker:     NewPythonChunker(chunkCfg),
+		javaChunker:       NewJavaChunker(chunkCfg),
+		rustChunker:       NewRustChunker(chunkCfg),
+		csharpChunker:     NewCSharpChunker(chunkCfg),
+		rubyChunker:       NewRubyChunker(chunkCfg),
+		cChunker:          NewCChunker(chunkCfg),
+		sqlChunker:        NewSQLChunker(chunkCfg),
 		markdownChunker:   NewMarkdownChunker(chunkCfg),
 		genericChunker:    NewGenericChunker(chunkCfg),
 	}
 }
 
+// MergeSiblingChunks merges tiny chunks of sibling files with the factory's
+// chunking config (see MergeSiblingChunks).
+func (f *Factory) MergeSiblingChunks(chunks []Chunk) []Chunk {
+	return MergeSiblingChunks(chunks, f.config)
+}
+
 // GetChunker returns the appropriate chunker for a file based on extension.
 func (f *Factory) GetChunker(filePath string) Chunker {
 	ext := strings.ToLower(filepath.Ext(filePath))
@@ -42,10 +72,24 @@ func (f *Factory) GetChunker(filePath string) Chunker {
 	switch ext {
 	case ".go":
 		return f.goChunker
-	case ".ts", ".tsx", ".js", ".jsx", ".vue":
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".vue":
 		return f.typescriptChunker
-	case ".php":
+	case ".php", ".phtml":
 		return f.phpChunker
+	case ".py":
+		return f.pythonChunker
+	case ".java":
+		return f.javaChunker
+	case ".rs":
+		return f.rustChunker
+	case ".cs":
+		return f.csharpChunker
+	case ".rb":
+		return f.rubyChunker
+	case ".c", ".h", ".cpp", ".hpp":
+		return f.cChunker
+	case ".sql":
+		return f.sqlChunker
 	case ".md", ".markdown":
 		return f.markdownChunker
 	default:
@@ -62,6 +106,20 @@ func (f *Factory) GetChunkerByStrategy(strategy string) Chunker {
 		return f.typescriptChunker
 	case "php":
 		return f.phpChunker
+	case "python":
+		return f.pythonChunker
+	case "java":
+		return f.javaChunker
+	case "rust":
+		return f.rustChunker
+	case "csharp":
+		return f.csharpChunker
+	case "ruby":
+		return f.rubyChunker
+	case "c":
+		return f.cChunker
+	case "sql":
+		return f.sqlChunker
 	case "heading":
 		return f.markdownChunker
 	case "fixed", "file":
@@ -83,11 +141,14 @@ func DetectLanguage(filePath string) string {
 		".js":       "javascript",
 		".ts":       "typescript",
 		".jsx":      "javascript",
+		".mjs":      "javascript",
+		".cjs":      "javascript",
 		".tsx":      "typescript",
 		".java":     "java",
 		".rs":       "rust",
 		".rb":       "ruby",
 		".php":      "php",
+		".phtml":    "php",
 		".c":        "c",
 		".cpp":      "cpp",
 		".h":        "c",
@@ -132,3 +193,21 @@ func ExtractModule(filePath string) string {
 	}
 	return ""
 }
+
+// PathChunk creates a file's path chunk with the factory's tokenizer (see
+// PathChunk).
+func (f *Factory) PathChunk(metadata FileMetadata) Chunk {
+	return PathChunk(metadata, f.config)
+}
+
+// WholeFileChunk returns the entire content as one "file" chunk, whatever
+// its size. It is the fallback when a file's chunker fails.
+func (f *Factory) WholeFileChunk(content []byte, metadata FileMetadata) Chunk {
+	return f.genericChunker.singleChunk(string(content), metadata)[0]
+}
+
+// SplitOversized splits a chunk exceeding maxTokens at line boundaries,
+// using the factory's tokenizer and hashing settings.
+func (f *Factory) SplitOversized(chunk Chunk, maxTokens int) []Chunk {
+	return f.config.SplitOversized(chunk, maxTokens)
+}
@@ -0,0 +1,89 @@
+package chunker
+
+import (
+	"testing"
+
+	"github.com/iasik/project-indexer/internal/config"
+)
+
+func TestFactory_GetChunker(t *testing.T) {
+	f := NewFactory(config.ChunkingConfig{MinTokens: 200, IdealTokens: 500, MaxTokens: 800}, nil)
+
+	tests := map[string]string{
+		"web/App.tsx":            "typescript",
+		"web/api.ts":             "typescript",
+		"web/legacy.js":          "typescript",
+		"web/Button.jsx":         "typescript",
+		"web/config.mjs":         "typescript",
+		"web/server.cjs":         "typescript",
+		"cmd/main.go":            "function",
+		"src/Controller.php":     "php",
+		"views/index.phtml":      "php",
+		"app/views.py":           "python",
+		"src/App.java":           "java",
+		"src/lib.rs":             "rust",
+		"Api/UsersController.cs": "csharp",
+		"app/models/user.rb":     "ruby",
+		"src/list.c":             "c",
+		"include/list.h":         "c",
+		"src/engine.cpp":         "c",
+		"include/engine.hpp":     "c",
+		"db/schema.sql":          "sql",
+		"docs/README.md":         "heading",
+		"scripts/deploy.yaml":    "fixed",
+	}
+	for path, want := range tests {
+		if got := f.GetChunker(path).Name(); got != want {
+			t.Errorf("GetChunker(%q).Name() = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestFactory_PHPControllerModule(t *testing.T) {
+	f := NewFactory(config.ChunkingConfig{MinTokens: 10, IdealTokens: 500, MaxTokens: 800}, nil)
+	content := []byte(`<?php
+
+namespace App\Http\Controllers;
+
+use App\Models\User;
+use Illuminate\Http\Request;
+
+class UserController extends Controller
+{
+    public function index(Request $request)
+    {
+        $users = User::query()->paginate($request->integer('per_page', 15));
+
+        return view('users.index', ['users' => $users]);
+    }
+
+    public function show(User $user)
+    {
+        return view('users.show', ['user' => $user]);
+    }
+}
+`)
+
+	c := f.GetChunker("app/Http/Controllers/UserController.php")
+	chunks, err := c.Chunk(content, FileMetadata{
+		FilePath:  "app/Http/Controllers/UserController.php",
+		Language:  DetectLanguage("UserController.php"),
+		ProjectID: "test-project",
+	})
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	var found bool
+	for _, chunk := range chunks {
+		if chunk.SymbolType == "class" {
+			found = true
+			if chunk.Module != "App\\Http\\Controllers" {
+				t.Errorf("Expected class Module App\\Http\\Controllers, got %q", chunk.Module)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("Expected a class chunk, got %+v", chunks)
+	}
+}
@@ -24,7 +24,7 @@ func (g *GenericChunker) Name() string {
 // Chunk splits content into fixed-size chunks based on token limits.
 func (g *GenericChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error) {
 	contentStr := string(content)
-	totalTokens := EstimateTokens(contentStr)
+	totalTokens := g.config.CountTokens(contentStr)
 
 	// If content fits in one chunk, return as-is
 	if totalTokens <= g.config.MaxTokens {
@@ -33,33 +33,39 @@ func (g *GenericChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk,
 
 	// Split into multiple chunks
 	lines := strings.Split(contentStr, "\n")
+	midComment := commentBoundaries(lines, metadata.Language)
 	chunks := make([]Chunk, 0)
 
 	var currentLines []string
-	var currentTokens int
+	var lineTokens []int  // token count of each line in currentLines
+	var currentTokens int // excludes overlap lines, which don't count toward MaxTokens
+	overlapLines := 0
 	startLine := 1
 
 	for i, line := range lines {
-		lineTokens := EstimateTokens(line)
+		tokens := g.config.CountTokens(line)
 
 		// Check if adding this line would exceed max
-		if currentTokens+lineTokens > g.config.MaxTokens && len(currentLines) > 0 {
+		if currentTokens+tokens > g.config.MaxTokens && len(currentLines) > overlapLines &&
+			!(midComment[i] && currentTokens+tokens <= g.config.MaxTokens*3/2) {
 			// Create chunk from accumulated lines
 			chunk := g.createChunk(currentLines, startLine, i, metadata)
 			chunks = append(chunks, chunk)
 
-			// Reset for next chunk
-			currentLines = []string{line}
-			currentTokens = lineTokens
-			startLine = i + 1
-		} else {
-			currentLines = append(currentLines, line)
-			currentTokens += lineTokens
+			// Reset for next chunk, carrying the trailing overlap lines
+			overlapLines = g.overlapLineCount(lineTokens)
+			currentLines = append([]string(nil), currentLines[len(currentLines)-overlapLines:]...)
+			lineTokens = append([]int(nil), lineTokens[len(lineTokens)-overlapLines:]...)
+			currentTokens = 0
+			startLine = i + 1 - overlapLines
 		}
+		currentLines = append(currentLines, line)
+		lineTokens = append(lineTokens, tokens)
+		currentTokens += tokens
 	}
 
 	// Add remaining lines as final chunk
-	if len(currentLines) > 0 {
+	if len(currentLines) > overlapLines {
 		chunk := g.createChunk(currentLines, startLine, len(lines), metadata)
 		chunks = append(chunks, chunk)
 	}
@@ -67,9 +73,85 @@ func (g *GenericChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk,
 	return chunks, nil
 }
 
+// genericCommentStyles lists the comment syntax of languages handled by the
+// generic chunker; "slash" is "//" and "/* */", "hash" is "#".
+var genericCommentStyles = map[string]struct{ slash, hash bool }{
+	"swift":  {slash: true},
+	"kotlin": {slash: true},
+	"scala":  {slash: true},
+	"css":    {slash: true},
+	"scss":   {slash: true},
+	"less":   {slash: true},
+	"shell":  {hash: true},
+	"yaml":   {hash: true},
+}
+
+// commentBoundaries reports, per line index, whether a chunk boundary just
+// before that line would split a comment: a "/* */" block still open, or a
+// run of line comments continuing. Chunks are extended past such boundaries
+// (up to 1.5x MaxTokens) so comments stay whole.
+func commentBoundaries(lines []string, language string) []bool {
+	midComment := make([]bool, len(lines))
+	style, ok := genericCommentStyles[language]
+	if !ok {
+		return midComment
+	}
+
+	isLineComment := func(line string) bool {
+		line = strings.TrimSpace(line)
+		return (style.slash && strings.HasPrefix(line, "//")) || (style.hash && strings.HasPrefix(line, "#"))
+	}
+
+	inBlock := false
+	for i, line := range lines {
+		midComment[i] = inBlock || (i > 0 && isLineComment(lines[i-1]) && isLineComment(line))
+		if !style.slash {
+			continue
+		}
+
+		// Track "/* */" state across the line; "//" ends the scan
+		for rest := line; rest != ""; {
+			if inBlock {
+				end := strings.Index(rest, "*/")
+				if end < 0 {
+					break
+				}
+				inBlock = false
+				rest = rest[end+2:]
+				continue
+			}
+			start := strings.Index(rest, "/*")
+			lineComment := strings.Index(rest, "//")
+			if start < 0 || (lineComment >= 0 && lineComment < start) {
+				break
+			}
+			inBlock = true
+			rest = rest[start+2:]
+		}
+	}
+	return midComment
+}
+
+// overlapLineCount returns how many trailing lines fit within OverlapTokens.
+// Whole lines are carried so fragments still start at a line boundary.
+func (g *GenericChunker) overlapLineCount(lineTokens []int) int {
+	budget := g.config.OverlapTokens
+	if budget <= 0 {
+		return 0
+	}
+
+	n := 0
+	for n < len(lineTokens)-1 && lineTokens[len(lineTokens)-1-n] <= budget {
+		budget -= lineTokens[len(lineTokens)-1-n]
+		n++
+	}
+	return n
+}
+
 // singleChunk creates a single chunk from the entire content.
 func (g *GenericChunker) singleChunk(content string, metadata FileMetadata) []Chunk {
-	contentHash := HashContent(content)
+	contentHash := g.config.HashContent(content)
+	exactHash := HashContent(content)
 	symbol := filepath.Base(metadata.FilePath)
 
 	return []Chunk{{
@@ -79,8 +161,9 @@ func (g *GenericChunker) singleChunk(content string, metadata FileMetadata) []Ch
 		SymbolType:  "file",
 		StartLine:   1,
 		EndLine:     strings.Count(content, "\n") + 1,
-		TokenCount:  EstimateTokens(content),
+		TokenCount:  g.config.CountTokens(content),
 		ContentHash: contentHash,
+		ExactHash:   exactHash,
 		FilePath:    metadata.FilePath,
 		Language:    metadata.Language,
 		Module:      metadata.Module,
@@ -91,7 +174,8 @@ func (g *GenericChunker) singleChunk(content string, metadata FileMetadata) []Ch
 // createChunk creates a chunk from a slice of lines.
 func (g *GenericChunker) createChunk(lines []string, startLine, endLine int, metadata FileMetadata) Chunk {
 	content := strings.Join(lines, "\n")
-	contentHash := HashContent(content)
+	contentHash := g.config.HashContent(content)
+	exactHash := HashContent(content)
 
 	// Create symbol name based on line range
 	symbol := filepath.Base(metadata.FilePath)
@@ -107,8 +191,9 @@ func (g *GenericChunker) createChunk(lines []string, startLine, endLine int, met
 		SymbolType:  "fragment",
 		StartLine:   startLine,
 		EndLine:     endLine,
-		TokenCount:  EstimateTokens(content),
+		TokenCount:  g.config.CountTokens(content),
 		ContentHash: contentHash,
+		ExactHash:   exactHash,
 		FilePath:    metadata.FilePath,
 		Language:    metadata.Language,
 		Module:      metadata.Module,
@@ -0,0 +1,125 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// wordTokenizer counts whitespace-separated words, for predictable sizes.
+type wordTokenizer struct{}
+
+func (wordTokenizer) CountTokens(text string) int { return len(strings.Fields(text)) }
+func (wordTokenizer) Name() string                { return "words" }
+
+func TestGenericChunker_Overlap(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("line%02d a b c", i)) // 4 tokens each
+	}
+	content := strings.Join(lines, "\n")
+
+	c := NewGenericChunker(ChunkingConfig{MaxTokens: 12, OverlapTokens: 4, Tokenizer: wordTokenizer{}})
+	chunks, err := c.Chunk([]byte(content), FileMetadata{FilePath: "notes.txt", ProjectID: "p"})
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	// Three fresh lines per chunk (overlap doesn't count toward MaxTokens),
+	// plus the last line of the previous chunk
+	wantRanges := [][2]int{{1, 3}, {3, 6}, {6, 9}, {9, 10}}
+	if len(chunks) != len(wantRanges) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(wantRanges))
+	}
+	for i, want := range wantRanges {
+		if chunks[i].StartLine != want[0] || chunks[i].EndLine != want[1] {
+			t.Errorf("chunk %d: lines %d-%d, want %d-%d", i, chunks[i].StartLine, chunks[i].EndLine, want[0], want[1])
+		}
+		if got := extractLines(lines, want[0], want[1]); chunks[i].Content != got {
+			t.Errorf("chunk %d content = %q, want %q", i, chunks[i].Content, got)
+		}
+	}
+
+	// Adjacent chunks share the overlap text
+	for i := 1; i < len(chunks); i++ {
+		prevLines := strings.Split(chunks[i-1].Content, "\n")
+		shared := prevLines[len(prevLines)-1]
+		if !strings.HasPrefix(chunks[i].Content, shared+"\n") {
+			t.Errorf("chunk %d doesn't start with overlap %q", i, shared)
+		}
+	}
+}
+
+func TestGenericChunker_NoOverlapByDefault(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 6; i++ {
+		lines = append(lines, fmt.Sprintf("line%02d a b c", i))
+	}
+
+	c := NewGenericChunker(ChunkingConfig{MaxTokens: 12, Tokenizer: wordTokenizer{}})
+	chunks, err := c.Chunk([]byte(strings.Join(lines, "\n")), FileMetadata{FilePath: "notes.txt", ProjectID: "p"})
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+	if len(chunks) != 2 || chunks[0].EndLine != 3 || chunks[1].StartLine != 4 {
+		t.Errorf("unexpected chunks without overlap: %+v", chunks)
+	}
+}
+
+func chunkRanges(chunks []Chunk) [][2]int {
+	ranges := make([][2]int, len(chunks))
+	for i, c := range chunks {
+		ranges[i] = [2]int{c.StartLine, c.EndLine}
+	}
+	return ranges
+}
+
+func TestGenericChunker_KeepsCommentsWhole(t *testing.T) {
+	c := NewGenericChunker(ChunkingConfig{MaxTokens: 10, Tokenizer: wordTokenizer{}})
+
+	tests := []struct {
+		name     string
+		language string
+		lines    []string
+		want     [][2]int
+	}{
+		{
+			// The 10-token boundary falls after line 3, inside the block comment
+			name:     "block comment",
+			language: "kotlin",
+			lines:    []string{"a b c", "d e f", "/* g h", "i j k", "*/", "l m n", "o p q"},
+			want:     [][2]int{{1, 5}, {6, 7}},
+		},
+		{
+			name:     "line comment run",
+			language: "shell",
+			lines:    []string{"a b c", "d e f", "# g h", "# i j", "# k", "l m n"},
+			want:     [][2]int{{1, 5}, {6, 6}},
+		},
+		{
+			// Unknown languages split at the token limit as before
+			name:     "no comment syntax",
+			language: "text",
+			lines:    []string{"a b c", "d e f", "/* g h", "i j k", "*/", "l m n", "o p q"},
+			want:     [][2]int{{1, 3}, {4, 7}},
+		},
+		{
+			// Comments longer than the 1.5x extension cap are still split
+			name:     "extension cap",
+			language: "kotlin",
+			lines:    []string{"a b c", "d e f", "/* g h", "i j k", "l m n", "o p q", "*/"},
+			want:     [][2]int{{1, 5}, {6, 7}},
+		},
+	}
+
+	for _, tt := range tests {
+		content := strings.Join(tt.lines, "\n")
+		chunks, err := c.Chunk([]byte(content), FileMetadata{FilePath: "f", Language: tt.language, ProjectID: "p"})
+		if err != nil {
+			t.Fatalf("%s: Chunk failed: %v", tt.name, err)
+		}
+		if got := chunkRanges(chunks); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: got ranges %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
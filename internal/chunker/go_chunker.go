@@ -36,6 +36,9 @@ type goSymbol struct {
 	endLine    int
 	content    string
 	tokens     int
+	imports    []string
+	references []string
+	node       ast.Node // declaration the symbol came from
 }
 
 // Chunk splits Go source code into function/type-level chunks.
@@ -61,6 +64,10 @@ func (g *GoChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error
 		return g.chunkAsFile(content, metadata), nil
 	}
 
+	if g.config.ExtractRelationships {
+		g.attachRelationships(file, symbols)
+	}
+
 	// Sort by start line
 	sort.Slice(symbols, func(i, j int) bool {
 		return symbols[i].startLine < symbols[j].startLine
@@ -74,7 +81,8 @@ func (g *GoChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error
 	// Convert symbols to chunks
 	chunks := make([]Chunk, 0, len(symbols))
 	for _, sym := range symbols {
-		contentHash := HashContent(sym.content)
+		contentHash := g.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
 		chunk := Chunk{
 			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
 			Content:     sym.content,
@@ -84,10 +92,13 @@ func (g *GoChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error
 			EndLine:     sym.endLine,
 			TokenCount:  sym.tokens,
 			ContentHash: contentHash,
+			ExactHash:   exactHash,
 			FilePath:    metadata.FilePath,
 			Language:    "go",
 			Module:      module,
 			ProjectID:   metadata.ProjectID,
+			Imports:     sym.imports,
+			References:  sym.references,
 		}
 		chunks = append(chunks, chunk)
 	}
@@ -148,7 +159,7 @@ func (g *GoChunker) extractFunction(fset *token.FileSet, fn *ast.FuncDecl, lines
 		symbolType = "method"
 		// Include receiver type in name for methods
 		if recv := fn.Recv.List[0]; recv.Type != nil {
-			recvType := extractReceiverType(recv.Type)
+			recvType := extractReceiverType(recv.Type, g.config.ReceiverNaming)
 			if recvType != "" {
 				name = fmt.Sprintf("%s.%s", recvType, fn.Name.Name)
 			}
@@ -161,7 +172,8 @@ func (g *GoChunker) extractFunction(fset *token.FileSet, fn *ast.FuncDecl, lines
 		startLine:  startLine,
 		endLine:    endLine,
 		content:    content,
-		tokens:     EstimateTokens(content),
+		tokens:     g.config.CountTokens(content),
+		node:       fn,
 	}
 }
 
@@ -194,20 +206,54 @@ func (g *GoChunker) extractType(fset *token.FileSet, genDecl *ast.GenDecl, ts *a
 		startLine:  startLine,
 		endLine:    endLine,
 		content:    content,
-		tokens:     EstimateTokens(content),
+		tokens:     g.config.CountTokens(content),
+		node:       ts,
 	}
 }
 
-// extractReceiverType extracts the receiver type name from a method.
-func extractReceiverType(expr ast.Expr) string {
+// extractReceiverType names the receiver type of a method per the naming
+// scheme: the bare type name (Cache), or with ReceiverNamingPointer the
+// pointer as well ((*Cache)), or with ReceiverNamingFull also the type
+// parameters (Cache[K], (*Cache[K])).
+func extractReceiverType(expr ast.Expr, naming string) string {
+	pointer := false
+	if star, ok := expr.(*ast.StarExpr); ok {
+		pointer = true
+		expr = star.X
+	}
+
+	var name string
+	var params []string
 	switch t := expr.(type) {
 	case *ast.Ident:
-		return t.Name
-	case *ast.StarExpr:
-		if ident, ok := t.X.(*ast.Ident); ok {
-			return ident.Name
+		name = t.Name
+	case *ast.IndexExpr:
+		name, params = typeParamName(t.X), []string{typeParamName(t.Index)}
+	case *ast.IndexListExpr:
+		name = typeParamName(t.X)
+		for _, index := range t.Indices {
+			params = append(params, typeParamName(index))
 		}
 	}
+	if name == "" {
+		return ""
+	}
+
+	if naming == ReceiverNamingFull && len(params) > 0 {
+		name += "[" + strings.Join(params, ", ") + "]"
+	}
+	if pointer && (naming == ReceiverNamingPointer || naming == ReceiverNamingFull) {
+		name = "(*" + name + ")"
+	}
+	return name
+}
+
+// typeParamName returns the name of a receiver type or type parameter
+// identifier ("_" parameters included), or "" for other expressions.
+func typeParamName(expr ast.Expr) string {
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
 	return ""
 }
 
@@ -239,9 +285,13 @@ func (g *GoChunker) mergeSmallSymbols(symbols []goSymbol) []goSymbol {
 			// Accumulate small symbols
 			pendingSmall = append(pendingSmall, sym)
 		} else {
-			// Found a large symbol, attach pending small ones
+			// Found a large symbol, attach pending small ones if within its limit
 			if len(pendingSmall) > 0 {
-				sym = g.mergeInto(sym, pendingSmall)
+				if g.fitsWith(sym, pendingSmall) {
+					sym = g.mergeInto(sym, pendingSmall)
+				} else {
+					result = append(result, g.combineSymbols(pendingSmall))
+				}
 				pendingSmall = pendingSmall[:0]
 			}
 			result = append(result, sym)
@@ -250,10 +300,12 @@ func (g *GoChunker) mergeSmallSymbols(symbols []goSymbol) []goSymbol {
 
 	// Handle remaining small symbols
 	if len(pendingSmall) > 0 {
-		if len(result) > 0 {
+		if len(result) > 0 && g.fitsWith(result[len(result)-1], pendingSmall) {
 			// Merge into last large symbol
 			last := &result[len(result)-1]
 			*last = g.mergeInto(*last, pendingSmall)
+		} else if len(result) > 0 {
+			result = append(result, g.combineSymbols(pendingSmall))
 		} else {
 			// All symbols are small, combine them all
 			merged := g.combineSymbols(pendingSmall)
@@ -264,6 +316,16 @@ func (g *GoChunker) mergeSmallSymbols(symbols []goSymbol) []goSymbol {
 	return result
 }
 
+// fitsWith reports whether merging small symbols into target stays within
+// the target's per-type token limit.
+func (g *GoChunker) fitsWith(target goSymbol, small []goSymbol) bool {
+	total := target.tokens
+	for _, s := range small {
+		total += s.tokens
+	}
+	return total <= g.config.MaxTokensFor(target.symbolType)
+}
+
 // mergeInto merges small symbols into a larger symbol.
 func (g *GoChunker) mergeInto(target goSymbol, small []goSymbol) goSymbol {
 	// Combine content
@@ -276,8 +338,12 @@ func (g *GoChunker) mergeInto(target goSymbol, small []goSymbol) goSymbol {
 	}
 
 	// Update target
+	for _, s := range small {
+		target.imports = mergeRelationships(target.imports, s.imports)
+		target.references = mergeRelationships(target.references, s.references)
+	}
 	target.content = strings.Join(allContent, "\n\n")
-	target.tokens = EstimateTokens(target.content)
+	target.tokens = g.config.CountTokens(target.content)
 
 	// Update line range
 	for _, s := range small {
@@ -305,12 +371,15 @@ func (g *GoChunker) combineSymbols(symbols []goSymbol) goSymbol {
 
 	var content []string
 	var names []string
+	var imports, references []string
 	startLine := symbols[0].startLine
 	endLine := symbols[0].endLine
 
 	for _, s := range symbols {
 		content = append(content, s.content)
 		names = append(names, s.name)
+		imports = mergeRelationships(imports, s.imports)
+		references = mergeRelationships(references, s.references)
 		if s.startLine < startLine {
 			startLine = s.startLine
 		}
@@ -327,14 +396,17 @@ func (g *GoChunker) combineSymbols(symbols []goSymbol) goSymbol {
 		startLine:  startLine,
 		endLine:    endLine,
 		content:    combinedContent,
-		tokens:     EstimateTokens(combinedContent),
+		tokens:     g.config.CountTokens(combinedContent),
+		imports:    imports,
+		references: references,
 	}
 }
 
 // chunkAsFile returns the entire file as a single chunk.
 func (g *GoChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
 	contentStr := string(content)
-	contentHash := HashContent(contentStr)
+	contentHash := g.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
 
 	// Extract package name from file path
 	symbol := filepath.Base(metadata.FilePath)
@@ -346,11 +418,109 @@ func (g *GoChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
 		SymbolType:  "file",
 		StartLine:   1,
 		EndLine:     strings.Count(contentStr, "\n") + 1,
-		TokenCount:  EstimateTokens(contentStr),
+		TokenCount:  g.config.CountTokens(contentStr),
 		ContentHash: contentHash,
+		ExactHash:   exactHash,
 		FilePath:    metadata.FilePath,
 		Language:    "go",
 		Module:      metadata.Module,
 		ProjectID:   metadata.ProjectID,
 	}}
 }
+
+// maxRelationships bounds imports/references stored per symbol to keep payloads small.
+const maxRelationships = 32
+
+// goBuiltins are predeclared identifiers that are not useful as references.
+var goBuiltins = map[string]bool{
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true,
+	"copy": true, "delete": true, "imag": true, "len": true, "make": true,
+	"max": true, "min": true, "new": true, "panic": true, "print": true,
+	"println": true, "real": true, "recover": true,
+	"bool": true, "byte": true, "error": true, "float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"rune": true, "string": true, "uint": true, "uint8": true, "uint16": true,
+	"uint32": true, "uint64": true, "uintptr": true, "any": true,
+}
+
+// attachRelationships records, per symbol, the import paths it uses and the
+// functions it calls ("pkg.Func" for package calls, "Func"/"Method" otherwise).
+func (g *GoChunker) attachRelationships(file *ast.File, symbols []goSymbol) {
+	// Local package name -> import path
+	importNames := make(map[string]string)
+	for _, imp := range file.Imports {
+		path := strings.Trim(imp.Path.Value, `"`)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name != "_" && name != "." {
+			importNames[name] = path
+		}
+	}
+
+	for i := range symbols {
+		if symbols[i].node == nil {
+			continue
+		}
+		var imports, references []string
+		ast.Inspect(symbols[i].node, func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.SelectorExpr:
+				if ident, ok := x.X.(*ast.Ident); ok {
+					if path, ok := importNames[ident.Name]; ok {
+						imports = mergeRelationships(imports, []string{path})
+					}
+				}
+			case *ast.CallExpr:
+				if ref := callReference(x.Fun, importNames); ref != "" {
+					references = mergeRelationships(references, []string{ref})
+				}
+			}
+			return true
+		})
+		symbols[i].imports = imports
+		symbols[i].references = references
+	}
+}
+
+// callReference names the function called by a call expression's Fun.
+func callReference(fun ast.Expr, importNames map[string]string) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		if !goBuiltins[f.Name] {
+			return f.Name
+		}
+	case *ast.SelectorExpr:
+		if ident, ok := f.X.(*ast.Ident); ok {
+			if _, isPkg := importNames[ident.Name]; isPkg {
+				return ident.Name + "." + f.Sel.Name
+			}
+		}
+		return f.Sel.Name
+	case *ast.IndexExpr: // generic instantiation: Func[T](...)
+		return callReference(f.X, importNames)
+	}
+	return ""
+}
+
+// mergeRelationships appends new entries to list, skipping duplicates and
+// stopping at maxRelationships. Order of first appearance is kept.
+func mergeRelationships(list, add []string) []string {
+	for _, a := range add {
+		if len(list) >= maxRelationships {
+			break
+		}
+		found := false
+		for _, l := range list {
+			if l == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, a)
+		}
+	}
+	return list
+}
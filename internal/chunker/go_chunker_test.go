@@ -0,0 +1,218 @@
+package chunker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGoChunker_ExtractRelationships(t *testing.T) {
+	content := []byte(`package auth
+
+import (
+	"fmt"
+	"net/http"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// Login validates credentials and issues a token.
+func Login(w http.ResponseWriter, r *http.Request) {
+	user := lookupUser(r.FormValue("user"))
+	token, err := jwt.Parse(user.Token, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid: %v", err), http.StatusUnauthorized)
+		return
+	}
+	w.Write([]byte(token.Raw))
+}
+
+func lookupUser(name string) *User {
+	return &User{Name: name}
+}
+
+type User struct {
+	Name  string
+	Token string
+}
+`)
+	metadata := FileMetadata{FilePath: "auth/login.go", ProjectID: "test-project"}
+
+	chunker := NewGoChunker(ChunkingConfig{
+		MinTokens:            1,
+		IdealTokens:          200,
+		MaxTokens:            500,
+		ExtractRelationships: true,
+	})
+	chunks, err := chunker.Chunk(content, metadata)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	var login *Chunk
+	for i := range chunks {
+		if chunks[i].Symbol == "Login" {
+			login = &chunks[i]
+		}
+	}
+	if login == nil {
+		t.Fatalf("Expected a Login chunk, got %d chunks", len(chunks))
+	}
+
+	imports := "," + strings.Join(login.Imports, ",") + ","
+	for _, imp := range []string{"fmt", "net/http", "github.com/golang-jwt/jwt/v5"} {
+		if !strings.Contains(imports, ","+imp+",") {
+			t.Errorf("Expected import %s, got %v", imp, login.Imports)
+		}
+	}
+
+	refs := "," + strings.Join(login.References, ",") + ","
+	for _, ref := range []string{"lookupUser", "jwt.Parse", "http.Error", "fmt.Sprintf", "FormValue", "Write"} {
+		if !strings.Contains(refs, ","+ref+",") {
+			t.Errorf("Expected reference %s, got %v", ref, login.References)
+		}
+	}
+	if strings.Contains(refs, ",byte,") {
+		t.Errorf("Expected builtins excluded, got %v", login.References)
+	}
+
+	// Disabled by default
+	plain, _ := NewGoChunker(ChunkingConfig{MinTokens: 1, IdealTokens: 200, MaxTokens: 500}).Chunk(content, metadata)
+	for _, c := range plain {
+		if len(c.Imports) > 0 || len(c.References) > 0 {
+			t.Errorf("Expected no relationships without the flag, got %v / %v", c.Imports, c.References)
+		}
+	}
+}
+
+func TestGoChunker_NormalizeHash(t *testing.T) {
+	original := []byte(`package calc
+
+// Add returns the sum of a and b.
+func Add(a, b int) int {
+	return a + b
+}
+`)
+	reformatted := []byte(`package calc
+
+// Add returns the sum of a and b.
+func Add(a, b int) int {
+        return a   +   b
+
+}
+`)
+	metadata := FileMetadata{FilePath: "calc/add.go", ProjectID: "test-project"}
+
+	findAdd := func(t *testing.T, cfg ChunkingConfig, content []byte) Chunk {
+		t.Helper()
+		chunks, err := NewGoChunker(cfg).Chunk(content, metadata)
+		if err != nil {
+			t.Fatalf("Chunk failed: %v", err)
+		}
+		for _, c := range chunks {
+			if c.Symbol == "Add" {
+				return c
+			}
+		}
+		t.Fatalf("Add chunk not found in %d chunks", len(chunks))
+		return Chunk{}
+	}
+
+	cfg := ChunkingConfig{MinTokens: 1, IdealTokens: 500, MaxTokens: 800}
+
+	t.Run("exact hashing changes ID on reformat", func(t *testing.T) {
+		before := findAdd(t, cfg, original)
+		after := findAdd(t, cfg, reformatted)
+		if before.ID == after.ID {
+			t.Errorf("Expected ID to change without normalization, both were %s", before.ID)
+		}
+		if before.ContentHash != before.ExactHash {
+			t.Errorf("Expected ContentHash to equal ExactHash without normalization")
+		}
+	})
+
+	t.Run("normalized hashing keeps ID stable on reformat", func(t *testing.T) {
+		normCfg := cfg
+		normCfg.NormalizeHash = true
+		before := findAdd(t, normCfg, original)
+		after := findAdd(t, normCfg, reformatted)
+		if before.ID != after.ID {
+			t.Errorf("Expected stable ID, got %s and %s", before.ID, after.ID)
+		}
+		if before.ContentHash != after.ContentHash {
+			t.Errorf("Expected stable ContentHash under normalization")
+		}
+		if before.ExactHash == after.ExactHash {
+			t.Errorf("Expected ExactHash to reflect the reformatting")
+		}
+		if after.ExactHash != HashContent(after.Content) {
+			t.Errorf("Expected ExactHash to hash the exact content")
+		}
+	})
+
+	t.Run("normalized hashing still detects logic changes", func(t *testing.T) {
+		normCfg := cfg
+		normCfg.NormalizeHash = true
+		changed := []byte(strings.Replace(string(original), "a + b", "a - b", 1))
+		if findAdd(t, normCfg, original).ID == findAdd(t, normCfg, changed).ID {
+			t.Errorf("Expected ID to change when the code changes")
+		}
+	})
+}
+
+func TestGoChunker_ReceiverNaming(t *testing.T) {
+	// Not compilable Go, but parseable: both receivers of Save side by side
+	content := []byte(`package cache
+
+// Save persists the cache.
+func (c *Cache) Save() error {
+	return nil
+}
+
+// Save on a copy is a no-op.
+func (c Cache) Save() error {
+	return nil
+}
+
+// Get returns the value of a key.
+func (c *Store[K, V]) Get(key K) V {
+	return c.items[key]
+}
+
+// Len returns the number of items.
+func (l List[_]) Len() int {
+	return len(l)
+}
+`)
+	metadata := FileMetadata{FilePath: "cache/cache.go", ProjectID: "test-project"}
+
+	tests := []struct {
+		naming string
+		want   []string
+	}{
+		{"", []string{"Cache.Save", "Cache.Save", "Store.Get", "List.Len"}},
+		{ReceiverNamingType, []string{"Cache.Save", "Cache.Save", "Store.Get", "List.Len"}},
+		{ReceiverNamingPointer, []string{"(*Cache).Save", "Cache.Save", "(*Store).Get", "List.Len"}},
+		{ReceiverNamingFull, []string{"(*Cache).Save", "Cache.Save", "(*Store[K, V]).Get", "List[_].Len"}},
+	}
+	for _, tt := range tests {
+		cfg := ChunkingConfig{MinTokens: 1, IdealTokens: 500, MaxTokens: 800, ReceiverNaming: tt.naming}
+		chunks, err := NewGoChunker(cfg).Chunk(content, metadata)
+		if err != nil {
+			t.Fatalf("Chunk failed: %v", err)
+		}
+
+		var symbols []string
+		for _, c := range chunks {
+			if c.SymbolType != "method" {
+				continue
+			}
+			symbols = append(symbols, c.Symbol)
+			if !strings.Contains(c.ID, ":"+c.Symbol+":") {
+				t.Errorf("naming %q: expected ID %s to carry symbol %s", tt.naming, c.ID, c.Symbol)
+			}
+		}
+		if !reflect.DeepEqual(symbols, tt.want) {
+			t.Errorf("naming %q: symbols = %q, want %q", tt.naming, symbols, tt.want)
+		}
+	}
+}
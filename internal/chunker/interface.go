@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // Chunker defines the interface for all chunking strategies.
@@ -56,14 +57,37 @@ type Chunk struct {
 	// Estimated token count
 	TokenCount int
 
-	// SHA256 hash of content for change detection
+	// SHA256 hash of content for change detection (whitespace-normalized
+	// when NormalizeHash is enabled)
 	ContentHash string
 
+	// SHA256 hash of the exact content, regardless of NormalizeHash
+	ExactHash string
+
 	// Inherited from FileMetadata
 	FilePath  string
 	Language  string
 	Module    string
 	ProjectID string
+
+	// Every file of a chunk merged from sibling files (first is FilePath);
+	// empty for single-file chunks
+	FilePaths []string
+
+	// File owner, set by the indexer when ownership capture is enabled
+	Owner string
+
+	// File modification time, set by the indexer
+	ModTime time.Time
+
+	// Enclosing type of a method (e.g. "Server" for "Server.Handle"),
+	// set by the indexer from Symbol; empty for top-level symbols
+	ParentSymbol string
+
+	// Imported packages used and functions called by the symbol
+	// (Go only, when ExtractRelationships is enabled)
+	Imports    []string
+	References []string
 }
 
 // ChunkingConfig holds chunking parameters.
@@ -77,8 +101,60 @@ type ChunkingConfig struct {
 	// Maximum tokens per chunk
 	MaxTokens int
 
+	// Per-symbol-type maximum tokens (e.g. function: 600); falls back to MaxTokens
+	MaxTokensByType map[string]int
+
 	// Whether to merge small chunks into parent scope
 	MergeSmallChunks bool
+
+	// Extract imports and references per symbol (Go only)
+	ExtractRelationships bool
+
+	// Compute ContentHash over whitespace-collapsed content so reformatting
+	// alone doesn't change chunk IDs
+	NormalizeHash bool
+
+	// Tokens of the previous fragment repeated at the start of the next one
+	// (fixed-size chunking only; not counted toward MaxTokens)
+	OverlapTokens int
+
+	// Tokenizer used for chunk sizing (nil uses the default cl100k_base tokenizer)
+	Tokenizer Tokenizer
+
+	// How Go method receivers are named (ReceiverNamingType when empty)
+	ReceiverNaming string
+}
+
+// Go receiver naming schemes for method symbols.
+const (
+	ReceiverNamingType    = "type"    // Cache.Save
+	ReceiverNamingPointer = "pointer" // (*Cache).Save
+	ReceiverNamingFull    = "full"    // (*Cache[K]).Save
+)
+
+// HashContent returns the change-detection hash for content, normalizing
+// whitespace first when NormalizeHash is enabled.
+func (c ChunkingConfig) HashContent(content string) string {
+	if c.NormalizeHash {
+		return HashContent(NormalizeWhitespace(content))
+	}
+	return HashContent(content)
+}
+
+// CountTokens counts tokens in content with the configured tokenizer.
+func (c ChunkingConfig) CountTokens(content string) int {
+	if c.Tokenizer == nil {
+		return DefaultTokenizer().CountTokens(content)
+	}
+	return c.Tokenizer.CountTokens(content)
+}
+
+// MaxTokensFor returns the maximum tokens allowed for a symbol type.
+func (c ChunkingConfig) MaxTokensFor(symbolType string) int {
+	if max, ok := c.MaxTokensByType[symbolType]; ok && max > 0 {
+		return max
+	}
+	return c.MaxTokens
 }
 
 // DefaultConfig returns default chunking configuration.
@@ -109,18 +185,72 @@ func GenerateChunkID(projectID, filePath, symbol, contentHash string) string {
 	return fmt.Sprintf("%s:%s:%s:%s", projectID, filePath, symbolPart, hashPrefix)
 }
 
+// ParentSymbol returns the enclosing type of a method or constructor symbol
+// qualified as Type.method, Type#method (Ruby) or Type::method (Rust), or ""
+// for other symbols. A #N split suffix is ignored, as are the pointer and
+// type parameters of Go receivers.
+func ParentSymbol(symbol, symbolType string) string {
+	if symbolType != "method" && symbolType != "constructor" {
+		return ""
+	}
+	if i := strings.LastIndexByte(symbol, '#'); i >= 0 && isDigits(symbol[i+1:]) {
+		symbol = symbol[:i]
+	}
+	cut := -1
+	for _, sep := range []string{".", "#", "::"} {
+		if i := strings.LastIndex(symbol, sep); i > cut {
+			cut = i
+		}
+	}
+	if cut <= 0 {
+		return ""
+	}
+	return receiverBaseType(symbol[:cut])
+}
+
+// receiverBaseType strips the pointer and type parameters of a Go receiver
+// named with ReceiverNamingPointer or ReceiverNamingFull: "(*Cache[K])" is
+// "Cache".
+func receiverBaseType(recv string) string {
+	if strings.HasPrefix(recv, "(*") && strings.HasSuffix(recv, ")") {
+		recv = recv[2 : len(recv)-1]
+	}
+	if i := strings.IndexByte(recv, '['); i > 0 && strings.HasSuffix(recv, "]") {
+		recv = recv[:i]
+	}
+	return recv
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // HashContent creates a SHA256 hash of content.
 func HashContent(content string) string {
 	h := sha256.Sum256([]byte(content))
 	return fmt.Sprintf("%x", h)
 }
 
-// EstimateTokens provides a rough token count estimate.
-// Uses a simple heuristic: ~4 characters per token for code.
+// NormalizeWhitespace collapses every run of whitespace into a single space
+// and trims the ends, so formatting-only edits produce identical output.
+func NormalizeWhitespace(content string) string {
+	return strings.Join(strings.Fields(content), " ")
+}
+
+// EstimateTokens counts the tokens of content with the default cl100k_base
+// tokenizer. Chunkers use ChunkingConfig.CountTokens instead so a configured
+// tokenizer file is honored.
 func EstimateTokens(content string) int {
-	// Simple heuristic: average 4 chars per token
-	// This works reasonably well for code
-	return len(content) / 4
+	return DefaultTokenizer().CountTokens(content)
 }
 
 // SplitIntoLines splits content into lines while preserving line numbers.
@@ -0,0 +1,409 @@
+// Package chunker provides Java code chunking using brace matching.
+// It extracts top-level classes, interfaces, enums and records and their methods.
+package chunker
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// JavaChunker implements class/method-level chunking for Java.
+type JavaChunker struct {
+	config ChunkingConfig
+}
+
+// NewJavaChunker creates a new Java chunker.
+func NewJavaChunker(cfg ChunkingConfig) *JavaChunker {
+	return &JavaChunker{config: cfg}
+}
+
+// Name returns the chunker strategy name.
+func (j *JavaChunker) Name() string {
+	return "java"
+}
+
+// javaSymbol represents an extracted Java symbol.
+type javaSymbol struct {
+	name       string
+	symbolType string
+	startLine  int
+	endLine    int
+	content    string
+	tokens     int
+}
+
+// javaLineState is the lexer state of a single line.
+type javaLineState struct {
+	code       bool // line starts outside block comments and text blocks
+	depthStart int  // brace depth at line start
+	depthEnd   int  // brace depth at line end
+	depthMax   int  // highest brace depth reached on the line
+}
+
+// Regex patterns for Java symbol extraction
+var (
+	// Package: package com.example.web;
+	javaPackagePattern = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+
+	// Type: public final class Foo, interface Bar<T>, enum Baz, record Point(...), @interface Ann
+	javaTypePattern = regexp.MustCompile(`^\s*(?:@[\w.]+(?:\([^)]*\))?\s+)*(?:(?:public|protected|private|abstract|final|static|sealed|non-sealed|strictfp)\s+)*(class|interface|enum|record|@interface)\s+(\w+)`)
+
+	// Method or constructor: annotations, modifiers, type parameters, return type, name, "("
+	javaMethodPattern = regexp.MustCompile(`^\s*(?:@[\w.]+(?:\([^)]*\))?\s+)*(?:(?:public|protected|private|static|final|abstract|synchronized|native|default|strictfp)\s+)*(?:<[^>]*>\s+)?([\w.$]+(?:<.*>)?(?:\[\])*\s+)?(\w+)\s*\(`)
+)
+
+// javaKeywords are words the method pattern could mistake for a name.
+var javaKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true,
+	"return": true, "new": true, "throw": true, "else": true, "do": true,
+	"try": true, "synchronized": true, "super": true, "this": true,
+}
+
+// javaTypeKinds maps declaration keywords to symbol types.
+var javaTypeKinds = map[string]string{
+	"class":      "class",
+	"interface":  "interface",
+	"enum":       "enum",
+	"record":     "record",
+	"@interface": "annotation",
+}
+
+// Chunk splits Java source code into type and method chunks.
+func (j *JavaChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error) {
+	contentStr := string(content)
+	lines := strings.Split(contentStr, "\n")
+
+	module := metadata.Module
+	if m := javaPackagePattern.FindStringSubmatch(contentStr); m != nil {
+		module = m[1]
+	}
+
+	symbols := j.extractSymbols(lines, javaScanLines(lines))
+	if len(symbols) == 0 {
+		return j.chunkAsFile(content, metadata, module), nil
+	}
+
+	if j.config.MergeSmallChunks {
+		symbols = j.mergeSmallSymbols(symbols)
+	}
+
+	chunks := make([]Chunk, 0, len(symbols))
+	for _, sym := range symbols {
+		contentHash := j.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
+		chunks = append(chunks, Chunk{
+			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
+			Content:     sym.content,
+			Symbol:      sym.name,
+			SymbolType:  sym.symbolType,
+			StartLine:   sym.startLine,
+			EndLine:     sym.endLine,
+			TokenCount:  sym.tokens,
+			ContentHash: contentHash,
+			ExactHash:   exactHash,
+			FilePath:    metadata.FilePath,
+			Language:    "java",
+			Module:      module,
+			ProjectID:   metadata.ProjectID,
+		})
+	}
+
+	return chunks, nil
+}
+
+// extractSymbols finds top-level types and the methods declared directly in
+// them. A type chunk covers its header up to the first method; each method
+// runs until the next method or the type's closing brace. Interfaces and
+// annotations are kept whole.
+func (j *JavaChunker) extractSymbols(lines []string, states []javaLineState) []javaSymbol {
+	var symbols []javaSymbol
+
+	for i := 0; i < len(lines); i++ {
+		if !states[i].code || states[i].depthStart != 0 {
+			continue
+		}
+		m := javaTypePattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		kind, name := javaTypeKinds[m[1]], m[2]
+
+		start := j.findPrecedingComment(lines, i+1)
+		end := j.findBraceEnd(lines, states, i+1)
+
+		var methods []javaSymbol
+		if kind != "interface" && kind != "annotation" {
+			methods = j.findMethods(lines, states, name, i+1, end)
+		}
+
+		typeEnd := end
+		if len(methods) > 0 {
+			typeEnd = trimTrailingBlankLines(lines, start, methods[0].startLine-1)
+		}
+		symbols = append(symbols, j.newSymbol(lines, name, kind, start, typeEnd))
+		symbols = append(symbols, methods...)
+
+		i = end - 1
+	}
+
+	sort.SliceStable(symbols, func(a, b int) bool {
+		return symbols[a].startLine < symbols[b].startLine
+	})
+	return symbols
+}
+
+// findMethods returns the methods and constructors at depth 1 of a type
+// declared at typeLine and closed at typeEnd.
+func (j *JavaChunker) findMethods(lines []string, states []javaLineState, typeName string, typeLine, typeEnd int) []javaSymbol {
+	type methodMatch struct {
+		name       string
+		symbolType string
+		start      int
+	}
+	var matches []methodMatch
+
+	for i := typeLine; i < typeEnd-1; i++ {
+		if !states[i].code || states[i].depthStart != 1 {
+			continue
+		}
+		m := javaMethodPattern.FindStringSubmatch(lines[i])
+		if m == nil || javaKeywords[m[2]] {
+			continue
+		}
+		symbolType := "method"
+		if m[1] == "" {
+			// Without a return type only a constructor is a declaration
+			// (this also rules out enum constants like RED("red"))
+			if m[2] != typeName {
+				continue
+			}
+			symbolType = "constructor"
+		}
+		matches = append(matches, methodMatch{
+			name:       typeName + "." + m[2],
+			symbolType: symbolType,
+			start:      j.findPrecedingComment(lines, i+1),
+		})
+	}
+
+	methods := make([]javaSymbol, 0, len(matches))
+	for i, m := range matches {
+		// Stop before the type's closing brace
+		end := typeEnd - 1
+		if i < len(matches)-1 {
+			end = matches[i+1].start - 1
+		}
+		end = trimTrailingBlankLines(lines, m.start, end)
+		methods = append(methods, j.newSymbol(lines, m.name, m.symbolType, m.start, end))
+	}
+	return methods
+}
+
+// findPrecedingComment finds Javadoc, line comments and annotations before a declaration.
+func (j *JavaChunker) findPrecedingComment(lines []string, symbolLine int) int {
+	startLine := symbolLine
+
+	for i := symbolLine - 2; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+
+		// Javadoc or block comment end
+		if strings.HasSuffix(line, "*/") {
+			for k := i; k >= 0; k-- {
+				if strings.Contains(lines[k], "/*") {
+					startLine = k + 1
+					i = k
+					break
+				}
+			}
+			continue
+		}
+
+		// Line comment or annotation
+		if strings.HasPrefix(line, "//") || strings.HasPrefix(line, "@") {
+			startLine = i + 1
+			continue
+		}
+
+		break
+	}
+
+	return startLine
+}
+
+// findBraceEnd returns the line closing the block opened at or after
+// startLine, or the line ending a body-less declaration with ';'.
+func (j *JavaChunker) findBraceEnd(lines []string, states []javaLineState, startLine int) int {
+	base := states[startLine-1].depthStart
+	opened := false
+
+	for i := startLine - 1; i < len(lines); i++ {
+		if states[i].depthMax > base {
+			opened = true
+		}
+		if opened && states[i].depthEnd <= base {
+			return i + 1
+		}
+		if !opened && strings.HasSuffix(strings.TrimSpace(lines[i]), ";") {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// newSymbol builds a symbol from a 1-indexed inclusive line range.
+func (j *JavaChunker) newSymbol(lines []string, name, symbolType string, start, end int) javaSymbol {
+	if end < start {
+		end = start
+	}
+	content := extractLines(lines, start, end)
+	return javaSymbol{
+		name:       name,
+		symbolType: symbolType,
+		startLine:  start,
+		endLine:    end,
+		content:    content,
+		tokens:     j.config.CountTokens(content),
+	}
+}
+
+// javaScanLines tracks brace depth per line, ignoring braces inside
+// comments, string and char literals and text blocks.
+func javaScanLines(lines []string) []javaLineState {
+	states := make([]javaLineState, len(lines))
+	depth := 0
+	inBlockComment := false
+	inTextBlock := false
+
+	for i, line := range lines {
+		st := javaLineState{code: !inBlockComment && !inTextBlock, depthStart: depth, depthMax: depth}
+
+		for k := 0; k < len(line); k++ {
+			switch {
+			case inBlockComment:
+				if strings.HasPrefix(line[k:], "*/") {
+					inBlockComment = false
+					k++
+				}
+			case inTextBlock:
+				if line[k] == '\\' {
+					k++
+				} else if strings.HasPrefix(line[k:], `"""`) {
+					inTextBlock = false
+					k += 2
+				}
+			case strings.HasPrefix(line[k:], "//"):
+				k = len(line)
+			case strings.HasPrefix(line[k:], "/*"):
+				inBlockComment = true
+				k++
+			case strings.HasPrefix(line[k:], `"""`):
+				inTextBlock = true
+				k += 2
+			case line[k] == '"' || line[k] == '\'':
+				quote := line[k]
+				for k++; k < len(line) && line[k] != quote; k++ {
+					if line[k] == '\\' {
+						k++
+					}
+				}
+			case line[k] == '{':
+				depth++
+				if depth > st.depthMax {
+					st.depthMax = depth
+				}
+			case line[k] == '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+
+		st.depthEnd = depth
+		states[i] = st
+	}
+	return states
+}
+
+// trimTrailingBlankLines moves end back over blank lines, not past start.
+func trimTrailingBlankLines(lines []string, start, end int) int {
+	for end > start && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return end
+}
+
+// mergeSmallSymbols merges small symbols into adjacent larger ones.
+func (j *JavaChunker) mergeSmallSymbols(symbols []javaSymbol) []javaSymbol {
+	if len(symbols) <= 1 {
+		return symbols
+	}
+
+	result := make([]javaSymbol, 0, len(symbols))
+	var pending *javaSymbol
+
+	for i := range symbols {
+		sym := symbols[i]
+
+		if sym.tokens < j.config.MinTokens {
+			if pending == nil {
+				pending = &sym
+			} else {
+				pending.content += "\n\n" + sym.content
+				pending.endLine = sym.endLine
+				pending.tokens = j.config.CountTokens(pending.content)
+				pending.name = pending.name + "+" + sym.name
+			}
+		} else {
+			if pending != nil {
+				if pending.tokens+sym.tokens <= j.config.MaxTokensFor(sym.symbolType) {
+					sym.content = pending.content + "\n\n" + sym.content
+					sym.startLine = pending.startLine
+					sym.tokens = j.config.CountTokens(sym.content)
+				} else {
+					result = append(result, *pending)
+				}
+				pending = nil
+			}
+			result = append(result, sym)
+		}
+	}
+
+	// Attach trailing small symbols to the previous chunk if it fits
+	if pending != nil {
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= j.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = j.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
+	}
+
+	return result
+}
+
+// chunkAsFile creates a single chunk for the entire file.
+func (j *JavaChunker) chunkAsFile(content []byte, metadata FileMetadata, module string) []Chunk {
+	contentStr := string(content)
+	contentHash := j.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
+	symbol := metadata.FilePath
+
+	return []Chunk{{
+		ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, symbol, contentHash),
+		Content:     contentStr,
+		Symbol:      symbol,
+		SymbolType:  "file",
+		StartLine:   1,
+		EndLine:     strings.Count(contentStr, "\n") + 1,
+		TokenCount:  j.config.CountTokens(contentStr),
+		ContentHash: contentHash,
+		ExactHash:   exactHash,
+		FilePath:    metadata.FilePath,
+		Language:    "java",
+		Module:      module,
+		ProjectID:   metadata.ProjectID,
+	}}
+}
@@ -0,0 +1,156 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJavaChunker_SpringRestController(t *testing.T) {
+	chunks := chunkSource(t, NewJavaChunker(testChunkingConfig), "src/main/java/com/example/web/UserController.java", "java", `package com.example.web;
+
+import java.util.List;
+import org.springframework.web.bind.annotation.*;
+
+/**
+ * REST endpoints for users.
+ */
+@RestController
+@RequestMapping("/api/users")
+public class UserController {
+
+    private final UserService service;
+
+    public UserController(UserService service) {
+        this.service = service;
+    }
+
+    /**
+     * Lists all users.
+     */
+    @GetMapping
+    public List<UserDto> list() {
+        return service.findAll();
+    }
+
+    @GetMapping("/{id}")
+    @ResponseStatus(HttpStatus.OK)
+    public UserDto get(@PathVariable long id) {
+        if (id < 0) {
+            throw new IllegalArgumentException("id must be >= 0 but was {" + id + "}");
+        }
+        char brace = '}';
+        return service.find(id);
+    }
+}
+`)
+
+	class := findChunk(chunks, "UserController")
+	if class == nil || class.SymbolType != "class" {
+		t.Fatalf("Expected UserController class chunk, got %+v", chunks)
+	}
+	for _, want := range []string{"REST endpoints for users.", "@RestController", `@RequestMapping("/api/users")`, "private final UserService service;"} {
+		if !strings.Contains(class.Content, want) {
+			t.Errorf("Expected class chunk to contain %q, got:\n%s", want, class.Content)
+		}
+	}
+	if strings.Contains(class.Content, "public List<UserDto> list()") {
+		t.Errorf("Expected methods outside the class header chunk, got:\n%s", class.Content)
+	}
+
+	ctor := findChunk(chunks, "UserController.UserController")
+	if ctor == nil || ctor.SymbolType != "constructor" {
+		t.Errorf("Expected constructor chunk, got %+v", chunks)
+	}
+
+	list := findChunk(chunks, "UserController.list")
+	if list == nil || list.SymbolType != "method" {
+		t.Fatalf("Expected list method chunk, got %+v", chunks)
+	}
+	if !strings.Contains(list.Content, "Lists all users.") || !strings.Contains(list.Content, "@GetMapping") {
+		t.Errorf("Expected Javadoc and annotation in list chunk, got:\n%s", list.Content)
+	}
+
+	get := findChunk(chunks, "UserController.get")
+	if get == nil || get.SymbolType != "method" {
+		t.Fatalf("Expected get method chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(get.Content), `@GetMapping("/{id}")`) ||
+		!strings.Contains(get.Content, "@ResponseStatus(HttpStatus.OK)") {
+		t.Errorf("Expected annotations in get chunk, got:\n%s", get.Content)
+	}
+	// Braces in string and char literals must not end the method early
+	if !strings.HasSuffix(get.Content, "return service.find(id);\n    }") {
+		t.Errorf("Expected get chunk to end at its closing brace, got:\n%s", get.Content)
+	}
+
+	for _, c := range chunks {
+		if c.Module != "com.example.web" {
+			t.Errorf("Expected module com.example.web for %s, got %q", c.Symbol, c.Module)
+		}
+		if c.Language != "java" {
+			t.Errorf("Expected language java, got %s", c.Language)
+		}
+	}
+}
+
+func TestJavaChunker_InterfaceEnumRecord(t *testing.T) {
+	chunks := chunkSource(t, NewJavaChunker(testChunkingConfig), "src/main/java/com/example/web/UserController.java", "java", `package com.example.model;
+
+public interface Repository<T> {
+    T find(long id);
+
+    default List<T> all() {
+        return List.of();
+    }
+}
+
+public enum Color {
+    RED("red"),
+    GREEN("green");
+
+    private final String label;
+
+    Color(String label) {
+        this.label = label;
+    }
+
+    public String label() {
+        return label;
+    }
+}
+
+public record Point(int x, int y) {
+    public double norm() {
+        return Math.sqrt(x * x + y * y);
+    }
+}
+`)
+
+	repo := findChunk(chunks, "Repository")
+	if repo == nil || repo.SymbolType != "interface" || !strings.Contains(repo.Content, "default List<T> all()") {
+		t.Errorf("Expected interface chunk kept whole, got %+v", repo)
+	}
+	if findChunk(chunks, "Repository.find") != nil {
+		t.Errorf("Expected interface methods not to be split out")
+	}
+
+	if c := findChunk(chunks, "Color"); c == nil || c.SymbolType != "enum" {
+		t.Errorf("Expected enum chunk, got %+v", chunks)
+	}
+	if findChunk(chunks, "Color.RED") != nil || findChunk(chunks, "Color.GREEN") != nil {
+		t.Errorf("Expected enum constants not to be mistaken for methods")
+	}
+	if c := findChunk(chunks, "Color.Color"); c == nil || c.SymbolType != "constructor" {
+		t.Errorf("Expected enum constructor chunk, got %+v", chunks)
+	}
+	if c := findChunk(chunks, "Color.label"); c == nil || c.SymbolType != "method" {
+		t.Errorf("Expected enum method chunk, got %+v", chunks)
+	}
+
+	if c := findChunk(chunks, "Point"); c == nil || c.SymbolType != "record" {
+		t.Errorf("Expected record chunk, got %+v", chunks)
+	}
+	if c := findChunk(chunks, "Point.norm"); c == nil || c.SymbolType != "method" {
+		t.Errorf("Expected record method chunk, got %+v", chunks)
+	}
+}
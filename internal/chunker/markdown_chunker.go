@@ -4,6 +4,7 @@
 package chunker
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -25,12 +26,13 @@ func (m *MarkdownChunker) Name() string {
 
 // mdSection represents a section of a markdown document.
 type mdSection struct {
-	heading    string
-	level      int
-	startLine  int
-	endLine    int
-	content    string
-	tokens     int
+	heading   string
+	path      string
+	level     int
+	startLine int
+	endLine   int
+	content   string
+	tokens    int
 }
 
 // headingPattern matches markdown headings.
@@ -53,9 +55,10 @@ func (m *MarkdownChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk,
 	// Convert sections to chunks
 	chunks := make([]Chunk, 0, len(sections))
 	for _, sec := range sections {
-		contentHash := HashContent(sec.content)
+		contentHash := m.config.HashContent(sec.content)
+		exactHash := HashContent(sec.content)
 		chunk := Chunk{
-			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sec.heading, contentHash),
+			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sec.path, contentHash),
 			Content:     sec.content,
 			Symbol:      sec.heading,
 			SymbolType:  "heading",
@@ -63,6 +66,7 @@ func (m *MarkdownChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk,
 			EndLine:     sec.endLine,
 			TokenCount:  sec.tokens,
 			ContentHash: contentHash,
+			ExactHash:   exactHash,
 			FilePath:    metadata.FilePath,
 			Language:    "markdown",
 			Module:      metadata.Module,
@@ -74,11 +78,20 @@ func (m *MarkdownChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk,
 	return chunks, nil
 }
 
+// headingPathSeparator joins ancestor headings into a section path.
+const headingPathSeparator = " > "
+
 // extractSections extracts sections from markdown lines.
+// Each section carries its heading path (e.g. "Guide > Install > Linux") so
+// chunk IDs stay distinct for repeated heading names under different parents.
 func (m *MarkdownChunker) extractSections(lines []string) []mdSection {
 	sections := make([]mdSection, 0)
 	var currentSection *mdSection
 
+	// Ancestor headings indexed by level (1-6)
+	var ancestors [7]string
+	pathCounts := make(map[string]int)
+
 	for i, line := range lines {
 		lineNum := i + 1
 
@@ -91,13 +104,33 @@ func (m *MarkdownChunker) extractSections(lines []string) []mdSection {
 			// Close previous section
 			if currentSection != nil {
 				currentSection.endLine = lineNum - 1
-				currentSection.tokens = EstimateTokens(currentSection.content)
+				currentSection.tokens = m.config.CountTokens(currentSection.content)
 				sections = append(sections, *currentSection)
 			}
 
+			// Track the heading path for stable IDs
+			ancestors[level] = heading
+			for l := level + 1; l < len(ancestors); l++ {
+				ancestors[l] = ""
+			}
+			parts := make([]string, 0, level)
+			for l := 1; l <= level; l++ {
+				if ancestors[l] != "" {
+					parts = append(parts, ancestors[l])
+				}
+			}
+			path := strings.Join(parts, headingPathSeparator)
+
+			// Disambiguate identical paths (e.g. two "Example" siblings)
+			pathCounts[path]++
+			if n := pathCounts[path]; n > 1 {
+				path = fmt.Sprintf("%s#%d", path, n)
+			}
+
 			// Start new section
 			currentSection = &mdSection{
 				heading:   heading,
+				path:      path,
 				level:     level,
 				startLine: lineNum,
 				content:   line,
@@ -109,6 +142,7 @@ func (m *MarkdownChunker) extractSections(lines []string) []mdSection {
 			// Content before first heading - create implicit section
 			currentSection = &mdSection{
 				heading:   "(intro)",
+				path:      "(intro)",
 				level:     0,
 				startLine: lineNum,
 				content:   line,
@@ -119,7 +153,7 @@ func (m *MarkdownChunker) extractSections(lines []string) []mdSection {
 	// Close last section
 	if currentSection != nil {
 		currentSection.endLine = len(lines)
-		currentSection.tokens = EstimateTokens(currentSection.content)
+		currentSection.tokens = m.config.CountTokens(currentSection.content)
 		sections = append(sections, *currentSection)
 	}
 
@@ -140,13 +174,13 @@ func (m *MarkdownChunker) mergeSmallSections(sections []mdSection) []mdSection {
 			prev := &result[len(result)-1]
 			prev.content += "\n\n" + sec.content
 			prev.endLine = sec.endLine
-			prev.tokens = EstimateTokens(prev.content)
+			prev.tokens = m.config.CountTokens(prev.content)
 		} else if sec.tokens < m.config.MinTokens && i < len(sections)-1 {
 			// Will merge with next section
 			nextSec := &sections[i+1]
 			nextSec.content = sec.content + "\n\n" + nextSec.content
 			nextSec.startLine = sec.startLine
-			nextSec.tokens = EstimateTokens(nextSec.content)
+			nextSec.tokens = m.config.CountTokens(nextSec.content)
 		} else {
 			result = append(result, sec)
 		}
@@ -158,7 +192,8 @@ func (m *MarkdownChunker) mergeSmallSections(sections []mdSection) []mdSection {
 // chunkAsFile returns the entire file as a single chunk.
 func (m *MarkdownChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
 	contentStr := string(content)
-	contentHash := HashContent(contentStr)
+	contentHash := m.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
 
 	// Try to extract title from first heading
 	symbol := metadata.FilePath
@@ -177,8 +212,9 @@ func (m *MarkdownChunker) chunkAsFile(content []byte, metadata FileMetadata) []C
 		SymbolType:  "document",
 		StartLine:   1,
 		EndLine:     len(lines),
-		TokenCount:  EstimateTokens(contentStr),
+		TokenCount:  m.config.CountTokens(contentStr),
 		ContentHash: contentHash,
+		ExactHash:   exactHash,
 		FilePath:    metadata.FilePath,
 		Language:    "markdown",
 		Module:      metadata.Module,
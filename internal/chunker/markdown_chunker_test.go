@@ -0,0 +1,106 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownChunker_StableHeadingIDs(t *testing.T) {
+	chunker := NewMarkdownChunker(ChunkingConfig{
+		MinTokens:        5,
+		IdealTokens:      200,
+		MaxTokens:        500,
+		MergeSmallChunks: false,
+	})
+
+	original := `# Guide
+
+Welcome to the guide.
+
+## Install
+
+Run the installer and follow the prompts.
+
+## Configure
+
+Edit the configuration file to match your environment.
+
+## Usage
+
+Start the service and open the dashboard.
+`
+	edited := strings.Replace(original, "Edit the configuration file", "Update the config file", 1)
+
+	metadata := FileMetadata{FilePath: "docs/guide.md", Language: "markdown", ProjectID: "test-project"}
+
+	before, err := chunker.Chunk([]byte(original), metadata)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+	after, err := chunker.Chunk([]byte(edited), metadata)
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	if len(before) != len(after) {
+		t.Fatalf("Expected same chunk count, got %d and %d", len(before), len(after))
+	}
+
+	changed := 0
+	for i := range before {
+		if before[i].ID != after[i].ID {
+			changed++
+			if before[i].Symbol != "Configure" {
+				t.Errorf("Expected only 'Configure' to change ID, but %s changed", before[i].Symbol)
+			}
+		}
+	}
+	if changed != 1 {
+		t.Errorf("Expected exactly 1 chunk ID to change, got %d", changed)
+	}
+}
+
+func TestMarkdownChunker_HeadingPathDisambiguatesIDs(t *testing.T) {
+	chunker := NewMarkdownChunker(ChunkingConfig{
+		MinTokens:        1,
+		IdealTokens:      200,
+		MaxTokens:        500,
+		MergeSmallChunks: false,
+	})
+
+	content := []byte(`# API
+
+## Users
+
+### Example
+
+See below.
+
+## Orders
+
+### Example
+
+See below.
+`)
+
+	chunks, err := chunker.Chunk(content, FileMetadata{FilePath: "api.md", Language: "markdown", ProjectID: "test-project"})
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	var exampleIDs []string
+	for _, c := range chunks {
+		if c.Symbol == "Example" {
+			exampleIDs = append(exampleIDs, c.ID)
+		}
+	}
+	if len(exampleIDs) != 2 {
+		t.Fatalf("Expected 2 'Example' chunks, got %d", len(exampleIDs))
+	}
+	if exampleIDs[0] == exampleIDs[1] {
+		t.Errorf("Expected identical headings under different parents to get distinct IDs, both were %s", exampleIDs[0])
+	}
+	if !strings.Contains(exampleIDs[0], "API > Users > Example") {
+		t.Errorf("Expected ID to be keyed by heading path, got %s", exampleIDs[0])
+	}
+}
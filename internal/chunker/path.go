@@ -0,0 +1,71 @@
+package chunker
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// PathSymbolType is the symbol type of synthetic path chunks.
+const PathSymbolType = "path"
+
+// HumanizePath turns a file path into space-separated lower-case words so
+// path-oriented queries can match it: directories, the file name without
+// its extension, and camelCase, snake_case and kebab-case parts are split,
+// e.g. "internal/vectordb/qdrantClient.go" -> "internal vectordb qdrant client".
+func HumanizePath(path string) string {
+	path = strings.TrimSuffix(path, filepath.Ext(path))
+	fields := strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var words []string
+	for _, f := range fields {
+		words = append(words, splitCamelCase(f)...)
+	}
+	return strings.ToLower(strings.Join(words, " "))
+}
+
+// splitCamelCase splits an identifier at lower-to-upper transitions and
+// before the last capital of an acronym ("HTTPServer" -> "HTTP", "Server").
+func splitCamelCase(s string) []string {
+	runes := []rune(s)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) &&
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+// PathChunk creates the synthetic chunk of a file whose content is its
+// humanized path, so queries naming a file ("the qdrant client file") find it
+// even when none of its code chunks mention those words. Tokens are counted
+// with cfg's tokenizer.
+func PathChunk(metadata FileMetadata, cfg ChunkingConfig) Chunk {
+	content := HumanizePath(metadata.FilePath)
+	contentHash := HashContent(content)
+	symbol := filepath.Base(metadata.FilePath)
+
+	return Chunk{
+		ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, symbol, contentHash),
+		Content:     content,
+		Symbol:      symbol,
+		SymbolType:  PathSymbolType,
+		StartLine:   1,
+		EndLine:     1,
+		TokenCount:  cfg.CountTokens(content),
+		ContentHash: contentHash,
+		ExactHash:   contentHash,
+		FilePath:    metadata.FilePath,
+		Language:    metadata.Language,
+		Module:      metadata.Module,
+		ProjectID:   metadata.ProjectID,
+	}
+}
@@ -0,0 +1,36 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHumanizePath(t *testing.T) {
+	tests := map[string]string{
+		"internal/vectordb/qdrant_client.go": "internal vectordb qdrant client",
+		"src/components/UserProfile.tsx":     "src components user profile",
+		"pkg/HTTPServer/web-socket.js":       "pkg http server web socket",
+		"Makefile":                           "makefile",
+		"docs/v2/README.md":                  "docs v2 readme",
+	}
+	for path, want := range tests {
+		if got := HumanizePath(path); got != want {
+			t.Errorf("HumanizePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestPathChunk(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tokenizer = fixedTokenizer(7)
+	c := PathChunk(FileMetadata{FilePath: "internal/vectordb/qdrant.go", Language: "go", Module: "vectordb", ProjectID: "proj"}, cfg)
+	if c.Content != "internal vectordb qdrant" || c.SymbolType != PathSymbolType || c.Symbol != "qdrant.go" {
+		t.Errorf("Unexpected path chunk %+v", c)
+	}
+	if !strings.HasPrefix(c.ID, "proj:internal/vectordb/qdrant.go:qdrant.go:") || c.ContentHash != HashContent(c.Content) {
+		t.Errorf("Expected ID keyed by file and content hash, got %s", c.ID)
+	}
+	if c.TokenCount != 7 {
+		t.Errorf("Expected tokens counted with the configured tokenizer, got %d", c.TokenCount)
+	}
+}
@@ -108,7 +108,8 @@ func (p *PHPChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, erro
 	// Convert to chunks
 	chunks := make([]Chunk, 0, len(symbols))
 	for _, sym := range symbols {
-		contentHash := HashContent(sym.content)
+		contentHash := p.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
 
 		// Include namespace in symbol name for context
 		symbolName := sym.name
@@ -125,6 +126,7 @@ func (p *PHPChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, erro
 			EndLine:     sym.endLine,
 			TokenCount:  sym.tokens,
 			ContentHash: contentHash,
+			ExactHash:   exactHash,
 			FilePath:    metadata.FilePath,
 			Language:    "php",
 			Module:      sym.namespace,
@@ -254,7 +256,7 @@ func (p *PHPChunker) extractSymbolBoundaries(content string, lines []string, mat
 
 		// Extract content
 		symContent := p.extractLines(lines, startLine, endLine)
-		tokens := EstimateTokens(symContent)
+		tokens := p.config.CountTokens(symContent)
 
 		symbols = append(symbols, phpSymbol{
 			name:       m.name,
@@ -372,15 +374,15 @@ func (p *PHPChunker) mergeSmallSymbols(symbols []phpSymbol) []phpSymbol {
 			} else {
 				pending.content += "\n\n" + sym.content
 				pending.endLine = sym.endLine
-				pending.tokens = EstimateTokens(pending.content)
+				pending.tokens = p.config.CountTokens(pending.content)
 				pending.name = pending.name + "+" + sym.name
 			}
 		} else {
 			if pending != nil {
-				if pending.tokens+sym.tokens <= p.config.MaxTokens {
+				if pending.tokens+sym.tokens <= p.config.MaxTokensFor(sym.symbolType) {
 					sym.content = pending.content + "\n\n" + sym.content
 					sym.startLine = pending.startLine
-					sym.tokens = EstimateTokens(sym.content)
+					sym.tokens = p.config.CountTokens(sym.content)
 				} else {
 					result = append(result, *pending)
 				}
@@ -390,8 +392,16 @@ func (p *PHPChunker) mergeSmallSymbols(symbols []phpSymbol) []phpSymbol {
 		}
 	}
 
+	// Attach trailing small symbols to the previous chunk if it fits
 	if pending != nil {
-		result = append(result, *pending)
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= p.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = p.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
 	}
 
 	return result
@@ -414,7 +424,8 @@ func (p *PHPChunker) extractLines(lines []string, startLine, endLine int) string
 // chunkAsFile creates a single chunk for the entire file.
 func (p *PHPChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
 	contentStr := string(content)
-	contentHash := HashContent(contentStr)
+	contentHash := p.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
 	symbol := metadata.FilePath
 
 	return []Chunk{{
@@ -424,8 +435,9 @@ func (p *PHPChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk
 		SymbolType:  "file",
 		StartLine:   1,
 		EndLine:     strings.Count(contentStr, "\n") + 1,
-		TokenCount:  EstimateTokens(contentStr),
+		TokenCount:  p.config.CountTokens(contentStr),
 		ContentHash: contentHash,
+		ExactHash:   exactHash,
 		FilePath:    metadata.FilePath,
 		Language:    "php",
 		Module:      metadata.Module,
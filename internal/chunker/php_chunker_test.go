@@ -472,3 +472,42 @@ func TestPHPChunker_EmptyFile(t *testing.T) {
 		t.Errorf("Expected 0 or 1 chunk for empty file, got %d", len(chunks))
 	}
 }
+
+func TestPHPChunker_TrailingSmallSymbolsMerge(t *testing.T) {
+	chunker := NewPHPChunker(ChunkingConfig{
+		MinTokens:        50,
+		IdealTokens:      200,
+		MaxTokens:        500,
+		MergeSmallChunks: true,
+	})
+
+	content := []byte(`<?php
+
+function processOrder($order) {
+    $items = array_filter($order['items'], fn($item) => $item['quantity'] > 0);
+    $subtotal = array_sum(array_map(fn($item) => $item['price'] * $item['quantity'], $items));
+    $tax = $subtotal * $order['tax_rate'];
+    $shipping = $subtotal > 100 ? 0 : $order['shipping_fee'];
+    return ['items' => $items, 'total' => $subtotal + $tax + $shipping];
+}
+
+function a() { return 1; }
+
+function b() { return 2; }
+`)
+
+	chunks, err := chunker.Chunk(content, FileMetadata{FilePath: "order.php", Language: "php", ProjectID: "test-project"})
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	if len(chunks) != 1 {
+		t.Fatalf("Expected trailing small symbols to merge into 1 chunk, got %d", len(chunks))
+	}
+	if chunks[0].Symbol != "processOrder" {
+		t.Errorf("Expected merged chunk symbol 'processOrder', got %s", chunks[0].Symbol)
+	}
+	if !strings.Contains(chunks[0].Content, "function a()") || !strings.Contains(chunks[0].Content, "function b()") {
+		t.Error("Expected merged chunk to contain trailing functions")
+	}
+}
@@ -0,0 +1,380 @@
+// Package chunker provides Python code chunking using indentation-aware block parsing.
+// It extracts top-level functions, classes and class methods as individual chunks.
+package chunker
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PythonChunker implements function/class-level chunking for Python.
+type PythonChunker struct {
+	config ChunkingConfig
+}
+
+// NewPythonChunker creates a new Python chunker.
+func NewPythonChunker(cfg ChunkingConfig) *PythonChunker {
+	return &PythonChunker{config: cfg}
+}
+
+// Name returns the chunker strategy name.
+func (p *PythonChunker) Name() string {
+	return "python"
+}
+
+// pySymbol represents an extracted Python symbol.
+type pySymbol struct {
+	name       string
+	symbolType string
+	startLine  int
+	endLine    int
+	content    string
+	tokens     int
+}
+
+// pyDecl is a def/class declaration found on a logical line start.
+type pyDecl struct {
+	name    string
+	isClass bool
+	indent  int
+	line    int // 1-indexed
+}
+
+// pyDeclPattern matches def, async def and class declarations.
+var pyDeclPattern = regexp.MustCompile(`^([ \t]*)(?:(async[ \t]+def|def)|(class))[ \t]+(\w+)`)
+
+// Chunk splits Python source code into function/class/method chunks.
+// Nested functions and classes stay inside their parent's chunk.
+func (p *PythonChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error) {
+	contentStr := string(content)
+	lines := strings.Split(contentStr, "\n")
+	codeLine := pyLogicalLineStarts(lines)
+
+	symbols := p.extractSymbols(lines, codeLine)
+	if len(symbols) == 0 {
+		return p.chunkAsFile(content, metadata), nil
+	}
+
+	if p.config.MergeSmallChunks {
+		symbols = p.mergeSmallSymbols(symbols)
+	}
+
+	chunks := make([]Chunk, 0, len(symbols))
+	for _, sym := range symbols {
+		contentHash := p.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
+		chunks = append(chunks, Chunk{
+			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
+			Content:     sym.content,
+			Symbol:      sym.name,
+			SymbolType:  sym.symbolType,
+			StartLine:   sym.startLine,
+			EndLine:     sym.endLine,
+			TokenCount:  sym.tokens,
+			ContentHash: contentHash,
+			ExactHash:   exactHash,
+			FilePath:    metadata.FilePath,
+			Language:    "python",
+			Module:      metadata.Module,
+			ProjectID:   metadata.ProjectID,
+		})
+	}
+
+	return chunks, nil
+}
+
+// extractSymbols finds top-level functions and classes plus the methods of
+// top-level classes. A class chunk covers its header up to the first method;
+// each method runs until the next method or the end of the class.
+func (p *PythonChunker) extractSymbols(lines []string, codeLine []bool) []pySymbol {
+	var symbols []pySymbol
+
+	for _, decl := range p.findDecls(lines, codeLine) {
+		if decl.indent > 0 {
+			continue
+		}
+		start := p.findDecoratorStart(lines, codeLine, decl.line)
+		end := p.findBlockEnd(lines, codeLine, decl.line, decl.indent)
+
+		if !decl.isClass {
+			symbols = append(symbols, p.newSymbol(lines, decl.name, "function", start, end))
+			continue
+		}
+
+		methods := p.findMethods(lines, codeLine, decl, end)
+		classEnd := end
+		if len(methods) > 0 {
+			classEnd = trimTrailingBlankLines(lines, start, methods[0].startLine-1)
+		}
+		symbols = append(symbols, p.newSymbol(lines, decl.name, "class", start, classEnd))
+		symbols = append(symbols, methods...)
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].startLine < symbols[j].startLine
+	})
+	return symbols
+}
+
+// findMethods returns the methods directly inside a top-level class ending at classEnd.
+func (p *PythonChunker) findMethods(lines []string, codeLine []bool, class pyDecl, classEnd int) []pySymbol {
+	var starts []int
+	var names []string
+	bodyIndent := -1
+
+	for i := class.line; i < classEnd; i++ {
+		if !codeLine[i] {
+			continue
+		}
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := pyIndent(lines[i])
+		if bodyIndent < 0 {
+			bodyIndent = indent
+		}
+		if indent != bodyIndent {
+			continue
+		}
+		m := pyDeclPattern.FindStringSubmatch(lines[i])
+		if m == nil || m[3] != "" {
+			continue
+		}
+		starts = append(starts, p.findDecoratorStart(lines, codeLine, i+1))
+		names = append(names, m[4])
+	}
+
+	methods := make([]pySymbol, 0, len(starts))
+	for i, start := range starts {
+		end := classEnd
+		if i < len(starts)-1 {
+			end = starts[i+1] - 1
+		}
+		end = trimTrailingBlankLines(lines, start, end)
+		methods = append(methods, p.newSymbol(lines, class.name+"."+names[i], "method", start, end))
+	}
+	return methods
+}
+
+// findDecls returns every def/class declaration that starts a logical line.
+func (p *PythonChunker) findDecls(lines []string, codeLine []bool) []pyDecl {
+	var decls []pyDecl
+	for i, line := range lines {
+		if !codeLine[i] {
+			continue
+		}
+		m := pyDeclPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		decls = append(decls, pyDecl{
+			name:    m[4],
+			isClass: m[3] != "",
+			indent:  len(m[1]),
+			line:    i + 1,
+		})
+	}
+	return decls
+}
+
+// findDecoratorStart walks back from a declaration over decorators
+// (including multi-line ones) and directly preceding comments.
+func (p *PythonChunker) findDecoratorStart(lines []string, codeLine []bool, declLine int) int {
+	start := declLine
+	for i := declLine - 2; i >= 0; i-- {
+		// Step back to the start of a multi-line logical line
+		lineStart := i
+		for lineStart > 0 && !codeLine[lineStart] {
+			lineStart--
+		}
+		trimmed := strings.TrimSpace(lines[lineStart])
+		if !strings.HasPrefix(trimmed, "@") && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		start = lineStart + 1
+		i = lineStart
+	}
+	return start
+}
+
+// findBlockEnd returns the last line of the block opened at declLine: the
+// last non-blank line indented deeper than the declaration before the code
+// dedents. Comment-only lines never end a block.
+func (p *PythonChunker) findBlockEnd(lines []string, codeLine []bool, declLine, indent int) int {
+	end := declLine
+	for i := declLine; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !codeLine[i] {
+			// Continuation of the header, a bracketed expression or a string
+			if trimmed != "" {
+				end = i + 1
+			}
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		if pyIndent(lines[i]) <= indent {
+			if strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// newSymbol builds a symbol from a 1-indexed inclusive line range.
+func (p *PythonChunker) newSymbol(lines []string, name, symbolType string, start, end int) pySymbol {
+	if end < start {
+		end = start
+	}
+	content := strings.Join(lines[start-1:end], "\n")
+	return pySymbol{
+		name:       name,
+		symbolType: symbolType,
+		startLine:  start,
+		endLine:    end,
+		content:    content,
+		tokens:     p.config.CountTokens(content),
+	}
+}
+
+// pyLogicalLineStarts reports, per line, whether it begins a new logical
+// line, i.e. it is not inside a triple-quoted string, an open bracket or a
+// backslash continuation. Only such lines are considered for declarations
+// and indentation, so "def" inside a docstring is never mistaken for one.
+func pyLogicalLineStarts(lines []string) []bool {
+	starts := make([]bool, len(lines))
+	triple := "" // open triple quote delimiter, if any
+	depth := 0   // open bracket depth
+	continued := false
+
+	for i, line := range lines {
+		starts[i] = triple == "" && depth == 0 && !continued
+		continued = false
+
+		for j := 0; j < len(line); j++ {
+			if triple != "" {
+				if line[j] == '\\' {
+					j++
+				} else if strings.HasPrefix(line[j:], triple) {
+					j += len(triple) - 1
+					triple = ""
+				}
+				continue
+			}
+
+			switch ch := line[j]; ch {
+			case '#':
+				j = len(line)
+			case '"', '\'':
+				if q := line[j : j+1]; strings.HasPrefix(line[j:], q+q+q) {
+					triple = q + q + q
+					j += 2
+				} else {
+					// Single-line string: skip to the closing quote
+					for j++; j < len(line) && line[j] != ch; j++ {
+						if line[j] == '\\' {
+							j++
+						}
+					}
+				}
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				if depth > 0 {
+					depth--
+				}
+			case '\\':
+				if j == len(line)-1 {
+					continued = true
+				}
+			}
+		}
+	}
+	return starts
+}
+
+// pyIndent returns the width of a line's leading whitespace.
+func pyIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// mergeSmallSymbols merges small symbols into adjacent larger ones.
+func (p *PythonChunker) mergeSmallSymbols(symbols []pySymbol) []pySymbol {
+	if len(symbols) <= 1 {
+		return symbols
+	}
+
+	result := make([]pySymbol, 0, len(symbols))
+	var pending *pySymbol
+
+	for i := range symbols {
+		sym := symbols[i]
+
+		if sym.tokens < p.config.MinTokens {
+			if pending == nil {
+				pending = &sym
+			} else {
+				pending.content += "\n\n" + sym.content
+				pending.endLine = sym.endLine
+				pending.tokens = p.config.CountTokens(pending.content)
+				pending.name = pending.name + "+" + sym.name
+			}
+		} else {
+			if pending != nil {
+				if pending.tokens+sym.tokens <= p.config.MaxTokensFor(sym.symbolType) {
+					sym.content = pending.content + "\n\n" + sym.content
+					sym.startLine = pending.startLine
+					sym.tokens = p.config.CountTokens(sym.content)
+				} else {
+					result = append(result, *pending)
+				}
+				pending = nil
+			}
+			result = append(result, sym)
+		}
+	}
+
+	// Attach trailing small symbols to the previous chunk if it fits
+	if pending != nil {
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= p.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = p.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
+	}
+
+	return result
+}
+
+// chunkAsFile creates a single chunk for the entire file.
+func (p *PythonChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
+	contentStr := string(content)
+	contentHash := p.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
+	symbol := metadata.FilePath
+
+	return []Chunk{{
+		ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, symbol, contentHash),
+		Content:     contentStr,
+		Symbol:      symbol,
+		SymbolType:  "file",
+		StartLine:   1,
+		EndLine:     strings.Count(contentStr, "\n") + 1,
+		TokenCount:  p.config.CountTokens(contentStr),
+		ContentHash: contentHash,
+		ExactHash:   exactHash,
+		FilePath:    metadata.FilePath,
+		Language:    "python",
+		Module:      metadata.Module,
+		ProjectID:   metadata.ProjectID,
+	}}
+}
@@ -0,0 +1,206 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+// testChunkingConfig keeps each symbol of the language chunker fixtures in
+// its own chunk.
+var testChunkingConfig = ChunkingConfig{
+	MinTokens:        1,
+	IdealTokens:      500,
+	MaxTokens:        800,
+	MergeSmallChunks: false,
+}
+
+// chunkSource chunks content as the file at path, failing the test on error.
+func chunkSource(t *testing.T, chunker Chunker, path, language, content string) []Chunk {
+	t.Helper()
+	chunks, err := chunker.Chunk([]byte(content), FileMetadata{
+		FilePath:  path,
+		Language:  language,
+		ProjectID: "test-project",
+	})
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+	return chunks
+}
+
+// findChunk returns the chunk with the given symbol, or nil.
+func findChunk(chunks []Chunk, symbol string) *Chunk {
+	for i := range chunks {
+		if chunks[i].Symbol == symbol {
+			return &chunks[i]
+		}
+	}
+	return nil
+}
+
+func TestPythonChunker_FunctionsAndDecorators(t *testing.T) {
+	chunks := chunkSource(t, NewPythonChunker(testChunkingConfig), "app/views.py", "python", `import json
+
+from flask import Flask
+
+app = Flask(__name__)
+
+
+# Health endpoint for the load balancer
+@app.route(
+    "/health",
+    methods=["GET"],
+)
+@login_exempt
+def health():
+    """Report liveness."""
+    return json.dumps({"status": "ok"})
+
+
+async def fetch_user(user_id):
+    user = await db.get(user_id)
+
+    return user
+`)
+
+	health := findChunk(chunks, "health")
+	if health == nil {
+		t.Fatalf("Expected health chunk, got %+v", chunks)
+	}
+	if health.SymbolType != "function" {
+		t.Errorf("Expected function, got %s", health.SymbolType)
+	}
+	for _, want := range []string{"# Health endpoint", "@app.route(", `"/health",`, "@login_exempt", `"""Report liveness."""`, "return json.dumps"} {
+		if !strings.Contains(health.Content, want) {
+			t.Errorf("Expected health chunk to contain %q, got:\n%s", want, health.Content)
+		}
+	}
+	if health.StartLine != 8 || health.EndLine != 16 {
+		t.Errorf("Expected health at lines 8-16, got %d-%d", health.StartLine, health.EndLine)
+	}
+
+	fetch := findChunk(chunks, "fetch_user")
+	if fetch == nil || fetch.SymbolType != "function" {
+		t.Fatalf("Expected async fetch_user function chunk, got %+v", chunks)
+	}
+	if !strings.HasSuffix(fetch.Content, "return user") {
+		t.Errorf("Expected fetch_user to span its blank line and end at return, got:\n%s", fetch.Content)
+	}
+	if fetch.Language != "python" {
+		t.Errorf("Expected language python, got %s", fetch.Language)
+	}
+}
+
+func TestPythonChunker_ClassesAndMethods(t *testing.T) {
+	chunks := chunkSource(t, NewPythonChunker(testChunkingConfig), "app/views.py", "python", `class UserView(View):
+    """Users listing."""
+
+    template_name = "users.html"
+
+    def get(self, request):
+        return render(request, self.template_name)
+
+    @transaction.atomic
+    def post(self, request):
+        form = UserForm(request.POST)
+        form.save()
+        return redirect("users")
+
+
+def helper():
+    pass
+`)
+
+	class := findChunk(chunks, "UserView")
+	if class == nil || class.SymbolType != "class" {
+		t.Fatalf("Expected UserView class chunk, got %+v", chunks)
+	}
+	if !strings.Contains(class.Content, `template_name = "users.html"`) || strings.Contains(class.Content, "def get") {
+		t.Errorf("Expected class chunk to hold the header only, got:\n%s", class.Content)
+	}
+
+	get := findChunk(chunks, "UserView.get")
+	if get == nil || get.SymbolType != "method" {
+		t.Fatalf("Expected UserView.get method chunk, got %+v", chunks)
+	}
+	if strings.Contains(get.Content, "@transaction.atomic") {
+		t.Errorf("Expected post's decorator to belong to post, got:\n%s", get.Content)
+	}
+
+	post := findChunk(chunks, "UserView.post")
+	if post == nil || post.SymbolType != "method" {
+		t.Fatalf("Expected UserView.post method chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(post.Content, "    @transaction.atomic") || !strings.HasSuffix(post.Content, `return redirect("users")`) {
+		t.Errorf("Expected post to span decorator through body, got:\n%s", post.Content)
+	}
+
+	if helper := findChunk(chunks, "helper"); helper == nil || helper.SymbolType != "function" {
+		t.Errorf("Expected top-level helper after the class, got %+v", chunks)
+	}
+}
+
+func TestPythonChunker_NestedFunctionsStayInParent(t *testing.T) {
+	chunks := chunkSource(t, NewPythonChunker(testChunkingConfig), "app/views.py", "python", `def retry(times):
+    def decorator(fn):
+        def wrapper(*args, **kwargs):
+            return fn(*args, **kwargs)
+        return wrapper
+    return decorator
+`)
+
+	if len(chunks) != 1 {
+		t.Fatalf("Expected a single chunk for retry, got %d: %+v", len(chunks), chunks)
+	}
+	if chunks[0].Symbol != "retry" || !strings.Contains(chunks[0].Content, "def wrapper") ||
+		!strings.HasSuffix(chunks[0].Content, "return decorator") {
+		t.Errorf("Expected nested functions inside retry, got %+v", chunks[0])
+	}
+}
+
+func TestPythonChunker_DefInsideStrings(t *testing.T) {
+	chunks := chunkSource(t, NewPythonChunker(testChunkingConfig), "app/views.py", "python", `def render_docs():
+    """
+Example:
+
+def not_a_function():
+    pass
+class NotAClass:
+    pass
+"""
+    template = '''
+def also_not_real(): ...
+'''
+    return template
+
+
+QUERY = "def fake(): pass"
+`)
+
+	if findChunk(chunks, "not_a_function") != nil || findChunk(chunks, "NotAClass") != nil ||
+		findChunk(chunks, "also_not_real") != nil || findChunk(chunks, "fake") != nil {
+		t.Fatalf("Expected declarations inside strings to be ignored, got %+v", chunks)
+	}
+
+	render := findChunk(chunks, "render_docs")
+	if render == nil {
+		t.Fatalf("Expected render_docs chunk, got %+v", chunks)
+	}
+	// The unindented docstring body must not end the function early
+	if !strings.HasSuffix(render.Content, "return template") {
+		t.Errorf("Expected render_docs to end at its return, got:\n%s", render.Content)
+	}
+}
+
+func TestPythonChunker_NoDeclarationsFallsBackToFile(t *testing.T) {
+	chunks := chunkSource(t, NewPythonChunker(testChunkingConfig), "app/views.py", "python", `from django.urls import path
+
+urlpatterns = [
+    path("users/", views.UserView.as_view()),
+]
+`)
+
+	if len(chunks) != 1 || chunks[0].SymbolType != "file" {
+		t.Errorf("Expected a single file chunk, got %+v", chunks)
+	}
+}
@@ -0,0 +1,497 @@
+// Package chunker provides Ruby code chunking using end-keyword matching.
+// It extracts modules, classes and methods, including nested namespaces.
+package chunker
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RubyChunker implements module/class/method-level chunking for Ruby.
+type RubyChunker struct {
+	config ChunkingConfig
+}
+
+// NewRubyChunker creates a new Ruby chunker.
+func NewRubyChunker(cfg ChunkingConfig) *RubyChunker {
+	return &RubyChunker{config: cfg}
+}
+
+// Name returns the chunker strategy name.
+func (r *RubyChunker) Name() string {
+	return "ruby"
+}
+
+// rbSymbol represents an extracted Ruby symbol.
+type rbSymbol struct {
+	name       string
+	symbolType string
+	startLine  int
+	endLine    int
+	content    string
+	tokens     int
+}
+
+// rbDecl is a def/class/module declaration and the declarations nested in it.
+type rbDecl struct {
+	kind      string // def, class, module
+	name      string // as written, e.g. "Admin::User" or "self.find"
+	singleton bool   // class << self
+	line      int    // 1-indexed
+	end       int    // line of the matching end
+	children  []*rbDecl
+}
+
+// rbLineState is the lexer state of a single line.
+type rbLineState struct {
+	code       bool // line starts outside heredocs and =begin comments
+	depthStart int  // end-block depth at line start
+	depthEnd   int  // end-block depth at line end
+	depthMax   int  // highest end-block depth reached on the line
+}
+
+// Regex patterns for Ruby symbol extraction
+var (
+	// Method: def name, def self.name, def ==(other), private def name
+	rbDefPattern = regexp.MustCompile(`^\s*(?:(?:private|protected|public|module_function)\s+)?def\s+((?:self\.)?(?:\w+[?!=]?|[^\s(;]+))`)
+
+	// Class and module: class Admin::User < Base, module Billing
+	rbClassPattern     = regexp.MustCompile(`^\s*class\s+((?:::)?[A-Z][\w:]*)`)
+	rbSingletonPattern = regexp.MustCompile(`^\s*class\s*<<\s*self\b`)
+	rbModulePattern    = regexp.MustCompile(`^\s*module\s+((?:::)?[A-Z][\w:]*)`)
+
+	// Endless method (Ruby 3): def name = expr, def name(arg) = expr
+	rbEndlessDefPattern = regexp.MustCompile(`^def\s+(?:self\.)?\w+[?!]?(?:\([^)]*\)\s*|\s+)=(?:[^=~>]|$)`)
+
+	// Heredoc start: <<~SQL, <<-EOS, <<'TEXT'
+	rbHeredocPattern = regexp.MustCompile(`<<[~-]?(['"]?)([A-Z_][A-Z0-9_]*)(['"]?)`)
+)
+
+// rbModifierKeywords open a block only at the start of a statement; after
+// an expression they are modifiers (return x if y).
+var rbModifierKeywords = map[string]bool{
+	"if": true, "unless": true, "while": true, "until": true,
+}
+
+// rbBlockKeywords always open a block that closes with end.
+var rbBlockKeywords = map[string]bool{
+	"def": true, "class": true, "module": true, "case": true, "begin": true, "for": true,
+}
+
+// Chunk splits Ruby source code into module, class and method chunks.
+func (r *RubyChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error) {
+	contentStr := string(content)
+	lines := strings.Split(contentStr, "\n")
+
+	roots := r.findDecls(lines, rubyScanLines(lines))
+	if len(roots) == 0 {
+		return r.chunkAsFile(content, metadata), nil
+	}
+
+	var symbols []rbSymbol
+	for _, d := range roots {
+		symbols = r.appendSymbols(symbols, lines, d, "", false, d.end)
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].startLine < symbols[j].startLine
+	})
+
+	if r.config.MergeSmallChunks {
+		symbols = r.mergeSmallSymbols(symbols)
+	}
+
+	chunks := make([]Chunk, 0, len(symbols))
+	for _, sym := range symbols {
+		contentHash := r.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
+		chunks = append(chunks, Chunk{
+			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
+			Content:     sym.content,
+			Symbol:      sym.name,
+			SymbolType:  sym.symbolType,
+			StartLine:   sym.startLine,
+			EndLine:     sym.endLine,
+			TokenCount:  sym.tokens,
+			ContentHash: contentHash,
+			ExactHash:   exactHash,
+			FilePath:    metadata.FilePath,
+			Language:    "ruby",
+			Module:      metadata.Module,
+			ProjectID:   metadata.ProjectID,
+		})
+	}
+
+	return chunks, nil
+}
+
+// findDecls builds the tree of top-level declarations. Declarations inside
+// a method body stay part of that method.
+func (r *RubyChunker) findDecls(lines []string, states []rbLineState) []*rbDecl {
+	var roots, stack []*rbDecl
+	defEnd := 0
+
+	for i, line := range lines {
+		lineNum := i + 1
+		if !states[i].code || lineNum <= defEnd {
+			continue
+		}
+
+		d := &rbDecl{line: lineNum}
+		if m := rbDefPattern.FindStringSubmatch(line); m != nil {
+			d.kind, d.name = "def", m[1]
+		} else if rbSingletonPattern.MatchString(line) {
+			d.kind, d.singleton = "class", true
+		} else if m := rbClassPattern.FindStringSubmatch(line); m != nil {
+			d.kind, d.name = "class", m[1]
+		} else if m := rbModulePattern.FindStringSubmatch(line); m != nil {
+			d.kind, d.name = "module", m[1]
+		} else {
+			continue
+		}
+		d.end = r.findEnd(states, lineNum)
+
+		for len(stack) > 0 && stack[len(stack)-1].end < lineNum {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, d)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, d)
+		}
+
+		if d.kind == "def" {
+			defEnd = d.end
+		} else {
+			stack = append(stack, d)
+		}
+	}
+	return roots
+}
+
+// appendSymbols emits chunks for d and its children. A class or module chunk
+// covers its header up to the first nested declaration; each method runs
+// until the next sibling (regionEnd for the last one) so that visibility
+// keywords and macros between methods are kept.
+func (r *RubyChunker) appendSymbols(symbols []rbSymbol, lines []string, d *rbDecl, scope string, classMethods bool, regionEnd int) []rbSymbol {
+	start := r.findPrecedingComment(lines, d.line)
+
+	if d.kind == "def" {
+		end := trimTrailingBlankLines(lines, start, regionEnd)
+		return append(symbols, r.newSymbol(lines, rbMethodName(scope, d.name, classMethods), "method", start, end))
+	}
+
+	name := scope
+	if d.singleton {
+		classMethods = true
+	} else {
+		name = strings.TrimPrefix(d.name, "::")
+		if scope != "" {
+			name = scope + "::" + name
+		}
+		headerEnd := d.end
+		if len(d.children) > 0 {
+			first := r.findPrecedingComment(lines, d.children[0].line)
+			headerEnd = trimTrailingBlankLines(lines, start, first-1)
+		}
+		symbols = append(symbols, r.newSymbol(lines, name, d.kind, start, headerEnd))
+	}
+
+	for i, child := range d.children {
+		// Stop before the container's own end
+		childEnd := d.end - 1
+		if i < len(d.children)-1 {
+			childEnd = r.findPrecedingComment(lines, d.children[i+1].line) - 1
+		}
+		if childEnd < child.end {
+			childEnd = child.end
+		}
+		symbols = r.appendSymbols(symbols, lines, child, name, classMethods, childEnd)
+	}
+	return symbols
+}
+
+// rbMethodName qualifies a method with its scope: Scope#method for instance
+// methods and Scope.method for class methods (def self.x, class << self).
+func rbMethodName(scope, name string, classMethod bool) string {
+	if rest := strings.TrimPrefix(name, "self."); rest != name {
+		name, classMethod = rest, true
+	}
+	switch {
+	case scope == "":
+		return name
+	case classMethod:
+		return scope + "." + name
+	default:
+		return scope + "#" + name
+	}
+}
+
+// findEnd returns the line of the end closing the block opened on declLine,
+// or declLine itself for one-line and endless definitions.
+func (r *RubyChunker) findEnd(states []rbLineState, declLine int) int {
+	base := states[declLine-1].depthStart
+	if states[declLine-1].depthMax <= base {
+		return declLine
+	}
+	for i := declLine - 1; i < len(states); i++ {
+		if states[i].depthEnd <= base {
+			return i + 1
+		}
+	}
+	return len(states)
+}
+
+// findPrecedingComment finds # comment lines directly before a declaration.
+func (r *RubyChunker) findPrecedingComment(lines []string, symbolLine int) int {
+	startLine := symbolLine
+	for i := symbolLine - 2; i >= 0; i-- {
+		if !strings.HasPrefix(strings.TrimSpace(lines[i]), "#") {
+			break
+		}
+		startLine = i + 1
+	}
+	return startLine
+}
+
+// newSymbol builds a symbol from a 1-indexed inclusive line range.
+func (r *RubyChunker) newSymbol(lines []string, name, symbolType string, start, end int) rbSymbol {
+	if end < start {
+		end = start
+	}
+	content := extractLines(lines, start, end)
+	return rbSymbol{
+		name:       name,
+		symbolType: symbolType,
+		startLine:  start,
+		endLine:    end,
+		content:    content,
+		tokens:     r.config.CountTokens(content),
+	}
+}
+
+// rubyScanLines tracks the depth of end-terminated blocks per line. Keywords
+// inside comments, strings, heredocs and =begin/=end blocks are ignored, as
+// are method calls (x.class), symbols (:end) and hash keys (if:).
+func rubyScanLines(lines []string) []rbLineState {
+	states := make([]rbLineState, len(lines))
+	depth := 0
+	heredocs := []string(nil) // pending heredoc terminators
+	inDocComment := false
+
+	for i, line := range lines {
+		st := rbLineState{code: len(heredocs) == 0 && !inDocComment, depthStart: depth, depthMax: depth}
+
+		switch {
+		case len(heredocs) > 0:
+			if strings.TrimSpace(line) == heredocs[0] {
+				heredocs = heredocs[1:]
+			}
+		case inDocComment:
+			if strings.HasPrefix(line, "=end") {
+				inDocComment = false
+			}
+		case strings.HasPrefix(line, "=begin"):
+			inDocComment = true
+			st.code = false
+		default:
+			code := rbStripLiterals(line)
+			for _, m := range rbHeredocPattern.FindAllStringSubmatch(code, -1) {
+				heredocs = append(heredocs, m[2])
+			}
+			depth = rbCountBlocks(code, depth, &st.depthMax)
+		}
+
+		st.depthEnd = depth
+		states[i] = st
+	}
+	return states
+}
+
+// rbStripLiterals blanks out string literal contents and drops a trailing
+// comment, keeping heredoc markers (which start with <<) intact.
+func rbStripLiterals(line string) string {
+	b := []byte(line)
+	for k := 0; k < len(b); k++ {
+		switch b[k] {
+		case '#':
+			return string(b[:k])
+		case '"', '\'', '`':
+			// Quoted heredoc identifiers are kept for rbHeredocPattern
+			if rbHeredocQuote(b, k) {
+				continue
+			}
+			quote := b[k]
+			for k++; k < len(b) && b[k] != quote; k++ {
+				if b[k] == '\\' && k+1 < len(b) {
+					b[k] = ' '
+					k++
+				}
+				b[k] = ' '
+			}
+		}
+	}
+	return string(b)
+}
+
+// rbHeredocQuote reports whether the quote at k opens a quoted heredoc
+// identifier such as <<~'SQL'.
+func rbHeredocQuote(b []byte, k int) bool {
+	i := k - 1
+	if i >= 0 && (b[i] == '~' || b[i] == '-') {
+		i--
+	}
+	return i >= 1 && b[i] == '<' && b[i-1] == '<'
+}
+
+// rbCountBlocks applies the block openers and ends of a literal-free line
+// to depth and returns the new depth, raising *depthMax as blocks open.
+func rbCountBlocks(code string, depth int, depthMax *int) int {
+	loopOpened := false
+	skipName := false
+
+	for k := 0; k < len(code); {
+		c := code[k]
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			k++
+			continue
+		}
+		start := k
+		for k < len(code) && isIdentByte(code[k]) {
+			k++
+		}
+		word := code[start:k]
+		if k < len(code) && (code[k] == '?' || code[k] == '!') {
+			// Predicate and bang methods (empty?, save!) are never keywords
+			k++
+			continue
+		}
+
+		if skipName {
+			// The name after def may itself be a keyword (def end)
+			skipName = word == "self"
+			continue
+		}
+
+		prev := rbPrevSignificant(code, start)
+		if prev == '.' || prev == ':' || (k < len(code) && code[k] == ':' && !strings.HasPrefix(code[k:], "::")) {
+			// Method call, symbol or hash key
+			continue
+		}
+
+		open := false
+		switch {
+		case word == "end":
+			if depth > 0 {
+				depth--
+			}
+		case word == "def":
+			skipName = true
+			open = !rbEndlessDefPattern.MatchString(code[start:])
+		case rbBlockKeywords[word]:
+			open = true
+			loopOpened = loopOpened || word == "for"
+		case rbModifierKeywords[word]:
+			if prev == 0 || strings.IndexByte("=(;[,|&{", prev) >= 0 {
+				open = true
+				loopOpened = loopOpened || word == "while" || word == "until"
+			}
+		case word == "do":
+			// The optional do of while/until/for does not open another block
+			open = !loopOpened
+		}
+		if open {
+			depth++
+			if depth > *depthMax {
+				*depthMax = depth
+			}
+		}
+	}
+	return depth
+}
+
+// rbPrevSignificant returns the last non-space byte before position k, or 0.
+func rbPrevSignificant(code string, k int) byte {
+	for k--; k >= 0; k-- {
+		if code[k] != ' ' && code[k] != '\t' {
+			return code[k]
+		}
+	}
+	return 0
+}
+
+// mergeSmallSymbols merges small symbols into adjacent larger ones.
+func (r *RubyChunker) mergeSmallSymbols(symbols []rbSymbol) []rbSymbol {
+	if len(symbols) <= 1 {
+		return symbols
+	}
+
+	result := make([]rbSymbol, 0, len(symbols))
+	var pending *rbSymbol
+
+	for i := range symbols {
+		sym := symbols[i]
+
+		if sym.tokens < r.config.MinTokens {
+			if pending == nil {
+				pending = &sym
+			} else {
+				pending.content += "\n\n" + sym.content
+				pending.endLine = sym.endLine
+				pending.tokens = r.config.CountTokens(pending.content)
+				pending.name = pending.name + "+" + sym.name
+			}
+		} else {
+			if pending != nil {
+				if pending.tokens+sym.tokens <= r.config.MaxTokensFor(sym.symbolType) {
+					sym.content = pending.content + "\n\n" + sym.content
+					sym.startLine = pending.startLine
+					sym.tokens = r.config.CountTokens(sym.content)
+				} else {
+					result = append(result, *pending)
+				}
+				pending = nil
+			}
+			result = append(result, sym)
+		}
+	}
+
+	// Attach trailing small symbols to the previous chunk if it fits
+	if pending != nil {
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= r.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = r.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
+	}
+
+	return result
+}
+
+// chunkAsFile creates a single chunk for the entire file.
+func (r *RubyChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
+	contentStr := string(content)
+	contentHash := r.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
+	symbol := metadata.FilePath
+
+	return []Chunk{{
+		ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, symbol, contentHash),
+		Content:     contentStr,
+		Symbol:      symbol,
+		SymbolType:  "file",
+		StartLine:   1,
+		EndLine:     strings.Count(contentStr, "\n") + 1,
+		TokenCount:  r.config.CountTokens(contentStr),
+		ContentHash: contentHash,
+		ExactHash:   exactHash,
+		FilePath:    metadata.FilePath,
+		Language:    "ruby",
+		Module:      metadata.Module,
+		ProjectID:   metadata.ProjectID,
+	}}
+}
@@ -0,0 +1,136 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRubyChunker_RailsModel(t *testing.T) {
+	chunks := chunkSource(t, NewRubyChunker(testChunkingConfig), "app/models/user.rb", "ruby", `# frozen_string_literal: true
+
+# A registered user.
+class User < ApplicationRecord
+  has_many :posts, dependent: :destroy
+  validates :email, presence: true
+
+  # Full display name.
+  def full_name
+    if middle_name.present?
+      "#{first_name} #{middle_name} #{last_name}"
+    else
+      "#{first_name} #{last_name}"
+    end
+  end
+
+  def recent_posts(limit = 5)
+    return [] unless active?
+
+    posts.order(created_at: :desc).limit(limit).each do |post|
+      post.touch if post.stale?
+    end
+  end
+end
+`)
+
+	if len(chunks) != 3 {
+		t.Fatalf("Expected class and two method chunks, got %d: %+v", len(chunks), chunks)
+	}
+
+	user := findChunk(chunks, "User")
+	if user == nil || user.SymbolType != "class" {
+		t.Fatalf("Expected User class chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(user.Content, "# A registered user.") || !strings.Contains(user.Content, "validates :email") ||
+		strings.Contains(user.Content, "def full_name") {
+		t.Errorf("Expected class chunk with its comment and header only, got:\n%s", user.Content)
+	}
+
+	fullName := findChunk(chunks, "User#full_name")
+	if fullName == nil || fullName.SymbolType != "method" {
+		t.Fatalf("Expected User#full_name method chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(fullName.Content, "  # Full display name.") || !strings.HasSuffix(fullName.Content, "    end\n  end") {
+		t.Errorf("Expected full_name with comment through its end, got:\n%s", fullName.Content)
+	}
+
+	recent := findChunk(chunks, "User#recent_posts")
+	if recent == nil || recent.SymbolType != "method" {
+		t.Fatalf("Expected User#recent_posts method chunk, got %+v", chunks)
+	}
+	// Modifier unless/if must not open blocks; the do block must
+	if recent.StartLine != 17 || recent.EndLine != 23 {
+		t.Errorf("Expected recent_posts at lines 17-23, got %d-%d", recent.StartLine, recent.EndLine)
+	}
+	if recent.Language != "ruby" {
+		t.Errorf("Expected language ruby, got %s", recent.Language)
+	}
+}
+
+func TestRubyChunker_NamespacesAndClassMethods(t *testing.T) {
+	chunks := chunkSource(t, NewRubyChunker(testChunkingConfig), "app/models/user.rb", "ruby", `module Billing
+  class Invoice
+    STATUSES = %i[draft paid].freeze
+
+    def self.overdue
+      where(status: :end).select { |i| i.due_at < Time.current }
+    end
+
+    class << self
+      def export(io)
+        io.write <<~CSV
+          id,total
+          end
+        CSV
+      end
+    end
+
+    def total = line_items.sum(&:amount)
+
+    def settle!
+      total = 0
+      while total < amount do
+        total += step
+      end
+      result = if paid? then :ok else :pending end
+      result
+    end
+  end
+end
+`)
+
+	want := map[string]string{
+		"Billing":                  "module",
+		"Billing::Invoice":         "class",
+		"Billing::Invoice.overdue": "method",
+		"Billing::Invoice.export":  "method",
+		"Billing::Invoice#total":   "method",
+		"Billing::Invoice#settle!": "method",
+	}
+	for symbol, symbolType := range want {
+		if c := findChunk(chunks, symbol); c == nil || c.SymbolType != symbolType {
+			t.Errorf("Expected %s %s chunk, got %+v", symbolType, symbol, c)
+		}
+	}
+
+	// The heredoc body's "end" must not close export early
+	if export := findChunk(chunks, "Billing::Invoice.export"); export != nil && !strings.Contains(export.Content, "CSV\n      end") {
+		t.Errorf("Expected export to include its heredoc, got:\n%s", export.Content)
+	}
+	if total := findChunk(chunks, "Billing::Invoice#total"); total != nil && total.StartLine != total.EndLine {
+		t.Errorf("Expected endless method on one line, got %d-%d", total.StartLine, total.EndLine)
+	}
+	if settle := findChunk(chunks, "Billing::Invoice#settle!"); settle != nil && !strings.HasSuffix(settle.Content, "result\n    end") {
+		t.Errorf("Expected settle! to end at its own end, got:\n%s", settle.Content)
+	}
+}
+
+func TestRubyChunker_NoDeclarationsFallsBackToFile(t *testing.T) {
+	chunks := chunkSource(t, NewRubyChunker(testChunkingConfig), "app/models/user.rb", "ruby", `Rails.application.routes.draw do
+  resources :users
+end
+`)
+
+	if len(chunks) != 1 || chunks[0].SymbolType != "file" {
+		t.Errorf("Expected a single file chunk, got %+v", chunks)
+	}
+}
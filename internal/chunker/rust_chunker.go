@@ -0,0 +1,512 @@
+// Package chunker provides Rust code chunking using brace matching.
+// It extracts top-level functions, structs, enums, traits and impl blocks with their methods.
+package chunker
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// RustChunker implements item/method-level chunking for Rust.
+type RustChunker struct {
+	config ChunkingConfig
+}
+
+// NewRustChunker creates a new Rust chunker.
+func NewRustChunker(cfg ChunkingConfig) *RustChunker {
+	return &RustChunker{config: cfg}
+}
+
+// Name returns the chunker strategy name.
+func (r *RustChunker) Name() string {
+	return "rust"
+}
+
+// rustSymbol represents an extracted Rust symbol.
+type rustSymbol struct {
+	name       string
+	symbolType string
+	startLine  int
+	endLine    int
+	content    string
+	tokens     int
+}
+
+// rustLineState is the lexer state of a single line.
+type rustLineState struct {
+	code       bool // line starts outside block comments and string literals
+	depthStart int  // brace depth at line start
+	depthEnd   int  // brace depth at line end
+	depthMax   int  // highest brace depth reached on the line
+}
+
+// rustQualifiers matches visibility and qualifiers before an item keyword:
+// pub, pub(crate), const, async, unsafe, default, extern "C"
+const rustQualifiers = `^\s*(?:pub(?:\s*\([^)]*\))?\s+)?(?:(?:default|const|async|unsafe|extern(?:\s+"[^"]*")?)\s+)*`
+
+// Regex patterns for Rust symbol extraction
+var (
+	// Item: pub fn, pub(crate) struct, unsafe trait, impl<'a, T> ... for ...
+	rustItemPattern = regexp.MustCompile(rustQualifiers + `(fn|struct|enum|trait|impl)\b`)
+
+	// Name after fn/struct/enum/trait
+	rustNamePattern = regexp.MustCompile(`^\s+(\w+)`)
+
+	// Method inside an impl block
+	rustFnPattern = regexp.MustCompile(rustQualifiers + `fn\s+(\w+)`)
+)
+
+// rustItemKinds maps item keywords to symbol types.
+var rustItemKinds = map[string]string{
+	"fn":     "function",
+	"struct": "struct",
+	"enum":   "enum",
+	"trait":  "trait",
+	"impl":   "impl",
+}
+
+// Chunk splits Rust source code into item and method chunks.
+func (r *RustChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error) {
+	contentStr := string(content)
+	lines := strings.Split(contentStr, "\n")
+
+	symbols := r.extractSymbols(lines, rustScanLines(lines))
+	if len(symbols) == 0 {
+		return r.chunkAsFile(content, metadata), nil
+	}
+
+	if r.config.MergeSmallChunks {
+		symbols = r.mergeSmallSymbols(symbols)
+	}
+
+	chunks := make([]Chunk, 0, len(symbols))
+	for _, sym := range symbols {
+		contentHash := r.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
+		chunks = append(chunks, Chunk{
+			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
+			Content:     sym.content,
+			Symbol:      sym.name,
+			SymbolType:  sym.symbolType,
+			StartLine:   sym.startLine,
+			EndLine:     sym.endLine,
+			TokenCount:  sym.tokens,
+			ContentHash: contentHash,
+			ExactHash:   exactHash,
+			FilePath:    metadata.FilePath,
+			Language:    "rust",
+			Module:      metadata.Module,
+			ProjectID:   metadata.ProjectID,
+		})
+	}
+
+	return chunks, nil
+}
+
+// extractSymbols finds top-level items and the methods of impl blocks. An
+// impl chunk covers its header up to the first method; each method runs
+// until the next method or the block's closing brace. Traits are kept whole.
+func (r *RustChunker) extractSymbols(lines []string, states []rustLineState) []rustSymbol {
+	var symbols []rustSymbol
+
+	for i := 0; i < len(lines); i++ {
+		if !states[i].code || states[i].depthStart != 0 {
+			continue
+		}
+		loc := rustItemPattern.FindStringSubmatchIndex(lines[i])
+		if loc == nil {
+			continue
+		}
+		kind := rustItemKinds[lines[i][loc[2]:loc[3]]]
+
+		start := r.findPrecedingComment(lines, i+1)
+		end := r.findBraceEnd(lines, states, i+1)
+
+		if kind != "impl" {
+			m := rustNamePattern.FindStringSubmatch(lines[i][loc[3]:])
+			if m == nil {
+				continue
+			}
+			symbols = append(symbols, r.newSymbol(lines, m[1], kind, start, end))
+			i = end - 1
+			continue
+		}
+
+		typeName := rustImplType(r.implHeader(lines, states, i, end))
+		methods := r.findMethods(lines, states, typeName, i+1, end)
+
+		implEnd := end
+		if len(methods) > 0 {
+			implEnd = trimTrailingBlankLines(lines, start, methods[0].startLine-1)
+		}
+		symbols = append(symbols, r.newSymbol(lines, typeName, kind, start, implEnd))
+		symbols = append(symbols, methods...)
+
+		i = end - 1
+	}
+
+	sort.SliceStable(symbols, func(a, b int) bool {
+		return symbols[a].startLine < symbols[b].startLine
+	})
+	return symbols
+}
+
+// findMethods returns the functions at depth 1 of an impl block declared at
+// implLine and closed at implEnd, named Type::method.
+func (r *RustChunker) findMethods(lines []string, states []rustLineState, typeName string, implLine, implEnd int) []rustSymbol {
+	var starts []int
+	var names []string
+
+	for i := implLine; i < implEnd-1; i++ {
+		if !states[i].code || states[i].depthStart != 1 {
+			continue
+		}
+		m := rustFnPattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		starts = append(starts, r.findPrecedingComment(lines, i+1))
+		names = append(names, typeName+"::"+m[1])
+	}
+
+	methods := make([]rustSymbol, 0, len(starts))
+	for i, start := range starts {
+		// Stop before the impl's closing brace
+		end := implEnd - 1
+		if i < len(starts)-1 {
+			end = starts[i+1] - 1
+		}
+		end = trimTrailingBlankLines(lines, start, end)
+		methods = append(methods, r.newSymbol(lines, names[i], "method", start, end))
+	}
+	return methods
+}
+
+// implHeader returns the impl declaration text from line idx (0-indexed) up
+// to the opening brace of its body.
+func (r *RustChunker) implHeader(lines []string, states []rustLineState, idx, end int) string {
+	var header []string
+	for i := idx; i < end; i++ {
+		header = append(header, lines[i])
+		if states[i].depthMax > 0 {
+			break
+		}
+	}
+	text := strings.Join(header, " ")
+	if k := strings.Index(text, "{"); k >= 0 {
+		text = text[:k]
+	}
+	return text
+}
+
+// rustImplType returns the implementing type of an impl header, e.g. "Parser"
+// for "impl<'a, R: Read> Iterator for Parser<'a, R> where R: Send".
+// Returns "impl" when no type can be found.
+func rustImplType(header string) string {
+	fields := strings.Fields(rustStripGenerics(header))
+
+	for i, f := range fields {
+		if f != "impl" {
+			continue
+		}
+		rest := fields[i+1:]
+		for k, g := range rest {
+			if g == "for" {
+				rest = rest[k+1:]
+				break
+			}
+		}
+		for _, g := range rest {
+			if g == "where" {
+				break
+			}
+			// Skip references, lifetimes and qualifiers: &'a mut dyn Trait
+			g = strings.TrimLeft(g, "&!")
+			if g == "" || g == "mut" || g == "dyn" || strings.HasPrefix(g, "'") {
+				continue
+			}
+			if k := strings.LastIndex(g, "::"); k >= 0 {
+				g = g[k+2:]
+			}
+			if g = strings.Trim(g, "()[],;"); g != "" {
+				return g
+			}
+		}
+		break
+	}
+	return "impl"
+}
+
+// rustStripGenerics removes everything inside angle brackets, leaving the
+// "->" of Fn bounds intact so it does not close a bracket.
+func rustStripGenerics(s string) string {
+	var b strings.Builder
+	depth := 0
+	for k := 0; k < len(s); k++ {
+		switch {
+		case s[k] == '<':
+			depth++
+		case s[k] == '>' && !(k > 0 && s[k-1] == '-'):
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0:
+			b.WriteByte(s[k])
+		}
+	}
+	return b.String()
+}
+
+// findPrecedingComment finds doc comments, line comments and attributes
+// (including multi-line ones) before an item. Inner //! docs and #![...]
+// attributes belong to the enclosing module and are not attached.
+func (r *RustChunker) findPrecedingComment(lines []string, symbolLine int) int {
+	startLine := symbolLine
+
+	for i := symbolLine - 2; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+
+		switch {
+		case strings.HasPrefix(line, "//!"):
+			return startLine
+		case strings.HasPrefix(line, "//"), strings.HasPrefix(line, "#["):
+			startLine = i + 1
+		case strings.HasSuffix(line, "*/"):
+			// Doc or block comment end
+			for k := i; k >= 0; k-- {
+				if strings.Contains(lines[k], "/*") {
+					startLine = k + 1
+					i = k
+					break
+				}
+			}
+		case strings.HasSuffix(line, "]"):
+			// Last line of a multi-line attribute
+			k := i
+			for k >= 0 && !strings.HasPrefix(strings.TrimSpace(lines[k]), "#[") {
+				if strings.TrimSpace(lines[k]) == "" {
+					return startLine
+				}
+				k--
+			}
+			if k < 0 {
+				return startLine
+			}
+			startLine = k + 1
+			i = k
+		default:
+			return startLine
+		}
+	}
+
+	return startLine
+}
+
+// findBraceEnd returns the line closing the block opened at or after
+// startLine, or the line ending a body-less item with ';' (unit and tuple
+// structs, trait method signatures).
+func (r *RustChunker) findBraceEnd(lines []string, states []rustLineState, startLine int) int {
+	base := states[startLine-1].depthStart
+	opened := false
+
+	for i := startLine - 1; i < len(lines); i++ {
+		if states[i].depthMax > base {
+			opened = true
+		}
+		if opened && states[i].depthEnd <= base {
+			return i + 1
+		}
+		if !opened && strings.HasSuffix(strings.TrimSpace(lines[i]), ";") {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// newSymbol builds a symbol from a 1-indexed inclusive line range.
+func (r *RustChunker) newSymbol(lines []string, name, symbolType string, start, end int) rustSymbol {
+	if end < start {
+		end = start
+	}
+	content := extractLines(lines, start, end)
+	return rustSymbol{
+		name:       name,
+		symbolType: symbolType,
+		startLine:  start,
+		endLine:    end,
+		content:    content,
+		tokens:     r.config.CountTokens(content),
+	}
+}
+
+// rustScanLines tracks brace depth per line, ignoring braces inside
+// (nested) comments, string, raw string and char literals. Lifetimes such
+// as 'a are told apart from char literals like '{'.
+func rustScanLines(lines []string) []rustLineState {
+	states := make([]rustLineState, len(lines))
+	depth := 0
+	commentDepth := 0 // block comments nest in Rust
+	inString := false
+	rawHashes := -1 // '#' count of the open raw string, -1 outside one
+
+	for i, line := range lines {
+		st := rustLineState{code: commentDepth == 0 && !inString && rawHashes < 0, depthStart: depth, depthMax: depth}
+
+		for k := 0; k < len(line); k++ {
+			switch {
+			case commentDepth > 0:
+				if strings.HasPrefix(line[k:], "*/") {
+					commentDepth--
+					k++
+				} else if strings.HasPrefix(line[k:], "/*") {
+					commentDepth++
+					k++
+				}
+			case rawHashes >= 0:
+				if line[k] == '"' && strings.HasPrefix(line[k+1:], strings.Repeat("#", rawHashes)) {
+					k += rawHashes
+					rawHashes = -1
+				}
+			case inString:
+				if line[k] == '\\' {
+					k++
+				} else if line[k] == '"' {
+					inString = false
+				}
+			case strings.HasPrefix(line[k:], "//"):
+				k = len(line)
+			case strings.HasPrefix(line[k:], "/*"):
+				commentDepth++
+				k++
+			case line[k] == '"':
+				inString = true
+			case line[k] == 'r' && (k == 0 || !isIdentByte(line[k-1]) || (line[k-1] == 'b' && (k == 1 || !isIdentByte(line[k-2])))):
+				// Raw string: r"..", r#".."#, br".."
+				j := k + 1
+				for j < len(line) && line[j] == '#' {
+					j++
+				}
+				if j < len(line) && line[j] == '"' {
+					rawHashes = j - k - 1
+					k = j
+				}
+			case line[k] == '\'':
+				k = rustSkipCharLiteral(line, k)
+			case line[k] == '{':
+				depth++
+				if depth > st.depthMax {
+					st.depthMax = depth
+				}
+			case line[k] == '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+
+		st.depthEnd = depth
+		states[i] = st
+	}
+	return states
+}
+
+// rustSkipCharLiteral returns the index of the closing quote of a char
+// literal starting at k, or k itself for a lifetime or loop label like 'a.
+func rustSkipCharLiteral(line string, k int) int {
+	if k+1 < len(line) && line[k+1] == '\\' {
+		// Escaped char: '\n', '\'', '\u{7FFF}'
+		for j := k + 3; j < len(line); j++ {
+			if line[j] == '\'' {
+				return j
+			}
+		}
+		return len(line)
+	}
+	_, size := utf8.DecodeRuneInString(line[k+1:])
+	if size > 0 && k+1+size < len(line) && line[k+1+size] == '\'' {
+		return k + 1 + size
+	}
+	return k
+}
+
+// isIdentByte reports whether b can be part of an ASCII identifier.
+func isIdentByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// mergeSmallSymbols merges small symbols into adjacent larger ones.
+func (r *RustChunker) mergeSmallSymbols(symbols []rustSymbol) []rustSymbol {
+	if len(symbols) <= 1 {
+		return symbols
+	}
+
+	result := make([]rustSymbol, 0, len(symbols))
+	var pending *rustSymbol
+
+	for i := range symbols {
+		sym := symbols[i]
+
+		if sym.tokens < r.config.MinTokens {
+			if pending == nil {
+				pending = &sym
+			} else {
+				pending.content += "\n\n" + sym.content
+				pending.endLine = sym.endLine
+				pending.tokens = r.config.CountTokens(pending.content)
+				pending.name = pending.name + "+" + sym.name
+			}
+		} else {
+			if pending != nil {
+				if pending.tokens+sym.tokens <= r.config.MaxTokensFor(sym.symbolType) {
+					sym.content = pending.content + "\n\n" + sym.content
+					sym.startLine = pending.startLine
+					sym.tokens = r.config.CountTokens(sym.content)
+				} else {
+					result = append(result, *pending)
+				}
+				pending = nil
+			}
+			result = append(result, sym)
+		}
+	}
+
+	// Attach trailing small symbols to the previous chunk if it fits
+	if pending != nil {
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= r.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = r.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
+	}
+
+	return result
+}
+
+// chunkAsFile creates a single chunk for the entire file.
+func (r *RustChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
+	contentStr := string(content)
+	contentHash := r.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
+	symbol := metadata.FilePath
+
+	return []Chunk{{
+		ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, symbol, contentHash),
+		Content:     contentStr,
+		Symbol:      symbol,
+		SymbolType:  "file",
+		StartLine:   1,
+		EndLine:     strings.Count(contentStr, "\n") + 1,
+		TokenCount:  r.config.CountTokens(contentStr),
+		ContentHash: contentHash,
+		ExactHash:   exactHash,
+		FilePath:    metadata.FilePath,
+		Language:    "rust",
+		Module:      metadata.Module,
+		ProjectID:   metadata.ProjectID,
+	}}
+}
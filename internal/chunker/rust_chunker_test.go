@@ -0,0 +1,165 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRustChunker_ItemsAndImplMethods(t *testing.T) {
+	chunks := chunkSource(t, NewRustChunker(testChunkingConfig), "src/parser.rs", "rust", `//! Tokenizer for the query language.
+
+use std::fmt;
+
+/// A parsed token.
+#[derive(
+    Debug,
+    Clone,
+)]
+pub struct Token<'a> {
+    pub text: &'a str,
+}
+
+pub(crate) enum Kind {
+    Word,
+    Number,
+}
+
+pub struct Marker;
+
+impl<'a> Token<'a> {
+    /// Creates a token.
+    #[inline]
+    pub fn new(text: &'a str) -> Self {
+        Token { text }
+    }
+
+    pub fn is_open(&self) -> bool {
+        self.text == "{" || self.text.starts_with('{')
+    }
+}
+
+impl<'a, W: fmt::Write> fmt::Display for Token<'a>
+where
+    W: Send,
+{
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "{}", self.text)
+    }
+}
+
+pub trait Visitor {
+    fn visit(&mut self, token: &Token);
+    fn done(&self) -> bool { true }
+}
+
+pub async fn tokenize<'a>(input: &'a str) -> Vec<Token<'a>> {
+    let close = '}';
+    input.split(close).map(Token::new).collect()
+}
+`)
+
+	token := findChunk(chunks, "Token")
+	if token == nil || token.SymbolType != "struct" {
+		t.Fatalf("Expected Token struct chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(token.Content, "/// A parsed token.\n#[derive(") || !strings.HasSuffix(token.Content, "}") {
+		t.Errorf("Expected struct with doc comment and attribute, got:\n%s", token.Content)
+	}
+	if strings.Contains(token.Content, "Tokenizer for") {
+		t.Errorf("Expected inner //! docs not to be attached, got:\n%s", token.Content)
+	}
+
+	if kind := findChunk(chunks, "Kind"); kind == nil || kind.SymbolType != "enum" {
+		t.Errorf("Expected Kind enum chunk, got %+v", chunks)
+	}
+	if marker := findChunk(chunks, "Marker"); marker == nil || marker.StartLine != marker.EndLine {
+		t.Errorf("Expected single-line unit struct Marker, got %+v", marker)
+	}
+
+	newFn := findChunk(chunks, "Token::new")
+	if newFn == nil || newFn.SymbolType != "method" {
+		t.Fatalf("Expected Token::new method chunk, got %+v", chunks)
+	}
+	if !strings.HasPrefix(newFn.Content, "    /// Creates a token.\n    #[inline]") {
+		t.Errorf("Expected method doc comment and attribute, got:\n%s", newFn.Content)
+	}
+
+	// Braces in string and char literals must not end the method early
+	isOpen := findChunk(chunks, "Token::is_open")
+	if isOpen == nil || !strings.HasSuffix(isOpen.Content, "starts_with('{')\n    }") {
+		t.Errorf("Expected is_open to span its full body, got %+v", isOpen)
+	}
+
+	// Trait impls with generic bounds and a where clause are named by the type
+	if fmtFn := findChunk(chunks, "Token::fmt"); fmtFn == nil || !strings.Contains(fmtFn.Content, `write!(f, "{}", self.text)`) {
+		t.Errorf("Expected Token::fmt from the Display impl, got %+v", chunks)
+	}
+
+	visitor := findChunk(chunks, "Visitor")
+	if visitor == nil || visitor.SymbolType != "trait" || !strings.Contains(visitor.Content, "fn done") {
+		t.Errorf("Expected Visitor trait kept whole, got %+v", visitor)
+	}
+	if findChunk(chunks, "Visitor::visit") != nil {
+		t.Errorf("Expected no method chunks for trait declarations")
+	}
+
+	tokenize := findChunk(chunks, "tokenize")
+	if tokenize == nil || tokenize.SymbolType != "function" {
+		t.Fatalf("Expected tokenize function chunk, got %+v", chunks)
+	}
+	if !strings.HasSuffix(tokenize.Content, "collect()\n}") || tokenize.Language != "rust" {
+		t.Errorf("Expected tokenize to end at its closing brace, got:\n%s", tokenize.Content)
+	}
+}
+
+func TestRustChunker_LiteralsAndComments(t *testing.T) {
+	chunks := chunkSource(t, NewRustChunker(testChunkingConfig), "src/parser.rs", "rust", `fn template() -> &'static str {
+    r#"
+fn fake() {
+"#
+}
+
+/* outer /* nested } */ still comment { */
+fn escapes() -> (char, char) {
+    ('\'', '\u{7B}')
+}
+
+fn labeled() {
+    'outer: loop {
+        break 'outer;
+    }
+}
+`)
+
+	if findChunk(chunks, "fake") != nil {
+		t.Fatalf("Expected fn inside a raw string to be ignored, got %+v", chunks)
+	}
+	for _, name := range []string{"template", "escapes", "labeled"} {
+		if findChunk(chunks, name) == nil {
+			t.Fatalf("Expected %s chunk, got %+v", name, chunks)
+		}
+	}
+	if labeled := findChunk(chunks, "labeled"); !strings.HasSuffix(labeled.Content, "    }\n}") {
+		t.Errorf("Expected loop labels not to be read as char literals, got:\n%s", labeled.Content)
+	}
+	if escapes := findChunk(chunks, "escapes"); escapes.StartLine != 7 || escapes.EndLine != 10 {
+		t.Errorf("Expected escapes with its block comment at lines 7-10, got %d-%d", escapes.StartLine, escapes.EndLine)
+	}
+}
+
+func TestRustImplType(t *testing.T) {
+	tests := map[string]string{
+		"impl Parser":                                                      "Parser",
+		"impl<'a> Token<'a>":                                               "Token",
+		"impl<T: Fn() -> u32> Runner<T>":                                   "Runner",
+		"unsafe impl<T> Send for crate::sync::Handle<T>":                   "Handle",
+		"impl<'a> From<&'a str> for &'a mut Buffer where":                  "Buffer",
+		"impl !Sync for Cell":                                              "Cell",
+		"impl<'a, W: fmt::Write> fmt::Display for Token<'a> where W: Send": "Token",
+	}
+	for header, want := range tests {
+		if got := rustImplType(header); got != want {
+			t.Errorf("rustImplType(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package chunker
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// siblingSeparator separates the file contents of a merged sibling chunk.
+const siblingSeparator = "\n\n"
+
+// MergeSiblingChunks merges tiny chunks of sibling files (one per file, e.g.
+// one interface per file) into combined chunks, so a directory of
+// one-declaration files doesn't become one tiny vector per file.
+//
+// Chunks under cfg.MinTokens are packed in file path order, per directory,
+// into groups of at most cfg.MaxTokens. Each group of two or more becomes a
+// chunk listing its files in FilePaths; everything else is returned as is.
+// The result is sorted by file path, so the same files always merge the
+// same way.
+func MergeSiblingChunks(chunks []Chunk, cfg ChunkingConfig) []Chunk {
+	sorted := make([]Chunk, len(chunks))
+	copy(sorted, chunks)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].FilePath < sorted[j].FilePath })
+
+	var merged, group []Chunk
+	groupContent := ""
+	flush := func() {
+		if len(group) == 1 {
+			merged = append(merged, group[0])
+		} else if len(group) > 1 {
+			merged = append(merged, combineSiblings(group, cfg))
+		}
+		group, groupContent = nil, ""
+	}
+
+	for _, c := range sorted {
+		if c.TokenCount >= cfg.MinTokens {
+			flush()
+			merged = append(merged, c)
+			continue
+		}
+		if len(group) > 0 && (path.Dir(group[0].FilePath) != path.Dir(c.FilePath) ||
+			cfg.CountTokens(groupContent+siblingSeparator+c.Content) > cfg.MaxTokens) {
+			flush()
+		}
+		if len(group) > 0 {
+			groupContent += siblingSeparator
+		}
+		group = append(group, c)
+		groupContent += c.Content
+	}
+	flush()
+
+	return merged
+}
+
+// combineSiblings combines chunks of sibling files into one chunk. It keeps
+// the first file's path and lines; FilePaths lists every file.
+func combineSiblings(group []Chunk, cfg ChunkingConfig) Chunk {
+	first := group[0]
+
+	var content, names, filePaths []string
+	var imports, references []string
+	modTime := first.ModTime
+	for _, c := range group {
+		content = append(content, c.Content)
+		names = append(names, c.Symbol)
+		filePaths = append(filePaths, c.FilePath)
+		imports = mergeRelationships(imports, c.Imports)
+		references = mergeRelationships(references, c.References)
+		if c.ModTime.After(modTime) {
+			modTime = c.ModTime
+		}
+	}
+	combinedContent := strings.Join(content, siblingSeparator)
+	contentHash := cfg.HashContent(combinedContent)
+	symbol := strings.Join(names, "+")
+
+	return Chunk{
+		ID:          GenerateChunkID(first.ProjectID, first.FilePath, symbol, contentHash),
+		Content:     combinedContent,
+		Symbol:      symbol,
+		SymbolType:  "combined",
+		StartLine:   first.StartLine,
+		EndLine:     first.EndLine,
+		TokenCount:  cfg.CountTokens(combinedContent),
+		ContentHash: contentHash,
+		ExactHash:   HashContent(combinedContent),
+		FilePath:    first.FilePath,
+		FilePaths:   filePaths,
+		Language:    first.Language,
+		Module:      first.Module,
+		ProjectID:   first.ProjectID,
+		Owner:       first.Owner,
+		ModTime:     modTime,
+		Imports:     imports,
+		References:  references,
+	}
+}
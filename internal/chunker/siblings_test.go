@@ -0,0 +1,69 @@
+package chunker
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMergeSiblingChunks(t *testing.T) {
+	goChunker := NewGoChunker(DefaultConfig())
+
+	// Tiny one-interface files, given out of order
+	var chunks []Chunk
+	for _, name := range []string{"writer", "closer", "reader", "seeker"} {
+		content := fmt.Sprintf("package io\n\n// %s is implemented by %s types.\ntype %s interface {\n\tDo() error\n}\n", name, name, name)
+		fileChunks, err := goChunker.Chunk([]byte(content), FileMetadata{FilePath: "pkg/io/" + name + ".go", Language: "go", ProjectID: "proj"})
+		if err != nil || len(fileChunks) != 1 {
+			t.Fatalf("Expected one chunk for %s, got %d (%v)", name, len(fileChunks), err)
+		}
+		chunks = append(chunks, fileChunks...)
+	}
+	// A big file in the same directory and a tiny file elsewhere stay alone
+	chunks = append(chunks,
+		Chunk{ID: "big", FilePath: "pkg/io/big.go", TokenCount: 80},
+		Chunk{ID: "lone", FilePath: "pkg/os/file.go", TokenCount: 5},
+	)
+
+	// Room for two interfaces per merged chunk, not three
+	tokens := chunks[0].TokenCount
+	cfg := DefaultConfig()
+	cfg.MinTokens = tokens + 1
+	cfg.MaxTokens = tokens*5/2 + 1
+
+	merged := MergeSiblingChunks(chunks, cfg)
+	var gotFiles [][]string
+	for _, c := range merged {
+		files := c.FilePaths
+		if files == nil {
+			files = []string{c.FilePath}
+		}
+		gotFiles = append(gotFiles, files)
+	}
+	want := [][]string{
+		{"pkg/io/big.go"},
+		{"pkg/io/closer.go", "pkg/io/reader.go"},
+		{"pkg/io/seeker.go", "pkg/io/writer.go"},
+		{"pkg/os/file.go"},
+	}
+	if !reflect.DeepEqual(gotFiles, want) {
+		t.Fatalf("Merged files = %v, want %v", gotFiles, want)
+	}
+
+	pair := merged[1]
+	if pair.Symbol != "closer+reader" || pair.SymbolType != "combined" || pair.FilePath != "pkg/io/closer.go" {
+		t.Errorf("Unexpected merged chunk %+v", pair)
+	}
+	if pair.TokenCount > cfg.MaxTokens || pair.ContentHash != HashContent(pair.Content) {
+		t.Errorf("Expected a hashed chunk within max_tokens, got %d tokens", pair.TokenCount)
+	}
+
+	// Input order doesn't matter
+	reversed := make([]Chunk, len(chunks))
+	for i, c := range chunks {
+		reversed[len(chunks)-1-i] = c
+	}
+	if again := MergeSiblingChunks(reversed, cfg); !reflect.DeepEqual(again, merged) {
+		t.Error("Expected the same merge regardless of input order")
+	}
+}
@@ -0,0 +1,119 @@
+// Package chunker provides splitting of chunks that exceed a token limit.
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SplitOversized splits a chunk whose content exceeds maxTokens into
+// sub-chunks named symbol#1, symbol#2, ... Each keeps the original chunk's
+// metadata with its own line range, hashes and ID. Splits happen at line
+// boundaries; parts that still don't fit are split again, down to cutting
+// inside a single over-long line, so no part exceeds maxTokens.
+// Returns the chunk unchanged if it fits.
+func (c ChunkingConfig) SplitOversized(chunk Chunk, maxTokens int) []Chunk {
+	if maxTokens <= 0 || c.CountTokens(chunk.Content) <= maxTokens {
+		return []Chunk{chunk}
+	}
+
+	lines := strings.Split(chunk.Content, "\n")
+	segments := c.splitLines(lines, 0, maxTokens)
+	if len(segments) == 1 {
+		return []Chunk{chunk}
+	}
+
+	parts := make([]Chunk, 0, len(segments))
+	for i, seg := range segments {
+		part := chunk
+		part.Content = seg.content
+		part.Symbol = fmt.Sprintf("%s#%d", chunk.Symbol, i+1)
+		part.StartLine = chunk.StartLine + seg.first
+		part.EndLine = chunk.StartLine + seg.last
+		part.TokenCount = c.CountTokens(seg.content)
+		part.ContentHash = c.HashContent(seg.content)
+		part.ExactHash = HashContent(seg.content)
+		part.ID = GenerateChunkID(chunk.ProjectID, chunk.FilePath, part.Symbol, part.ContentHash)
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// splitSegment is a piece of split content and its line offsets (inclusive).
+type splitSegment struct {
+	content     string
+	first, last int
+}
+
+// splitLines packs lines greedily into segments of at most maxTokens,
+// re-splitting any segment whose actual count is still over. offset is the
+// line offset of lines[0] within the chunk.
+func (c ChunkingConfig) splitLines(lines []string, offset, maxTokens int) []splitSegment {
+	var ranges [][2]int // [start, end) line offsets into lines
+	start, tokens := 0, 0
+	for i, line := range lines {
+		// Each line also costs roughly one token for its newline
+		lineTokens := c.CountTokens(line) + 1
+		if tokens+lineTokens > maxTokens && i > start {
+			ranges = append(ranges, [2]int{start, i})
+			start, tokens = i, 0
+		}
+		tokens += lineTokens
+	}
+	ranges = append(ranges, [2]int{start, len(lines)})
+
+	var segments []splitSegment
+	for _, r := range ranges {
+		content := strings.Join(lines[r[0]:r[1]], "\n")
+		switch {
+		case c.CountTokens(content) <= maxTokens:
+			segments = append(segments, splitSegment{content, offset + r[0], offset + r[1] - 1})
+		case r[1]-r[0] > 1:
+			// Per-line estimates undercounted; halve until the parts fit
+			mid := (r[0] + r[1]) / 2
+			segments = append(segments, c.splitLines(lines[r[0]:mid], offset+r[0], maxTokens)...)
+			segments = append(segments, c.splitLines(lines[mid:r[1]], offset+mid, maxTokens)...)
+		default:
+			for _, piece := range c.splitLine(content, maxTokens) {
+				segments = append(segments, splitSegment{piece, offset + r[0], offset + r[0]})
+			}
+		}
+	}
+	return segments
+}
+
+// splitLine cuts a single line that exceeds maxTokens into pieces that fit,
+// preferring to cut after whitespace.
+func (c ChunkingConfig) splitLine(line string, maxTokens int) []string {
+	var pieces []string
+	runes := []rune(line)
+	for len(runes) > 0 {
+		if c.CountTokens(string(runes)) <= maxTokens {
+			pieces = append(pieces, string(runes))
+			break
+		}
+
+		// Longest prefix that fits (at least one rune, so this always advances)
+		lo, hi := 1, len(runes)
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if c.CountTokens(string(runes[:mid])) <= maxTokens {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		cut := lo
+		for i := lo; i > lo/2; i-- {
+			if unicode.IsSpace(runes[i-1]) {
+				cut = i
+				break
+			}
+		}
+
+		pieces = append(pieces, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	return pieces
+}
@@ -0,0 +1,101 @@
+package chunker
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSplitOversized(t *testing.T) {
+	cfg := ChunkingConfig{Tokenizer: wordTokenizer{}}
+	lines := []string{"a b c", "d e f", "g h i", "j k l", "m n o"}
+	chunk := Chunk{
+		Content:   strings.Join(lines, "\n"),
+		Symbol:    "Big",
+		StartLine: 10,
+		EndLine:   14,
+		FilePath:  "big.go",
+		Module:    "pkg",
+		ProjectID: "p",
+	}
+
+	// Each line costs 3 words + 1 for its newline, so two lines per part
+	parts := cfg.SplitOversized(chunk, 8)
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+	want := []struct {
+		symbol     string
+		start, end int
+	}{{"Big#1", 10, 11}, {"Big#2", 12, 13}, {"Big#3", 14, 14}}
+	for i, w := range want {
+		p := parts[i]
+		if p.Symbol != w.symbol || p.StartLine != w.start || p.EndLine != w.end {
+			t.Errorf("part %d = %s %d-%d, want %s %d-%d", i, p.Symbol, p.StartLine, p.EndLine, w.symbol, w.start, w.end)
+		}
+		if p.FilePath != "big.go" || p.Module != "pkg" || p.TokenCount > 8 {
+			t.Errorf("part %d: unexpected metadata %+v", i, p)
+		}
+		if p.ID != GenerateChunkID("p", "big.go", w.symbol, p.ContentHash) {
+			t.Errorf("part %d: unexpected ID %q", i, p.ID)
+		}
+	}
+
+	// Chunks within the limit are returned as-is
+	if got := cfg.SplitOversized(chunk, 100); len(got) != 1 || got[0].Symbol != "Big" {
+		t.Errorf("expected chunk within limit to be unchanged, got %+v", got)
+	}
+}
+
+func TestSplitOversized_SplitsLongLines(t *testing.T) {
+	cfg := ChunkingConfig{Tokenizer: wordTokenizer{}}
+	giant := strings.TrimSpace(strings.Repeat("word ", 50))
+	chunk := Chunk{Content: "short line\n" + giant + "\nlast", Symbol: "Gen", StartLine: 1}
+
+	parts := cfg.SplitOversized(chunk, 8)
+	for i, p := range parts {
+		if p.TokenCount > 8 {
+			t.Errorf("part %d has %d tokens, want <= 8", i, p.TokenCount)
+		}
+	}
+	if len(parts) < 7 {
+		t.Fatalf("expected the 50-word line to be cut into pieces, got %d parts", len(parts))
+	}
+
+	// Pieces of the long line share its line number and lose no content
+	var lineParts []string
+	for _, p := range parts {
+		if p.StartLine == 2 && p.EndLine == 2 {
+			lineParts = append(lineParts, p.Content)
+		}
+	}
+	if got := strings.Join(lineParts, ""); got != giant {
+		t.Errorf("pieces of line 2 don't rebuild it: %q", got)
+	}
+	if parts[len(parts)-1].Symbol != "Gen#"+strconv.Itoa(len(parts)) {
+		t.Errorf("expected sequential part names, got %q last", parts[len(parts)-1].Symbol)
+	}
+}
+
+func TestParentSymbol(t *testing.T) {
+	tests := []struct {
+		symbol, symbolType, want string
+	}{
+		{"Server.Handle", "method", "Server"},
+		{"UserService.UserService", "constructor", "UserService"},
+		{"Admin::User#save", "method", "Admin::User"},
+		{"Point::new", "method", "Point"},
+		{"Server.Handle#2", "method", "Server"}, // split part
+		{"(*Cache).Save", "method", "Cache"},
+		{"(*Store[K, V]).Get", "method", "Store"},
+		{"List[_].Len", "method", "List"},
+		{"Handle", "method", ""},
+		{"Server", "class", ""},
+		{"config.Load", "function", ""},
+	}
+	for _, tt := range tests {
+		if got := ParentSymbol(tt.symbol, tt.symbolType); got != tt.want {
+			t.Errorf("ParentSymbol(%q, %q) = %q, want %q", tt.symbol, tt.symbolType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+// Package chunker provides SQL chunking on statement boundaries.
+// Each statement becomes a chunk named after the object it creates.
+package chunker
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SQLChunker implements statement-level chunking for SQL files.
+type SQLChunker struct {
+	config ChunkingConfig
+}
+
+// NewSQLChunker creates a new SQL chunker.
+func NewSQLChunker(cfg ChunkingConfig) *SQLChunker {
+	return &SQLChunker{config: cfg}
+}
+
+// Name returns the chunker strategy name.
+func (s *SQLChunker) Name() string {
+	return "sql"
+}
+
+// sqlSymbol represents an extracted SQL statement.
+type sqlSymbol struct {
+	name       string
+	symbolType string
+	startLine  int
+	endLine    int
+	content    string
+	tokens     int
+}
+
+// sqlStatement is the 1-indexed inclusive line range of a statement.
+type sqlStatement struct {
+	startLine int
+	endLine   int
+}
+
+// Regex patterns for SQL object extraction
+var (
+	// CREATE [OR REPLACE] [modifiers] TABLE|VIEW|FUNCTION|... [IF NOT EXISTS] name
+	sqlCreatePattern = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:OR\s+(?:REPLACE|ALTER)\s+)?(?:DEFINER\s*=\s*\S+\s+)?` +
+		`(?:(?:GLOBAL|LOCAL|TEMP|TEMPORARY|UNLOGGED|MATERIALIZED|UNIQUE|RECURSIVE|ALGORITHM\s*=\s*\w+|SQL\s+SECURITY\s+\w+)\s+)*` +
+		`(TABLE|VIEW|FUNCTION|PROCEDURE|PROC|TRIGGER|INDEX|SEQUENCE|TYPE|SCHEMA|DOMAIN)\s+` +
+		`(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` +
+		"((?:[\\w$]+|\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\])(?:\\.(?:[\\w$]+|\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]))*)")
+
+	// MySQL client delimiter switch: DELIMITER //
+	sqlDelimiterPattern = regexp.MustCompile(`(?i)^\s*DELIMITER\s+(\S+)\s*$`)
+)
+
+// Chunk splits SQL source into one chunk per statement.
+func (s *SQLChunker) Chunk(content []byte, metadata FileMetadata) ([]Chunk, error) {
+	contentStr := string(content)
+	lines := strings.Split(contentStr, "\n")
+
+	symbols := s.extractSymbols(lines)
+	if len(symbols) == 0 {
+		return s.chunkAsFile(content, metadata), nil
+	}
+
+	if s.config.MergeSmallChunks {
+		symbols = s.mergeSmallSymbols(symbols)
+	}
+
+	chunks := make([]Chunk, 0, len(symbols))
+	for _, sym := range symbols {
+		contentHash := s.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
+		chunks = append(chunks, Chunk{
+			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
+			Content:     sym.content,
+			Symbol:      sym.name,
+			SymbolType:  sym.symbolType,
+			StartLine:   sym.startLine,
+			EndLine:     sym.endLine,
+			TokenCount:  sym.tokens,
+			ContentHash: contentHash,
+			ExactHash:   exactHash,
+			FilePath:    metadata.FilePath,
+			Language:    "sql",
+			Module:      metadata.Module,
+			ProjectID:   metadata.ProjectID,
+		})
+	}
+
+	return chunks, nil
+}
+
+// extractSymbols names each statement by the object it creates, falling back
+// to a numbered fragment, and attaches the comments directly above it.
+func (s *SQLChunker) extractSymbols(lines []string) []sqlSymbol {
+	statements := sqlSplitStatements(lines)
+
+	symbols := make([]sqlSymbol, 0, len(statements))
+	prevEnd := 0
+	for n, stmt := range statements {
+		text := extractLines(lines, stmt.startLine, stmt.endLine)
+
+		name := fmt.Sprintf("fragment_%d", n+1)
+		symbolType := "statement"
+		if m := sqlCreatePattern.FindStringSubmatch(text); m != nil {
+			name = strings.NewReplacer(`"`, "", "`", "", "[", "", "]", "").Replace(m[2])
+			symbolType = strings.ToLower(m[1])
+			if symbolType == "proc" {
+				symbolType = "procedure"
+			}
+		}
+
+		start := s.findPrecedingComment(lines, stmt.startLine, prevEnd)
+		content := extractLines(lines, start, stmt.endLine)
+		symbols = append(symbols, sqlSymbol{
+			name:       name,
+			symbolType: symbolType,
+			startLine:  start,
+			endLine:    stmt.endLine,
+			content:    content,
+			tokens:     s.config.CountTokens(content),
+		})
+		prevEnd = stmt.endLine
+	}
+	return symbols
+}
+
+// findPrecedingComment finds -- and /* */ comments directly before a
+// statement, without reaching into the previous statement.
+func (s *SQLChunker) findPrecedingComment(lines []string, symbolLine, prevEnd int) int {
+	startLine := symbolLine
+
+	for i := symbolLine - 2; i >= prevEnd; i-- {
+		line := strings.TrimSpace(lines[i])
+
+		// Block comment end
+		if strings.HasSuffix(line, "*/") {
+			for k := i; k >= prevEnd; k-- {
+				if strings.Contains(lines[k], "/*") {
+					startLine = k + 1
+					i = k
+					break
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "--") {
+			startLine = i + 1
+			continue
+		}
+
+		break
+	}
+
+	return startLine
+}
+
+// sqlSplitStatements splits lines into statements ending with the delimiter
+// (';' unless changed with DELIMITER) at parenthesis and block depth zero.
+// Delimiters inside comments, quoted strings and identifiers, dollar-quoted
+// bodies ($$ ... $$) and BEGIN ... END / CASE ... END blocks are ignored.
+// Trailing text without a delimiter forms a final statement.
+func sqlSplitStatements(lines []string) []sqlStatement {
+	var statements []sqlStatement
+	start := 0 // 1-indexed start of the open statement (0 = none)
+	delimiter := ";"
+	inBlockComment := false
+	quote := byte(0) // open ', " or ` quote
+	dollarTag := ""  // open dollar quote, e.g. $body$
+	parenDepth, blockDepth := 0, 0
+
+	for i, line := range lines {
+		if quote == 0 && dollarTag == "" && !inBlockComment {
+			if m := sqlDelimiterPattern.FindStringSubmatch(line); m != nil {
+				delimiter = m[1]
+				continue
+			}
+		}
+
+		for k := 0; k < len(line); k++ {
+			switch {
+			case inBlockComment:
+				if strings.HasPrefix(line[k:], "*/") {
+					inBlockComment = false
+					k++
+				}
+				continue
+			case quote != 0:
+				if line[k] == quote {
+					if k+1 < len(line) && line[k+1] == quote {
+						k++ // doubled quote escape
+					} else {
+						quote = 0
+					}
+				} else if line[k] == '\\' && quote == '\'' {
+					k++
+				}
+				continue
+			case dollarTag != "":
+				if strings.HasPrefix(line[k:], dollarTag) {
+					k += len(dollarTag) - 1
+					dollarTag = ""
+				}
+				continue
+			case strings.HasPrefix(line[k:], "--"):
+				k = len(line)
+				continue
+			case strings.HasPrefix(line[k:], "/*"):
+				inBlockComment = true
+				k++
+				continue
+			case line[k] == ' ' || line[k] == '\t' || line[k] == '\r':
+				continue
+			}
+
+			// Code starts (or continues) a statement
+			if start == 0 {
+				start = i + 1
+			}
+
+			if strings.HasPrefix(line[k:], delimiter) && (delimiter != ";" || parenDepth == 0 && blockDepth == 0) {
+				statements = append(statements, sqlStatement{startLine: start, endLine: i + 1})
+				start = 0
+				parenDepth, blockDepth = 0, 0
+				k += len(delimiter) - 1
+				continue
+			}
+
+			switch c := line[k]; {
+			case c == '\'' || c == '"' || c == '`':
+				quote = c
+			case c == '$':
+				if tag := sqlDollarTag(line[k:]); tag != "" {
+					dollarTag = tag
+					k += len(tag) - 1
+				}
+			case c == '(':
+				parenDepth++
+			case c == ')':
+				if parenDepth > 0 {
+					parenDepth--
+				}
+			case isIdentByte(c):
+				end := k
+				for end < len(line) && isIdentByte(line[end]) {
+					end++
+				}
+				blockDepth += sqlBlockDelta(strings.ToUpper(line[k:end]), strings.ToUpper(strings.TrimSpace(line[end:])))
+				if blockDepth < 0 {
+					blockDepth = 0 // stray END
+				}
+				k = end - 1
+			}
+		}
+	}
+
+	if start != 0 {
+		end := len(lines)
+		for end > start && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+		statements = append(statements, sqlStatement{startLine: start, endLine: end})
+	}
+	return statements
+}
+
+// sqlBlockDelta returns the block depth change for a keyword given the rest
+// of its line. BEGIN and CASE open blocks; END closes them unless it ends an
+// IF/LOOP/WHILE/REPEAT statement. Transaction statements (BEGIN;,
+// BEGIN TRANSACTION) do not open blocks.
+func sqlBlockDelta(word, rest string) int {
+	switch word {
+	case "CASE":
+		return 1
+	case "BEGIN":
+		for _, prefix := range []string{";", "TRANSACTION", "TRAN", "WORK", "DEFERRED", "IMMEDIATE", "EXCLUSIVE"} {
+			if strings.HasPrefix(rest, prefix) {
+				return 0
+			}
+		}
+		return 1
+	case "END":
+		for _, prefix := range []string{"IF", "LOOP", "WHILE", "REPEAT", "FOR"} {
+			if strings.HasPrefix(rest, prefix) && (len(rest) == len(prefix) || !isIdentByte(rest[len(prefix)])) {
+				return 0
+			}
+		}
+		return -1
+	}
+	return 0
+}
+
+// sqlDollarTag returns the dollar-quote opener at the start of s ($$ or
+// $tag$), or "" for positional parameters like $1.
+func sqlDollarTag(s string) string {
+	for k := 1; k < len(s); k++ {
+		switch {
+		case s[k] == '$':
+			return s[:k+1]
+		case s[k] >= '0' && s[k] <= '9' && k == 1:
+			return ""
+		case !isIdentByte(s[k]):
+			return ""
+		}
+	}
+	return ""
+}
+
+// mergeSmallSymbols merges small symbols into adjacent larger ones.
+func (s *SQLChunker) mergeSmallSymbols(symbols []sqlSymbol) []sqlSymbol {
+	if len(symbols) <= 1 {
+		return symbols
+	}
+
+	result := make([]sqlSymbol, 0, len(symbols))
+	var pending *sqlSymbol
+
+	for i := range symbols {
+		sym := symbols[i]
+
+		if sym.tokens < s.config.MinTokens {
+			if pending == nil {
+				pending = &sym
+			} else {
+				pending.content += "\n\n" + sym.content
+				pending.endLine = sym.endLine
+				pending.tokens = s.config.CountTokens(pending.content)
+				pending.name = pending.name + "+" + sym.name
+			}
+		} else {
+			if pending != nil {
+				if pending.tokens+sym.tokens <= s.config.MaxTokensFor(sym.symbolType) {
+					sym.content = pending.content + "\n\n" + sym.content
+					sym.startLine = pending.startLine
+					sym.tokens = s.config.CountTokens(sym.content)
+				} else {
+					result = append(result, *pending)
+				}
+				pending = nil
+			}
+			result = append(result, sym)
+		}
+	}
+
+	// Attach trailing small symbols to the previous chunk if it fits
+	if pending != nil {
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= s.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = s.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
+	}
+
+	return result
+}
+
+// chunkAsFile creates a single chunk for the entire file.
+func (s *SQLChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
+	contentStr := string(content)
+	contentHash := s.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
+	symbol := metadata.FilePath
+
+	return []Chunk{{
+		ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, symbol, contentHash),
+		Content:     contentStr,
+		Symbol:      symbol,
+		SymbolType:  "file",
+		StartLine:   1,
+		EndLine:     strings.Count(contentStr, "\n") + 1,
+		TokenCount:  s.config.CountTokens(contentStr),
+		ContentHash: contentHash,
+		ExactHash:   exactHash,
+		FilePath:    metadata.FilePath,
+		Language:    "sql",
+		Module:      metadata.Module,
+		ProjectID:   metadata.ProjectID,
+	}}
+}
@@ -0,0 +1,99 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLChunker_CreateTables(t *testing.T) {
+	chunks := chunkSource(t, NewSQLChunker(testChunkingConfig), "db/migrations/001_init.sql", "sql", `-- Registered users.
+-- Emails are unique.
+CREATE TABLE IF NOT EXISTS "users" (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL DEFAULT 'none;',
+    CHECK (email <> '')
+);
+
+CREATE TABLE public.orders (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users (id) /* ; */
+);
+`)
+
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d: %+v", len(chunks), chunks)
+	}
+
+	users := chunks[0]
+	if users.Symbol != "users" || users.SymbolType != "table" {
+		t.Errorf("Expected users table chunk, got %s (%s)", users.Symbol, users.SymbolType)
+	}
+	if !strings.HasPrefix(users.Content, "-- Registered users.\n-- Emails are unique.\nCREATE TABLE") || !strings.HasSuffix(users.Content, ");") {
+		t.Errorf("Expected users table with its comments, got:\n%s", users.Content)
+	}
+	if users.StartLine != 1 || users.EndLine != 7 || users.Language != "sql" {
+		t.Errorf("Expected users at lines 1-7, got %d-%d", users.StartLine, users.EndLine)
+	}
+
+	if orders := chunks[1]; orders.Symbol != "public.orders" || orders.StartLine != 9 || orders.EndLine != 12 {
+		t.Errorf("Expected public.orders at lines 9-12, got %s %d-%d", orders.Symbol, orders.StartLine, orders.EndLine)
+	}
+}
+
+func TestSQLChunker_RoutinesAndFragments(t *testing.T) {
+	chunks := chunkSource(t, NewSQLChunker(testChunkingConfig), "db/migrations/001_init.sql", "sql", `BEGIN;
+
+CREATE OR REPLACE FUNCTION touch_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+INSERT INTO settings (key, value) VALUES ('mode', 'a;b');
+
+DELIMITER //
+CREATE PROCEDURE archive_orders(IN cutoff DATE)
+BEGIN
+    IF cutoff IS NULL THEN
+        SET cutoff = CURDATE();
+    END IF;
+    DELETE FROM orders WHERE created_at < cutoff;
+END //
+DELIMITER ;
+
+COMMIT;
+`)
+
+	want := []struct {
+		symbol, symbolType string
+	}{
+		{"fragment_1", "statement"},
+		{"touch_updated_at", "function"},
+		{"fragment_3", "statement"},
+		{"archive_orders", "procedure"},
+		{"fragment_5", "statement"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, w := range want {
+		if chunks[i].Symbol != w.symbol || chunks[i].SymbolType != w.symbolType {
+			t.Errorf("chunk %d: expected %s (%s), got %s (%s)", i, w.symbol, w.symbolType, chunks[i].Symbol, chunks[i].SymbolType)
+		}
+	}
+
+	if fn := chunks[1]; !strings.HasSuffix(fn.Content, "$$ LANGUAGE plpgsql;") {
+		t.Errorf("Expected the dollar-quoted body kept whole, got:\n%s", fn.Content)
+	}
+	if proc := chunks[3]; proc.StartLine != 13 || proc.EndLine != 19 {
+		t.Errorf("Expected archive_orders at lines 13-19, got %d-%d", proc.StartLine, proc.EndLine)
+	}
+}
+
+func TestSQLChunker_FallbackToFile(t *testing.T) {
+	chunks := chunkSource(t, NewSQLChunker(testChunkingConfig), "db/migrations/001_init.sql", "sql", "-- nothing to see here\n")
+	if len(chunks) != 1 || chunks[0].SymbolType != "file" {
+		t.Errorf("Expected a single file chunk, got %+v", chunks)
+	}
+}
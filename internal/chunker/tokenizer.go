@@ -0,0 +1,398 @@
+// Package chunker provides token counting for chunk sizing.
+// Counts come from byte-level BPE, by default with the vendored cl100k_base
+// vocabulary; a heuristic over the same pre-tokenization is the fallback.
+package chunker
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Tokenizer counts tokens the way the embedding model would.
+type Tokenizer interface {
+	// CountTokens returns the number of tokens in text.
+	CountTokens(text string) int
+
+	// Name identifies the tokenizer in logs.
+	Name() string
+}
+
+// cl100kRanks is the cl100k_base ranks file from openai/tiktoken (MIT).
+//
+//go:embed encodings/cl100k_base.tiktoken
+var cl100kRanks []byte
+
+var (
+	defaultTokenizerOnce sync.Once
+	defaultTokenizer     Tokenizer
+)
+
+// DefaultTokenizer returns the cl100k_base BPE tokenizer, parsed from the
+// vendored ranks on first use. It falls back to the heuristic tokenizer if
+// the ranks can't be parsed.
+func DefaultTokenizer() Tokenizer {
+	defaultTokenizerOnce.Do(func() {
+		tok, err := parseBPERanks("cl100k_base", bytes.NewReader(cl100kRanks))
+		if err != nil {
+			defaultTokenizer = HeuristicTokenizer{}
+			return
+		}
+		defaultTokenizer = tok
+	})
+	return defaultTokenizer
+}
+
+// LoadTokenizer returns a BPE tokenizer for a tiktoken ranks file, or the
+// default cl100k_base tokenizer when path is empty.
+func LoadTokenizer(path string) (Tokenizer, error) {
+	if path == "" {
+		return DefaultTokenizer(), nil
+	}
+	return LoadBPETokenizer(path)
+}
+
+// HeuristicTokenizer approximates cl100k_base-style BPE counts without a
+// vocabulary: text is pre-tokenized like the BPE would be, then each piece is
+// costed by shape (words per camelCase/snake_case part, punctuation in pairs).
+type HeuristicTokenizer struct{}
+
+// Name returns the tokenizer name.
+func (HeuristicTokenizer) Name() string {
+	return "heuristic"
+}
+
+// CountTokens estimates the number of tokens in text.
+func (HeuristicTokenizer) CountTokens(text string) int {
+	total := 0
+	for _, piece := range pretokenize(text) {
+		total += heuristicPieceTokens([]rune(piece))
+	}
+	return total
+}
+
+// heuristicPieceTokens estimates the tokens of a single pre-tokenized piece.
+func heuristicPieceTokens(piece []rune) int {
+	// A leading space or symbol merges into the following word
+	if len(piece) > 1 && !unicode.IsLetter(piece[0]) && unicode.IsLetter(piece[1]) {
+		piece = piece[1:]
+	}
+
+	switch c := piece[0]; {
+	case unicode.IsLetter(c):
+		// Common words are single tokens; long identifiers split at case humps
+		tokens, hump := 0, 0
+		for i, r := range piece {
+			upperStart := i > 0 && unicode.IsUpper(r) &&
+				(unicode.IsLower(piece[i-1]) || (i+1 < len(piece) && unicode.IsLower(piece[i+1])))
+			if upperStart && hump > 0 {
+				tokens += (hump + 5) / 6
+				hump = 0
+			}
+			// Non-ASCII letters mostly end up as tokens of their own
+			if r > unicode.MaxASCII {
+				tokens += (hump+5)/6 + 1
+				hump = 0
+				continue
+			}
+			hump++
+		}
+		return tokens + (hump+5)/6
+	case unicode.IsNumber(c), unicode.IsSpace(c):
+		return 1
+	default:
+		// Punctuation runs: operators like := or () are usually one token
+		symbols := 0
+		for _, r := range piece {
+			if !unicode.IsSpace(r) {
+				symbols++
+			}
+		}
+		return max((symbols+1)/2, 1)
+	}
+}
+
+// BPETokenizer counts tokens with byte-level BPE over a ranked vocabulary,
+// as used by tiktoken encodings.
+type BPETokenizer struct {
+	name  string
+	ranks map[string]int
+}
+
+// LoadBPETokenizer reads a tiktoken ranks file: one "<base64 token> <rank>"
+// pair per line.
+func LoadBPETokenizer(path string) (*BPETokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tokenizer file: %w", err)
+	}
+	defer f.Close()
+
+	return parseBPERanks(strings.TrimSuffix(filepath.Base(path), ".tiktoken"), f)
+}
+
+// parseBPERanks parses tiktoken ranks into a tokenizer called name.
+func parseBPERanks(name string, r io.Reader) (*BPETokenizer, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("tokenizer file line %d: expected \"<base64 token> <rank>\"", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("tokenizer file line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("tokenizer file line %d: %w", line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tokenizer file: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("tokenizer file %s is empty", name)
+	}
+
+	return &BPETokenizer{name: name, ranks: ranks}, nil
+}
+
+// Name returns the encoding name (the ranks file name).
+func (t *BPETokenizer) Name() string {
+	return t.name
+}
+
+// CountTokens returns the number of BPE tokens in text.
+func (t *BPETokenizer) CountTokens(text string) int {
+	total := 0
+	for _, piece := range pretokenize(text) {
+		total += t.countPiece([]byte(piece))
+	}
+	return total
+}
+
+// longPieceBytes is the piece length from which countPiece merges through a
+// heap, keeping long symbol or whitespace runs (minified code) from taking
+// quadratic time.
+const longPieceBytes = 256
+
+// countPiece merges the lowest-ranked adjacent pair until no pair is in the
+// vocabulary, and returns the number of remaining parts.
+func (t *BPETokenizer) countPiece(piece []byte) int {
+	if _, ok := t.ranks[string(piece)]; ok {
+		return 1
+	}
+	if len(piece) >= longPieceBytes {
+		return t.countLongPiece(piece)
+	}
+
+	// bounds[i]..bounds[i+1] is the i-th part; parts start as single bytes
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := t.ranks[string(piece[bounds[i]:bounds[i+2]])]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// countLongPiece is countPiece with the candidate pairs in a heap. Stale
+// entries are skipped when popped: a pair is still current when its start
+// part is alive and the part with its successor still has the popped rank.
+func (t *BPETokenizer) countLongPiece(piece []byte) int {
+	// Parts start at every byte; next[i] is the start of the part after i
+	next := make([]int, len(piece)+1)
+	prev := make([]int, len(piece)+1)
+	for i := range next {
+		next[i], prev[i] = i+1, i-1
+	}
+	alive := make([]bool, len(piece))
+	for i := range alive {
+		alive[i] = true
+	}
+
+	pairs := &pairHeap{}
+	pairRank := func(i int) (int, bool) {
+		if i < 0 || next[i] >= len(piece) {
+			return 0, false
+		}
+		rank, ok := t.ranks[string(piece[i:next[next[i]]])]
+		return rank, ok
+	}
+	push := func(i int) {
+		if rank, ok := pairRank(i); ok {
+			heap.Push(pairs, bpePair{rank: rank, start: i})
+		}
+	}
+	for i := 0; i+1 < len(piece); i++ {
+		push(i)
+	}
+
+	parts := len(piece)
+	for pairs.Len() > 0 {
+		p := heap.Pop(pairs).(bpePair)
+		if !alive[p.start] {
+			continue
+		}
+		if rank, ok := pairRank(p.start); !ok || rank != p.rank {
+			continue
+		}
+
+		// Merge the part after p.start into it
+		merged := next[p.start]
+		alive[merged] = false
+		next[p.start] = next[merged]
+		if next[merged] < len(piece) {
+			prev[next[merged]] = p.start
+		}
+		parts--
+
+		push(prev[p.start])
+		push(p.start)
+	}
+	return parts
+}
+
+// bpePair is a candidate merge of the part starting at start with its
+// successor.
+type bpePair struct {
+	rank  int
+	start int
+}
+
+// pairHeap orders candidate merges by rank, then position, like the
+// leftmost-lowest-rank scan of countPiece.
+type pairHeap []bpePair
+
+func (h pairHeap) Len() int { return len(h) }
+func (h pairHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].start < h[j].start
+}
+func (h pairHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *pairHeap) Push(x any)   { *h = append(*h, x.(bpePair)) }
+func (h *pairHeap) Pop() any {
+	old := *h
+	p := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return p
+}
+
+// pretokenize splits text like the cl100k_base pattern:
+// contractions, words with one optional leading symbol or space, numbers of
+// up to three digits, symbol runs with an optional leading space and trailing
+// newlines, and whitespace (leaving a single space to lead the next word).
+func pretokenize(text string) []string {
+	runes := []rune(text)
+	var pieces []string
+	for i := 0; i < len(runes); {
+		n := pieceLen(runes, i)
+		pieces = append(pieces, string(runes[i:i+n]))
+		i += n
+	}
+	return pieces
+}
+
+// pieceLen returns the length in runes of the piece starting at i.
+func pieceLen(r []rune, i int) int {
+	c := r[i]
+	isNewline := func(c rune) bool { return c == '\r' || c == '\n' }
+	isSymbol := func(c rune) bool { return !unicode.IsSpace(c) && !unicode.IsLetter(c) && !unicode.IsNumber(c) }
+
+	// Contractions: 's 't 're 've 'm 'll 'd
+	if c == '\'' {
+		rest := strings.ToLower(string(r[i+1 : min(i+3, len(r))]))
+		for _, suffix := range []string{"re", "ve", "ll", "s", "t", "m", "d"} {
+			if strings.HasPrefix(rest, suffix) {
+				return 1 + len(suffix)
+			}
+		}
+	}
+
+	// Word with an optional leading non-letter, non-digit, non-newline rune
+	start := i
+	if !unicode.IsLetter(c) && !unicode.IsNumber(c) && !isNewline(c) && i+1 < len(r) && unicode.IsLetter(r[i+1]) {
+		start = i + 1
+	}
+	if unicode.IsLetter(r[start]) {
+		j := start
+		for j < len(r) && unicode.IsLetter(r[j]) {
+			j++
+		}
+		return j - i
+	}
+
+	// Numbers in groups of up to three digits
+	if unicode.IsNumber(c) {
+		j := i
+		for j < len(r) && j-i < 3 && unicode.IsNumber(r[j]) {
+			j++
+		}
+		return j - i
+	}
+
+	// Symbol run with an optional leading space and trailing newlines
+	j := i
+	if c == ' ' {
+		j++
+	}
+	if j < len(r) && isSymbol(r[j]) {
+		for j < len(r) && isSymbol(r[j]) {
+			j++
+		}
+		for j < len(r) && isNewline(r[j]) {
+			j++
+		}
+		return j - i
+	}
+
+	// Whitespace: up to the last newline of the run, otherwise all but the
+	// last rune when a word follows
+	if unicode.IsSpace(c) {
+		j, lastNewline := i, -1
+		for j < len(r) && unicode.IsSpace(r[j]) {
+			if isNewline(r[j]) {
+				lastNewline = j
+			}
+			j++
+		}
+		if lastNewline >= 0 {
+			return lastNewline + 1 - i
+		}
+		if j == len(r) || j-i == 1 {
+			return j - i
+		}
+		return j - i - 1
+	}
+
+	return 1
+}
@@ -0,0 +1,259 @@
+package chunker
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPretokenize(t *testing.T) {
+	tests := map[string][]string{
+		"hello world":              {"hello", " world"},
+		"func main() {}":           {"func", " main", "()", " {}"},
+		"import os\nimport sys\n":  {"import", " os", "\n", "import", " sys", "\n"},
+		"x := 12345":               {"x", " :=", " ", "123", "45"},
+		"it's fine":                {"it", "'s", " fine"},
+		"a  b":                     {"a", " ", " b"},
+		"fmt.Println(\"hi\")\n\n}": {"fmt", ".Println", "(\"", "hi", "\")\n\n", "}"},
+	}
+
+	for text, want := range tests {
+		if got := pretokenize(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("pretokenize(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestBPETokenizer_MergesByRank(t *testing.T) {
+	tokens := []string{"h", "e", "l", "o", " ", "w", "r", "d", "he", "ll", "hell", "hello", " w", "or", " wor"}
+	var sb strings.Builder
+	for rank, token := range tokens {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	path := filepath.Join(t.TempDir(), "mini.tiktoken")
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := LoadTokenizer(path)
+	if err != nil {
+		t.Fatalf("LoadTokenizer: %v", err)
+	}
+	if tok.Name() != "mini" {
+		t.Errorf("Name() = %q, want mini", tok.Name())
+	}
+
+	tests := map[string]int{
+		"hello":       1, // whole piece is in the vocabulary
+		"hellohello":  2, // he+ll -> hell -> hello, twice
+		" world":      3, // " w"+"or" -> " wor", then "l", "d"
+		"hello world": 4,
+	}
+	for text, want := range tests {
+		if got := tok.CountTokens(text); got != want {
+			t.Errorf("CountTokens(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestLoadTokenizer_Errors(t *testing.T) {
+	if tok, err := LoadTokenizer(""); err != nil || tok.Name() != "cl100k_base" {
+		t.Errorf("LoadTokenizer(\"\") = %v; want cl100k_base", err)
+	}
+	if _, err := LoadTokenizer(filepath.Join(t.TempDir(), "missing.tiktoken")); err == nil {
+		t.Error("expected error for missing file")
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.tiktoken")
+	if err := os.WriteFile(bad, []byte("aGVsbG8= notarank\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTokenizer(bad); err == nil {
+		t.Error("expected error for malformed rank")
+	}
+}
+
+func TestDefaultTokenizer_MatchesCl100k(t *testing.T) {
+	tok := DefaultTokenizer()
+	if tok.Name() != "cl100k_base" {
+		t.Fatalf("DefaultTokenizer() = %s, want cl100k_base", tok.Name())
+	}
+	for _, tt := range cl100kSamples {
+		if got := tok.CountTokens(tt.text); got != tt.tokens {
+			t.Errorf("CountTokens(%s sample) = %d, want %d", tt.name, got, tt.tokens)
+		}
+	}
+}
+
+func TestBPETokenizer_LongPiecesMergeLikeShortOnes(t *testing.T) {
+	tok := DefaultTokenizer().(*BPETokenizer)
+
+	// The heap merge must pick the same pairs as the linear scan
+	for _, tt := range cl100kSamples {
+		for _, piece := range pretokenize(tt.text) {
+			if got, want := tok.countLongPiece([]byte(piece)), tok.countPiece([]byte(piece)); got != want {
+				t.Errorf("countLongPiece(%q) = %d, want %d", piece, got, want)
+			}
+		}
+	}
+	for _, piece := range []string{"====", "})})})", "aaaaaaa", "\t\t\t\t\t", "Başlangıç"} {
+		if got, want := tok.countLongPiece([]byte(piece)), tok.countPiece([]byte(piece)); got != want {
+			t.Errorf("countLongPiece(%q) = %d, want %d", piece, got, want)
+		}
+	}
+
+	// Minified-style runs stay fast
+	start := time.Now()
+	if n := tok.CountTokens(strings.Repeat("})", 100000)); n == 0 {
+		t.Error("Expected tokens for a long symbol run")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Counting a 200KB symbol run took %v", elapsed)
+	}
+}
+
+func TestHeuristicTokenizer_CloseToCl100k(t *testing.T) {
+	// The fallback is within 20% per sample and 5% overall
+	var tok HeuristicTokenizer
+	got, want := 0, 0
+	for _, tt := range cl100kSamples {
+		n := tok.CountTokens(tt.text)
+		if diff := n - tt.tokens; diff*5 > tt.tokens || -diff*5 > tt.tokens {
+			t.Errorf("CountTokens(%s sample) = %d, want %d ±20%%", tt.name, n, tt.tokens)
+		}
+		got += n
+		want += tt.tokens
+	}
+	if diff := got - want; diff*20 > want || -diff*20 > want {
+		t.Errorf("Total CountTokens = %d, want %d ±5%%", got, want)
+	}
+}
+
+func TestChunkingConfig_CountTokensUsesTokenizer(t *testing.T) {
+	cfg := ChunkingConfig{}
+	if got, want := cfg.CountTokens("hello world"), EstimateTokens("hello world"); got != want {
+		t.Errorf("default CountTokens = %d, want %d", got, want)
+	}
+
+	cfg.Tokenizer = fixedTokenizer(7)
+	if got := cfg.CountTokens("hello world"); got != 7 {
+		t.Errorf("CountTokens with tokenizer = %d, want 7", got)
+	}
+}
+
+// fixedTokenizer reports the same count for any text.
+type fixedTokenizer int
+
+func (f fixedTokenizer) CountTokens(string) int { return int(f) }
+func (f fixedTokenizer) Name() string           { return "fixed" }
+
+// cl100kSamples are real-world snippets with reference counts from the
+// cl100k_base encoding (tiktoken).
+var cl100kSamples = []struct {
+	name   string
+	text   string
+	tokens int
+}{
+	{"go", `// dropSeenChunks drops chunks whose ID is in seen and adds the rest to it.
+func dropSeenChunks(chunks []chunker.Chunk, seen map[string]bool) []chunker.Chunk {
+	kept := chunks[:0]
+	for _, c := range chunks {
+		if !seen[c.ID] {
+			seen[c.ID] = true
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// LoadBPETokenizer reads a tiktoken ranks file.
+func LoadBPETokenizer(path string) (*BPETokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tokenizer file: %w", err)
+	}
+	defer f.Close()
+	return parseBPERanks(strings.TrimSuffix(filepath.Base(path), ".tiktoken"), f)
+}
+`, 179},
+	{"python", `class RateLimiter:
+    """Token bucket limiting requests per client."""
+
+    def __init__(self, rate: float, burst: int = 10) -> None:
+        self.rate = rate
+        self.burst = burst
+        self._buckets: dict[str, tuple[float, float]] = {}
+
+    def allow(self, client_id: str, now: float) -> bool:
+        tokens, last = self._buckets.get(client_id, (self.burst, now))
+        tokens = min(self.burst, tokens + (now - last) * self.rate)
+        if tokens < 1:
+            self._buckets[client_id] = (tokens, now)
+            return False
+        self._buckets[client_id] = (tokens - 1, now)
+        return True
+`, 164},
+	{"typescript", `export async function fetchProjects(baseUrl: string, signal?: AbortSignal): Promise<Project[]> {
+  const response = await fetch(baseUrl + "/projects?limit=100", { signal });
+  if (!response.ok) {
+    throw new Error("Failed to fetch projects: " + response.status + " " + response.statusText);
+  }
+  const body = (await response.json()) as { projects: Project[] };
+  return body.projects.filter((p) => p.indexedAt !== null).sort((a, b) => a.name.localeCompare(b.name));
+}
+`, 113},
+	{"sql", `CREATE TABLE IF NOT EXISTS chunk_embeddings (
+    id          TEXT PRIMARY KEY,
+    project_id  TEXT NOT NULL,
+    file_path   TEXT NOT NULL,
+    start_line  INTEGER NOT NULL DEFAULT 0,
+    embedding   vector(1024),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+SELECT project_id, COUNT(*) AS chunks, MAX(updated_at) AS last_indexed
+FROM chunk_embeddings
+WHERE project_id = $1 AND file_path LIKE 'internal/%'
+GROUP BY project_id;
+`, 106},
+	{"markdown", `## Hızlı Başlangıç
+
+Proje kaynak kökündeki opsiyonel .project-indexer.yaml dosyası, index/diff sırasında merkezi proje config'inin üzerine birleştirilir. Sadece chunking, include_extensions ve exclude_paths override edilebilir; sonuç merkezi config ile aynı şekilde doğrulanır.
+
+- Değişen dosyalar yeniden işlenir, silinenlerin chunk'ları kaldırılır.
+- Güncelleme sırasında arama kesintisiz devam eder (çalışma süresi ~12.5 sn).
+`, 147},
+	{"yaml", `server:
+  port: 8080
+  read_timeout: 30s
+  write_timeout: 60s
+
+embedding:
+  provider: "ollama"
+  model: "bge-m3"
+  batch_size: 32
+  base_url: "http://localhost:11434"
+
+chunking:
+  min_tokens: 100
+  ideal_tokens: 400
+  max_tokens: 800
+  overlap_tokens: 50
+`, 93},
+	{"json", `{
+  "project_id": "crm-backend",
+  "query": "where do we retry failed webhook deliveries?",
+  "top_k": 8,
+  "score_threshold": 0.45,
+  "filters": {"language": ["go", "sql"], "path_prefix": "internal/webhooks/"},
+  "results": [
+    {"file_path": "internal/webhooks/retry.go", "start_line": 42, "end_line": 97, "score": 0.8731}
+  ]
+}
+`, 108},
+}
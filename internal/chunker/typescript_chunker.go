@@ -107,7 +107,8 @@ func (t *TypeScriptChunker) Chunk(content []byte, metadata FileMetadata) ([]Chun
 	// Convert to chunks
 	chunks := make([]Chunk, 0, len(symbols))
 	for _, sym := range symbols {
-		contentHash := HashContent(sym.content)
+		contentHash := t.config.HashContent(sym.content)
+		exactHash := HashContent(sym.content)
 		chunk := Chunk{
 			ID:          GenerateChunkID(metadata.ProjectID, metadata.FilePath, sym.name, contentHash),
 			Content:     sym.content,
@@ -117,6 +118,7 @@ func (t *TypeScriptChunker) Chunk(content []byte, metadata FileMetadata) ([]Chun
 			EndLine:     sym.endLine,
 			TokenCount:  sym.tokens,
 			ContentHash: contentHash,
+			ExactHash:   exactHash,
 			FilePath:    metadata.FilePath,
 			Language:    metadata.Language,
 			Module:      metadata.Module,
@@ -242,7 +244,7 @@ func (t *TypeScriptChunker) extractSymbolBoundaries(content string, lines []stri
 
 		// Extract content
 		symContent := extractLines(lines, startLine, endLine)
-		tokens := EstimateTokens(symContent)
+		tokens := t.config.CountTokens(symContent)
 
 		symbols = append(symbols, tsSymbol{
 			name:       m.name,
@@ -395,16 +397,16 @@ func (t *TypeScriptChunker) mergeSmallSymbols(symbols []tsSymbol) []tsSymbol {
 				// Merge with pending
 				pending.content += "\n\n" + sym.content
 				pending.endLine = sym.endLine
-				pending.tokens = EstimateTokens(pending.content)
+				pending.tokens = t.config.CountTokens(pending.content)
 				pending.name = pending.name + "+" + sym.name
 			}
 		} else {
 			if pending != nil {
 				// Merge pending into current if total doesn't exceed max
-				if pending.tokens+sym.tokens <= t.config.MaxTokens {
+				if pending.tokens+sym.tokens <= t.config.MaxTokensFor(sym.symbolType) {
 					sym.content = pending.content + "\n\n" + sym.content
 					sym.startLine = pending.startLine
-					sym.tokens = EstimateTokens(sym.content)
+					sym.tokens = t.config.CountTokens(sym.content)
 				} else {
 					result = append(result, *pending)
 				}
@@ -414,8 +416,16 @@ func (t *TypeScriptChunker) mergeSmallSymbols(symbols []tsSymbol) []tsSymbol {
 		}
 	}
 
+	// Attach trailing small symbols to the previous chunk if it fits
 	if pending != nil {
-		result = append(result, *pending)
+		if n := len(result); n > 0 && result[n-1].tokens+pending.tokens <= t.config.MaxTokensFor(result[n-1].symbolType) {
+			last := &result[n-1]
+			last.content += "\n\n" + pending.content
+			last.endLine = pending.endLine
+			last.tokens = t.config.CountTokens(last.content)
+		} else {
+			result = append(result, *pending)
+		}
 	}
 
 	return result
@@ -424,7 +434,8 @@ func (t *TypeScriptChunker) mergeSmallSymbols(symbols []tsSymbol) []tsSymbol {
 // chunkAsFile creates a single chunk for the entire file.
 func (t *TypeScriptChunker) chunkAsFile(content []byte, metadata FileMetadata) []Chunk {
 	contentStr := string(content)
-	contentHash := HashContent(contentStr)
+	contentHash := t.config.HashContent(contentStr)
+	exactHash := HashContent(contentStr)
 	symbol := metadata.FilePath
 
 	return []Chunk{{
@@ -434,8 +445,9 @@ func (t *TypeScriptChunker) chunkAsFile(content []byte, metadata FileMetadata) [
 		SymbolType:  "file",
 		StartLine:   1,
 		EndLine:     strings.Count(contentStr, "\n") + 1,
-		TokenCount:  EstimateTokens(contentStr),
+		TokenCount:  t.config.CountTokens(contentStr),
 		ContentHash: contentHash,
+		ExactHash:   exactHash,
 		FilePath:    metadata.FilePath,
 		Language:    metadata.Language,
 		Module:      metadata.Module,
@@ -423,3 +423,113 @@ const y = 2;
 		t.Error("Expected at least one chunk for file fallback")
 	}
 }
+
+func TestTypeScriptChunker_TrailingSmallSymbolsMerge(t *testing.T) {
+	chunker := NewTypeScriptChunker(ChunkingConfig{
+		MinTokens:        50,
+		IdealTokens:      200,
+		MaxTokens:        500,
+		MergeSmallChunks: true,
+	})
+
+	content := []byte(`export function processOrder(order: Order): Result {
+    const items = order.items.filter(item => item.quantity > 0);
+    const subtotal = items.reduce((sum, item) => sum + item.price * item.quantity, 0);
+    const tax = subtotal * order.taxRate;
+    const shipping = subtotal > 100 ? 0 : order.shippingFee;
+    return { items, subtotal, tax, shipping, total: subtotal + tax + shipping };
+}
+
+function a() { return 1; }
+
+function b() { return 2; }
+
+function c() { return 3; }
+`)
+
+	chunks, err := chunker.Chunk(content, FileMetadata{FilePath: "order.ts", Language: "typescript", ProjectID: "test-project"})
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	if len(chunks) != 1 {
+		t.Fatalf("Expected trailing small symbols to merge into 1 chunk, got %d", len(chunks))
+	}
+	if chunks[0].Symbol != "processOrder" {
+		t.Errorf("Expected merged chunk symbol 'processOrder', got %s", chunks[0].Symbol)
+	}
+	for _, fn := range []string{"function a()", "function b()", "function c()"} {
+		if !strings.Contains(chunks[0].Content, fn) {
+			t.Errorf("Expected merged chunk to contain %q", fn)
+		}
+	}
+	if chunks[0].EndLine != 13 {
+		t.Errorf("Expected merged chunk to end at line 13, got %d", chunks[0].EndLine)
+	}
+}
+
+func TestTypeScriptChunker_PerSymbolTypeMaxTokens(t *testing.T) {
+	chunker := NewTypeScriptChunker(ChunkingConfig{
+		MinTokens:        20,
+		IdealTokens:      200,
+		MaxTokens:        500,
+		MaxTokensByType:  map[string]int{"function": 60, "interface": 1000},
+		MergeSmallChunks: true,
+	})
+
+	content := []byte(`type UserId = string;
+
+export interface User {
+    id: UserId;
+    name: string;
+    email: string;
+    createdAt: Date;
+    updatedAt: Date;
+    roles: string[];
+    preferences: Record<string, string>;
+}
+
+function tiny() { return 1; }
+
+export function processOrder(order: Order): Result {
+    const items = order.items.filter(item => item.quantity > 0);
+    const subtotal = items.reduce((sum, item) => sum + item.price * item.quantity, 0);
+    const tax = subtotal * order.taxRate;
+    const shipping = subtotal > 100 ? 0 : order.shippingFee;
+    return { items, subtotal, tax, shipping, total: subtotal + tax + shipping };
+}
+`)
+
+	chunks, err := chunker.Chunk(content, FileMetadata{FilePath: "user.ts", Language: "typescript", ProjectID: "test-project"})
+	if err != nil {
+		t.Fatalf("Chunk failed: %v", err)
+	}
+
+	var iface, fn *Chunk
+	for i := range chunks {
+		switch chunks[i].SymbolType {
+		case "interface":
+			iface = &chunks[i]
+		case "function":
+			if chunks[i].Symbol == "processOrder" {
+				fn = &chunks[i]
+			}
+		}
+	}
+	if iface == nil || fn == nil {
+		t.Fatalf("Expected interface and processOrder chunks, got %+v", chunks)
+	}
+
+	// Interface limit is generous: the small type alias merges into it
+	if !strings.Contains(iface.Content, "type UserId") {
+		t.Error("Expected small type alias to merge into the interface chunk")
+	}
+
+	// Function limit is tight: the small helper stays separate
+	if strings.Contains(fn.Content, "function tiny()") {
+		t.Error("Expected small helper not to merge into the function beyond its limit")
+	}
+	if len(chunks) != 3 {
+		t.Errorf("Expected 3 chunks, got %d", len(chunks))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,8 +21,10 @@ type Config struct {
 	VectorDB  VectorDBConfig  `yaml:"vectordb"`
 	Projects  ProjectsConfig  `yaml:"projects"`
 	Chunking  ChunkingConfig  `yaml:"chunking"`
+	Indexing  IndexingConfig  `yaml:"indexing"`
 	Cache     CacheConfig     `yaml:"cache"`
 	Server    ServerConfig    `yaml:"server"`
+	Rerank    RerankConfig    `yaml:"rerank"`
 	Logging   LoggingConfig   `yaml:"logging"`
 }
 
@@ -43,17 +46,63 @@ type EmbeddingConfig struct {
 	// Batch size for bulk embedding requests
 	BatchSize int `yaml:"batch_size"`
 
+	// Number of embedding batches kept in flight during indexing (1 = sequential).
+	// Values > 1 also send a small warm-up batch before the pipeline starts.
+	WarmPoolSize int `yaml:"warm_pool_size,omitempty"`
+
+	// Parallel requests per batch for providers without a batch endpoint
+	// (Ollama < 0.3); 1 = sequential
+	Concurrency int `yaml:"concurrency,omitempty"`
+
 	// Request timeout
 	Timeout string `yaml:"timeout"`
 
+	// Attempts per embedding request on transient failures (429, 5xx,
+	// connection errors), with exponential backoff; 1 = no retries
+	RetryAttempts int `yaml:"retry_attempts,omitempty"`
+
 	// Environment variable name for API key (used by OpenAI, etc.)
 	APIKeyEnv string `yaml:"api_key_env,omitempty"`
+
+	// Path to a file containing the API key (Docker/K8s secrets); wins over api_key_env
+	APIKeyFile string `yaml:"api_key_file,omitempty"`
+
+	// Prepend file path and symbol name to the embedded text (stored content is unchanged)
+	IncludePathInEmbedding bool `yaml:"include_path_in_embedding"`
+
+	// Content transforms applied to the embedded text per language, in order
+	// (stored content is unchanged). Key "*" applies to languages without an entry.
+	// Supported: strip_comments | collapse_whitespace | lowercase
+	Transforms map[string][]string `yaml:"transforms,omitempty"`
+
+	// Extra HTTP headers sent with every provider request (e.g. auth proxy tokens)
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Price per 1,000 embedding tokens for cost estimates (0 = no estimate)
+	PricePer1KTokens float64 `yaml:"price_per_1k_tokens,omitempty"`
+
+	// Keep retrying startup health checks while the model is missing (e.g. a
+	// sidecar is still pulling it); by default a missing model fails fast
+	WaitForModel bool `yaml:"wait_for_model,omitempty"`
+}
+
+// EstimateCost returns the estimated cost of the given token count.
+func (e EmbeddingConfig) EstimateCost(tokens int) float64 {
+	return float64(tokens) / 1000 * e.PricePer1KTokens
+}
+
+// TransformsFor returns the content transforms configured for a language.
+func (e EmbeddingConfig) TransformsFor(language string) []string {
+	if t, ok := e.Transforms[language]; ok {
+		return t
+	}
+	return e.Transforms["*"]
 }
 
 // VectorDBConfig holds vector database settings.
-// Supports multiple providers: qdrant, milvus, weaviate.
+// Supports multiple providers: qdrant, memory, milvus, weaviate, pgvector.
 type VectorDBConfig struct {
-	// Provider name: qdrant | milvus | weaviate
+	// Provider name: qdrant | memory | milvus | weaviate | pgvector
 	Provider string `yaml:"provider"`
 
 	// Provider endpoint URL
@@ -64,6 +113,34 @@ type VectorDBConfig struct {
 
 	// Request timeout
 	Timeout string `yaml:"timeout"`
+
+	// Whether to store raw chunk content in the payload (default: true).
+	// When false, only metadata is stored and content is resolved from source on demand.
+	StoreContent *bool `yaml:"store_content,omitempty"`
+
+	// Extra HTTP headers sent with every provider request (e.g. X-Tenant-ID)
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Environment variable name for the API key (Qdrant Cloud, Weaviate Cloud, etc.)
+	APIKeyEnv string `yaml:"api_key_env,omitempty"`
+
+	// Path to a file containing the API key (Docker/K8s secrets); wins over api_key_env
+	APIKeyFile string `yaml:"api_key_file,omitempty"`
+
+	// Tombstone deleted chunks instead of removing them (audit trail)
+	SoftDelete SoftDeleteConfig `yaml:"soft_delete,omitempty"`
+
+	// Maximum points per upsert request; larger upserts are split (default: 256)
+	UpsertBatchSize int `yaml:"upsert_batch_size,omitempty"`
+}
+
+// SoftDeleteConfig controls soft-delete tombstones in the vector store.
+type SoftDeleteConfig struct {
+	// Mark deleted chunks with deleted_at and hide them from search
+	Enabled bool `yaml:"enabled"`
+
+	// How long tombstones are kept before purge hard-deletes them (default: 720h)
+	Retention string `yaml:"retention,omitempty"`
 }
 
 // ProjectsConfig holds project discovery settings.
@@ -87,8 +164,56 @@ type ChunkingConfig struct {
 	// Maximum tokens per chunk
 	MaxTokens int `yaml:"max_tokens"`
 
+	// Per-symbol-type maximum tokens (e.g. function: 600, interface: 1200)
+	MaxTokensByType map[string]int `yaml:"max_tokens_by_type,omitempty"`
+
 	// Whether to merge small chunks into parent scope
 	MergeSmallChunks bool `yaml:"merge_small_chunks"`
+
+	// Store imported packages and called functions per Go symbol (payload imports/references)
+	ExtractRelationships bool `yaml:"extract_relationships,omitempty"`
+
+	// Hash chunk content with whitespace collapsed so reformatting doesn't force re-embedding
+	NormalizeHash bool `yaml:"normalize_hash,omitempty"`
+
+	// Tokens of the previous fragment repeated at the start of the next one by
+	// the fixed-size chunker (0 = no overlap)
+	OverlapTokens int `yaml:"overlap_tokens,omitempty"`
+
+	// tiktoken BPE ranks file (e.g. o200k_base.tiktoken) used to count tokens;
+	// empty uses the vendored cl100k_base encoding
+	TokenizerFile string `yaml:"tokenizer_file,omitempty"`
+
+	// Emit an extra "path" chunk per file whose content is the humanized path
+	// (e.g. "internal vectordb qdrant client") so path-oriented queries match
+	IndexPaths bool `yaml:"index_paths,omitempty"`
+
+	// Merge chunks of tiny single-symbol files (below min_tokens) with those of
+	// sibling files in the same directory, up to max_tokens per merged chunk
+	MergeSiblingFiles bool `yaml:"merge_sibling_files,omitempty"`
+
+	// How Go method receivers are named in symbols: type (Cache.Save, default),
+	// pointer ((*Cache).Save) or full (pointer plus type parameters,
+	// (*Cache[K]).Save). Changing it changes chunk IDs of methods.
+	ReceiverNaming string `yaml:"receiver_naming,omitempty"`
+}
+
+// IndexingConfig holds indexing run guards.
+type IndexingConfig struct {
+	// Abort a project run once the project would hold more chunks than this,
+	// counting the cached chunks of files the run doesn't reprocess
+	// (0 = unlimited). Guards against misconfigured includes (e.g.
+	// node_modules) filling the vector DB.
+	MaxChunksPerProject int `yaml:"max_chunks_per_project,omitempty"`
+
+	// Skip chunks with fewer non-comment, non-whitespace characters than this.
+	// Chunks with no such content (e.g. comment-only files) are always skipped.
+	MinChunkChars int `yaml:"min_chunk_chars,omitempty"`
+
+	// Refresh start_line/end_line of chunks whose content is unchanged but
+	// moved (e.g. lines inserted above) with a payload-only update, instead
+	// of leaving them stale. Nothing is re-embedded.
+	UpdateShiftedLines bool `yaml:"update_shifted_lines,omitempty"`
 }
 
 // CacheConfig holds index cache settings.
@@ -98,6 +223,29 @@ type CacheConfig struct {
 
 	// Cache format (currently only "json" is supported)
 	Format string `yaml:"format"`
+
+	// Format for oversized chunk reports: json | csv
+	ReportFormat string `yaml:"report_format"`
+
+	// Commit (store pending chunks and save the cache) every N indexed
+	// files during incremental runs (0 = only at the end)
+	FlushEveryN int `yaml:"flush_every_n,omitempty"`
+
+	// Commit at most this long after the previous commit during incremental
+	// runs, e.g. "30s" (empty = only at the end)
+	FlushInterval string `yaml:"flush_interval,omitempty"`
+
+	// Skip hashing files whose mod-time and size match the cache entry.
+	// Disable on CI runners that clone fresh (every mod-time changes).
+	TrustModTime bool `yaml:"trust_modtime,omitempty"`
+
+	// Keep embedding vectors on disk keyed by content hash, so unchanged
+	// chunks of reprocessed files (and full reindexes) aren't re-embedded
+	EmbeddingCache bool `yaml:"embedding_cache,omitempty"`
+
+	// Refuse to start an indexing run when the cache directory's volume has
+	// less free space than this many bytes (0 = no check)
+	MinFreeBytes int64 `yaml:"min_free_bytes,omitempty"`
 }
 
 // ServerConfig holds HTTP server settings.
@@ -113,6 +261,78 @@ type ServerConfig struct {
 
 	// Graceful shutdown timeout
 	ShutdownTimeout string `yaml:"shutdown_timeout"`
+
+	// Default number of results when a request omits top_k
+	DefaultTopK int `yaml:"default_top_k"`
+
+	// Default minimum similarity score when a request omits score_threshold
+	DefaultScoreThreshold float32 `yaml:"default_score_threshold,omitempty"`
+
+	// Score multiplier for results whose symbol appears verbatim in the query (0 or 1 = disabled)
+	ExactSymbolBoost float32 `yaml:"exact_symbol_boost,omitempty"`
+
+	// Whether JSON responses are compact (default: true) or indented
+	CompactJSON *bool `yaml:"compact_json,omitempty"`
+
+	// Boost doc or code results by detected query intent when no symbol_type filter is given
+	IntentRouting bool `yaml:"intent_routing"`
+
+	// Halve the score of results for every this much age of their file
+	// modification time, e.g. "720h" (empty = no recency decay)
+	RecencyHalfLife string `yaml:"recency_half_life,omitempty"`
+
+	// Maximum concurrent /retrieve requests (0 = unlimited)
+	MaxInFlight int `yaml:"max_in_flight"`
+
+	// Requests allowed to wait for a free slot beyond max_in_flight
+	// (nil = defaultQueueDepth; 0 rejects every request over capacity)
+	QueueDepth *int `yaml:"queue_depth,omitempty"`
+
+	// How long a queued request waits before returning 503
+	QueueTimeout string `yaml:"queue_timeout"`
+
+	// Reject /retrieve for projects without a project config (404) and flag
+	// known projects that have no indexed chunks yet
+	ValidateProjects bool `yaml:"validate_projects"`
+
+	// Start in maintenance mode (/retrieve returns 503 until toggled off)
+	Maintenance bool `yaml:"maintenance"`
+
+	// Environment variable holding the bearer token for /admin endpoints
+	AdminTokenEnv string `yaml:"admin_token_env,omitempty"`
+
+	// File holding the admin bearer token (wins over admin_token_env)
+	AdminTokenFile string `yaml:"admin_token_file,omitempty"`
+
+	// Maximum number of cached /retrieve responses (0 = result cache disabled)
+	ResultCacheSize int `yaml:"result_cache_size,omitempty"`
+
+	// How long a cached /retrieve response is served, e.g. "5m"
+	ResultCacheTTL string `yaml:"result_cache_ttl,omitempty"`
+}
+
+// RerankConfig holds the optional cross-encoder reranking step of /retrieve.
+type RerankConfig struct {
+	// Provider name: tei | cohere (empty = reranking disabled)
+	Provider string `yaml:"provider,omitempty"`
+
+	// Reranker endpoint URL (cohere default: https://api.cohere.com)
+	Endpoint string `yaml:"endpoint,omitempty"`
+
+	// Model name (cohere only; TEI serves a single model)
+	Model string `yaml:"model,omitempty"`
+
+	// Number of top candidates sent to the reranker (default: 20)
+	TopN int `yaml:"top_n,omitempty"`
+
+	// Request timeout
+	Timeout string `yaml:"timeout,omitempty"`
+
+	// Environment variable name for the API key
+	APIKeyEnv string `yaml:"api_key_env,omitempty"`
+
+	// Path to a file containing the API key; wins over api_key_env
+	APIKeyFile string `yaml:"api_key_file,omitempty"`
 }
 
 // LoggingConfig holds logging settings.
@@ -133,12 +353,10 @@ func (e *EmbeddingConfig) GetTimeout() time.Duration {
 	return d
 }
 
-// GetAPIKey returns the API key from environment variable.
+// GetAPIKey returns the API key from api_key_file, <api_key_env>_FILE
+// or the api_key_env environment variable, in that order.
 func (e *EmbeddingConfig) GetAPIKey() string {
-	if e.APIKeyEnv == "" {
-		return ""
-	}
-	return os.Getenv(e.APIKeyEnv)
+	return resolveSecret(e.APIKeyEnv, e.APIKeyFile)
 }
 
 // GetTimeout parses and returns the vectordb timeout duration.
@@ -150,6 +368,51 @@ func (v *VectorDBConfig) GetTimeout() time.Duration {
 	return d
 }
 
+// GetAPIKey returns the API key from api_key_file, <api_key_env>_FILE
+// or the api_key_env environment variable, in that order.
+func (v *VectorDBConfig) GetAPIKey() string {
+	return resolveSecret(v.APIKeyEnv, v.APIKeyFile)
+}
+
+// GetTimeout parses and returns the reranker timeout duration.
+func (r *RerankConfig) GetTimeout() time.Duration {
+	d, err := time.ParseDuration(r.Timeout)
+	if err != nil {
+		return 10 * time.Second
+	}
+	return d
+}
+
+// GetAPIKey returns the API key from api_key_file, <api_key_env>_FILE
+// or the api_key_env environment variable, in that order.
+func (r *RerankConfig) GetAPIKey() string {
+	return resolveSecret(r.APIKeyEnv, r.APIKeyFile)
+}
+
+// GetRetention parses and returns the tombstone retention period.
+func (s *SoftDeleteConfig) GetRetention() time.Duration {
+	d, err := time.ParseDuration(s.Retention)
+	if err != nil {
+		return 720 * time.Hour
+	}
+	return d
+}
+
+// GetFlushInterval parses and returns the periodic cache commit interval
+// (0 when unset or invalid).
+func (c *CacheConfig) GetFlushInterval() time.Duration {
+	d, err := time.ParseDuration(c.FlushInterval)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// ShouldStoreContent reports whether chunk content is stored in the vector payload.
+func (v *VectorDBConfig) ShouldStoreContent() bool {
+	return v.StoreContent == nil || *v.StoreContent
+}
+
 // GetReadTimeout parses and returns the server read timeout.
 func (s *ServerConfig) GetReadTimeout() time.Duration {
 	d, err := time.ParseDuration(s.ReadTimeout)
@@ -177,30 +440,95 @@ func (s *ServerConfig) GetShutdownTimeout() time.Duration {
 	return d
 }
 
+// GetAdminToken returns the admin bearer token, or "" when admin endpoints are disabled.
+func (s *ServerConfig) GetAdminToken() string {
+	return resolveSecret(s.AdminTokenEnv, s.AdminTokenFile)
+}
+
+// defaultQueueDepth is the request queue depth when queue_depth is unset.
+const defaultQueueDepth = 32
+
+// GetQueueDepth returns the number of requests allowed to wait for a slot.
+func (s *ServerConfig) GetQueueDepth() int {
+	if s.QueueDepth == nil {
+		return defaultQueueDepth
+	}
+	return *s.QueueDepth
+}
+
+// GetQueueTimeout parses and returns the request queue wait timeout.
+func (s *ServerConfig) GetQueueTimeout() time.Duration {
+	d, err := time.ParseDuration(s.QueueTimeout)
+	if err != nil {
+		return 5 * time.Second
+	}
+	return d
+}
+
+// GetResultCacheTTL parses and returns the result cache TTL.
+func (s *ServerConfig) GetResultCacheTTL() time.Duration {
+	d, err := time.ParseDuration(s.ResultCacheTTL)
+	if err != nil {
+		return 5 * time.Minute
+	}
+	return d
+}
+
+// GetRecencyHalfLife parses and returns the recency decay half-life
+// (0 = recency decay disabled).
+func (s *ServerConfig) GetRecencyHalfLife() time.Duration {
+	d, err := time.ParseDuration(s.RecencyHalfLife)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// IsCompactJSON reports whether JSON responses should be compact.
+func (s *ServerConfig) IsCompactJSON() bool {
+	return s.CompactJSON == nil || *s.CompactJSON
+}
+
 // Manager handles configuration loading and hot reload.
 type Manager struct {
-	configPath string
-	config     *Config
-	mu         sync.RWMutex
-	onChange   []func(*Config)
+	configPaths []string
+	config      *Config
+	mu          sync.RWMutex
+	onChange    []func(*Config)
 }
 
 // NewManager creates a new configuration manager.
+// configPath may list several comma-separated files; later files are
+// deep-merged over earlier ones (e.g. "config.yaml,config.prod.yaml").
 func NewManager(configPath string) *Manager {
 	return &Manager{
-		configPath: configPath,
-		onChange:   make([]func(*Config), 0),
+		configPaths: splitConfigPaths(configPath),
+		onChange:    make([]func(*Config), 0),
 	}
 }
 
-// Load reads and parses the configuration file.
+// Load reads, merges and parses the configuration files.
 func (m *Manager) Load() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	data, err := os.ReadFile(m.configPath)
+	merged := make(map[string]interface{})
+	for _, path := range m.configPaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		var layer map[string]interface{}
+		if err := yaml.Unmarshal(data, &layer); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		merged = mergeYAMLMaps(merged, layer)
+	}
+
+	data, err := yaml.Marshal(merged)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return fmt.Errorf("failed to merge config files: %w", err)
 	}
 
 	var cfg Config
@@ -270,6 +598,15 @@ func applyDefaults(cfg *Config) {
 	if cfg.Embedding.Timeout == "" {
 		cfg.Embedding.Timeout = "30s"
 	}
+	if cfg.Embedding.WarmPoolSize == 0 {
+		cfg.Embedding.WarmPoolSize = 1
+	}
+	if cfg.Embedding.Concurrency == 0 {
+		cfg.Embedding.Concurrency = 1
+	}
+	if cfg.Embedding.RetryAttempts == 0 {
+		cfg.Embedding.RetryAttempts = 3
+	}
 
 	// VectorDB defaults
 	if cfg.VectorDB.Provider == "" {
@@ -284,6 +621,9 @@ func applyDefaults(cfg *Config) {
 	if cfg.VectorDB.Timeout == "" {
 		cfg.VectorDB.Timeout = "30s"
 	}
+	if cfg.VectorDB.UpsertBatchSize == 0 {
+		cfg.VectorDB.UpsertBatchSize = 256
+	}
 
 	// Projects defaults
 	if cfg.Projects.ConfigDir == "" {
@@ -311,6 +651,9 @@ func applyDefaults(cfg *Config) {
 	if cfg.Cache.Format == "" {
 		cfg.Cache.Format = "json"
 	}
+	if cfg.Cache.ReportFormat == "" {
+		cfg.Cache.ReportFormat = "json"
+	}
 
 	// Server defaults
 	if cfg.Server.Port == 0 {
@@ -325,6 +668,14 @@ func applyDefaults(cfg *Config) {
 	if cfg.Server.ShutdownTimeout == "" {
 		cfg.Server.ShutdownTimeout = "10s"
 	}
+	if cfg.Server.DefaultTopK == 0 {
+		cfg.Server.DefaultTopK = 5
+	}
+
+	// Rerank defaults
+	if cfg.Rerank.TopN == 0 {
+		cfg.Rerank.TopN = 20
+	}
 
 	// Logging defaults
 	if cfg.Logging.Level == "" {
@@ -349,21 +700,125 @@ func validate(cfg *Config) error {
 	if cfg.Embedding.Dimensions <= 0 {
 		return fmt.Errorf("embedding dimensions must be positive")
 	}
+	if cfg.Embedding.WarmPoolSize < 1 {
+		return fmt.Errorf("embedding warm_pool_size must be at least 1")
+	}
+	if cfg.Embedding.Concurrency < 1 {
+		return fmt.Errorf("embedding concurrency must be at least 1")
+	}
+	if cfg.Embedding.RetryAttempts < 1 {
+		return fmt.Errorf("embedding retry_attempts must be at least 1")
+	}
+	if cfg.Embedding.PricePer1KTokens < 0 {
+		return fmt.Errorf("embedding price_per_1k_tokens must not be negative")
+	}
+	validTransforms := map[string]bool{
+		"strip_comments":      true,
+		"collapse_whitespace": true,
+		"lowercase":           true,
+	}
+	for language, transforms := range cfg.Embedding.Transforms {
+		for _, t := range transforms {
+			if !validTransforms[t] {
+				return fmt.Errorf("invalid embedding transform for %s: %s", language, t)
+			}
+		}
+	}
 
 	// Validate vectordb config
 	validVectorDBProviders := map[string]bool{
 		"qdrant":   true,
+		"memory":   true,
 		"milvus":   true,
 		"weaviate": true,
+		"pgvector": true,
 	}
 	if !validVectorDBProviders[cfg.VectorDB.Provider] {
 		return fmt.Errorf("invalid vectordb provider: %s", cfg.VectorDB.Provider)
 	}
+	if r := cfg.VectorDB.SoftDelete.Retention; r != "" {
+		if d, err := time.ParseDuration(r); err != nil || d < 0 {
+			return fmt.Errorf("invalid vectordb soft_delete retention: %s", r)
+		}
+	}
 
 	// Validate chunking config
 	if cfg.Chunking.MinTokens >= cfg.Chunking.MaxTokens {
 		return fmt.Errorf("min_tokens must be less than max_tokens")
 	}
+	for symbolType, max := range cfg.Chunking.MaxTokensByType {
+		if max <= cfg.Chunking.MinTokens {
+			return fmt.Errorf("max_tokens_by_type.%s must be greater than min_tokens", symbolType)
+		}
+	}
+	if cfg.Chunking.OverlapTokens < 0 {
+		return fmt.Errorf("chunking overlap_tokens must not be negative")
+	}
+	if cfg.Chunking.OverlapTokens >= cfg.Chunking.MaxTokens {
+		return fmt.Errorf("overlap_tokens must be less than max_tokens")
+	}
+	if f := cfg.Chunking.TokenizerFile; f != "" {
+		if _, err := os.Stat(f); err != nil {
+			return fmt.Errorf("invalid chunking tokenizer_file: %w", err)
+		}
+	}
+	switch cfg.Chunking.ReceiverNaming {
+	case "", "type", "pointer", "full":
+	default:
+		return fmt.Errorf("invalid chunking receiver_naming: %s (supported: type, pointer, full)", cfg.Chunking.ReceiverNaming)
+	}
+
+	if cfg.Server.ExactSymbolBoost < 0 {
+		return fmt.Errorf("server exact_symbol_boost must not be negative")
+	}
+	if cfg.Server.MaxInFlight < 0 || cfg.Server.GetQueueDepth() < 0 {
+		return fmt.Errorf("server max_in_flight and queue_depth must not be negative")
+	}
+	if cfg.Server.ResultCacheSize < 0 {
+		return fmt.Errorf("server result_cache_size must not be negative")
+	}
+	if h := cfg.Server.RecencyHalfLife; h != "" {
+		if d, err := time.ParseDuration(h); err != nil || d < 0 {
+			return fmt.Errorf("invalid server recency_half_life: %s", h)
+		}
+	}
+
+	// Validate rerank config
+	switch cfg.Rerank.Provider {
+	case "", "cohere":
+	case "tei":
+		if cfg.Rerank.Endpoint == "" {
+			return fmt.Errorf("rerank endpoint is required for provider tei")
+		}
+	default:
+		return fmt.Errorf("invalid rerank provider: %s (supported: tei, cohere)", cfg.Rerank.Provider)
+	}
+	if cfg.Rerank.TopN < 0 {
+		return fmt.Errorf("rerank top_n must not be negative")
+	}
+
+	if cfg.Indexing.MaxChunksPerProject < 0 {
+		return fmt.Errorf("indexing max_chunks_per_project must not be negative")
+	}
+	if cfg.Indexing.MinChunkChars < 0 {
+		return fmt.Errorf("indexing min_chunk_chars must not be negative")
+	}
+
+	// Validate report format
+	if cfg.Cache.ReportFormat != "json" && cfg.Cache.ReportFormat != "csv" {
+		return fmt.Errorf("invalid cache report_format: %s (supported: json, csv)", cfg.Cache.ReportFormat)
+	}
+	if cfg.Cache.FlushEveryN < 0 {
+		return fmt.Errorf("cache flush_every_n must not be negative")
+	}
+	if i := cfg.Cache.FlushInterval; i != "" {
+		if d, err := time.ParseDuration(i); err != nil || d < 0 {
+			return fmt.Errorf("invalid cache flush_interval: %s", i)
+		}
+	}
+	if cfg.Cache.MinFreeBytes < 0 {
+		return fmt.Errorf("cache min_free_bytes must not be negative")
+	}
 
 	// Validate server port
 	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
@@ -373,23 +828,27 @@ func validate(cfg *Config) error {
 	return nil
 }
 
-// LoadFromEnv loads configuration from the path specified in CONFIG_PATH env var.
+// LoadFromEnv loads configuration from the path(s) specified in CONFIG_PATH env var.
+// Multiple comma-separated paths are merged in order.
 func LoadFromEnv() (*Manager, error) {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
 		configPath = "configs/config.yaml"
 	}
 
-	// Make path absolute
-	if !filepath.IsAbs(configPath) {
-		wd, err := os.Getwd()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get working directory: %w", err)
+	// Make paths absolute
+	paths := splitConfigPaths(configPath)
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			wd, err := os.Getwd()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get working directory: %w", err)
+			}
+			paths[i] = filepath.Join(wd, path)
 		}
-		configPath = filepath.Join(wd, configPath)
 	}
 
-	manager := NewManager(configPath)
+	manager := NewManager(strings.Join(paths, ","))
 	if err := manager.Load(); err != nil {
 		return nil, err
 	}
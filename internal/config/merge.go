@@ -0,0 +1,34 @@
+package config
+
+import "strings"
+
+// splitConfigPaths splits a comma-separated list of config file paths,
+// dropping empty entries.
+func splitConfigPaths(configPath string) []string {
+	var paths []string
+	for _, p := range strings.Split(configPath, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// mergeYAMLMaps deep-merges overlay into base and returns base.
+// Nested maps are merged key by key; any other value in overlay
+// (scalars, lists, explicit nulls) replaces the base value.
+func mergeYAMLMaps(base, overlay map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{})
+	}
+	for key, overlayVal := range overlay {
+		overlayMap, overlayIsMap := overlayVal.(map[string]interface{})
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		if overlayIsMap && baseIsMap {
+			base[key] = mergeYAMLMaps(baseMap, overlayMap)
+			continue
+		}
+		base[key] = overlayVal
+	}
+	return base
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManager_LoadMergesOverlay(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	overlay := filepath.Join(dir, "config.prod.yaml")
+
+	os.WriteFile(base, []byte(`
+embedding:
+  model: "nomic-embed-text"
+  batch_size: 16
+server:
+  port: 8080
+  default_top_k: 3
+logging:
+  level: "debug"
+`), 0644)
+	os.WriteFile(overlay, []byte(`
+embedding:
+  batch_size: 64
+server:
+  port: 9090
+logging:
+  level: "warn"
+`), 0644)
+
+	m := NewManager(base + "," + overlay)
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	cfg := m.Get()
+
+	// Overlay values win
+	if cfg.Embedding.BatchSize != 64 {
+		t.Errorf("Expected batch_size 64 from overlay, got %d", cfg.Embedding.BatchSize)
+	}
+	if cfg.Server.Port != 9090 {
+		t.Errorf("Expected port 9090 from overlay, got %d", cfg.Server.Port)
+	}
+	if cfg.Logging.Level != "warn" {
+		t.Errorf("Expected level warn from overlay, got %s", cfg.Logging.Level)
+	}
+
+	// Unspecified base values persist
+	if cfg.Embedding.Model != "nomic-embed-text" {
+		t.Errorf("Expected model from base, got %s", cfg.Embedding.Model)
+	}
+	if cfg.Server.DefaultTopK != 3 {
+		t.Errorf("Expected default_top_k 3 from base, got %d", cfg.Server.DefaultTopK)
+	}
+}
+
+func TestMergeYAMLMaps_ListsReplaced(t *testing.T) {
+	base := map[string]interface{}{
+		"a": map[string]interface{}{"list": []interface{}{1, 2}, "keep": "x"},
+	}
+	overlay := map[string]interface{}{
+		"a": map[string]interface{}{"list": []interface{}{3}},
+	}
+
+	merged := mergeYAMLMaps(base, overlay)
+	a := merged["a"].(map[string]interface{})
+	if list := a["list"].([]interface{}); len(list) != 1 || list[0] != 3 {
+		t.Errorf("Expected overlay list to replace base list, got %v", list)
+	}
+	if a["keep"] != "x" {
+		t.Errorf("Expected base key to persist, got %v", a["keep"])
+	}
+}
+
+func TestSplitConfigPaths(t *testing.T) {
+	paths := splitConfigPaths(" base.yaml, ,prod.yaml ")
+	if len(paths) != 2 || paths[0] != "base.yaml" || paths[1] != "prod.yaml" {
+		t.Errorf("Unexpected paths: %v", paths)
+	}
+}
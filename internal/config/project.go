@@ -2,11 +2,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"golang.org/x/text/encoding/htmlindex"
 	"gopkg.in/yaml.v3"
 )
 
@@ -28,11 +34,106 @@ type ProjectConfig struct {
 	// Paths/patterns to exclude from indexing
 	ExcludePaths []string `yaml:"exclude_paths"`
 
+	// Skip files ignored by .gitignore files in the source tree (and in its
+	// ancestors up to the git root)
+	RespectGitignore bool `yaml:"respect_gitignore,omitempty"`
+
+	// Regex patterns matched against chunk symbols; matching chunks are not
+	// indexed (e.g. "^get[A-Z]" for generated accessors)
+	ExcludeSymbols []string `yaml:"exclude_symbols,omitempty"`
+
+	// Anchor for stored file paths: source (default, relative to source_path)
+	// or git (relative to the nearest ancestor containing .git)
+	PathRoot string `yaml:"path_root,omitempty"`
+
+	// File ownership capture: "" (off), codeowners (CODEOWNERS under the
+	// path root) or git (top contributor from git shortlog)
+	Ownership string `yaml:"ownership,omitempty"`
+
+	// Allowed source encodings for non-UTF-8 files (e.g. windows-1252, iso-8859-1),
+	// tried in order and transcoded to UTF-8 before chunking. Empty = read as-is.
+	Encodings []string `yaml:"encodings,omitempty"`
+
 	// Chunking configuration overrides
 	Chunking ProjectChunkingConfig `yaml:"chunking"`
 
 	// Optional metadata for filtering
 	Metadata ProjectMetadata `yaml:"metadata"`
+
+	// Retrieval defaults for this project (override server defaults)
+	Retrieval ProjectRetrievalConfig `yaml:"retrieval"`
+
+	// Link template for retrieve results (optional)
+	SourceURL SourceURLConfig `yaml:"source_url,omitempty"`
+
+	// File the config was loaded from, with its mod time and size at load
+	file    string
+	modTime time.Time
+	size    int64
+}
+
+// ProjectRetrievalConfig holds project-specific retrieval defaults.
+type ProjectRetrievalConfig struct {
+	// Default number of results when a request omits top_k (optional)
+	DefaultTopK int `yaml:"default_top_k,omitempty"`
+
+	// Default minimum similarity score when a request omits score_threshold
+	// (optional; nil = server default, so 0 disables the server's threshold)
+	DefaultScoreThreshold *float32 `yaml:"default_score_threshold,omitempty"`
+}
+
+// SourceURLConfig builds clickable links (GitHub/GitLab/IDE) for results.
+type SourceURLConfig struct {
+	// URL template with placeholders {repo}, {ref}, {path}, {start}, {end}
+	// e.g. "https://git.example.com/{repo}/blob/{ref}/{path}#L{start}-L{end}"
+	Template string `yaml:"template,omitempty"`
+
+	// Value for {repo} (e.g. "team/crm-backend")
+	Repo string `yaml:"repo,omitempty"`
+
+	// Fallback for {ref} when a chunk has no stored git_ref (e.g. "main")
+	Ref string `yaml:"ref,omitempty"`
+}
+
+// Expand renders the template for a result. The stored gitRef wins over the
+// configured Ref. Returns "" when no template is set or a placeholder it
+// uses has no value.
+func (c SourceURLConfig) Expand(gitRef, path string, startLine, endLine int) string {
+	if c.Template == "" {
+		return ""
+	}
+	ref := gitRef
+	if ref == "" {
+		ref = c.Ref
+	}
+
+	lineValue := func(n int) string {
+		if n <= 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+
+	// Expanded in a fixed order so values containing braces are never re-expanded
+	values := [][2]string{
+		{"{repo}", c.Repo},
+		{"{ref}", ref},
+		{"{start}", lineValue(startLine)},
+		{"{end}", lineValue(endLine)},
+		{"{path}", path},
+	}
+
+	url := c.Template
+	for _, kv := range values {
+		if !strings.Contains(url, kv[0]) {
+			continue
+		}
+		if kv[1] == "" {
+			return ""
+		}
+		url = strings.ReplaceAll(url, kv[0], kv[1])
+	}
+	return url
 }
 
 // ProjectChunkingConfig holds project-specific chunking settings.
@@ -51,6 +152,12 @@ type ProjectChunkingConfig struct {
 
 	// Override for maximum tokens (optional)
 	MaxTokens int `yaml:"max_tokens,omitempty"`
+
+	// Per-symbol-type maximum token overrides, merged over global ones (optional)
+	MaxTokensByType map[string]int `yaml:"max_tokens_by_type,omitempty"`
+
+	// Enable merging of tiny sibling files for this project (optional)
+	MergeSiblingFiles bool `yaml:"merge_sibling_files,omitempty"`
 }
 
 // CodeChunkingConfig holds code-specific chunking settings.
@@ -72,6 +179,22 @@ type ProjectMetadata struct {
 
 	// Tags for categorization
 	Tags []string `yaml:"tags,omitempty"`
+
+	// Custom key/value metadata stored on every chunk and filterable at
+	// retrieve time (e.g. service: billing, criticality: high)
+	Custom map[string]string `yaml:"custom,omitempty"`
+}
+
+// customKeyPattern matches valid custom metadata keys: lowercase snake_case,
+// so they are safe as payload field names in every provider.
+var customKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// ValidateCustomKey checks a custom metadata key name.
+func ValidateCustomKey(key string) error {
+	if !customKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid custom metadata key %q (lowercase letters, digits and underscores, starting with a letter, max 64)", key)
+	}
+	return nil
 }
 
 // GetFullSourcePath returns the absolute path to the project source.
@@ -79,6 +202,35 @@ func (p *ProjectConfig) GetFullSourcePath(basePath string) string {
 	return filepath.Join(basePath, p.SourcePath)
 }
 
+// GetPathRoot returns the directory stored file paths are made relative to.
+// With path_root "git" this is the nearest ancestor of sourceRoot (inclusive)
+// containing .git, so paths stay stable across different mount points.
+// Falls back to sourceRoot when no repository root is found.
+func (p *ProjectConfig) GetPathRoot(sourceRoot string) string {
+	if p.PathRoot != "git" {
+		return sourceRoot
+	}
+	if root := FindGitRoot(sourceRoot); root != "" {
+		return root
+	}
+	return sourceRoot
+}
+
+// FindGitRoot returns the nearest ancestor of dir (inclusive) containing
+// .git, or "" when dir is not inside a repository.
+func FindGitRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
 // ShouldIncludeFile checks if a file should be included based on extension.
 func (p *ProjectConfig) ShouldIncludeFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -114,6 +266,19 @@ func (p *ProjectConfig) ShouldExcludePath(path string) bool {
 	return false
 }
 
+// ExcludeSymbolPatterns compiles the exclude_symbols regex patterns.
+func (p *ProjectConfig) ExcludeSymbolPatterns() ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(p.ExcludeSymbols))
+	for _, expr := range p.ExcludeSymbols {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_symbols pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
 // GetChunkingStrategy returns the appropriate chunking strategy for a file.
 func (p *ProjectConfig) GetChunkingStrategy(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -147,6 +312,19 @@ func (p *ProjectConfig) GetEffectiveChunking(global ChunkingConfig) ChunkingConf
 	if p.Chunking.MaxTokens > 0 {
 		result.MaxTokens = p.Chunking.MaxTokens
 	}
+	if len(p.Chunking.MaxTokensByType) > 0 {
+		merged := make(map[string]int, len(global.MaxTokensByType)+len(p.Chunking.MaxTokensByType))
+		for k, v := range global.MaxTokensByType {
+			merged[k] = v
+		}
+		for k, v := range p.Chunking.MaxTokensByType {
+			merged[k] = v
+		}
+		result.MaxTokensByType = merged
+	}
+	if p.Chunking.MergeSiblingFiles {
+		result.MergeSiblingFiles = true
+	}
 
 	return result
 }
@@ -172,6 +350,37 @@ func (p *ProjectConfig) Validate() error {
 		return fmt.Errorf("at least one include_extension is required")
 	}
 
+	if p.PathRoot != "" && p.PathRoot != "source" && p.PathRoot != "git" {
+		return fmt.Errorf("invalid path_root: %s (supported: source, git)", p.PathRoot)
+	}
+
+	if p.Ownership != "" && p.Ownership != "codeowners" && p.Ownership != "git" {
+		return fmt.Errorf("invalid ownership: %s (supported: codeowners, git)", p.Ownership)
+	}
+
+	if _, err := p.ExcludeSymbolPatterns(); err != nil {
+		return err
+	}
+
+	for key := range p.Metadata.Custom {
+		if err := ValidateCustomKey(key); err != nil {
+			return fmt.Errorf("metadata.custom: %w", err)
+		}
+	}
+
+	for _, name := range p.Encodings {
+		if _, err := htmlindex.Get(name); err != nil {
+			return fmt.Errorf("invalid encoding: %s", name)
+		}
+	}
+
+	if p.Retrieval.DefaultTopK < 0 {
+		return fmt.Errorf("retrieval.default_top_k must not be negative")
+	}
+	if t := p.Retrieval.DefaultScoreThreshold; t != nil && (*t < 0 || *t > 1) {
+		return fmt.Errorf("retrieval.default_score_threshold must be between 0 and 1")
+	}
+
 	// Validate code chunking strategy
 	validCodeStrategies := map[string]bool{
 		"function": true,
@@ -199,6 +408,10 @@ func (p *ProjectConfig) Validate() error {
 
 // LoadProjectConfig loads a single project configuration from file.
 func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project config: %w", err)
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read project config: %w", err)
@@ -208,6 +421,7 @@ func LoadProjectConfig(path string) (*ProjectConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse project config: %w", err)
 	}
+	cfg.file, cfg.modTime, cfg.size = path, info.ModTime(), info.Size()
 
 	// Apply defaults
 	applyProjectDefaults(&cfg)
@@ -219,9 +433,72 @@ func LoadProjectConfig(path string) (*ProjectConfig, error) {
 	return &cfg, nil
 }
 
+// RepoOverridesFile is the optional file at the root of a project's source
+// tree whose settings are merged over the central project config.
+const RepoOverridesFile = ".project-indexer.yaml"
+
+// repoOverrideKeys are the top-level keys an in-repo overrides file may set.
+var repoOverrideKeys = map[string]bool{
+	"chunking":           true,
+	"include_extensions": true,
+	"exclude_paths":      true,
+}
+
+// WithRepoOverrides returns the config with the in-repo overrides file under
+// sourcePath deep-merged over it (lists replace), or p itself when the file
+// does not exist. The merged config is validated like a central one.
+func (p *ProjectConfig) WithRepoOverrides(sourcePath string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(filepath.Join(sourcePath, RepoOverridesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", RepoOverridesFile, err)
+	}
+
+	var overlay map[string]interface{}
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RepoOverridesFile, err)
+	}
+	for key := range overlay {
+		if !repoOverrideKeys[key] {
+			return nil, fmt.Errorf("%s: %s cannot be overridden in-repo (supported: chunking, include_extensions, exclude_paths)",
+				RepoOverridesFile, key)
+		}
+	}
+
+	baseData, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode project config: %w", err)
+	}
+	var base map[string]interface{}
+	if err := yaml.Unmarshal(baseData, &base); err != nil {
+		return nil, fmt.Errorf("failed to encode project config: %w", err)
+	}
+	merged, err := yaml.Marshal(mergeYAMLMaps(base, overlay))
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge %s: %w", RepoOverridesFile, err)
+	}
+
+	var cfg ProjectConfig
+	if err := yaml.Unmarshal(merged, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to merge %s: %w", RepoOverridesFile, err)
+	}
+	applyProjectDefaults(&cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s validation failed: %w", RepoOverridesFile, err)
+	}
+
+	return &cfg, nil
+}
+
 // LoadAllProjects loads all project configurations from the config directory.
+// Files that fail to load don't stop the others: the projects that loaded are
+// returned together with the joined per-file errors (see ProjectLoadError).
 func LoadAllProjects(configDir string) (map[string]*ProjectConfig, error) {
 	projects := make(map[string]*ProjectConfig)
+	var loadErrs []error
 
 	entries, err := os.ReadDir(configDir)
 	if err != nil {
@@ -242,15 +519,34 @@ func LoadAllProjects(configDir string) (map[string]*ProjectConfig, error) {
 		path := filepath.Join(configDir, name)
 		cfg, err := LoadProjectConfig(path)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load %s: %w", name, err)
+			loadErrs = append(loadErrs, &ProjectLoadError{File: name, Err: err})
+			continue
 		}
 
 		projects[cfg.ProjectID] = cfg
 	}
 
-	return projects, nil
+	return projects, errors.Join(loadErrs...)
+}
+
+// ProjectLoadError reports a project config file that failed to load.
+type ProjectLoadError struct {
+	File string
+	Err  error
+}
+
+func (e *ProjectLoadError) Error() string {
+	return fmt.Sprintf("failed to load %s: %v", e.File, e.Err)
+}
+
+func (e *ProjectLoadError) Unwrap() error {
+	return e.Err
 }
 
+// ErrProjectNotFound is returned by GetProject when no project config
+// declares the requested project ID.
+var ErrProjectNotFound = errors.New("project not found")
+
 // GetProject loads a specific project configuration by ID.
 func GetProject(configDir, projectID string) (*ProjectConfig, error) {
 	// Try common file naming patterns
@@ -275,7 +571,48 @@ func GetProject(configDir, projectID string) (*ProjectConfig, error) {
 		return cfg, nil
 	}
 
-	return nil, fmt.Errorf("project not found: %s", projectID)
+	return nil, fmt.Errorf("%w: %s", ErrProjectNotFound, projectID)
+}
+
+// ProjectCache memoizes GetProject for long-running readers such as the API
+// server. A cached config is reused while its file's mod time and size are
+// unchanged, so edits are picked up with a stat instead of a full reload.
+// Lookups that fail are not cached.
+type ProjectCache struct {
+	mu      sync.Mutex
+	entries map[string]*ProjectConfig // by config dir + project ID
+}
+
+// NewProjectCache creates an empty project config cache.
+func NewProjectCache() *ProjectCache {
+	return &ProjectCache{entries: make(map[string]*ProjectConfig)}
+}
+
+// Get returns the project config like GetProject, reusing the cached one
+// when its file has not changed. The returned config must not be modified.
+func (c *ProjectCache) Get(configDir, projectID string) (*ProjectConfig, error) {
+	key := configDir + "\x00" + projectID
+
+	c.mu.Lock()
+	cached := c.entries[key]
+	c.mu.Unlock()
+
+	if cached != nil {
+		info, err := os.Stat(cached.file)
+		if err == nil && info.ModTime().Equal(cached.modTime) && info.Size() == cached.size {
+			return cached, nil
+		}
+	}
+
+	cfg, err := GetProject(configDir, projectID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		delete(c.entries, key)
+		return nil, err
+	}
+	c.entries[key] = cfg
+	return cfg, nil
 }
 
 // applyProjectDefaults sets default values for missing project configuration fields.
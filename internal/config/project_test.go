@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProjectConfig_WithRepoOverrides(t *testing.T) {
+	central := &ProjectConfig{
+		ProjectID:         "crm",
+		SourcePath:        "crm",
+		IncludeExtensions: []string{".go", ".md"},
+		ExcludePaths:      []string{"vendor/", "testdata/"},
+		Chunking: ProjectChunkingConfig{
+			Code:      CodeChunkingConfig{Strategy: "function"},
+			MinTokens: 100,
+			MaxTokens: 800,
+		},
+		Retrieval: ProjectRetrievalConfig{DefaultTopK: 7},
+	}
+
+	// Without an in-repo file the central config is used as-is
+	dir := t.TempDir()
+	got, err := central.WithRepoOverrides(dir)
+	if err != nil || got != central {
+		t.Fatalf("Expected central config unchanged, got %+v, %v", got, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, RepoOverridesFile), []byte(`
+exclude_paths:
+  - "generated/"
+chunking:
+  max_tokens: 400
+`), 0644)
+
+	got, err = central.WithRepoOverrides(dir)
+	if err != nil {
+		t.Fatalf("WithRepoOverrides failed: %v", err)
+	}
+	if !reflect.DeepEqual(got.ExcludePaths, []string{"generated/"}) {
+		t.Errorf("Expected in-repo exclude_paths to replace central ones, got %v", got.ExcludePaths)
+	}
+	if got.Chunking.MaxTokens != 400 || got.Chunking.MinTokens != 100 || got.Chunking.Code.Strategy != "function" {
+		t.Errorf("Expected chunking merged key by key, got %+v", got.Chunking)
+	}
+	if got.ProjectID != "crm" || got.Retrieval.DefaultTopK != 7 || len(got.IncludeExtensions) != 2 {
+		t.Errorf("Expected unrelated settings kept, got %+v", got)
+	}
+	if len(central.ExcludePaths) != 2 {
+		t.Errorf("Expected the central config not to be modified, got %v", central.ExcludePaths)
+	}
+}
+
+func TestProjectConfig_WithRepoOverridesRejectsInvalid(t *testing.T) {
+	central := &ProjectConfig{ProjectID: "crm", SourcePath: "crm", IncludeExtensions: []string{".go"}}
+
+	tests := map[string]string{
+		"disallowed key":   "source_path: /etc\n",
+		"invalid strategy": "chunking:\n  code:\n    strategy: sentence\n",
+		"empty extensions": "include_extensions: []\n",
+	}
+	for name, content := range tests {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, RepoOverridesFile), []byte(content), 0644)
+		_, err := central.WithRepoOverrides(dir)
+		if err == nil || !strings.Contains(err.Error(), RepoOverridesFile) {
+			t.Errorf("%s: expected an error naming %s, got %v", name, RepoOverridesFile, err)
+		}
+	}
+}
+
+func TestProjectConfig_ExcludeSymbols(t *testing.T) {
+	cfg := ProjectConfig{
+		ProjectID:         "proj",
+		SourcePath:        "proj",
+		IncludeExtensions: []string{".go"},
+		ExcludeSymbols:    []string{"^get[A-Z]", "__construct$"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	patterns, err := cfg.ExcludeSymbolPatterns()
+	if err != nil || len(patterns) != 2 || !patterns[0].MatchString("getName") || patterns[0].MatchString("getaway") {
+		t.Errorf("Expected compiled patterns, got %v (%v)", patterns, err)
+	}
+
+	cfg.ExcludeSymbols = []string{"^get[A-Z"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "exclude_symbols") {
+		t.Errorf("Expected invalid regex to fail validation, got %v", err)
+	}
+}
+
+func TestProjectConfig_CustomMetadataKeys(t *testing.T) {
+	cfg := ProjectConfig{
+		ProjectID:         "proj",
+		SourcePath:        "proj",
+		IncludeExtensions: []string{".go"},
+		Metadata:          ProjectMetadata{Custom: map[string]string{"service": "billing", "data_class_2": "pii"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	for _, key := range []string{"Service", "2fa", "data-class", "a=b", ""} {
+		cfg.Metadata.Custom = map[string]string{key: "x"}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "metadata.custom") {
+			t.Errorf("Expected key %q to fail validation, got %v", key, err)
+		}
+	}
+}
+
+func TestProjectCache_ReloadsChangedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crm.yaml")
+	write := func(topK string, modTime time.Time) {
+		t.Helper()
+		content := "project_id: crm\nsource_path: crm\ninclude_extensions: [\".go\"]\nretrieval:\n  default_top_k: " + topK + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := time.Now().Add(-time.Hour)
+	write("3", base)
+
+	cache := NewProjectCache()
+	first, err := cache.Get(dir, "crm")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if again, _ := cache.Get(dir, "crm"); again != first {
+		t.Error("Expected unchanged config to be served from cache")
+	}
+
+	write("9", base.Add(time.Second))
+	reloaded, err := cache.Get(dir, "crm")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if reloaded.Retrieval.DefaultTopK != 9 {
+		t.Errorf("Expected edited config to be reloaded, got default_top_k %d", reloaded.Retrieval.DefaultTopK)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Get(dir, "crm"); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("Expected removed project to be not found, got %v", err)
+	}
+}
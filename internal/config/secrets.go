@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// secretFileSuffix is appended to an env var name to point at a secret file
+// (e.g. OPENAI_API_KEY_FILE=/run/secrets/openai).
+const secretFileSuffix = "_FILE"
+
+// resolveSecret returns a secret from, in order of precedence:
+// the explicit file path, the file named by envName+"_FILE", or envName itself.
+// Files that are missing or empty fall through to the next source.
+// The value is never logged.
+func resolveSecret(envName, filePath string) string {
+	if v, ok := readSecretFile(filePath); ok {
+		return v
+	}
+	if envName == "" {
+		return ""
+	}
+	if v, ok := readSecretFile(os.Getenv(envName + secretFileSuffix)); ok {
+		return v
+	}
+	return os.Getenv(envName)
+}
+
+// readSecretFile reads a secret file and trims surrounding whitespace.
+func readSecretFile(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	v := strings.TrimSpace(string(data))
+	return v, v != ""
+}
+
+// RedactHeaders returns header names with their values redacted, sorted,
+// for logging configured provider headers without leaking tokens.
+func RedactHeaders(headers map[string]string) []string {
+	redacted := make([]string, 0, len(headers))
+	for name := range headers {
+		redacted = append(redacted, name+"=[REDACTED]")
+	}
+	sort.Strings(redacted)
+	return redacted
+}
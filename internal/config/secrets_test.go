@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetAPIKey_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_key")
+	if err := os.WriteFile(path, []byte("  sk-from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	t.Setenv("TEST_API_KEY", "sk-from-env")
+
+	cfg := EmbeddingConfig{APIKeyEnv: "TEST_API_KEY", APIKeyFile: path}
+	if got := cfg.GetAPIKey(); got != "sk-from-file" {
+		t.Errorf("Expected key from file, got %q", got)
+	}
+}
+
+func TestGetAPIKey_FileSuffixEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_key")
+	os.WriteFile(path, []byte("sk-suffix\n"), 0600)
+	t.Setenv("TEST_API_KEY", "sk-from-env")
+	t.Setenv("TEST_API_KEY_FILE", path)
+
+	cfg := EmbeddingConfig{APIKeyEnv: "TEST_API_KEY"}
+	if got := cfg.GetAPIKey(); got != "sk-suffix" {
+		t.Errorf("Expected key from _FILE env, got %q", got)
+	}
+}
+
+func TestGetAPIKey_FallsBackToEnv(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "sk-from-env")
+
+	cfg := EmbeddingConfig{
+		APIKeyEnv:  "TEST_API_KEY",
+		APIKeyFile: filepath.Join(t.TempDir(), "missing"),
+	}
+	if got := cfg.GetAPIKey(); got != "sk-from-env" {
+		t.Errorf("Expected fallback to env var, got %q", got)
+	}
+
+	if got := (&EmbeddingConfig{}).GetAPIKey(); got != "" {
+		t.Errorf("Expected empty key without sources, got %q", got)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	got := RedactHeaders(map[string]string{"X-Tenant-ID": "acme", "X-Gateway-Token": "secret"})
+	want := []string{"X-Gateway-Token=[REDACTED]", "X-Tenant-ID=[REDACTED]"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -3,6 +3,7 @@ package embedder
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/iasik/project-indexer/internal/config"
 )
@@ -16,24 +17,36 @@ func NewProvider(cfg config.EmbeddingConfig) (Provider, error) {
 		Endpoint:       cfg.Endpoint,
 		Dimensions:     cfg.Dimensions,
 		BatchSize:      cfg.BatchSize,
+		Concurrency:    cfg.Concurrency,
 		APIKey:         cfg.GetAPIKey(),
 		TimeoutSeconds: int(cfg.GetTimeout().Seconds()),
+		Headers:        cfg.Headers,
+		WaitForModel:   cfg.WaitForModel,
 	}
 
+	var provider Provider
+	var err error
 	switch cfg.Provider {
 	case "ollama":
-		return NewOllamaEmbedder(providerCfg)
+		provider, err = NewOllamaEmbedder(providerCfg)
 
 	case "openai":
-		return NewOpenAIEmbedder(providerCfg)
+		provider, err = NewOpenAIEmbedder(providerCfg)
 
 	case "huggingface":
-		// TODO: Implement HuggingFace embedder
-		return nil, fmt.Errorf("huggingface provider not yet implemented")
+		provider, err = NewHuggingFaceEmbedder(providerCfg)
 
 	default:
 		return nil, fmt.Errorf("unknown embedding provider: %s (supported: ollama, openai, huggingface)", cfg.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.RetryAttempts > 1 {
+		provider = NewRetryEmbedder(provider, cfg.RetryAttempts)
+	}
+	return provider, nil
 }
 
 // MustNewProvider creates a provider or panics on failure.
@@ -45,3 +58,11 @@ func MustNewProvider(cfg config.EmbeddingConfig) Provider {
 	}
 	return provider
 }
+
+// setHeaders attaches configured extra headers to a provider request.
+// They are applied last, so they override built-in headers of the same name.
+func setHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
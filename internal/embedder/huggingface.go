@@ -0,0 +1,139 @@
+// Package embedder provides HuggingFace text-embeddings-inference (TEI) implementation.
+package embedder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HuggingFaceEmbedder implements the Provider interface for a self-hosted
+// text-embeddings-inference server.
+type HuggingFaceEmbedder struct {
+	client     *http.Client
+	endpoint   string
+	model      string
+	apiKey     string
+	dimensions int
+	headers    map[string]string
+}
+
+// hfEmbedRequest is the request body for the TEI /embed API.
+type hfEmbedRequest struct {
+	Inputs []string `json:"inputs"`
+}
+
+// NewHuggingFaceEmbedder creates a new HuggingFace TEI embedding provider.
+func NewHuggingFaceEmbedder(cfg Config) (*HuggingFaceEmbedder, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("HuggingFace TEI endpoint is required")
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &HuggingFaceEmbedder{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		endpoint:   cfg.Endpoint,
+		model:      cfg.Model,
+		apiKey:     cfg.APIKey,
+		dimensions: cfg.Dimensions,
+		headers:    cfg.Headers,
+	}, nil
+}
+
+// Embed generates an embedding vector for a single text.
+func (h *HuggingFaceEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	results, err := h.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// EmbedBatch generates embedding vectors for multiple texts in one request.
+func (h *HuggingFaceEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonBody, err := json.Marshal(hfEmbedRequest{Inputs: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/embed", h.endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	h.setAuth(req)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding request failed with %w", newStatusError(resp))
+	}
+
+	var vectors [][]float32
+	if err := json.NewDecoder(resp.Body).Decode(&vectors); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+
+	return vectors, nil
+}
+
+// ModelInfo returns information about the current model.
+func (h *HuggingFaceEmbedder) ModelInfo() ModelInfo {
+	return ModelInfo{
+		Provider:   "huggingface",
+		Model:      h.model,
+		Dimensions: h.dimensions,
+	}
+}
+
+// Health checks if the TEI server is ready via its /health endpoint.
+func (h *HuggingFaceEmbedder) Health(ctx context.Context) error {
+	url := fmt.Sprintf("%s/health", h.endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+	h.setAuth(req)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("huggingface TEI returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close releases resources (no-op for HuggingFace).
+func (h *HuggingFaceEmbedder) Close() error {
+	return nil
+}
+
+// setAuth attaches the bearer token, when configured, and extra headers.
+func (h *HuggingFaceEmbedder) setAuth(req *http.Request) {
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", h.apiKey))
+	}
+	setHeaders(req, h.headers)
+}
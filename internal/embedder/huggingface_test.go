@@ -0,0 +1,102 @@
+package embedder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFakeTEIServer answers /embed with one vector per input ([i, i, i]) and
+// /health with 200, recording the Authorization header and request count.
+func newFakeTEIServer(t *testing.T) (*httptest.Server, *string, *int) {
+	t.Helper()
+	var auth string
+	var embedCalls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /embed", func(w http.ResponseWriter, r *http.Request) {
+		embedCalls++
+		auth = r.Header.Get("Authorization")
+		var req hfEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		vectors := make([][]float32, len(req.Inputs))
+		for i := range req.Inputs {
+			vectors[i] = []float32{float32(i), float32(i), float32(i)}
+		}
+		json.NewEncoder(w).Encode(vectors)
+	})
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &auth, &embedCalls
+}
+
+func TestHuggingFaceEmbedder_EmbedBatch(t *testing.T) {
+	srv, auth, embedCalls := newFakeTEIServer(t)
+	emb, err := NewHuggingFaceEmbedder(Config{Endpoint: srv.URL, Model: "bge-small", Dimensions: 3, APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewHuggingFaceEmbedder failed: %v", err)
+	}
+
+	vectors, err := emb.EmbedBatch(context.Background(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	if len(vectors) != 3 || vectors[2][0] != 2 {
+		t.Errorf("Expected 3 vectors in input order, got %v", vectors)
+	}
+	if *embedCalls != 1 {
+		t.Errorf("Expected the batch to be sent in one request, got %d", *embedCalls)
+	}
+	if *auth != "Bearer secret" {
+		t.Errorf("Expected bearer token, got %q", *auth)
+	}
+
+	vector, err := emb.Embed(context.Background(), "q")
+	if err != nil || len(vector) != 3 {
+		t.Fatalf("Embed returned %v, %v", vector, err)
+	}
+
+	if err := emb.Health(context.Background()); err != nil {
+		t.Errorf("Health failed: %v", err)
+	}
+	if info := emb.ModelInfo(); info.Provider != "huggingface" || info.Model != "bge-small" || info.Dimensions != 3 {
+		t.Errorf("Unexpected model info: %+v", info)
+	}
+}
+
+func TestHuggingFaceEmbedder_NoAPIKeyAndErrors(t *testing.T) {
+	srv, auth, _ := newFakeTEIServer(t)
+	emb, err := NewHuggingFaceEmbedder(Config{Endpoint: srv.URL})
+	if err != nil {
+		t.Fatalf("NewHuggingFaceEmbedder failed: %v", err)
+	}
+	if _, err := emb.Embed(context.Background(), "q"); err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if *auth != "" {
+		t.Errorf("Expected no Authorization header without api key, got %q", *auth)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model loading", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	emb, _ = NewHuggingFaceEmbedder(Config{Endpoint: failing.URL})
+	if _, err := emb.Embed(context.Background(), "q"); err == nil {
+		t.Error("Expected error for non-200 embed response")
+	}
+	if err := emb.Health(context.Background()); err == nil {
+		t.Error("Expected error for non-200 health response")
+	}
+
+	if _, err := NewHuggingFaceEmbedder(Config{}); err == nil {
+		t.Error("Expected error without endpoint")
+	}
+}
@@ -4,6 +4,7 @@ package embedder
 
 import (
 	"context"
+	"fmt"
 )
 
 // Provider defines the interface for embedding providers.
@@ -55,11 +56,44 @@ type Config struct {
 	// Batch size for bulk operations
 	BatchSize int
 
+	// Parallel single-text requests when the provider has no batch endpoint
+	Concurrency int
+
 	// API key (for providers that require it)
 	APIKey string
 
 	// Request timeout in seconds
 	TimeoutSeconds int
+
+	// Extra HTTP headers attached to every request
+	Headers map[string]string
+
+	// Treat a missing model as retryable in health checks (it may still be
+	// being pulled) instead of failing immediately
+	WaitForModel bool
+}
+
+// ModelNotFoundError is returned by Health when the configured model isn't
+// available on the provider. It is permanent unless Retryable is set, so
+// startup fails fast instead of retrying.
+type ModelNotFoundError struct {
+	Provider  string
+	Model     string
+	Hint      string
+	Retryable bool
+}
+
+func (e *ModelNotFoundError) Error() string {
+	msg := fmt.Sprintf("model %s not found in %s", e.Model, e.Provider)
+	if e.Hint != "" {
+		msg += ", " + e.Hint
+	}
+	return msg
+}
+
+// Permanent reports whether retrying the health check is pointless.
+func (e *ModelNotFoundError) Permanent() bool {
+	return !e.Retryable
 }
 
 // EmbedResult represents the result of an embedding operation.
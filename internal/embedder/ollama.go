@@ -5,10 +5,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
 // OllamaEmbedder implements the Provider interface for Ollama.
@@ -17,6 +20,16 @@ type OllamaEmbedder struct {
 	endpoint   string
 	model      string
 	dimensions int
+	headers    map[string]string
+
+	// Parallel /api/embeddings requests in the per-text fallback
+	concurrency int
+
+	// Keep retrying health checks while the model is missing
+	waitForModel bool
+
+	// Set once the server answered 404 for /api/embed (Ollama < 0.3)
+	noBatchEndpoint atomic.Bool
 }
 
 // ollamaEmbedRequest is the request body for Ollama embeddings API.
@@ -30,6 +43,21 @@ type ollamaEmbedResponse struct {
 	Embedding []float32 `json:"embedding"`
 }
 
+// ollamaBatchEmbedRequest is the request body for Ollama's batch embed API.
+type ollamaBatchEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaBatchEmbedResponse is the response from Ollama's batch embed API.
+type ollamaBatchEmbedResponse struct {
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// errOllamaNoBatchEndpoint is returned when the server has no /api/embed.
+var errOllamaNoBatchEndpoint = errors.New("ollama /api/embed not available")
+
 // ollamaTagsResponse is the response from Ollama tags API (for health check).
 type ollamaTagsResponse struct {
 	Models []struct {
@@ -43,14 +71,21 @@ func NewOllamaEmbedder(cfg Config) (*OllamaEmbedder, error) {
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
+	concurrency := cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
 
 	return &OllamaEmbedder{
 		client: &http.Client{
 			Timeout: timeout,
 		},
-		endpoint:   cfg.Endpoint,
-		model:      cfg.Model,
-		dimensions: cfg.Dimensions,
+		endpoint:     cfg.Endpoint,
+		model:        cfg.Model,
+		dimensions:   cfg.Dimensions,
+		headers:      cfg.Headers,
+		concurrency:  concurrency,
+		waitForModel: cfg.WaitForModel,
 	}, nil
 }
 
@@ -72,6 +107,7 @@ func (o *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	setHeaders(req, o.headers)
 
 	resp, err := o.client.Do(req)
 	if err != nil {
@@ -80,8 +116,7 @@ func (o *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("embedding request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("embedding request failed with %w", newStatusError(resp))
 	}
 
 	var result ollamaEmbedResponse
@@ -97,27 +132,91 @@ func (o *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 }
 
 // EmbedBatch generates embedding vectors for multiple texts.
-// Ollama doesn't have native batch support, so we process sequentially.
+// Uses the native /api/embed batch endpoint, falling back to one
+// /api/embeddings request per text on servers that don't have it, with up to
+// concurrency requests in flight. Results keep input order; the first error
+// cancels the remaining requests and is returned.
 func (o *OllamaEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if !o.noBatchEndpoint.Load() {
+		results, err := o.embedBatchNative(ctx, texts)
+		if !errors.Is(err, errOllamaNoBatchEndpoint) {
+			return results, err
+		}
+		o.noBatchEndpoint.Store(true)
+	}
+
 	results := make([][]float32, len(texts))
 
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(o.concurrency)
 	for i, text := range texts {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-
-		embedding, err := o.Embed(ctx, text)
-		if err != nil {
-			return nil, fmt.Errorf("failed to embed text %d: %w", i, err)
+		if gctx.Err() != nil {
+			break
 		}
-		results[i] = embedding
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			embedding, err := o.Embed(gctx, text)
+			if err != nil {
+				return fmt.Errorf("failed to embed text %d: %w", i, err)
+			}
+			results[i] = embedding
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	return results, nil
 }
 
+// embedBatchNative embeds all texts in one /api/embed request. Returns
+// errOllamaNoBatchEndpoint when the server answers 404.
+func (o *OllamaEmbedder) embedBatchNative(ctx context.Context, texts []string) ([][]float32, error) {
+	jsonBody, err := json.Marshal(ollamaBatchEmbedRequest{Model: o.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/embed", o.endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setHeaders(req, o.headers)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("batch embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errOllamaNoBatchEndpoint
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("batch embedding request failed with %w", newStatusError(resp))
+	}
+
+	var result ollamaBatchEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Embeddings))
+	}
+	recordUsage(ctx, result.PromptEvalCount)
+
+	return result.Embeddings, nil
+}
+
 // ModelInfo returns information about the current model.
 func (o *OllamaEmbedder) ModelInfo() ModelInfo {
 	return ModelInfo{
@@ -134,6 +233,7 @@ func (o *OllamaEmbedder) Health(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
+	setHeaders(req, o.headers)
 
 	resp, err := o.client.Do(req)
 	if err != nil {
@@ -160,7 +260,12 @@ func (o *OllamaEmbedder) Health(ctx context.Context) error {
 	}
 
 	if !modelFound {
-		return fmt.Errorf("model %s not found in ollama, run: ollama pull %s", o.model, o.model)
+		return &ModelNotFoundError{
+			Provider:  "ollama",
+			Model:     o.model,
+			Hint:      fmt.Sprintf("run: ollama pull %s", o.model),
+			Retryable: o.waitForModel,
+		}
 	}
 
 	return nil
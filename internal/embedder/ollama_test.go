@@ -0,0 +1,219 @@
+package embedder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOllamaEmbedder_SendsConfiguredHeaders(t *testing.T) {
+	var seen []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Clone())
+		switch r.URL.Path {
+		case "/api/tags":
+			json.NewEncoder(w).Encode(map[string]any{"models": []any{}})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"embedding": []float32{0.1, 0.2}})
+		}
+	}))
+	defer srv.Close()
+
+	emb, err := NewOllamaEmbedder(Config{
+		Endpoint: srv.URL,
+		Headers:  map[string]string{"X-Tenant-ID": "acme", "X-Gateway-Token": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewOllamaEmbedder failed: %v", err)
+	}
+
+	if _, err := emb.Embed(context.Background(), "hello"); err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	emb.Health(context.Background())
+
+	if len(seen) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(seen))
+	}
+	for i, h := range seen {
+		if h.Get("X-Tenant-ID") != "acme" || h.Get("X-Gateway-Token") != "secret" {
+			t.Errorf("Request %d missing configured headers: %v", i, h)
+		}
+	}
+}
+
+func TestOllamaEmbedder_EmbedBatchNative(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var req ollamaBatchEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		embeddings := make([][]float32, len(req.Input))
+		for i := range req.Input {
+			embeddings[i] = []float32{float32(i), 0}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"embeddings": embeddings, "prompt_eval_count": 7})
+	}))
+	defer srv.Close()
+
+	emb, _ := NewOllamaEmbedder(Config{Endpoint: srv.URL, Model: "nomic-embed-text"})
+	usage := &Usage{}
+	vectors, err := emb.EmbedBatch(WithUsage(context.Background(), usage), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	if len(vectors) != 3 || vectors[2][0] != 2 {
+		t.Errorf("Expected 3 vectors in input order, got %v", vectors)
+	}
+	if len(paths) != 1 || paths[0] != "/api/embed" {
+		t.Errorf("Expected a single /api/embed request, got %v", paths)
+	}
+	if usage.Tokens() != 7 {
+		t.Errorf("Expected prompt_eval_count to be recorded, got %d", usage.Tokens())
+	}
+}
+
+func TestOllamaEmbedder_EmbedBatchFallsBackOn404(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/api/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"embedding": []float32{0.1, 0.2}})
+	}))
+	defer srv.Close()
+
+	emb, _ := NewOllamaEmbedder(Config{Endpoint: srv.URL})
+	for run := 0; run < 2; run++ {
+		vectors, err := emb.EmbedBatch(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatalf("EmbedBatch failed: %v", err)
+		}
+		if len(vectors) != 2 {
+			t.Fatalf("Expected 2 vectors, got %d", len(vectors))
+		}
+	}
+
+	// The missing batch endpoint is only probed once
+	want := []string{"/api/embed", "/api/embeddings", "/api/embeddings", "/api/embeddings", "/api/embeddings"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("Unexpected request sequence: %v", paths)
+	}
+
+	// Cancellation is checked between per-text requests
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := emb.EmbedBatch(ctx, []string{"a"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestOllamaEmbedder_EmbedBatchConcurrentFallback(t *testing.T) {
+	var inFlight, maxInFlight, started atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		started.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			cur := maxInFlight.Load()
+			if n <= cur || maxInFlight.CompareAndSwap(cur, n) {
+				break
+			}
+		}
+
+		var req ollamaEmbedRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case req.Prompt == "fail":
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		case strings.HasPrefix(req.Prompt, "hang"):
+			// Only returns once the client gives up on the request
+			<-r.Context().Done()
+			return
+		}
+
+		// Later texts answer first, so completion order differs from input order
+		num, _ := strconv.Atoi(req.Prompt)
+		time.Sleep(time.Duration(10-num) * 2 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]any{"embedding": []float32{float32(num)}})
+	}))
+	defer srv.Close()
+
+	emb, _ := NewOllamaEmbedder(Config{Endpoint: srv.URL, Concurrency: 4})
+
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+	vectors, err := emb.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	for i, v := range vectors {
+		if len(v) != 1 || v[0] != float32(i) {
+			t.Errorf("vectors[%d] = %v, want [%d]", i, v, i)
+		}
+	}
+	if got := maxInFlight.Load(); got < 2 || got > 4 {
+		t.Errorf("Expected 2-4 concurrent requests, got %d", got)
+	}
+
+	// A failing text cancels the hanging requests and stops dispatching the rest
+	started.Store(0)
+	texts = []string{"hang1", "fail", "hang2", "hang3", "4", "5", "6", "7"}
+	done := make(chan error, 1)
+	go func() {
+		_, err := emb.EmbedBatch(context.Background(), texts)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "failed to embed text 1") {
+			t.Errorf("Expected error for text 1, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("EmbedBatch did not abort after a failed request")
+	}
+	if got := started.Load(); got >= int32(len(texts)) {
+		t.Errorf("Expected remaining texts to be skipped, %d of %d requested", got, len(texts))
+	}
+}
+
+func TestOllamaEmbedder_HealthModelNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"models": []any{map[string]string{"name": "llama3:latest"}}})
+	}))
+	defer srv.Close()
+
+	emb, _ := NewOllamaEmbedder(Config{Endpoint: srv.URL, Model: "nomic-embed-text"})
+	err := emb.Health(context.Background())
+	var notFound *ModelNotFoundError
+	if !errors.As(err, &notFound) || !notFound.Permanent() {
+		t.Fatalf("Expected permanent *ModelNotFoundError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "ollama pull nomic-embed-text") {
+		t.Errorf("Expected pull hint in %q", err.Error())
+	}
+
+	emb, _ = NewOllamaEmbedder(Config{Endpoint: srv.URL, Model: "nomic-embed-text", WaitForModel: true})
+	if err := emb.Health(context.Background()); !errors.As(err, &notFound) || notFound.Permanent() {
+		t.Errorf("Expected retryable error with wait_for_model, got %v", err)
+	}
+}
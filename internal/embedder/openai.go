@@ -6,7 +6,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 )
@@ -18,6 +17,7 @@ type OpenAIEmbedder struct {
 	model      string
 	apiKey     string
 	dimensions int
+	headers    map[string]string
 }
 
 // openAIEmbedRequest is the request body for OpenAI embeddings API.
@@ -65,6 +65,7 @@ func NewOpenAIEmbedder(cfg Config) (*OpenAIEmbedder, error) {
 		model:      cfg.Model,
 		apiKey:     cfg.APIKey,
 		dimensions: cfg.Dimensions,
+		headers:    cfg.Headers,
 	}, nil
 }
 
@@ -99,6 +100,7 @@ func (o *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]fl
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", o.apiKey))
+	setHeaders(req, o.headers)
 
 	resp, err := o.client.Do(req)
 	if err != nil {
@@ -107,8 +109,7 @@ func (o *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]fl
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("embedding request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("embedding request failed with %w", newStatusError(resp))
 	}
 
 	var result openAIEmbedResponse
@@ -123,6 +124,7 @@ func (o *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]fl
 	if len(result.Data) == 0 {
 		return nil, fmt.Errorf("no embeddings returned")
 	}
+	recordUsage(ctx, result.Usage.TotalTokens)
 
 	// Sort results by index to maintain order
 	vectors := make([][]float32, len(texts))
@@ -0,0 +1,62 @@
+package embedder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFakeOpenAIServer answers embedding requests with zero vectors and
+// reports tokensPerInput tokens per input text.
+func newFakeOpenAIServer(t *testing.T, tokensPerInput int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := map[string]any{
+			"usage": map[string]int{"total_tokens": tokensPerInput * len(req.Input)},
+		}
+		data := make([]map[string]any, len(req.Input))
+		for i := range req.Input {
+			data[i] = map[string]any{"index": i, "embedding": []float32{0, 0, 0}}
+		}
+		resp["data"] = data
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAIEmbedder_RecordsTokenUsage(t *testing.T) {
+	srv := newFakeOpenAIServer(t, 5)
+	emb, err := NewOpenAIEmbedder(Config{Endpoint: srv.URL, APIKey: "test", Dimensions: 3})
+	if err != nil {
+		t.Fatalf("NewOpenAIEmbedder failed: %v", err)
+	}
+
+	usage := &Usage{}
+	ctx := WithUsage(context.Background(), usage)
+	if _, err := emb.EmbedBatch(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("EmbedBatch failed: %v", err)
+	}
+	if _, err := emb.Embed(ctx, "c"); err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if got := usage.Tokens(); got != 15 {
+		t.Errorf("Expected 15 tokens across calls, got %d", got)
+	}
+
+	// Calls without a Usage in context are not accounted
+	if _, err := emb.Embed(context.Background(), "d"); err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if got := usage.Tokens(); got != 15 {
+		t.Errorf("Expected usage unchanged by untracked call, got %d", got)
+	}
+}
@@ -0,0 +1,157 @@
+// Package embedder provides retries with backoff for embedding providers.
+package embedder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultRetryBaseDelay is the wait before the first retry; it doubles on each attempt.
+	defaultRetryBaseDelay = 500 * time.Millisecond
+
+	// maxRetryDelay caps both the backoff and a server-sent Retry-After.
+	maxRetryDelay = 30 * time.Second
+)
+
+// StatusError is returned by providers when the server answers with a
+// non-200 status. RetryAfter is set from the Retry-After header, if any.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
+// newStatusError reads the response body and Retry-After header of a failed request.
+func newStatusError(resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(resp.Body)
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses a Retry-After value in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryEmbedder wraps a Provider and retries Embed and EmbedBatch on
+// transient failures (429, 5xx, connection errors) with exponential backoff
+// and jitter. Other methods pass through to the wrapped provider.
+type RetryEmbedder struct {
+	Provider
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// NewRetryEmbedder wraps p so each call is tried up to maxAttempts times.
+func NewRetryEmbedder(p Provider, maxAttempts int) *RetryEmbedder {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &RetryEmbedder{
+		Provider:    p,
+		maxAttempts: maxAttempts,
+		baseDelay:   defaultRetryBaseDelay,
+	}
+}
+
+// Embed generates an embedding vector for a single text, retrying transient failures.
+func (r *RetryEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	var vector []float32
+	err := r.retry(ctx, func() (err error) {
+		vector, err = r.Provider.Embed(ctx, text)
+		return err
+	})
+	return vector, err
+}
+
+// EmbedBatch generates embedding vectors for multiple texts, retrying transient failures.
+func (r *RetryEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	err := r.retry(ctx, func() (err error) {
+		vectors, err = r.Provider.EmbedBatch(ctx, texts)
+		return err
+	})
+	return vectors, err
+}
+
+// retry runs call until it succeeds, fails permanently, attempts run out or
+// ctx is done.
+func (r *RetryEmbedder) retry(ctx context.Context, call func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = call(); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= r.maxAttempts || !isRetryable(err) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.backoff(attempt, err)):
+		}
+	}
+	if r.maxAttempts > 1 && isRetryable(err) {
+		return fmt.Errorf("giving up after %d attempts: %w", r.maxAttempts, err)
+	}
+	return err
+}
+
+// backoff returns the wait before the next attempt: the server's Retry-After
+// when given, otherwise baseDelay doubled per attempt with up to 50% jitter.
+func (r *RetryEmbedder) backoff(attempt int, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, maxRetryDelay)
+	}
+
+	delay := maxRetryDelay
+	if shift := attempt - 1; shift < 32 {
+		delay = min(r.baseDelay<<shift, maxRetryDelay)
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// isRetryable reports whether err is a transient failure worth retrying:
+// rate limiting, server errors, or a network error such as a connection reset.
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
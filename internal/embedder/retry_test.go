@@ -0,0 +1,120 @@
+package embedder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers the OpenAI embeddings API, failing the first
+// failures requests with status.
+func flakyServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, "try again", status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": []map[string]any{{"embedding": []float32{0.1, 0.2}, "index": 0}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestRetryEmbedder(t *testing.T, endpoint string, attempts int) *RetryEmbedder {
+	emb, err := NewOpenAIEmbedder(Config{Endpoint: endpoint, APIKey: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRetryEmbedder(emb, attempts)
+	r.baseDelay = time.Millisecond
+	return r
+}
+
+func TestRetryEmbedder_RetriesTransientFailures(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		srv, calls := flakyServer(t, 2, status, "")
+		emb := newTestRetryEmbedder(t, srv.URL, 3)
+
+		vectors, err := emb.EmbedBatch(context.Background(), []string{"hello"})
+		if err != nil {
+			t.Fatalf("status %d: EmbedBatch failed: %v", status, err)
+		}
+		if len(vectors) != 1 {
+			t.Errorf("status %d: expected 1 vector, got %d", status, len(vectors))
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("status %d: expected 3 requests, got %d", status, got)
+		}
+	}
+}
+
+func TestRetryEmbedder_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv, calls := flakyServer(t, 5, http.StatusInternalServerError, "")
+	emb := newTestRetryEmbedder(t, srv.URL, 2)
+
+	_, err := emb.Embed(context.Background(), "hello")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected *StatusError 500, got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+}
+
+func TestRetryEmbedder_DoesNotRetryClientErrors(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusBadRequest, "")
+	emb := newTestRetryEmbedder(t, srv.URL, 3)
+
+	if _, err := emb.Embed(context.Background(), "hello"); err == nil {
+		t.Fatal("Expected error for 400")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+}
+
+func TestRetryEmbedder_HonorsRetryAfterAndContext(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, "10")
+	emb := newTestRetryEmbedder(t, srv.URL, 3)
+
+	// The 10s Retry-After outlasts the context, so the wait is cut short
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := emb.Embed(ctx, "hello"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Retry wait ignored context cancellation (%s)", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("7"); got != 7*time.Second {
+		t.Errorf("parseRetryAfter(\"7\") = %s, want 7s", got)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got < 50*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %s, want ~1m", date, got)
+	}
+	for _, value := range []string{"", "soon", "-1"} {
+		if got := parseRetryAfter(value); got != 0 {
+			t.Errorf("parseRetryAfter(%q) = %s, want 0", value, got)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package embedder provides embedding token usage accounting.
+package embedder
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Usage accumulates embedding token counts reported by providers.
+// It is safe for concurrent use. Providers that do not report usage
+// (e.g. HuggingFace TEI, Ollama's per-text API) leave it at zero.
+type Usage struct {
+	tokens atomic.Int64
+}
+
+// Add records n tokens.
+func (u *Usage) Add(n int) {
+	u.tokens.Add(int64(n))
+}
+
+// Tokens returns the total tokens recorded so far.
+func (u *Usage) Tokens() int {
+	return int(u.tokens.Load())
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context whose embedding calls record token usage into u.
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// recordUsage adds n tokens to the Usage attached to ctx, if any.
+func recordUsage(ctx context.Context, n int) {
+	if u, ok := ctx.Value(usageKey{}).(*Usage); ok && n > 0 {
+		u.Add(n)
+	}
+}
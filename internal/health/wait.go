@@ -0,0 +1,97 @@
+// Package health waits for external dependencies to become healthy at startup.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Checker is implemented by providers that can report their health,
+// such as embedder.Provider and vectordb.Provider.
+type Checker interface {
+	Health(ctx context.Context) error
+}
+
+// Dependency is a named Checker.
+type Dependency struct {
+	Name    string
+	Checker Checker
+}
+
+// DependencyError reports a dependency that never became healthy.
+type DependencyError struct {
+	Name     string
+	Attempts int
+	Err      error
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("%s health check failed after %d attempts: %v", e.Name, e.Attempts, e.Err)
+}
+
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// permanent is implemented by health errors that retrying can't fix, such as
+// a model that isn't installed.
+type permanent interface {
+	Permanent() bool
+}
+
+// isPermanent reports whether err (or an error it wraps) is permanent.
+func isPermanent(err error) bool {
+	var p permanent
+	return errors.As(err, &p) && p.Permanent()
+}
+
+// WaitAll checks all dependencies concurrently, retrying each up to attempts
+// times with interval between tries, so total wait is bounded by the slowest
+// dependency rather than the sum. Permanent errors are not retried. The first
+// failure cancels the remaining checks and is returned as a *DependencyError;
+// ctx cancellation returns ctx.Err().
+func WaitAll(ctx context.Context, attempts int, interval time.Duration, deps ...Dependency) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	for _, dep := range deps {
+		dep := dep
+		g.Go(func() error {
+			return wait(gctx, attempts, interval, dep)
+		})
+	}
+
+	err := g.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// wait retries a single dependency until it is healthy or attempts run out.
+func wait(ctx context.Context, attempts int, interval time.Duration, dep Dependency) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = dep.Checker.Health(ctx); err == nil {
+			return nil
+		}
+		if isPermanent(err) {
+			return &DependencyError{Name: dep.Name, Attempts: i + 1, Err: err}
+		}
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+	return &DependencyError{Name: dep.Name, Attempts: attempts, Err: err}
+}
@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeChecker becomes healthy once readyAfter has elapsed since start.
+type fakeChecker struct {
+	start      time.Time
+	readyAfter time.Duration
+	calls      atomic.Int32
+}
+
+func (f *fakeChecker) Health(ctx context.Context) error {
+	f.calls.Add(1)
+	if time.Since(f.start) < f.readyAfter {
+		return errors.New("not ready")
+	}
+	return nil
+}
+
+// meeting is shared by checkers that are healthy only once all of them are
+// inside Health at the same time, which sequential checks never are.
+type meeting struct {
+	arrived sync.WaitGroup
+	all     chan struct{}
+}
+
+func newMeeting(n int) *meeting {
+	m := &meeting{all: make(chan struct{})}
+	m.arrived.Add(n)
+	go func() {
+		m.arrived.Wait()
+		close(m.all)
+	}()
+	return m
+}
+
+// meetingChecker waits in Health for the rest of its meeting.
+type meetingChecker struct {
+	m *meeting
+}
+
+func (c *meetingChecker) Health(ctx context.Context) error {
+	c.m.arrived.Done()
+	select {
+	case <-c.m.all:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(5 * time.Second):
+		return errors.New("checked alone")
+	}
+}
+
+func TestWaitAll_Concurrent(t *testing.T) {
+	m := newMeeting(2)
+
+	// A single attempt each: sequential checks would time out waiting for
+	// the other dependency
+	err := WaitAll(context.Background(), 1, time.Millisecond,
+		Dependency{Name: "embedder", Checker: &meetingChecker{m: m}},
+		Dependency{Name: "vectordb", Checker: &meetingChecker{m: m}},
+	)
+	if err != nil {
+		t.Fatalf("Expected dependencies to be checked concurrently, got %v", err)
+	}
+}
+
+func TestWaitAll_ReportsFailedDependency(t *testing.T) {
+	start := time.Now()
+	healthy := &fakeChecker{start: start}
+	down := &fakeChecker{start: start, readyAfter: time.Hour}
+
+	err := WaitAll(context.Background(), 3, time.Millisecond,
+		Dependency{Name: "embedder", Checker: healthy},
+		Dependency{Name: "vectordb", Checker: down},
+	)
+
+	var depErr *DependencyError
+	if !errors.As(err, &depErr) {
+		t.Fatalf("Expected *DependencyError, got %v", err)
+	}
+	if depErr.Name != "vectordb" || depErr.Attempts != 3 {
+		t.Errorf("Expected vectordb to fail after 3 attempts, got %+v", depErr)
+	}
+	if got := down.calls.Load(); got != 3 {
+		t.Errorf("Expected 3 health checks, got %d", got)
+	}
+}
+
+func TestWaitAll_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := WaitAll(ctx, 30, time.Second,
+		Dependency{Name: "vectordb", Checker: &fakeChecker{start: time.Now(), readyAfter: time.Hour}},
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// permanentErr is a health error that retrying can't fix.
+type permanentErr struct{}
+
+func (permanentErr) Error() string   { return "model not found" }
+func (permanentErr) Permanent() bool { return true }
+
+// failingChecker always fails with err.
+type failingChecker struct {
+	err   error
+	calls atomic.Int32
+}
+
+func (f *failingChecker) Health(ctx context.Context) error {
+	f.calls.Add(1)
+	return f.err
+}
+
+func TestWaitAll_PermanentErrorNotRetried(t *testing.T) {
+	missing := &failingChecker{err: fmt.Errorf("ollama: %w", permanentErr{})}
+
+	start := time.Now()
+	err := WaitAll(context.Background(), 30, time.Second, Dependency{Name: "embedder", Checker: missing})
+
+	var depErr *DependencyError
+	if !errors.As(err, &depErr) {
+		t.Fatalf("Expected *DependencyError, got %v", err)
+	}
+	if depErr.Attempts != 1 || missing.calls.Load() != 1 {
+		t.Errorf("Expected a single attempt, got attempts=%d calls=%d", depErr.Attempts, missing.calls.Load())
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected to fail fast, took %s", time.Since(start))
+	}
+}
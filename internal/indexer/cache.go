@@ -13,10 +13,17 @@ import (
 
 // Cache manages file hash storage for incremental indexing.
 type Cache struct {
-	path    string
-	entries map[string]CacheEntry
-	mu      sync.RWMutex
-	dirty   bool
+	path        string
+	entries     map[string]CacheEntry
+	fingerprint string
+	generation  string
+	mu          sync.RWMutex
+	dirty       bool
+
+	// saveMu serializes Save so a background flush and the final save
+	// never write the temp file at the same time. It is held across disk
+	// I/O; mu is only held while the entries are snapshotted.
+	saveMu sync.Mutex
 }
 
 // CacheEntry represents a cached file state.
@@ -27,6 +34,9 @@ type CacheEntry struct {
 	// Last modification time
 	ModTime time.Time `json:"mod_time"`
 
+	// File size in bytes (checked together with ModTime by cache.trust_modtime)
+	Size int64 `json:"size,omitempty"`
+
 	// When this file was last indexed
 	IndexedAt time.Time `json:"indexed_at"`
 
@@ -35,18 +45,28 @@ type CacheEntry struct {
 
 	// ChunkHashes maps chunk_id to content_hash for chunk-level diffing
 	ChunkHashes map[string]string `json:"chunk_hashes,omitempty"`
+
+	// ChunkLines maps chunk_id to start_line, to detect unchanged chunks that moved
+	ChunkLines map[string]int `json:"chunk_lines,omitempty"`
 }
 
 // CacheFile is the JSON structure stored on disk.
 type CacheFile struct {
-	ProjectID string                `json:"project_id"`
-	UpdatedAt time.Time             `json:"updated_at"`
-	Files     map[string]CacheEntry `json:"files"`
+	ProjectID string    `json:"project_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Embedding provider/model/dimensions the cached vectors were built with
+	EmbeddingFingerprint string `json:"embedding_fingerprint,omitempty"`
+
+	// Index generation of the vectors (changes on each successful full reindex)
+	Generation string `json:"generation,omitempty"`
+
+	Files map[string]CacheEntry `json:"files"`
 }
 
 // NewCache creates a new cache for a project.
 func NewCache(cacheDir, projectID string) (*Cache, error) {
-	path := filepath.Join(cacheDir, projectID+".json")
+	path := cacheFilePath(cacheDir, projectID)
 
 	cache := &Cache{
 		path:    path,
@@ -61,6 +81,22 @@ func NewCache(cacheDir, projectID string) (*Cache, error) {
 	return cache, nil
 }
 
+// CacheStamp identifies the saved state of a project's cache file by its
+// modification time and size, or returns "" when there is none. Every index
+// run that changes the index saves the cache, so the stamp changes with it.
+func CacheStamp(cacheDir, projectID string) string {
+	info, err := os.Stat(cacheFilePath(cacheDir, projectID))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// cacheFilePath returns the path of a project's cache file.
+func cacheFilePath(cacheDir, projectID string) string {
+	return filepath.Join(cacheDir, projectID+".json")
+}
+
 // load reads the cache from disk.
 func (c *Cache) load() error {
 	data, err := os.ReadFile(c.path)
@@ -76,6 +112,8 @@ func (c *Cache) load() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.entries = cacheFile.Files
+	c.fingerprint = cacheFile.EmbeddingFingerprint
+	c.generation = cacheFile.Generation
 	if c.entries == nil {
 		c.entries = make(map[string]CacheEntry)
 	}
@@ -83,26 +121,48 @@ func (c *Cache) load() error {
 	return nil
 }
 
-// Save writes the cache to disk.
+// Save writes the cache to disk if it changed since the last successful save.
+// Safe for concurrent use: saves are serialized, and changes made while a
+// save is writing mark the cache dirty for the next one.
 func (c *Cache) Save(projectID string) error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
 
+	c.mu.Lock()
 	if !c.dirty {
+		c.mu.Unlock()
 		return nil
 	}
 
 	cacheFile := CacheFile{
-		ProjectID: projectID,
-		UpdatedAt: time.Now().UTC(),
-		Files:     c.entries,
+		ProjectID:            projectID,
+		UpdatedAt:            time.Now().UTC(),
+		EmbeddingFingerprint: c.fingerprint,
+		Generation:           c.generation,
+		Files:                c.entries,
 	}
 
 	data, err := json.MarshalIndent(cacheFile, "", "  ")
+	if err == nil {
+		c.dirty = false
+	}
+	c.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache: %w", err)
 	}
 
+	if err := c.write(data); err != nil {
+		// Keep the changes pending for the next save
+		c.mu.Lock()
+		c.dirty = true
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write atomically replaces the cache file with data. Callers hold saveMu.
+func (c *Cache) write(data []byte) error {
 	// Ensure directory exists
 	dir := filepath.Dir(c.path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -119,7 +179,6 @@ func (c *Cache) Save(projectID string) error {
 		os.Remove(tmpPath)
 		return fmt.Errorf("failed to save cache: %w", err)
 	}
-
 	return nil
 }
 
@@ -191,13 +250,18 @@ func (c *Cache) Stats() CacheStats {
 	defer c.mu.RUnlock()
 
 	var totalChunks int
+	var lastIndexed time.Time
 	for _, entry := range c.entries {
 		totalChunks += len(entry.ChunkIDs)
+		if entry.IndexedAt.After(lastIndexed) {
+			lastIndexed = entry.IndexedAt
+		}
 	}
 
 	return CacheStats{
-		FileCount:  len(c.entries),
-		ChunkCount: totalChunks,
+		FileCount:   len(c.entries),
+		ChunkCount:  totalChunks,
+		LastIndexed: lastIndexed,
 	}
 }
 
@@ -205,6 +269,9 @@ func (c *Cache) Stats() CacheStats {
 type CacheStats struct {
 	FileCount  int
 	ChunkCount int
+
+	// Most recent file IndexedAt (zero for an empty cache)
+	LastIndexed time.Time
 }
 
 // GetChunkHashes returns chunk hashes for a file.
@@ -221,6 +288,12 @@ func (c *Cache) GetChunkHashes(filePath string) map[string]string {
 	return result
 }
 
+// GetChunkLines returns the chunk start lines recorded for a file (nil if none).
+func (c *Cache) GetChunkLines(filePath string) map[string]int {
+	entry, _ := c.Get(filePath)
+	return entry.ChunkLines
+}
+
 // SetChunkHashes updates chunk hashes for a file.
 func (c *Cache) SetChunkHashes(filePath string, hashes map[string]string) {
 	c.mu.Lock()
@@ -231,3 +304,37 @@ func (c *Cache) SetChunkHashes(filePath string, hashes map[string]string) {
 	c.entries[filePath] = entry
 	c.dirty = true
 }
+
+// Fingerprint returns the embedding fingerprint stored with the cache.
+func (c *Cache) Fingerprint() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.fingerprint
+}
+
+// SetFingerprint records the embedding fingerprint for the cached vectors.
+func (c *Cache) SetFingerprint(fingerprint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fingerprint != fingerprint {
+		c.fingerprint = fingerprint
+		c.dirty = true
+	}
+}
+
+// Generation returns the index generation stored with the cache.
+func (c *Cache) Generation() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// SetGeneration records the index generation of the cached vectors.
+func (c *Cache) SetGeneration(generation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		c.generation = generation
+		c.dirty = true
+	}
+}
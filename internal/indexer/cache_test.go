@@ -1,8 +1,11 @@
 package indexer
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -220,6 +223,65 @@ func TestCache_SaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestCache_SaveResetsDirty(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache, _ := NewCache(tmpDir, "test-project")
+	cache.Set("file1.go", CacheEntry{ContentHash: "hash1"})
+
+	if err := cache.Save("test-project"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if cache.dirty {
+		t.Error("Expected dirty to be reset after a successful save")
+	}
+
+	// An unchanged cache is not rewritten
+	cachePath := filepath.Join(tmpDir, "test-project.json")
+	os.Remove(cachePath)
+	if err := cache.Save("test-project"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Error("Expected no write for a clean cache")
+	}
+
+	cache.Set("file2.go", CacheEntry{ContentHash: "hash2"})
+	if !cache.dirty {
+		t.Error("Expected Set to mark the cache dirty again")
+	}
+	if err := cache.Save("test-project"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Errorf("Expected cache to be written after a change: %v", err)
+	}
+}
+
+func TestCache_ConsecutiveSavesWriteOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache, _ := NewCache(tmpDir, "test-project")
+	cache.Set("file1.go", CacheEntry{ContentHash: "hash1"})
+
+	if err := cache.Save("test-project"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	cachePath := filepath.Join(tmpDir, "test-project.json")
+	first, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("Failed to read cache: %v", err)
+	}
+
+	// A rewrite would carry a new updated_at timestamp
+	time.Sleep(10 * time.Millisecond)
+	if err := cache.Save("test-project"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	second, _ := os.ReadFile(cachePath)
+	if string(first) != string(second) {
+		t.Error("Expected the second Save without changes not to rewrite the cache")
+	}
+}
+
 func TestCache_Clear(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "cache_test")
 	if err != nil {
@@ -254,13 +316,20 @@ func TestCache_Stats(t *testing.T) {
 
 	cache, _ := NewCache(tmpDir, "test-project")
 
+	if stats := cache.Stats(); !stats.LastIndexed.IsZero() {
+		t.Errorf("Expected zero LastIndexed for an empty cache, got %v", stats.LastIndexed)
+	}
+
+	latest := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
 	cache.Set("file1.go", CacheEntry{
 		ContentHash: "hash1",
 		ChunkIDs:    []string{"chunk1", "chunk2", "chunk3"},
+		IndexedAt:   latest,
 	})
 	cache.Set("file2.go", CacheEntry{
 		ContentHash: "hash2",
 		ChunkIDs:    []string{"chunk4", "chunk5"},
+		IndexedAt:   latest.Add(-time.Hour),
 	})
 
 	stats := cache.Stats()
@@ -270,6 +339,9 @@ func TestCache_Stats(t *testing.T) {
 	if stats.ChunkCount != 5 {
 		t.Errorf("Expected ChunkCount 5, got %d", stats.ChunkCount)
 	}
+	if !stats.LastIndexed.Equal(latest) {
+		t.Errorf("Expected LastIndexed %v, got %v", latest, stats.LastIndexed)
+	}
 }
 
 func TestCache_ConcurrentAccess(t *testing.T) {
@@ -317,3 +389,82 @@ func TestCache_ConcurrentAccess(t *testing.T) {
 
 	// If we get here without panic, concurrent access works
 }
+
+func TestCache_ConcurrentSaves(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache, _ := NewCache(tmpDir, "test-project")
+
+	// A background flusher and the indexing workers save while entries change
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				cache.Set(fmt.Sprintf("w%d/file%d.go", w, i), CacheEntry{ContentHash: "hash"})
+				errs <- cache.Save("test-project")
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	// The final save sees every entry and leaves a complete, valid file
+	if err := cache.Save("test-project"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, "test-project.json"))
+	if err != nil {
+		t.Fatalf("Failed to read cache: %v", err)
+	}
+	var file CacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("Cache file is corrupt: %v", err)
+	}
+	if len(file.Files) != 100 {
+		t.Errorf("Expected 100 files in the saved cache, got %d", len(file.Files))
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "test-project.json.tmp")); !os.IsNotExist(err) {
+		t.Error("Expected no leftover temp file")
+	}
+}
+
+func TestEmbeddingCache_FingerprintAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := LoadEmbeddingCache(dir, "proj", "ollama:m:3")
+	if err != nil {
+		t.Fatalf("LoadEmbeddingCache failed: %v", err)
+	}
+	cache.Put("old", []float32{1})
+	cache.Put("kept", []float32{2})
+	if err := cache.Save("proj"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	cache, err = LoadEmbeddingCache(dir, "proj", "ollama:m:3")
+	if err != nil {
+		t.Fatalf("LoadEmbeddingCache failed: %v", err)
+	}
+	if v, ok := cache.Get("kept"); !ok || v[0] != 2 {
+		t.Fatalf("Expected cached vector for \"kept\", got %v, %v", v, ok)
+	}
+	cache.Prune()
+	if cache.Len() != 1 {
+		t.Errorf("Expected unused vector to be pruned, %d left", cache.Len())
+	}
+
+	// A different embedding model invalidates every vector
+	cache, err = LoadEmbeddingCache(dir, "proj", "openai:m:3")
+	if err != nil {
+		t.Fatalf("LoadEmbeddingCache failed: %v", err)
+	}
+	if _, ok := cache.Get("old"); ok || cache.Len() != 0 {
+		t.Errorf("Expected empty cache after fingerprint change, got %d vectors", cache.Len())
+	}
+}
@@ -0,0 +1,81 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/iasik/project-indexer/internal/config"
+)
+
+// DiffResult reports drift between the source tree and the index cache.
+type DiffResult struct {
+	ProjectID string
+
+	// Files present in the source tree but missing from the cache
+	Added []string
+
+	// Files in the cache that no longer exist on disk
+	Deleted []string
+
+	// Files whose current content hash differs from the cached hash
+	Modified []string
+}
+
+// HasDrift reports whether the index is out of sync with the source tree.
+func (d *DiffResult) HasDrift() bool {
+	return len(d.Added) > 0 || len(d.Deleted) > 0 || len(d.Modified) > 0
+}
+
+// Diff compares a project's source tree against its index cache without
+// modifying either. All lists are sorted by path.
+func (idx *Indexer) Diff(ctx context.Context, projectCfg *config.ProjectConfig) (*DiffResult, error) {
+	sourcePath := projectCfg.GetFullSourcePath(idx.cfg.Projects.SourceBasePath)
+	projectCfg, err := projectCfg.WithRepoOverrides(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := NewCache(idx.cfg.Cache.Dir, projectCfg.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+	}
+
+	files, err := idx.discoverFiles(sourcePath, projectCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover files: %w", err)
+	}
+
+	result := &DiffResult{
+		ProjectID: projectCfg.ProjectID,
+		Deleted:   idx.findDeletedFiles(cache, files),
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entry, cached := cache.Get(file.relPath)
+		if !cached {
+			result.Added = append(result.Added, file.relPath)
+			continue
+		}
+		if idx.unchangedByModTime(entry, file) {
+			continue
+		}
+
+		contentHash, err := hashFileFunc(file.absPath)
+		if err != nil {
+			return nil, fmt.Errorf("hash %s: %w", file.relPath, err)
+		}
+		if entry.ContentHash != contentHash {
+			result.Modified = append(result.Modified, file.relPath)
+		}
+	}
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Deleted)
+	sort.Strings(result.Modified)
+	return result, nil
+}
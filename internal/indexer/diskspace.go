@@ -0,0 +1,37 @@
+package indexer
+
+import (
+	"fmt"
+	"os"
+)
+
+// freeDiskSpace reports the bytes available to the current user on the
+// volume holding dir, and whether the platform supports the query.
+// A variable so tests can stub it.
+var freeDiskSpace = diskFreeBytes
+
+// checkFreeDiskSpace fails when the cache directory's volume has less than
+// minFree bytes available. A minFree of 0, or a platform that can't report
+// free space, skips the check.
+func checkFreeDiskSpace(dir string, minFree int64) error {
+	if minFree <= 0 {
+		return nil
+	}
+	// The cache directory is created on first save anyway
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	free, ok, err := freeDiskSpace(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check free disk space in %s: %w", dir, err)
+	}
+	if !ok {
+		return nil
+	}
+	if free < uint64(minFree) {
+		return fmt.Errorf("insufficient disk space in cache directory %s: %d bytes free, cache.min_free_bytes requires %d",
+			dir, free, minFree)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package indexer
+
+// diskFreeBytes reports free space as unknown on platforms without statfs,
+// which disables the cache.min_free_bytes check.
+func diskFreeBytes(dir string) (uint64, bool, error) {
+	return 0, false, nil
+}
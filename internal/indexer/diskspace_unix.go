@@ -0,0 +1,15 @@
+//go:build unix
+
+package indexer
+
+import "syscall"
+
+// diskFreeBytes returns the bytes available to unprivileged users on the
+// volume holding dir.
+func diskFreeBytes(dir string) (uint64, bool, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, false, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), true, nil
+}
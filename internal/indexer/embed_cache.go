@@ -0,0 +1,158 @@
+// Package indexer provides a content-hash embedding cache.
+// Vectors are keyed by a hash of the exact text sent to the embedder, so
+// chunks whose content didn't change are not re-embedded when their file is
+// reprocessed (edits elsewhere in the file, full reindexes).
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EmbeddingCache maps embedded-text hashes to vectors for one project.
+type EmbeddingCache struct {
+	path        string
+	fingerprint string
+	vectors     map[string][]float32
+	used        map[string]bool // keys looked up or stored since load
+	mu          sync.Mutex
+	dirty       bool
+}
+
+// embeddingCacheFile is the JSON structure stored on disk.
+type embeddingCacheFile struct {
+	ProjectID string    `json:"project_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Embedding provider/model/dimensions the vectors were built with
+	EmbeddingFingerprint string `json:"embedding_fingerprint"`
+
+	Vectors map[string][]float32 `json:"vectors"`
+}
+
+// LoadEmbeddingCache loads the project's embedding cache from cacheDir.
+// Vectors built with a different embedding fingerprint are discarded.
+func LoadEmbeddingCache(cacheDir, projectID, fingerprint string) (*EmbeddingCache, error) {
+	cache := newEmbeddingCache(cacheDir, projectID, fingerprint)
+
+	data, err := os.ReadFile(cache.path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+
+	var file embeddingCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding cache: %w", err)
+	}
+	if file.EmbeddingFingerprint == fingerprint && file.Vectors != nil {
+		cache.vectors = file.Vectors
+	} else {
+		cache.dirty = len(file.Vectors) > 0
+	}
+	return cache, nil
+}
+
+// newEmbeddingCache returns an empty embedding cache for the project.
+func newEmbeddingCache(cacheDir, projectID, fingerprint string) *EmbeddingCache {
+	return &EmbeddingCache{
+		path:        filepath.Join(cacheDir, "embeddings", projectID+".json"),
+		fingerprint: fingerprint,
+		vectors:     make(map[string][]float32),
+		used:        make(map[string]bool),
+	}
+}
+
+// embeddingCacheKey returns the cache key for an embedded text.
+func embeddingCacheKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached vector for text, if any.
+func (c *EmbeddingCache) Get(text string) ([]float32, bool) {
+	key := embeddingCacheKey(text)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	vector, ok := c.vectors[key]
+	if ok {
+		c.used[key] = true
+	}
+	return vector, ok
+}
+
+// Put stores the vector for text.
+func (c *EmbeddingCache) Put(text string, vector []float32) {
+	key := embeddingCacheKey(text)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vectors[key] = vector
+	c.used[key] = true
+	c.dirty = true
+}
+
+// Len returns the number of cached vectors.
+func (c *EmbeddingCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.vectors)
+}
+
+// Prune drops vectors not looked up or stored since the cache was loaded.
+// Call it after a run that embedded every chunk of the project (a full
+// reindex), so vectors of deleted or changed content don't accumulate.
+func (c *EmbeddingCache) Prune() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.vectors {
+		if !c.used[key] {
+			delete(c.vectors, key)
+			c.dirty = true
+		}
+	}
+}
+
+// Save writes the cache to disk if it changed since the last successful save.
+func (c *EmbeddingCache) Save(projectID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(embeddingCacheFile{
+		ProjectID:            projectID,
+		UpdatedAt:            time.Now().UTC(),
+		EmbeddingFingerprint: c.fingerprint,
+		Vectors:              c.vectors,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal embedding cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create embedding cache directory: %w", err)
+	}
+
+	// Write atomically using temp file
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save embedding cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
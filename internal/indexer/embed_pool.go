@@ -0,0 +1,147 @@
+// Package indexer provides a bounded embedding pipeline for indexing.
+// It keeps several embedding batches in flight so GPU-backed providers
+// stay busy between batches instead of idling on each round-trip.
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// warmupBatchSize is the number of texts sent ahead of the pipeline to warm the model.
+const warmupBatchSize = 2
+
+// embedBatch is a contiguous range of texts embedded in one request.
+type embedBatch struct {
+	num   int
+	start int
+	end   int
+}
+
+// embedTexts embeds texts in batches, returning vectors in input order.
+// With warm_pool_size > 1 a warm-up batch is embedded first, then up to
+// warm_pool_size batches are kept in flight concurrently.
+func (idx *Indexer) embedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	batchSize := idx.cfg.Embedding.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(texts)
+	}
+	poolSize := idx.cfg.Embedding.WarmPoolSize
+	if poolSize < 1 {
+		poolSize = 1
+	}
+
+	// Build batch ranges (warm-up batch first when pipelining)
+	var batches []embedBatch
+	offset := 0
+	if poolSize > 1 && len(texts) > warmupBatchSize {
+		warmup := warmupBatchSize
+		if warmup > batchSize {
+			warmup = batchSize
+		}
+		batches = append(batches, embedBatch{num: 1, start: 0, end: warmup})
+		offset = warmup
+	}
+	for i := offset; i < len(texts); i += batchSize {
+		end := i + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batches = append(batches, embedBatch{num: len(batches) + 1, start: i, end: end})
+	}
+
+	allVectors := make([][]float32, len(texts))
+	totalBatches := len(batches)
+	embedStart := time.Now()
+
+	var progressMu sync.Mutex
+	completed := 0
+	runBatch := func(ctx context.Context, b embedBatch) error {
+		batchStart := time.Now()
+		vectors, err := idx.embedder.EmbedBatch(ctx, texts[b.start:b.end])
+		batchDuration := time.Since(batchStart)
+		if err != nil {
+			return fmt.Errorf("embed batch %d-%d: %w", b.start, b.end, err)
+		}
+		if len(vectors) != b.end-b.start {
+			return fmt.Errorf("embed batch %d-%d: expected %d vectors, got %d", b.start, b.end, b.end-b.start, len(vectors))
+		}
+		copy(allVectors[b.start:b.end], vectors)
+
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		completed++
+
+		// Calculate ETA
+		elapsed := time.Since(embedStart)
+		avgPerBatch := elapsed / time.Duration(completed)
+		eta := avgPerBatch * time.Duration(totalBatches-completed)
+
+		fmt.Printf("[Embedding] Batch %d/%d (%d chunks) | took: %s | ETA: %s\n",
+			b.num, totalBatches, b.end-b.start,
+			batchDuration.Round(time.Millisecond),
+			eta.Round(time.Second))
+		return nil
+	}
+
+	if poolSize == 1 {
+		for _, b := range batches {
+			if err := runBatch(ctx, b); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Warm-up batch runs alone so the model is loaded before the pipeline fills
+		if err := runBatch(ctx, batches[0]); err != nil {
+			return nil, err
+		}
+		if err := idx.runEmbedPipeline(ctx, batches[1:], poolSize, runBatch); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Printf("[Embedding] Complete: %d chunks in %s\n", len(texts), time.Since(embedStart).Round(time.Second))
+	return allVectors, nil
+}
+
+// runEmbedPipeline runs batches with at most depth requests in flight.
+// The first error cancels remaining batches and is returned.
+func (idx *Indexer) runEmbedPipeline(ctx context.Context, batches []embedBatch, depth int, run func(context.Context, embedBatch) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, depth)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+
+	for _, b := range batches {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(b embedBatch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := run(ctx, b); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(b)
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
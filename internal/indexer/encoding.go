@@ -0,0 +1,36 @@
+package indexer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// errUndecodable is returned when no allowed encoding decodes a file cleanly.
+var errUndecodable = errors.New("content is not valid UTF-8 or any allowed encoding")
+
+// transcodeToUTF8 returns content unchanged if it is valid UTF-8; otherwise it
+// decodes it with the first allowed encoding that yields no replacement
+// characters. Encoding names are WHATWG labels (e.g. "windows-1252", "latin1").
+func transcodeToUTF8(content []byte, encodings []string) ([]byte, error) {
+	if utf8.Valid(content) {
+		return content, nil
+	}
+
+	for _, name := range encodings {
+		enc, err := htmlindex.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown encoding %s: %w", name, err)
+		}
+		decoded, err := enc.NewDecoder().Bytes(content)
+		if err != nil || !utf8.Valid(decoded) || bytes.ContainsRune(decoded, utf8.RuneError) {
+			continue
+		}
+		return decoded, nil
+	}
+
+	return nil, errUndecodable
+}
@@ -0,0 +1,217 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/iasik/project-indexer/internal/config"
+)
+
+// gitignoreRule is a single .gitignore pattern.
+type gitignoreRule struct {
+	pattern *regexp.Regexp // over slash-separated paths relative to the .gitignore's directory
+	negate  bool           // "!pattern" re-includes a path
+	dirOnly bool           // "pattern/" only matches directories
+}
+
+// gitignoreFile is the parsed .gitignore of a directory.
+type gitignoreFile struct {
+	dir   string
+	rules []gitignoreRule
+}
+
+// gitignoreMatcher reports paths ignored by .gitignore files, from the one
+// in the path's own directory up to the git root (or the source root outside
+// a repository). Each directory's file is read once.
+type gitignoreMatcher struct {
+	top   string
+	byDir map[string][]*gitignoreFile // applying files per directory, outermost first
+}
+
+// newGitignoreMatcher creates a matcher for a source tree rooted at root.
+func newGitignoreMatcher(root string) *gitignoreMatcher {
+	top := root
+	if gitRoot := config.FindGitRoot(root); gitRoot != "" {
+		top = gitRoot
+	}
+	return &gitignoreMatcher{top: top, byDir: make(map[string][]*gitignoreFile)}
+}
+
+// Ignored reports whether path is ignored. As in git, the last matching
+// rule wins and rules of deeper .gitignore files come last. A nil matcher
+// ignores nothing.
+func (m *gitignoreMatcher) Ignored(path string, isDir bool) (bool, error) {
+	if m == nil {
+		return false, nil
+	}
+	files, err := m.files(filepath.Dir(path))
+	if err != nil {
+		return false, err
+	}
+
+	ignored := false
+	for _, f := range files {
+		rel, err := filepath.Rel(f.dir, path)
+		if err != nil {
+			return false, err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, rule := range f.rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.pattern.MatchString(rel) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored, nil
+}
+
+// files returns the .gitignore files applying to entries of dir.
+func (m *gitignoreMatcher) files(dir string) ([]*gitignoreFile, error) {
+	if files, ok := m.byDir[dir]; ok {
+		return files, nil
+	}
+
+	var files []*gitignoreFile
+	if parent := filepath.Dir(dir); dir != m.top && parent != dir {
+		inherited, err := m.files(parent)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, inherited...)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read .gitignore: %w", err)
+	}
+	if err == nil {
+		rules, err := parseGitignore(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, ".gitignore"), err)
+		}
+		files = append(files, &gitignoreFile{dir: dir, rules: rules})
+	}
+
+	m.byDir[dir] = files
+	return files, nil
+}
+
+// parseGitignore parses .gitignore content. Blank lines and comments are
+// ignored; "\#" and "\!" escape a leading "#" or "!".
+func parseGitignore(data []byte) ([]gitignoreRule, error) {
+	var rules []gitignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule gitignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		re, err := gitignorePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		rule.pattern = re
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// gitignorePattern converts a .gitignore pattern (without "!" and trailing
+// "/") into a regexp over slash-separated relative paths. Patterns with a
+// slash are anchored to the .gitignore's directory; others match a name at
+// any depth. "**" spans directories and [...] is a character class.
+func gitignorePattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case pattern[i:] == "/**":
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		case pattern[i] == '[':
+			end := classEnd(pattern, i)
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i = end
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// classEnd returns the index of the "]" closing the character class opened
+// at start, or -1. A "]" right after "[" or "[!" is a literal member.
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '!' {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		if pattern[i] == ']' {
+			return i
+		}
+	}
+	return -1
+}
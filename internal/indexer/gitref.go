@@ -0,0 +1,76 @@
+package indexer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readGitRef returns the commit checked out in the repository at root, read
+// directly from .git (no git binary needed). Returns "" when root is empty or
+// the ref cannot be resolved; source URLs then fall back to the configured ref.
+func readGitRef(root string) string {
+	if root == "" {
+		return ""
+	}
+	gitDir := resolveGitDir(filepath.Join(root, ".git"))
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	ref, symbolic := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !symbolic {
+		// Detached HEAD holds the commit itself
+		return ref
+	}
+
+	if data, err := os.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	if commit := lookupPackedRef(gitDir, ref); commit != "" {
+		return commit
+	}
+	// Unborn or unreadable branch: the branch name is still a usable ref
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
+
+// resolveGitDir follows a "gitdir: <path>" file (worktrees, submodules) to the
+// actual git directory. Plain .git directories are returned unchanged.
+func resolveGitDir(dotGit string) string {
+	info, err := os.Stat(dotGit)
+	if err != nil || info.IsDir() {
+		return dotGit
+	}
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return dotGit
+	}
+	dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return dotGit
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(dotGit), dir)
+	}
+	return dir
+}
+
+// lookupPackedRef finds ref in gitDir/packed-refs ("<sha> <ref>" lines).
+func lookupPackedRef(gitDir, ref string) string {
+	f, err := os.Open(filepath.Join(gitDir, "packed-refs"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		commit, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == ref {
+			return commit
+		}
+	}
+	return ""
+}
@@ -3,15 +3,22 @@
 package indexer
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,12 +31,21 @@ import (
 
 // Indexer handles project indexing operations.
 type Indexer struct {
-	cfg             *config.Config
-	embedder        embedder.Provider
-	vectorDB        vectordb.Provider
-	chunkerFactory  *chunker.Factory
-	logger          *slog.Logger
-	workerCount     int
+	cfg            *config.Config
+	embedder       embedder.Provider
+	vectorDB       vectordb.Provider
+	chunkerFactory *chunker.Factory
+	tokenizer      chunker.Tokenizer
+	logger         *slog.Logger
+	workerCount    int
+	modifiedAfter  time.Time
+	generation     string            // index generation stamped on upserted points
+	owners         OwnerResolver     // nil when ownership capture is off
+	gitRef         string            // commit checked out in the source tree, if any
+	customMetadata map[string]string // project metadata.custom stamped on upserted points
+	slowestFiles   int               // number of slowest files to report (0 = off)
+	onProgress     ProgressFunc      // nil when nobody subscribes to progress
+	embedCache     *EmbeddingCache   // nil when cache.embedding_cache is off
 }
 
 // NewIndexer creates a new indexer instance.
@@ -39,16 +55,46 @@ func NewIndexer(
 	vdb vectordb.Provider,
 	logger *slog.Logger,
 ) *Indexer {
+	tokenizer, err := chunker.LoadTokenizer(cfg.Chunking.TokenizerFile)
+	if err != nil {
+		logger.Warn("failed to load tokenizer, using cl100k_base",
+			"file", cfg.Chunking.TokenizerFile,
+			"error", err)
+		tokenizer = chunker.DefaultTokenizer()
+	}
+
 	return &Indexer{
 		cfg:            cfg,
 		embedder:       emb,
 		vectorDB:       vdb,
-		chunkerFactory: chunker.NewFactory(cfg.Chunking),
+		chunkerFactory: chunker.NewFactory(cfg.Chunking, tokenizer),
+		tokenizer:      tokenizer,
 		logger:         logger,
 		workerCount:    4, // Parallel file processing
 	}
 }
 
+// SetModifiedAfter sets a coarse pre-filter: already-cached files whose
+// mod-time is not after t are skipped without hashing. The walk itself is
+// not pruned: a directory's mod-time doesn't change when a file in it is
+// edited, and every file must still be listed to detect deletions. Zero
+// disables it.
+func (idx *Indexer) SetModifiedAfter(t time.Time) {
+	idx.modifiedAfter = t
+}
+
+// SetSlowestFiles enables recording the n slowest files of each run in
+// IndexResult.SlowestFiles. Zero disables it.
+func (idx *Indexer) SetSlowestFiles(n int) {
+	idx.slowestFiles = n
+}
+
+// SetProgressFunc registers fn to receive a ProgressEvent as each file
+// completes, e.g. to stream progress to a dashboard. Nil disables it.
+func (idx *Indexer) SetProgressFunc(fn ProgressFunc) {
+	idx.onProgress = fn
+}
+
 // IndexResult contains the results of an indexing operation.
 type IndexResult struct {
 	ProjectID       string
@@ -58,9 +104,32 @@ type IndexResult struct {
 	FilesDeleted    int
 	ChunksCreated   int
 	ChunksDeleted   int
+	ChunksSkipped   int // empty or below indexing.min_chunk_chars, not embedded
+	ChunksExcluded  int // matched the project's exclude_symbols, not embedded
+	ChunksSplit     int // exceeded the token limit and were split at line boundaries
+	ChunksRelocated int // unchanged content at new lines; payload updated, not re-embedded
 	OversizedChunks []OversizedChunk
 	Duration        time.Duration
 	Errors          []error
+
+	// Embedding tokens reported by the provider during this run (0 if not reported)
+	EmbeddingTokens int
+
+	// Estimated embedding cost from embedding.price_per_1k_tokens (0 if unset)
+	EstimatedCost float64
+
+	// Path of the oversized chunks report (empty if none was written)
+	OversizedReportPath string
+
+	// Slowest processed files, slowest first (only when SetSlowestFiles > 0)
+	SlowestFiles []FileTiming
+}
+
+// FileTiming records how long a file took to read, chunk and diff.
+type FileTiming struct {
+	FilePath string        `json:"file_path"`
+	Duration time.Duration `json:"duration_ns"`
+	Chunks   int           `json:"chunks"`
 }
 
 // OversizedChunk represents a chunk that exceeds token limits.
@@ -73,6 +142,7 @@ type OversizedChunk struct {
 }
 
 // IndexProject indexes a single project.
+// An in-repo overrides file in the project source is merged over projectCfg.
 func (idx *Indexer) IndexProject(ctx context.Context, projectCfg *config.ProjectConfig, fullIndex bool) (*IndexResult, error) {
 	startTime := time.Now()
 	result := &IndexResult{
@@ -80,29 +150,76 @@ func (idx *Indexer) IndexProject(ctx context.Context, projectCfg *config.Project
 		Errors:    make([]error, 0),
 	}
 
+	projectCfg, err := projectCfg.WithRepoOverrides(projectCfg.GetFullSourcePath(idx.cfg.Projects.SourceBasePath))
+	if err != nil {
+		return nil, err
+	}
+
+	// Account embedding token usage for this run
+	usage := &embedder.Usage{}
+	ctx = embedder.WithUsage(ctx, usage)
+	defer func() {
+		result.EmbeddingTokens = usage.Tokens()
+		result.EstimatedCost = idx.cfg.Embedding.EstimateCost(result.EmbeddingTokens)
+	}()
+
 	idx.logger.Info("starting indexing",
 		"project", projectCfg.ProjectID,
 		"full_index", fullIndex)
 
+	excludeSymbols, err := projectCfg.ExcludeSymbolPatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail fast instead of running out of space mid-run
+	if err := checkFreeDiskSpace(idx.cfg.Cache.Dir, idx.cfg.Cache.MinFreeBytes); err != nil {
+		return nil, err
+	}
+
 	// Load or create cache
 	cache, err := NewCache(idx.cfg.Cache.Dir, projectCfg.ProjectID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize cache: %w", err)
 	}
 
+	// Force a full re-embed when the embedding model changed since the last run
+	fingerprint := idx.embeddingFingerprint()
+	if stored := cache.Fingerprint(); stored != "" && stored != fingerprint && !fullIndex {
+		idx.logger.Warn("embedding model changed, forcing full re-embed",
+			"project", projectCfg.ProjectID,
+			"previous", stored,
+			"current", fingerprint)
+		fullIndex = true
+	}
+
+	idx.generation = cache.Generation()
 	if fullIndex {
-		// Clear cache for full reindex
+		// Index into a new generation; the previous one is only deleted
+		// after the new one is fully upserted, so failures keep it intact
+		idx.generation = newGeneration()
 		cache.Clear()
-		// Delete all vectors for this project
-		if err := idx.vectorDB.DeleteByFilter(ctx, vectordb.Filter{ProjectID: projectCfg.ProjectID}); err != nil {
-			return nil, fmt.Errorf("failed to clear vectors: %w", err)
+		idx.logger.Info("starting new index generation",
+			"project", projectCfg.ProjectID,
+			"generation", idx.generation)
+	}
+	cache.SetFingerprint(fingerprint)
+
+	// A broken embedding cache only costs re-embedding, so start empty
+	idx.embedCache = nil
+	if idx.cfg.Cache.EmbeddingCache {
+		idx.embedCache, err = LoadEmbeddingCache(idx.cfg.Cache.Dir, projectCfg.ProjectID, fingerprint)
+		if err != nil {
+			idx.logger.Warn("embedding cache unreadable, starting empty",
+				"project", projectCfg.ProjectID,
+				"error", err)
+			idx.embedCache = newEmbeddingCache(idx.cfg.Cache.Dir, projectCfg.ProjectID, fingerprint)
 		}
-		idx.logger.Info("cleared existing index", "project", projectCfg.ProjectID)
 	}
 
 	// Get effective chunking config
 	chunkCfg := projectCfg.GetEffectiveChunking(idx.cfg.Chunking)
-	idx.chunkerFactory = chunker.NewFactory(chunkCfg)
+	idx.chunkerFactory = chunker.NewFactory(chunkCfg, idx.tokenizer)
 
 	// Discover files
 	sourcePath := projectCfg.GetFullSourcePath(idx.cfg.Projects.SourceBasePath)
@@ -110,12 +227,24 @@ func (idx *Indexer) IndexProject(ctx context.Context, projectCfg *config.Project
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover files: %w", err)
 	}
+
+	// Ownership is optional metadata; index without it rather than fail
+	idx.owners, err = newOwnerResolver(projectCfg.Ownership, projectCfg.GetPathRoot(sourcePath))
+	if err != nil {
+		idx.logger.Warn("ownership capture disabled",
+			"project", projectCfg.ProjectID,
+			"error", err)
+		idx.owners = nil
+	}
+	idx.gitRef = readGitRef(config.FindGitRoot(sourcePath))
+	idx.customMetadata = projectCfg.Metadata.Custom
 	result.FilesScanned = len(files)
 	idx.logger.Info("discovered files", "count", len(files))
 
 	// Find deleted files (in cache but not in filesystem)
+	var deletedFiles []string
 	if !fullIndex {
-		deletedFiles := idx.findDeletedFiles(cache, files)
+		deletedFiles = idx.findDeletedFiles(cache, files)
 		for _, filePath := range deletedFiles {
 			chunkIDs := cache.GetChunkIDs(filePath)
 			if len(chunkIDs) > 0 {
@@ -136,13 +265,34 @@ func (idx *Indexer) IndexProject(ctx context.Context, projectCfg *config.Project
 	// Process files
 	filesToProcess := make([]fileToProcess, 0)
 	for _, file := range files {
-		contentHash, err := hashFile(file.absPath)
+		// Coarse mod-time pre-filter before hash-based change detection
+		if !fullIndex && !idx.modifiedAfter.IsZero() && !file.modTime.After(idx.modifiedAfter) {
+			if _, cached := cache.Get(file.relPath); cached {
+				result.FilesSkipped++
+				continue
+			}
+		}
+
+		// Files still holding newly excluded symbols are reprocessed to prune them
+		entry, cached := cache.Get(file.relPath)
+		pruneSymbols := cached && hasExcludedChunks(entry, projectCfg.ProjectID, file.relPath, excludeSymbols)
+		if !fullIndex && cached && !pruneSymbols && idx.unchangedByModTime(entry, file) {
+			result.FilesSkipped++
+			continue
+		}
+
+		contentHash, err := hashFileFunc(file.absPath)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("hash %s: %w", file.relPath, err))
 			continue
 		}
 
-		if !fullIndex && !cache.HasChanged(file.relPath, contentHash) {
+		if !fullIndex && cached && !pruneSymbols && entry.ContentHash == contentHash {
+			// The hash is authoritative; refresh the stat so the next run can skip hashing
+			if idx.cfg.Cache.TrustModTime && (!entry.ModTime.Equal(file.modTime) || entry.Size != file.size) {
+				entry.ModTime, entry.Size = file.modTime, file.size
+				cache.Set(file.relPath, entry)
+			}
 			result.FilesSkipped++
 			continue
 		}
@@ -151,25 +301,86 @@ func (idx *Indexer) IndexProject(ctx context.Context, projectCfg *config.Project
 			absPath:     file.absPath,
 			relPath:     file.relPath,
 			contentHash: contentHash,
+			modTime:     file.modTime,
+			size:        file.size,
 		})
 	}
 
+	// Merged sibling chunks span files, so a change anywhere in a directory
+	// rechunks all of it
+	if chunkCfg.MergeSiblingFiles && !fullIndex {
+		for _, file := range siblingFiles(files, filesToProcess, deletedFiles) {
+			contentHash, err := hashFileFunc(file.absPath)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("hash %s: %w", file.relPath, err))
+				continue
+			}
+			result.FilesSkipped--
+			filesToProcess = append(filesToProcess, fileToProcess{
+				absPath:     file.absPath,
+				relPath:     file.relPath,
+				contentHash: contentHash,
+				modTime:     file.modTime,
+				size:        file.size,
+			})
+		}
+	}
+
 	idx.logger.Info("files to process",
 		"total", len(files),
 		"changed", len(filesToProcess),
 		"skipped", result.FilesSkipped)
 
 	// Process changed files in parallel
-	processResult := idx.processFiles(ctx, filesToProcess, projectCfg, cache)
+	processResult := idx.processFiles(ctx, filesToProcess, projectCfg, excludeSymbols, cache, fullIndex)
+	if processResult.limitErr != nil {
+		return nil, processResult.limitErr
+	}
 	result.FilesIndexed = processResult.filesIndexed
 	result.ChunksCreated = processResult.chunksCreated
 	result.ChunksDeleted += processResult.chunksDeleted
+	result.ChunksSkipped = processResult.chunksSkipped
+	result.ChunksExcluded = processResult.chunksExcluded
+	result.ChunksSplit = processResult.chunksSplit
+	result.ChunksRelocated = processResult.chunksRelocated
 	result.OversizedChunks = processResult.oversizedChunks
+	result.SlowestFiles = processResult.slowestFiles
 	result.Errors = append(result.Errors, processResult.errors...)
 
 	// Save oversized chunks report if any
 	if len(result.OversizedChunks) > 0 {
-		idx.saveOversizedReport(projectCfg.ProjectID, result.OversizedChunks)
+		result.OversizedReportPath = idx.saveOversizedReport(projectCfg.ProjectID, result.OversizedChunks)
+	}
+
+	if fullIndex {
+		if processResult.storeFailed || ctx.Err() != nil {
+			// Keep the previous generation and cache; the next run retries
+			result.Errors = append(result.Errors, fmt.Errorf("full reindex incomplete, previous index kept"))
+			result.Duration = time.Since(startTime)
+			return result, nil
+		}
+
+		// Drop vectors from superseded generations, counted first so
+		// ChunksDeleted covers them (an unknown count is left out)
+		superseded := vectordb.Filter{
+			ProjectID:         projectCfg.ProjectID,
+			ExcludeGeneration: idx.generation,
+		}
+		staleCount, countErr := idx.vectorDB.Count(ctx, superseded)
+		if err := idx.vectorDB.DeleteByFilter(ctx, superseded); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("delete previous generation: %w", err))
+		} else if countErr == nil {
+			result.ChunksDeleted += staleCount
+		}
+		cache.SetGeneration(idx.generation)
+
+		// Every chunk was embedded or looked up, so the rest is stale
+		if idx.embedCache != nil {
+			idx.embedCache.Prune()
+			if err := idx.embedCache.Save(projectCfg.ProjectID); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("save embedding cache: %w", err))
+			}
+		}
 	}
 
 	// Save cache
@@ -182,6 +393,7 @@ func (idx *Indexer) IndexProject(ctx context.Context, projectCfg *config.Project
 		"project", projectCfg.ProjectID,
 		"files_indexed", result.FilesIndexed,
 		"chunks_created", result.ChunksCreated,
+		"embedding_tokens", usage.Tokens(),
 		"duration", result.Duration)
 
 	return result, nil
@@ -191,18 +403,28 @@ func (idx *Indexer) IndexProject(ctx context.Context, projectCfg *config.Project
 type discoveredFile struct {
 	absPath string
 	relPath string
+	modTime time.Time
+	size    int64
 }
 
 // discoverFiles finds all indexable files in the project.
 func (idx *Indexer) discoverFiles(rootPath string, projectCfg *config.ProjectConfig) ([]discoveredFile, error) {
 	var files []discoveredFile
 
+	// Stored paths may be anchored above the source root (path_root: git)
+	pathRoot := projectCfg.GetPathRoot(rootPath)
+
+	var gitignore *gitignoreMatcher
+	if projectCfg.RespectGitignore {
+		gitignore = newGitignoreMatcher(rootPath)
+	}
+
 	err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Get relative path
+		// Get relative path (exclusions are relative to the source root)
 		relPath, err := filepath.Rel(rootPath, path)
 		if err != nil {
 			return err
@@ -216,6 +438,20 @@ func (idx *Indexer) discoverFiles(rootPath string, projectCfg *config.ProjectCon
 			return nil
 		}
 
+		// Check .gitignore files (the source root itself is always walked)
+		if relPath != "." {
+			ignored, err := gitignore.Ignored(path, d.IsDir())
+			if err != nil {
+				return err
+			}
+			if ignored {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
 		// Skip directories
 		if d.IsDir() {
 			return nil
@@ -226,14 +462,37 @@ func (idx *Indexer) discoverFiles(rootPath string, projectCfg *config.ProjectCon
 			return nil
 		}
 
-		files = append(files, discoveredFile{
+		storedPath, err := filepath.Rel(pathRoot, path)
+		if err != nil {
+			return err
+		}
+
+		file := discoveredFile{
 			absPath: path,
-			relPath: relPath,
-		})
+			relPath: filepath.ToSlash(storedPath),
+		}
+
+		// Mod-time is only needed for the --modified-after pre-filter
+		// and the cache.trust_modtime fast-path
+		if !idx.modifiedAfter.IsZero() || idx.cfg.Cache.TrustModTime {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			file.modTime = info.ModTime()
+			file.size = info.Size()
+		}
+
+		files = append(files, file)
 
 		return nil
 	})
 
+	// Sort by stored path so every run processes files in the same order
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].relPath < files[j].relPath
+	})
+
 	return files, err
 }
 
@@ -258,6 +517,16 @@ type fileToProcess struct {
 	absPath     string
 	relPath     string
 	contentHash string
+	modTime     time.Time
+	size        int64
+}
+
+// unchangedByModTime reports whether a cached file can be skipped without
+// hashing because cache.trust_modtime is enabled and its mod-time and size
+// both match the cache entry.
+func (idx *Indexer) unchangedByModTime(entry CacheEntry, file discoveredFile) bool {
+	return idx.cfg.Cache.TrustModTime && !entry.ModTime.IsZero() &&
+		entry.ModTime.Equal(file.modTime) && entry.Size == file.size
 }
 
 // processResult contains results from parallel file processing.
@@ -265,17 +534,24 @@ type processResult struct {
 	filesIndexed    int
 	chunksCreated   int
 	chunksDeleted   int
+	chunksSkipped   int
+	chunksExcluded  int
+	chunksSplit     int
+	chunksRelocated int
 	oversizedChunks []OversizedChunk
 	errors          []error
+	storeFailed     bool  // a vector DB write failed
+	limitErr        error // set when indexing.max_chunks_per_project was exceeded
+	slowestFiles    []FileTiming
 }
 
 // ProgressStats tracks processing progress and timing.
 type ProgressStats struct {
-	mu              sync.Mutex
-	totalFiles      int
-	processedFiles  int64
-	startTime       time.Time
-	fileTimes       []time.Duration
+	mu             sync.Mutex
+	totalFiles     int
+	processedFiles int64
+	startTime      time.Time
+	fileTimes      []time.Duration
 }
 
 // Update records a completed file and its processing time.
@@ -290,10 +566,10 @@ func (p *ProgressStats) Update(duration time.Duration) {
 func (p *ProgressStats) GetStats() (processed int, total int, avgDuration time.Duration, eta time.Duration) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	
+
 	processed = int(atomic.LoadInt64(&p.processedFiles))
 	total = p.totalFiles
-	
+
 	if len(p.fileTimes) > 0 {
 		var sum time.Duration
 		for _, d := range p.fileTimes {
@@ -306,12 +582,53 @@ func (p *ProgressStats) GetStats() (processed int, total int, avgDuration time.D
 	return
 }
 
+// ProgressEvent reports indexing progress after a file completes.
+type ProgressEvent struct {
+	ProjectID      string
+	File           string // file that just completed
+	FilesProcessed int
+	FilesTotal     int
+	Elapsed        time.Duration
+	AvgPerFile     time.Duration
+	ETA            time.Duration
+}
+
+// ProgressFunc receives progress events in completion order. It is called
+// from the indexing goroutine, so it should return quickly.
+type ProgressFunc func(ProgressEvent)
+
+// fileResult is the outcome of chunking one file in processFiles.
+type fileResult struct {
+	relPath       string
+	chunks        []chunker.Chunk
+	produced      []chunker.Chunk // every chunk of the file, changed or not
+	chunkIDs      []string
+	chunkHashes   map[string]string // chunk_id -> content_hash
+	chunkLines    map[string]int    // chunk_id -> start_line
+	shifted       []chunker.Chunk   // unchanged chunks whose lines moved
+	hash          string
+	modTime       time.Time
+	size          int64
+	oversized     []OversizedChunk
+	deletedChunks []string // chunk IDs to delete
+	skipped       int      // empty chunks dropped before embedding
+	excluded      int      // chunks dropped by exclude_symbols
+	split         int      // oversized chunks split into sub-chunks
+	duration      time.Duration
+	warning       error // non-fatal (e.g. recovered chunker panic)
+	err           error
+}
+
 // processFiles processes files in parallel with progress reporting.
+// Incremental runs commit periodically when cache.flush_every_n or
+// cache.flush_interval is set.
 func (idx *Indexer) processFiles(
 	ctx context.Context,
 	files []fileToProcess,
 	projectCfg *config.ProjectConfig,
+	excludeSymbols []*regexp.Regexp,
 	cache *Cache,
+	fullIndex bool,
 ) processResult {
 	result := processResult{
 		errors:          make([]error, 0),
@@ -329,13 +646,17 @@ func (idx *Indexer) processFiles(
 		fileTimes:  make([]time.Duration, 0, totalFiles),
 	}
 
+	// Workers stop early when the chunk limit aborts the run
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Progress reporter - prints status every 3 seconds
 	progressDone := make(chan struct{})
 	go func() {
 		defer close(progressDone)
 		ticker := time.NewTicker(3 * time.Second)
 		defer ticker.Stop()
-		
+
 		for {
 			select {
 			case <-ctx.Done():
@@ -345,16 +666,16 @@ func (idx *Indexer) processFiles(
 				if processed >= total {
 					return
 				}
-				
+
 				percent := float64(processed) / float64(total) * 100
 				elapsed := time.Since(stats.startTime).Round(time.Second)
-				
+
 				// Format ETA
 				etaStr := "calculating..."
 				if avgDur > 0 && processed > 0 {
 					etaStr = eta.Round(time.Second).String()
 				}
-				
+
 				// Print progress (with newline for Docker compatibility)
 				fmt.Printf("[Progress] %d/%d files (%.1f%%) | Elapsed: %s | ETA: %s | Avg: %s/file\n",
 					processed, total, percent, elapsed, etaStr, avgDur.Round(time.Millisecond))
@@ -370,24 +691,13 @@ func (idx *Indexer) processFiles(
 	close(workCh)
 
 	// Result collection
-	type fileResult struct {
-		relPath       string
-		chunks        []chunker.Chunk
-		chunkIDs      []string
-		chunkHashes   map[string]string // chunk_id -> content_hash
-		hash          string
-		oversized     []OversizedChunk
-		deletedChunks []string // chunk IDs to delete
-		duration      time.Duration
-		err           error
-	}
 	resultCh := make(chan fileResult, len(files))
 
 	// Token limit for embedding model (nomic-embed-text = 2048)
-	// Using ~2.5 chars per token for conservative estimation
 	// This catches chunks that might get truncated by the model
 	const maxTokens = 2048
-	const charsPerToken = 2.5
+	chunkCfg := projectCfg.GetEffectiveChunking(idx.cfg.Chunking)
+	updateLines := idx.cfg.Indexing.UpdateShiftedLines
 
 	// Start workers
 	var wg sync.WaitGroup
@@ -403,62 +713,51 @@ func (idx *Indexer) processFiles(
 				}
 
 				fileStart := time.Now()
-				chunks, err := idx.processFile(ctx, file, projectCfg)
+				chunks, warning, err := idx.processFile(ctx, file, projectCfg)
 				fileDuration := time.Since(fileStart)
 
-				var chunkIDs []string
-				var oversized []OversizedChunk
-				chunkHashes := make(map[string]string)
-				var deletedChunks []string
+				chunks, skipped := idx.dropEmptyChunks(chunks)
+				chunks, excluded := dropExcludedSymbols(chunks, excludeSymbols)
+				chunks, split := idx.splitOversizedChunks(chunks, chunkCfg, maxTokens)
 
-				// Get cached chunk hashes for this file
-				cachedHashes := cache.GetChunkHashes(file.relPath)
-
-				// Track which chunks are new/changed vs unchanged
-				var changedChunks []chunker.Chunk
-				newChunkIDs := make(map[string]bool)
+				diff := diffChunks(cache, file.relPath, chunks, updateLines)
 
+				// Chunks still over the limit couldn't be split (e.g. one giant line)
+				var oversized []OversizedChunk
 				for _, c := range chunks {
-					chunkIDs = append(chunkIDs, c.ID)
-					chunkHashes[c.ID] = c.ContentHash
-					newChunkIDs[c.ID] = true
-
-					// Check if chunk has changed
-					if cachedHash, exists := cachedHashes[c.ID]; !exists || cachedHash != c.ContentHash {
-						changedChunks = append(changedChunks, c)
-					}
-
-					// Check for oversized chunks
-					estimatedTokens := int(float64(len(c.Content)) / charsPerToken)
-					if estimatedTokens > maxTokens {
+					limit := tokenLimit(chunkCfg, maxTokens, c.SymbolType)
+					estimatedTokens := idx.tokenizer.CountTokens(c.Content)
+					if estimatedTokens > limit {
 						oversized = append(oversized, OversizedChunk{
 							FilePath:    file.relPath,
 							Symbol:      c.Symbol,
 							TokenCount:  estimatedTokens,
-							MaxAllowed:  maxTokens,
+							MaxAllowed:  limit,
 							ContentSize: len(c.Content),
 						})
 					}
 				}
 
-				// Find deleted chunks (in cache but not in new chunks)
-				for cachedID := range cachedHashes {
-					if !newChunkIDs[cachedID] {
-						deletedChunks = append(deletedChunks, cachedID)
-					}
-				}
-
 				stats.Update(fileDuration)
 
 				resultCh <- fileResult{
 					relPath:       file.relPath,
-					chunks:        changedChunks, // Only changed chunks for embedding
-					chunkIDs:      chunkIDs,
-					chunkHashes:   chunkHashes,
+					chunks:        diff.changed, // Only changed chunks for embedding
+					produced:      chunks,
+					chunkIDs:      diff.ids,
+					chunkHashes:   diff.hashes,
+					chunkLines:    diff.lines,
+					shifted:       diff.shifted,
 					hash:          file.contentHash,
+					modTime:       file.modTime,
+					size:          file.size,
 					oversized:     oversized,
-					deletedChunks: deletedChunks,
+					deletedChunks: diff.deleted,
+					skipped:       skipped,
+					excluded:      excluded,
+					split:         split,
 					duration:      fileDuration,
+					warning:       warning,
 					err:           err,
 				}
 			}
@@ -472,11 +771,81 @@ func (idx *Indexer) processFiles(
 	}()
 
 	// Collect results and batch upsert
-	var allChunks []chunker.Chunk
+	var allChunks, allShifted []chunker.Chunk
 	var allDeletedChunks []string
 	var mu sync.Mutex
+	maxChunks := idx.cfg.Indexing.MaxChunksPerProject
+	totalChunks := 0
+	chunksByDir := make(map[string]int)
+	countedChunks := make(map[string]bool) // merged chunks are listed by several files
+	countChunks := func(relPath string, chunkIDs []string) {
+		for _, id := range chunkIDs {
+			if !countedChunks[id] {
+				countedChunks[id] = true
+				totalChunks++
+				chunksByDir[topLevelDir(relPath)]++
+			}
+		}
+	}
+	if maxChunks > 0 {
+		// The limit is on the project total: files this run doesn't
+		// reprocess keep their cached chunks
+		processing := make(map[string]bool, len(files))
+		for _, f := range files {
+			processing[f.relPath] = true
+		}
+		for _, relPath := range cache.GetAllFiles() {
+			if !processing[relPath] {
+				countChunks(relPath, cache.GetChunkIDs(relPath))
+			}
+		}
+	}
+	var timings []FileTiming
+
+	// Periodic commits store pending chunks and save the cache mid-run.
+	// Full reindexes and chunk-limited runs must store nothing until they
+	// succeed, so they only commit at the end. Merged sibling chunks span
+	// several cache entries, which must be saved together.
+	flushEvery, flushInterval := idx.cfg.Cache.FlushEveryN, idx.cfg.Cache.GetFlushInterval()
+	periodic := !fullIndex && maxChunks == 0 && !chunkCfg.MergeSiblingFiles && (flushEvery > 0 || flushInterval > 0)
+	pendingFiles := 0
+	lastCommit := time.Now()
+	filesDone := 0
+
+	var results <-chan fileResult = resultCh
+	if chunkCfg.MergeSiblingFiles {
+		results = idx.mergeSiblingResults(resultCh, cache, updateLines)
+	}
+
+	for res := range results {
+		filesDone++
+		if idx.onProgress != nil {
+			_, _, avgDur, _ := stats.GetStats()
+			idx.onProgress(ProgressEvent{
+				ProjectID:      projectCfg.ProjectID,
+				File:           res.relPath,
+				FilesProcessed: filesDone,
+				FilesTotal:     totalFiles,
+				Elapsed:        time.Since(stats.startTime),
+				AvgPerFile:     avgDur,
+				ETA:            avgDur * time.Duration(totalFiles-filesDone),
+			})
+		}
+
+		if idx.slowestFiles > 0 {
+			timings = append(timings, FileTiming{FilePath: res.relPath, Duration: res.duration, Chunks: len(res.chunkIDs)})
+		}
+
+		if res.err == nil && maxChunks > 0 {
+			countChunks(res.relPath, res.chunkIDs)
+			if totalChunks > maxChunks {
+				// Nothing from this run is stored; the cache is left untouched
+				cancel()
+				result.limitErr = chunkLimitError(projectCfg.ProjectID, maxChunks, chunksByDir)
+				return result
+			}
+		}
 
-	for res := range resultCh {
 		if res.err != nil {
 			mu.Lock()
 			result.errors = append(result.errors, fmt.Errorf("%s: %w", res.relPath, res.err))
@@ -485,23 +854,56 @@ func (idx *Indexer) processFiles(
 		}
 
 		mu.Lock()
+		if res.warning != nil {
+			result.errors = append(result.errors, fmt.Errorf("%s: %w", res.relPath, res.warning))
+		}
 		result.filesIndexed++
 		result.chunksCreated += len(res.chunks)
+		result.chunksSkipped += res.skipped
+		result.chunksExcluded += res.excluded
+		result.chunksSplit += res.split
 		result.oversizedChunks = append(result.oversizedChunks, res.oversized...)
 		allChunks = append(allChunks, res.chunks...)
+		allShifted = append(allShifted, res.shifted...)
 		allDeletedChunks = append(allDeletedChunks, res.deletedChunks...)
 
 		// Update cache with chunk hashes
+		modTime := res.modTime
+		if modTime.IsZero() {
+			modTime = time.Now().UTC()
+		}
 		cache.Set(res.relPath, CacheEntry{
 			ContentHash: res.hash,
-			ModTime:     time.Now().UTC(),
+			ModTime:     modTime,
+			Size:        res.size,
 			IndexedAt:   time.Now().UTC(),
 			ChunkIDs:    res.chunkIDs,
 			ChunkHashes: res.chunkHashes,
+			ChunkLines:  res.chunkLines,
 		})
 		mu.Unlock()
+
+		pendingFiles++
+		if periodic && ((flushEvery > 0 && pendingFiles >= flushEvery) ||
+			(flushInterval > 0 && time.Since(lastCommit) >= flushInterval)) {
+			if err := idx.commitPending(ctx, projectCfg.ProjectID, cache, allChunks, allShifted, allDeletedChunks); err != nil {
+				// Pending chunks are retried by the final store
+				idx.logger.Warn("periodic commit failed, deferring to end of run",
+					"project", projectCfg.ProjectID,
+					"error", err)
+				periodic = false
+			} else {
+				result.chunksDeleted += len(allDeletedChunks)
+				result.chunksRelocated += len(allShifted)
+				allChunks, allShifted, allDeletedChunks = nil, nil, nil
+			}
+			pendingFiles = 0
+			lastCommit = time.Now()
+		}
 	}
 
+	result.slowestFiles = slowestFiles(timings, idx.slowestFiles)
+
 	// Print final progress
 	processed, total, avgDur, _ := stats.GetStats()
 	totalElapsed := time.Since(stats.startTime).Round(time.Second)
@@ -513,6 +915,7 @@ func (idx *Indexer) processFiles(
 		fmt.Printf("[Deleting] %d stale chunks from vector database...\n", len(allDeletedChunks))
 		if err := idx.vectorDB.Delete(ctx, allDeletedChunks); err != nil {
 			result.errors = append(result.errors, fmt.Errorf("delete stale chunks: %w", err))
+			result.storeFailed = true
 		} else {
 			result.chunksDeleted += len(allDeletedChunks)
 		}
@@ -523,24 +926,253 @@ func (idx *Indexer) processFiles(
 		fmt.Printf("[Upserting] %d changed chunks to vector database...\n", len(allChunks))
 		if err := idx.upsertChunks(ctx, allChunks); err != nil {
 			result.errors = append(result.errors, fmt.Errorf("upsert chunks: %w", err))
+			result.storeFailed = true
 		}
-	} else {
+	} else if result.chunksCreated == 0 {
 		fmt.Printf("[Upserting] No chunks changed, skipping embedding.\n")
 	}
 
+	// Refresh line numbers of moved chunks without re-embedding
+	if len(allShifted) > 0 {
+		fmt.Printf("[Updating] %d moved chunks' line numbers...\n", len(allShifted))
+		if err := idx.updateChunkLines(ctx, allShifted); err != nil {
+			result.errors = append(result.errors, fmt.Errorf("update chunk lines: %w", err))
+			result.storeFailed = true
+		} else {
+			result.chunksRelocated += len(allShifted)
+		}
+	}
+
 	return result
 }
 
+// chunkDiff is how a file's chunks differ from the ones cached for it.
+type chunkDiff struct {
+	ids     []string
+	hashes  map[string]string // chunk_id -> content_hash
+	lines   map[string]int    // chunk_id -> start_line
+	changed []chunker.Chunk   // new or changed chunks, to embed
+	shifted []chunker.Chunk   // unchanged chunks whose lines moved
+	deleted []string          // cached chunk IDs no longer produced
+}
+
+// diffChunks compares a file's chunks with its cache entry. With updateLines,
+// unchanged chunks at new lines are reported as shifted.
+func diffChunks(cache *Cache, relPath string, chunks []chunker.Chunk, updateLines bool) chunkDiff {
+	diff := chunkDiff{
+		hashes: make(map[string]string),
+		lines:  make(map[string]int),
+	}
+
+	cachedHashes := cache.GetChunkHashes(relPath)
+	cachedLines := cache.GetChunkLines(relPath)
+	newChunkIDs := make(map[string]bool)
+
+	for _, c := range chunks {
+		diff.ids = append(diff.ids, c.ID)
+		diff.hashes[c.ID] = c.ContentHash
+		diff.lines[c.ID] = c.StartLine
+		newChunkIDs[c.ID] = true
+
+		// Check if chunk has changed
+		if cachedHash, exists := cachedHashes[c.ID]; !exists || cachedHash != c.ContentHash {
+			diff.changed = append(diff.changed, c)
+		} else if updateLines {
+			// Unknown (older cache) or moved lines get a payload-only update
+			if line, ok := cachedLines[c.ID]; !ok || line != c.StartLine {
+				diff.shifted = append(diff.shifted, c)
+			}
+		}
+	}
+
+	// Find deleted chunks (in cache but not in new chunks)
+	for cachedID := range cachedHashes {
+		if !newChunkIDs[cachedID] {
+			diff.deleted = append(diff.deleted, cachedID)
+		}
+	}
+	return diff
+}
+
+// commitPending stores pending chunk deletions and upserts, then saves the
+// cache, so files indexed so far survive an interrupted run. Cache entries
+// are only persisted once their chunks are stored.
+func (idx *Indexer) commitPending(ctx context.Context, projectID string, cache *Cache, chunks, shifted []chunker.Chunk, deleted []string) error {
+	if len(deleted) > 0 {
+		if err := idx.vectorDB.Delete(ctx, deleted); err != nil {
+			return fmt.Errorf("delete stale chunks: %w", err)
+		}
+	}
+	if len(chunks) > 0 {
+		fmt.Printf("[Committing] %d changed chunks to vector database...\n", len(chunks))
+		if err := idx.upsertChunks(ctx, chunks); err != nil {
+			return fmt.Errorf("upsert chunks: %w", err)
+		}
+	}
+	if len(shifted) > 0 {
+		if err := idx.updateChunkLines(ctx, shifted); err != nil {
+			return fmt.Errorf("update chunk lines: %w", err)
+		}
+	}
+	return cache.Save(projectID)
+}
+
+// slowestFiles returns the n longest timings, slowest first (ties by path).
+func slowestFiles(timings []FileTiming, n int) []FileTiming {
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Duration != timings[j].Duration {
+			return timings[i].Duration > timings[j].Duration
+		}
+		return timings[i].FilePath < timings[j].FilePath
+	})
+	if len(timings) > n {
+		timings = timings[:n]
+	}
+	return timings
+}
+
+// dropEmptyChunks removes chunks with no meaningful content, or less than
+// indexing.min_chunk_chars of it, so they are neither embedded nor cached.
+// Path chunks are always kept. Returns the kept chunks and the number dropped.
+func (idx *Indexer) dropEmptyChunks(chunks []chunker.Chunk) ([]chunker.Chunk, int) {
+	minChars := idx.cfg.Indexing.MinChunkChars
+	if minChars < 1 {
+		minChars = 1
+	}
+
+	kept := chunks[:0]
+	for _, c := range chunks {
+		if c.SymbolType == chunker.PathSymbolType || meaningfulLength(c.Content, c.Language) >= minChars {
+			kept = append(kept, c)
+		}
+	}
+	return kept, len(chunks) - len(kept)
+}
+
+// dropExcludedSymbols removes chunks whose symbol matches an exclude_symbols
+// pattern. Returns the kept chunks and how many were dropped.
+func dropExcludedSymbols(chunks []chunker.Chunk, patterns []*regexp.Regexp) ([]chunker.Chunk, int) {
+	if len(patterns) == 0 {
+		return chunks, 0
+	}
+
+	kept := chunks[:0]
+	for _, c := range chunks {
+		if !matchesAnyPattern(c.Symbol, patterns) {
+			kept = append(kept, c)
+		}
+	}
+	return kept, len(chunks) - len(kept)
+}
+
+// hasExcludedChunks reports whether a cached file still holds chunks whose
+// symbol (recovered from the chunk ID) matches an exclude_symbols pattern.
+func hasExcludedChunks(entry CacheEntry, projectID, relPath string, patterns []*regexp.Regexp) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+
+	prefix := projectID + ":" + relPath + ":"
+	for _, id := range entry.ChunkIDs {
+		rest, ok := strings.CutPrefix(id, prefix)
+		if !ok {
+			continue
+		}
+		if i := strings.LastIndex(rest, ":"); i >= 0 && matchesAnyPattern(rest[:i], patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyPattern reports whether s matches any of the patterns.
+func matchesAnyPattern(s string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// topLevelDir returns the first path component of a relative path ("." for root files).
+func topLevelDir(relPath string) string {
+	if i := strings.Index(relPath, "/"); i >= 0 {
+		return relPath[:i]
+	}
+	return "."
+}
+
+// chunkLimitError describes an exceeded chunk limit, naming the top-level
+// directories that produced the most chunks as likely culprits.
+func chunkLimitError(projectID string, limit int, chunksByDir map[string]int) error {
+	dirs := make([]string, 0, len(chunksByDir))
+	for dir := range chunksByDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if chunksByDir[dirs[i]] != chunksByDir[dirs[j]] {
+			return chunksByDir[dirs[i]] > chunksByDir[dirs[j]]
+		}
+		return dirs[i] < dirs[j]
+	})
+	if len(dirs) > 3 {
+		dirs = dirs[:3]
+	}
+
+	top := make([]string, len(dirs))
+	for i, dir := range dirs {
+		top[i] = fmt.Sprintf("%s/ (%d)", dir, chunksByDir[dir])
+	}
+	return fmt.Errorf("project %s exceeded indexing.max_chunks_per_project (%d), run aborted; "+
+		"check include_extensions/exclude_paths for vendored or generated code (top directories: %s)",
+		projectID, limit, strings.Join(top, ", "))
+}
+
+// chunkerForFile selects the chunker for a file (swappable in tests).
+var chunkerForFile = func(f *chunker.Factory, relPath string) chunker.Chunker {
+	return f.GetChunker(relPath)
+}
+
+// errChunkerPanic marks errors produced by a recovered chunker panic.
+var errChunkerPanic = errors.New("chunker panicked")
+
+// safeChunk runs a chunker, converting a panic into an errChunkerPanic error.
+func safeChunk(c chunker.Chunker, content []byte, metadata chunker.FileMetadata) (chunks []chunker.Chunk, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			chunks = nil
+			err = fmt.Errorf("%s %w: %v", c.Name(), errChunkerPanic, r)
+		}
+	}()
+	return c.Chunk(content, metadata)
+}
+
 // processFile processes a single file: read, chunk, embed.
+// If the chunker panics, the file falls back to whole-file chunking and the
+// panic is returned as a non-fatal warning.
 func (idx *Indexer) processFile(
 	ctx context.Context,
 	file fileToProcess,
 	projectCfg *config.ProjectConfig,
-) ([]chunker.Chunk, error) {
+) (chunks []chunker.Chunk, warning error, err error) {
 	// Read file content
 	content, err := os.ReadFile(file.absPath)
 	if err != nil {
-		return nil, fmt.Errorf("read file: %w", err)
+		return nil, nil, fmt.Errorf("read file: %w", err)
+	}
+
+	// Transcode legacy encodings; undecodable files are skipped (no chunks)
+	if len(projectCfg.Encodings) > 0 {
+		decoded, err := transcodeToUTF8(content, projectCfg.Encodings)
+		if err != nil {
+			idx.logger.Warn("skipping undecodable file",
+				"file", file.relPath,
+				"encodings", projectCfg.Encodings,
+				"error", err)
+			return nil, nil, nil
+		}
+		content = decoded
 	}
 
 	// Create file metadata
@@ -552,88 +1184,93 @@ func (idx *Indexer) processFile(
 	}
 
 	// Get appropriate chunker
-	chunkr := idx.chunkerFactory.GetChunker(file.relPath)
+	chunkr := chunkerForFile(idx.chunkerFactory, file.relPath)
 
 	// Chunk the file
-	chunks, err := chunkr.Chunk(content, metadata)
+	chunks, err = safeChunk(chunkr, content, metadata)
+	if errors.Is(err, errChunkerPanic) {
+		warning = fmt.Errorf("%w; fell back to whole-file chunking", err)
+		chunks, err = []chunker.Chunk{idx.chunkerFactory.WholeFileChunk(content, metadata)}, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("chunk file: %w", err)
+		return nil, nil, fmt.Errorf("chunk file: %w", err)
+	}
+
+	// Make the file findable by its path alone
+	if idx.cfg.Chunking.IndexPaths {
+		chunks = append(chunks, idx.chunkerFactory.PathChunk(metadata))
 	}
 
-	return chunks, nil
+	// Mod-time is only collected during discovery when needed there
+	modTime := file.modTime
+	if modTime.IsZero() {
+		if info, err := os.Stat(file.absPath); err == nil {
+			modTime = info.ModTime()
+		}
+	}
+	for i := range chunks {
+		chunks[i].ModTime = modTime
+	}
+
+	if idx.owners != nil {
+		if owner := idx.owners.Owner(file.relPath); owner != "" {
+			for i := range chunks {
+				chunks[i].Owner = owner
+			}
+		}
+	}
+
+	for i := range chunks {
+		chunks[i].ParentSymbol = chunker.ParentSymbol(chunks[i].Symbol, chunks[i].SymbolType)
+	}
+
+	return chunks, warning, nil
 }
 
 // upsertChunks embeds and upserts chunks to vector DB.
+// Chunks are sorted by file and line first, since parallel processing
+// collects them in no particular order; a source tree always produces
+// the same upsert sequence.
 func (idx *Indexer) upsertChunks(ctx context.Context, chunks []chunker.Chunk) error {
 	if len(chunks) == 0 {
 		return nil
 	}
 
+	sort.Slice(chunks, func(i, j int) bool {
+		a, b := chunks[i], chunks[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.ID < b.ID
+	})
+
 	// Extract content for embedding
+	includePath := idx.cfg.Embedding.IncludePathInEmbedding
 	texts := make([]string, len(chunks))
 	for i, c := range chunks {
-		texts[i] = c.Content
+		texts[i] = applyTransforms(c.Content, c.Language, idx.cfg.Embedding.TransformsFor(c.Language))
+		if includePath {
+			texts[i] = embeddingText(c, texts[i])
+		}
 	}
 
 	// Get embeddings in batches with progress
-	batchSize := idx.cfg.Embedding.BatchSize
-	var allVectors [][]float32
-	totalBatches := (len(texts) + batchSize - 1) / batchSize
-	
-	embedStart := time.Now()
-	for i := 0; i < len(texts); i += batchSize {
-		end := i + batchSize
-		if end > len(texts) {
-			end = len(texts)
-		}
-
-		batchNum := (i / batchSize) + 1
-		batch := texts[i:end]
-		
-		batchStart := time.Now()
-		vectors, err := idx.embedder.EmbedBatch(ctx, batch)
-		batchDuration := time.Since(batchStart)
-		
-		if err != nil {
-			return fmt.Errorf("embed batch %d-%d: %w", i, end, err)
-		}
-		allVectors = append(allVectors, vectors...)
-		
-		// Calculate ETA
-		elapsed := time.Since(embedStart)
-		avgPerBatch := elapsed / time.Duration(batchNum)
-		remainingBatches := totalBatches - batchNum
-		eta := avgPerBatch * time.Duration(remainingBatches)
-		
-		fmt.Printf("[Embedding] Batch %d/%d (%d chunks) | took: %s | ETA: %s\n",
-			batchNum, totalBatches, len(batch), 
-			batchDuration.Round(time.Millisecond), 
-			eta.Round(time.Second))
-	}
-	
-	fmt.Printf("[Embedding] Complete: %d chunks in %s\n", len(texts), time.Since(embedStart).Round(time.Second))
+	allVectors, err := idx.embedWithCache(ctx, texts, chunks[0].ProjectID)
+	if err != nil {
+		return err
+	}
 
 	// Create points for vector DB
 	points := make([]vectordb.Point, len(chunks))
 	indexedAt := time.Now().UTC().Format(time.RFC3339)
-
 	for i, c := range chunks {
 		points[i] = vectordb.Point{
-			ID:     c.ID,
-			Vector: allVectors[i],
-			Payload: vectordb.Payload{
-				ProjectID:   c.ProjectID,
-				FilePath:    c.FilePath,
-				Symbol:      c.Symbol,
-				SymbolType:  c.SymbolType,
-				Language:    c.Language,
-				Module:      c.Module,
-				StartLine:   c.StartLine,
-				EndLine:     c.EndLine,
-				Content:     c.Content,
-				ContentHash: c.ContentHash,
-				IndexedAt:   indexedAt,
-			},
+			ID:      c.ID,
+			Vector:  allVectors[i],
+			Payload: idx.chunkPayload(c, indexedAt),
 		}
 	}
 
@@ -641,6 +1278,111 @@ func (idx *Indexer) upsertChunks(ctx context.Context, chunks []chunker.Chunk) er
 	return idx.vectorDB.Upsert(ctx, points)
 }
 
+// updateChunkLines moves chunks whose content is unchanged but whose lines
+// moved, updating only their line fields (and the file's modification time)
+// without re-embedding them.
+func (idx *Indexer) updateChunkLines(ctx context.Context, chunks []chunker.Chunk) error {
+	for _, c := range chunks {
+		fields := map[string]interface{}{
+			"start_line": c.StartLine,
+			"end_line":   c.EndLine,
+		}
+		if !c.ModTime.IsZero() {
+			fields["last_modified"] = c.ModTime.UTC().Format(time.RFC3339)
+		}
+		if err := idx.vectorDB.UpdatePayload(ctx, c.ID, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkPayload builds the vector DB payload stored for a chunk.
+func (idx *Indexer) chunkPayload(c chunker.Chunk, indexedAt string) vectordb.Payload {
+	content := c.Content
+	if !idx.cfg.VectorDB.ShouldStoreContent() {
+		content = ""
+	}
+	exactHash := c.ExactHash
+	if exactHash == c.ContentHash {
+		exactHash = ""
+	}
+	lastModified := ""
+	if !c.ModTime.IsZero() {
+		lastModified = c.ModTime.UTC().Format(time.RFC3339)
+	}
+	return vectordb.Payload{
+		ProjectID:   c.ProjectID,
+		FilePath:    c.FilePath,
+		Symbol:      c.Symbol,
+		SymbolType:  c.SymbolType,
+		Language:    c.Language,
+		Module:      c.Module,
+		StartLine:   c.StartLine,
+		EndLine:     c.EndLine,
+		Content:     content,
+		ContentHash: c.ContentHash,
+		ExactHash:   exactHash,
+		IndexedAt:   indexedAt,
+		FilePaths:   c.FilePaths,
+		Generation:  idx.generation,
+		Owner:       c.Owner,
+		GitRef:      idx.gitRef,
+		Custom:      idx.customMetadata,
+		Imports:     c.Imports,
+		References:  c.References,
+		TokenCount:  c.TokenCount,
+
+		ParentSymbol:   c.ParentSymbol,
+		LastModified:   lastModified,
+		PayloadVersion: vectordb.PayloadVersion,
+	}
+}
+
+// embedWithCache embeds texts, reusing vectors from the embedding cache when
+// it is enabled and storing newly embedded ones.
+func (idx *Indexer) embedWithCache(ctx context.Context, texts []string, projectID string) ([][]float32, error) {
+	if idx.embedCache == nil {
+		return idx.embedTexts(ctx, texts)
+	}
+
+	vectors := make([][]float32, len(texts))
+	var missing []string
+	var missingIdx []int
+	for i, text := range texts {
+		if vector, ok := idx.embedCache.Get(text); ok {
+			vectors[i] = vector
+			continue
+		}
+		missing = append(missing, text)
+		missingIdx = append(missingIdx, i)
+	}
+	if hits := len(texts) - len(missing); hits > 0 {
+		fmt.Printf("[Embedding] %d/%d chunks reused from embedding cache\n", hits, len(texts))
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := idx.embedTexts(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missingIdx {
+		vectors[i] = embedded[j]
+		idx.embedCache.Put(missing[j], embedded[j])
+	}
+
+	// Vectors stay valid even if the upsert fails, so persist them now
+	if err := idx.embedCache.Save(projectID); err != nil {
+		idx.logger.Warn("failed to save embedding cache", "project", projectID, "error", err)
+	}
+	return vectors, nil
+}
+
+// hashFileFunc is the file hasher used during change detection (swappable in tests).
+var hashFileFunc = hashFile
+
 // hashFile computes SHA256 hash of a file.
 func hashFile(path string) (string, error) {
 	f, err := os.Open(path)
@@ -657,49 +1399,125 @@ func hashFile(path string) (string, error) {
 	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
+// newGeneration returns a new, time-ordered index generation ID.
+func newGeneration() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// embeddingFingerprint identifies the embedding space vectors are built in.
+// Format: {provider}:{model}:{dimensions}[:path][:transforms=...]
+func (idx *Indexer) embeddingFingerprint() string {
+	e := idx.cfg.Embedding
+	fingerprint := fmt.Sprintf("%s:%s:%d", e.Provider, e.Model, e.Dimensions)
+	if e.IncludePathInEmbedding {
+		// Vectors built with a path header are not comparable to plain ones
+		fingerprint += ":path"
+	}
+	if len(e.Transforms) > 0 {
+		// Transformed text embeds differently; changing the pipeline needs a re-embed
+		fingerprint += ":transforms=" + transformsFingerprint(e.Transforms)
+	}
+	return fingerprint
+}
+
+// embeddingText prefixes the (transformed) chunk content with its file path
+// and symbol so path components (e.g. "auth controller") influence the vector.
+// Queries are embedded as-is since they carry no path.
+func embeddingText(c chunker.Chunk, content string) string {
+	header := "File: " + c.FilePath + "\n"
+	if len(c.FilePaths) > 0 {
+		header = "Files: " + strings.Join(c.FilePaths, ", ") + "\n"
+	}
+	if c.Symbol != "" {
+		header += "Symbol: " + c.Symbol + "\n"
+	}
+	return header + "\n" + content
+}
+
 // EnsureCollection ensures the vector DB collection exists.
 func (idx *Indexer) EnsureCollection(ctx context.Context) error {
 	return idx.vectorDB.EnsureCollection(ctx, idx.cfg.Embedding.Dimensions)
 }
 
-// saveOversizedReport saves oversized chunks to a JSON file for review.
-func (idx *Indexer) saveOversizedReport(projectID string, chunks []OversizedChunk) {
+// saveOversizedReport saves oversized chunks to a report file for review.
+// The format (json or csv) follows cache.report_format. Returns the report path.
+func (idx *Indexer) saveOversizedReport(projectID string, chunks []OversizedChunk) string {
 	reportDir := filepath.Join(idx.cfg.Cache.Dir, "reports")
 	if err := os.MkdirAll(reportDir, 0755); err != nil {
 		idx.logger.Error("failed to create reports directory", "error", err)
-		return
+		return ""
 	}
 
-	reportFile := filepath.Join(reportDir, fmt.Sprintf("%s-oversized.json", projectID))
-	
-	report := struct {
-		ProjectID  string           `json:"project_id"`
-		GeneratedAt string          `json:"generated_at"`
-		TotalCount int              `json:"total_count"`
-		MaxTokens  int              `json:"max_tokens_allowed"`
-		Chunks     []OversizedChunk `json:"chunks"`
-	}{
-		ProjectID:   projectID,
-		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
-		TotalCount:  len(chunks),
-		MaxTokens:   2048,
-		Chunks:      chunks,
+	format := idx.cfg.Cache.ReportFormat
+	if format == "" {
+		format = "json"
 	}
+	reportFile := filepath.Join(reportDir, fmt.Sprintf("%s-oversized.%s", projectID, format))
 
-	data, err := json.MarshalIndent(report, "", "  ")
+	var data []byte
+	var err error
+	if format == "csv" {
+		data, err = oversizedReportCSV(chunks)
+	} else {
+		data, err = oversizedReportJSON(projectID, chunks)
+	}
 	if err != nil {
-		idx.logger.Error("failed to marshal oversized report", "error", err)
-		return
+		idx.logger.Error("failed to encode oversized report", "error", err)
+		return ""
 	}
 
 	if err := os.WriteFile(reportFile, data, 0644); err != nil {
 		idx.logger.Error("failed to write oversized report", "error", err)
-		return
+		return ""
 	}
 
 	idx.logger.Warn("oversized chunks detected",
 		"count", len(chunks),
 		"report", reportFile)
+	return reportFile
+}
+
+// oversizedReportJSON encodes the oversized report as indented JSON.
+func oversizedReportJSON(projectID string, chunks []OversizedChunk) ([]byte, error) {
+	report := struct {
+		ProjectID   string           `json:"project_id"`
+		GeneratedAt string           `json:"generated_at"`
+		TotalCount  int              `json:"total_count"`
+		MaxTokens   int              `json:"max_tokens_allowed"`
+		Chunks      []OversizedChunk `json:"chunks"`
+	}{
+		ProjectID:   projectID,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		TotalCount:  len(chunks),
+		MaxTokens:   2048,
+		Chunks:      chunks,
+	}
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// oversizedReportCSV encodes the oversized report as CSV with a header row.
+func oversizedReportCSV(chunks []OversizedChunk) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write([]string{"file_path", "symbol", "token_count", "max_allowed", "content_size"}); err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		record := []string{
+			c.FilePath,
+			c.Symbol,
+			strconv.Itoa(c.TokenCount),
+			strconv.Itoa(c.MaxAllowed),
+			strconv.Itoa(c.ContentSize),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
 }
 
 // IndexAllProjects indexes all configured projects.
@@ -728,3 +1546,28 @@ func (idx *Indexer) IndexAllProjects(ctx context.Context, fullIndex bool) (map[s
 
 	return results, nil
 }
+
+// tokenLimit returns the token limit for a symbol type: its
+// chunking.max_tokens_by_type entry if set, otherwise the model limit.
+func tokenLimit(chunkCfg config.ChunkingConfig, modelLimit int, symbolType string) int {
+	if typeMax, ok := chunkCfg.MaxTokensByType[symbolType]; ok && typeMax > 0 {
+		return typeMax
+	}
+	return modelLimit
+}
+
+// splitOversizedChunks splits chunks over their token limit into sub-chunks
+// (at line boundaries, or inside over-long lines) so the embedding model
+// doesn't truncate them. Returns the chunks and how many were split.
+func (idx *Indexer) splitOversizedChunks(chunks []chunker.Chunk, chunkCfg config.ChunkingConfig, modelLimit int) ([]chunker.Chunk, int) {
+	var out []chunker.Chunk
+	split := 0
+	for _, c := range chunks {
+		parts := idx.chunkerFactory.SplitOversized(c, tokenLimit(chunkCfg, modelLimit, c.SymbolType))
+		if len(parts) > 1 {
+			split++
+		}
+		out = append(out, parts...)
+	}
+	return out, split
+}
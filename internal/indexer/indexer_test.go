@@ -0,0 +1,1969 @@
+package indexer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/iasik/project-indexer/internal/chunker"
+	"github.com/iasik/project-indexer/internal/config"
+	"github.com/iasik/project-indexer/internal/embedder"
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+// fakeEmbedder returns fixed vectors and records embedded texts.
+type fakeEmbedder struct {
+	mu    sync.Mutex
+	texts []string
+	err   error // returned by EmbedBatch when set
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.texts = append(f.texts, text)
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func (f *fakeEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v, _ := f.Embed(ctx, text)
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func (f *fakeEmbedder) ModelInfo() embedder.ModelInfo {
+	return embedder.ModelInfo{Provider: "fake", Model: "fake-model", Dimensions: 3}
+}
+
+func (f *fakeEmbedder) Health(ctx context.Context) error { return nil }
+func (f *fakeEmbedder) Close() error                     { return nil }
+
+// fakeVectorDB records upserted points and deleted IDs in memory.
+type fakeVectorDB struct {
+	mu      sync.Mutex
+	points  map[string]vectordb.Point
+	deleted []string
+	upserts int      // number of Upsert calls
+	order   []string // upserted IDs in call order
+}
+
+func newFakeVectorDB() *fakeVectorDB {
+	return &fakeVectorDB{points: make(map[string]vectordb.Point)}
+}
+
+func (f *fakeVectorDB) Upsert(ctx context.Context, points []vectordb.Point) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.upserts++
+	for _, p := range points {
+		f.points[p.ID] = p
+		f.order = append(f.order, p.ID)
+	}
+	return nil
+}
+
+func (f *fakeVectorDB) Search(ctx context.Context, query vectordb.SearchQuery) ([]vectordb.SearchResult, error) {
+	return nil, nil
+}
+
+func (f *fakeVectorDB) SearchBatch(ctx context.Context, queries []vectordb.SearchQuery) ([][]vectordb.SearchResult, error) {
+	return make([][]vectordb.SearchResult, len(queries)), nil
+}
+
+func (f *fakeVectorDB) Delete(ctx context.Context, ids []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, id := range ids {
+		delete(f.points, id)
+	}
+	f.deleted = append(f.deleted, ids...)
+	return nil
+}
+
+func (f *fakeVectorDB) DeleteByFilter(ctx context.Context, filter vectordb.Filter) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, p := range f.points {
+		if filter.ExcludeGeneration != "" && p.Payload.Generation == filter.ExcludeGeneration {
+			continue
+		}
+		if filter.ProjectID == "" || p.Payload.ProjectID == filter.ProjectID {
+			delete(f.points, id)
+		}
+	}
+	return nil
+}
+
+func (f *fakeVectorDB) PurgeDeleted(ctx context.Context, olderThan time.Time) error { return nil }
+func (f *fakeVectorDB) EnsureCollection(ctx context.Context, dimensions int) error  { return nil }
+func (f *fakeVectorDB) Health(ctx context.Context) error                            { return nil }
+func (f *fakeVectorDB) Close() error                                                { return nil }
+
+func (f *fakeVectorDB) Capabilities() vectordb.ProviderCapabilities {
+	return vectordb.ProviderCapabilities{}
+}
+
+func (f *fakeVectorDB) Scroll(ctx context.Context, filter vectordb.Filter, limit int) ([]vectordb.SearchResult, error) {
+	return nil, nil
+}
+
+func (f *fakeVectorDB) ScrollPage(ctx context.Context, query vectordb.ScrollQuery) ([]vectordb.SearchResult, string, error) {
+	return nil, "", nil
+}
+
+func (f *fakeVectorDB) UpdatePayloads(ctx context.Context, points []vectordb.Point) error {
+	return nil
+}
+
+func (f *fakeVectorDB) UpdatePayload(ctx context.Context, id string, fields map[string]interface{}) error {
+	return nil
+}
+
+func (f *fakeVectorDB) Count(ctx context.Context, filter vectordb.Filter) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, p := range f.points {
+		if filter.ExcludeGeneration != "" && p.Payload.Generation == filter.ExcludeGeneration {
+			continue
+		}
+		if filter.ProjectID == "" || p.Payload.ProjectID == filter.ProjectID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// newTestIndexer creates an indexer backed by fakes with test-friendly defaults.
+func newTestIndexer(t *testing.T, cfg *config.Config) (*Indexer, *fakeEmbedder, *fakeVectorDB) {
+	t.Helper()
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if cfg.Embedding.BatchSize == 0 {
+		cfg.Embedding.BatchSize = 8
+	}
+	if cfg.Chunking.MaxTokens == 0 {
+		cfg.Chunking = config.ChunkingConfig{MinTokens: 10, IdealTokens: 50, MaxTokens: 100}
+	}
+	if cfg.Cache.Dir == "" {
+		cfg.Cache.Dir = t.TempDir()
+	}
+
+	emb := &fakeEmbedder{}
+	vdb := newFakeVectorDB()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewIndexer(cfg, emb, vdb, logger), emb, vdb
+}
+
+// writeTestProject creates a source tree under cfg.Projects.SourceBasePath and
+// returns a matching project config.
+func writeTestProject(t *testing.T, cfg *config.Config, files map[string]string) *config.ProjectConfig {
+	t.Helper()
+	if cfg.Projects.SourceBasePath == "" {
+		cfg.Projects.SourceBasePath = t.TempDir()
+	}
+	root := filepath.Join(cfg.Projects.SourceBasePath, "proj")
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", rel, err)
+		}
+	}
+	return &config.ProjectConfig{
+		ProjectID:         "proj",
+		SourcePath:        "proj",
+		IncludeExtensions: []string{".go", ".md", ".txt"},
+	}
+}
+
+func TestUpsertChunks_StoreContentDisabled(t *testing.T) {
+	storeContent := false
+	cfg := &config.Config{}
+	cfg.VectorDB.StoreContent = &storeContent
+	idx, _, vdb := newTestIndexer(t, cfg)
+
+	chunks := []chunker.Chunk{{
+		ID:          "proj:main.go:main:abcd1234",
+		Content:     "func main() {}",
+		Symbol:      "main",
+		SymbolType:  "function",
+		StartLine:   1,
+		EndLine:     1,
+		ContentHash: "abcd1234",
+		FilePath:    "main.go",
+		ProjectID:   "proj",
+	}}
+
+	if err := idx.upsertChunks(context.Background(), chunks); err != nil {
+		t.Fatalf("upsertChunks failed: %v", err)
+	}
+
+	p, ok := vdb.points["proj:main.go:main:abcd1234"]
+	if !ok {
+		t.Fatal("Expected point to be upserted")
+	}
+	if p.Payload.Content != "" {
+		t.Errorf("Expected empty content, got %q", p.Payload.Content)
+	}
+	if p.Payload.Symbol != "main" || p.Payload.StartLine != 1 || p.Payload.ContentHash != "abcd1234" {
+		t.Errorf("Expected metadata to be kept, got %+v", p.Payload)
+	}
+}
+
+func TestIndexProject_ModifiedAfterSkipsHashing(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, _ := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"old.go": "package main\n\nfunc Old() {}\n",
+		"new.go": "package main\n\nfunc New() {}\n",
+	})
+
+	// First run populates the cache
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	root := projectCfg.GetFullSourcePath(cfg.Projects.SourceBasePath)
+	threshold := time.Now().Add(-time.Hour)
+	oldTime := threshold.Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "old.go"), oldTime, oldTime); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	var hashed []string
+	origHash := hashFileFunc
+	hashFileFunc = func(path string) (string, error) {
+		hashed = append(hashed, filepath.Base(path))
+		return origHash(path)
+	}
+	defer func() { hashFileFunc = origHash }()
+
+	idx.SetModifiedAfter(threshold)
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	if len(hashed) != 1 || hashed[0] != "new.go" {
+		t.Errorf("Expected only new.go to be hashed, got %v", hashed)
+	}
+	if result.FilesSkipped != 2 {
+		t.Errorf("Expected 2 skipped files, got %d", result.FilesSkipped)
+	}
+	if result.FilesDeleted != 0 {
+		t.Errorf("Expected pre-filtered files not to be treated as deleted, got %d", result.FilesDeleted)
+	}
+}
+
+// recordHashes records the base names of hashed files until the test ends.
+func recordHashes(t *testing.T) *[]string {
+	t.Helper()
+	var hashed []string
+	origHash := hashFileFunc
+	hashFileFunc = func(path string) (string, error) {
+		hashed = append(hashed, filepath.Base(path))
+		return origHash(path)
+	}
+	t.Cleanup(func() { hashFileFunc = origHash })
+	return &hashed
+}
+
+func TestIndexProject_TrustModTimeSkipsHashing(t *testing.T) {
+	cfg := &config.Config{Cache: config.CacheConfig{TrustModTime: true}}
+	idx, _, _ := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"a.go": "package main\n\nfunc A() {}\n",
+		"b.go": "package main\n\nfunc B() {}\n",
+	})
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	// Same content with a new mod-time (fresh clone): hashed, but not reindexed
+	root := projectCfg.GetFullSourcePath(cfg.Projects.SourceBasePath)
+	touched := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "b.go"), touched, touched); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	hashed := recordHashes(t)
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(*hashed) != 1 || (*hashed)[0] != "b.go" {
+		t.Errorf("Expected only the touched b.go to be hashed, got %v", *hashed)
+	}
+	if result.FilesIndexed != 0 || result.FilesSkipped != 2 {
+		t.Errorf("Expected both files skipped, got indexed=%d skipped=%d", result.FilesIndexed, result.FilesSkipped)
+	}
+
+	// The refreshed mod-time lets the next run skip hashing entirely
+	*hashed = nil
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(*hashed) != 0 {
+		t.Errorf("Expected no files hashed after mod-time refresh, got %v", *hashed)
+	}
+}
+
+func TestIndexProject_TrustModTimeOffAlwaysHashes(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, _ := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"a.go": "package main\n\nfunc A() {}\n",
+		"b.go": "package main\n\nfunc B() {}\n",
+	})
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	hashed := recordHashes(t)
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(*hashed) != 2 {
+		t.Errorf("Expected every file to be hashed, got %v", *hashed)
+	}
+	if result.FilesSkipped != 2 {
+		t.Errorf("Expected unchanged files skipped by hash, got %d", result.FilesSkipped)
+	}
+}
+
+// concurrencyEmbedder tracks the maximum number of concurrent EmbedBatch calls.
+type concurrencyEmbedder struct {
+	fakeEmbedder
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	calls       []int
+}
+
+func (c *concurrencyEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	c.calls = append(c.calls, len(texts))
+	c.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return c.fakeEmbedder.EmbedBatch(ctx, texts)
+}
+
+func TestEmbedTexts_PipelineDepth(t *testing.T) {
+	for _, depth := range []int{1, 3} {
+		cfg := &config.Config{}
+		cfg.Embedding.BatchSize = 2
+		cfg.Embedding.WarmPoolSize = depth
+		idx, _, _ := newTestIndexer(t, cfg)
+		emb := &concurrencyEmbedder{}
+		idx.embedder = emb
+
+		texts := make([]string, 20)
+		for i := range texts {
+			texts[i] = fmt.Sprintf("text-%d", i)
+		}
+
+		vectors, err := idx.embedTexts(context.Background(), texts)
+		if err != nil {
+			t.Fatalf("embedTexts failed: %v", err)
+		}
+		if len(vectors) != len(texts) {
+			t.Fatalf("Expected %d vectors, got %d", len(texts), len(vectors))
+		}
+		for i, v := range vectors {
+			if v == nil {
+				t.Errorf("Vector %d is missing", i)
+			}
+		}
+
+		if emb.maxInFlight != depth {
+			t.Errorf("depth %d: expected max %d in-flight requests, got %d", depth, depth, emb.maxInFlight)
+		}
+		if depth > 1 && emb.calls[0] != warmupBatchSize {
+			t.Errorf("depth %d: expected warm-up batch of %d, got %d", depth, warmupBatchSize, emb.calls[0])
+		}
+	}
+}
+
+func TestIndexProject_DeterministicUpsertOrder(t *testing.T) {
+	files := map[string]string{
+		"main.go":         "package main\n\nfunc Main() {}\n\nfunc Helper() {}\n",
+		"a.go":            "package main\n\nfunc A() {}\n",
+		"a/b.go":          "package a\n\nfunc B() {}\n",
+		"z/deep/y/x.go":   "package y\n\nfunc X() {}\n",
+		"auth/login.go":   "package auth\n\nfunc Login() {}\n\nfunc Logout() {}\n",
+		"auth/session.go": "package auth\n\nfunc Session() {}\n",
+	}
+
+	run := func() []string {
+		cfg := &config.Config{}
+		idx, _, vdb := newTestIndexer(t, cfg)
+		projectCfg := writeTestProject(t, cfg, files)
+		if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+			t.Fatalf("IndexProject failed: %v", err)
+		}
+		return vdb.order
+	}
+
+	first, second := run(), run()
+	if len(first) == 0 {
+		t.Fatal("Expected upserted chunks")
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected identical upsert order across runs:\n%v\n%v", first, second)
+	}
+	if !sort.SliceIsSorted(first, func(i, j int) bool {
+		return strings.SplitN(first[i], ":", 3)[1] < strings.SplitN(first[j], ":", 3)[1]
+	}) {
+		t.Errorf("Expected chunks upserted in file path order, got %v", first)
+	}
+}
+
+func TestIndexProject_ModelChangeForcesReembed(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Embedding.Provider = "ollama"
+	cfg.Embedding.Model = "model-a"
+	cfg.Embedding.Dimensions = 3
+	idx, emb, _ := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"main.go": "package main\n\nfunc main() {}\n",
+	})
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(emb.texts) == 0 {
+		t.Fatal("Expected initial run to embed chunks")
+	}
+
+	// Same model: nothing re-embedded
+	emb.texts = nil
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(emb.texts) != 0 {
+		t.Errorf("Expected no re-embedding with unchanged model, got %d texts", len(emb.texts))
+	}
+
+	// Different model: unchanged files are re-embedded
+	cfg.Embedding.Model = "model-b"
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(emb.texts) == 0 || result.FilesIndexed != 1 {
+		t.Errorf("Expected model change to re-embed unchanged file, got %d texts, %d files", len(emb.texts), result.FilesIndexed)
+	}
+
+	cache, _ := NewCache(cfg.Cache.Dir, "proj")
+	if got := cache.Fingerprint(); got != "ollama:model-b:3" {
+		t.Errorf("Expected stored fingerprint 'ollama:model-b:3', got %q", got)
+	}
+}
+
+func TestSaveOversizedReport_CSV(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Cache.ReportFormat = "csv"
+	idx, _, _ := newTestIndexer(t, cfg)
+
+	chunks := []OversizedChunk{
+		{FilePath: "big.go", Symbol: "Huge", TokenCount: 3000, MaxAllowed: 2048, ContentSize: 7500},
+		{FilePath: "docs/a,b.md", Symbol: "Intro \"quoted\"", TokenCount: 2100, MaxAllowed: 2048, ContentSize: 5250},
+	}
+
+	path := idx.saveOversizedReport("proj", chunks)
+	if filepath.Ext(path) != ".csv" {
+		t.Fatalf("Expected .csv report, got %q", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open report: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Report is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header + 2 rows, got %d records", len(records))
+	}
+
+	wantHeader := []string{"file_path", "symbol", "token_count", "max_allowed", "content_size"}
+	for i, col := range wantHeader {
+		if records[0][i] != col {
+			t.Errorf("Header column %d: expected %q, got %q", i, col, records[0][i])
+		}
+	}
+	if got := records[1]; got[0] != "big.go" || got[1] != "Huge" || got[2] != "3000" || got[3] != "2048" || got[4] != "7500" {
+		t.Errorf("Unexpected first row: %v", got)
+	}
+	if got := records[2]; got[0] != "docs/a,b.md" || got[1] != "Intro \"quoted\"" {
+		t.Errorf("Expected special characters to round-trip, got %v", got)
+	}
+}
+
+func TestIndexProject_GitPathRootStableAcrossMounts(t *testing.T) {
+	// Same repository checked out under two base paths, with source_path
+	// pointing at different depths inside it
+	mounts := []struct {
+		repoDir    string
+		sourcePath string
+	}{
+		{"repo", "repo/services"},
+		{"checkout", "checkout/services/api"},
+	}
+
+	var stored [][]string
+	for _, m := range mounts {
+		cfg := &config.Config{}
+		cfg.Projects.SourceBasePath = t.TempDir()
+		idx, _, vdb := newTestIndexer(t, cfg)
+
+		repo := filepath.Join(cfg.Projects.SourceBasePath, m.repoDir)
+		if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+			t.Fatalf("Failed to create .git: %v", err)
+		}
+		src := filepath.Join(repo, "services", "api")
+		os.MkdirAll(src, 0755)
+		os.WriteFile(filepath.Join(src, "main.go"), []byte("package api\n\nfunc Handle() {}\n"), 0644)
+
+		projectCfg := &config.ProjectConfig{
+			ProjectID:         "proj",
+			SourcePath:        m.sourcePath,
+			IncludeExtensions: []string{".go"},
+			PathRoot:          "git",
+		}
+		if _, err := idx.IndexProject(context.Background(), projectCfg, true); err != nil {
+			t.Fatalf("IndexProject failed: %v", err)
+		}
+
+		var paths []string
+		for _, p := range vdb.points {
+			paths = append(paths, p.Payload.FilePath)
+		}
+		stored = append(stored, paths)
+	}
+
+	for i, paths := range stored {
+		if len(paths) == 0 {
+			t.Fatalf("Mount %d stored no points", i)
+		}
+		for _, p := range paths {
+			if p != "services/api/main.go" {
+				t.Errorf("Mount %d stored path %q, want services/api/main.go", i, p)
+			}
+		}
+	}
+}
+
+func TestDiff_CategorizesDrift(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, _ := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"keep.go":   "package main\n\nfunc Keep() {}\n",
+		"modify.go": "package main\n\nfunc Modify() {}\n",
+		"delete.go": "package main\n\nfunc Delete() {}\n",
+	})
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	root := projectCfg.GetFullSourcePath(cfg.Projects.SourceBasePath)
+	os.WriteFile(filepath.Join(root, "modify.go"), []byte("package main\n\nfunc Modified() {}\n"), 0644)
+	os.Remove(filepath.Join(root, "delete.go"))
+	os.WriteFile(filepath.Join(root, "added.go"), []byte("package main\n\nfunc Added() {}\n"), 0644)
+
+	diff, err := idx.Diff(context.Background(), projectCfg)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	check := func(name string, got []string, want string) {
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("Expected %s = [%s], got %v", name, want, got)
+		}
+	}
+	check("added", diff.Added, "added.go")
+	check("deleted", diff.Deleted, "delete.go")
+	check("modified", diff.Modified, "modify.go")
+	if !diff.HasDrift() {
+		t.Error("Expected HasDrift to be true")
+	}
+
+	// Reindexing brings the index back in sync
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	diff, _ = idx.Diff(context.Background(), projectCfg)
+	if diff.HasDrift() {
+		t.Errorf("Expected no drift after reindex, got %+v", diff)
+	}
+}
+
+func TestUpsertChunks_IncludePathInEmbedding(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Embedding.IncludePathInEmbedding = true
+	idx, emb, vdb := newTestIndexer(t, cfg)
+
+	chunks := []chunker.Chunk{{
+		ID:          "proj:auth/controller.go:Login:abcd1234",
+		Content:     "func Login() {}",
+		Symbol:      "Login",
+		SymbolType:  "function",
+		ContentHash: "abcd1234",
+		FilePath:    "auth/controller.go",
+		ProjectID:   "proj",
+	}}
+
+	if err := idx.upsertChunks(context.Background(), chunks); err != nil {
+		t.Fatalf("upsertChunks failed: %v", err)
+	}
+
+	if len(emb.texts) != 1 {
+		t.Fatalf("Expected 1 embedded text, got %d", len(emb.texts))
+	}
+	text := emb.texts[0]
+	if !strings.Contains(text, "auth/controller.go") || !strings.Contains(text, "Login") || !strings.HasSuffix(text, "func Login() {}") {
+		t.Errorf("Expected embedded text to include path, symbol and content, got %q", text)
+	}
+
+	p := vdb.points["proj:auth/controller.go:Login:abcd1234"]
+	if p.Payload.Content != "func Login() {}" {
+		t.Errorf("Expected payload content without path header, got %q", p.Payload.Content)
+	}
+
+	if fp := idx.embeddingFingerprint(); !strings.HasSuffix(fp, ":path") {
+		t.Errorf("Expected fingerprint to reflect path embedding, got %q", fp)
+	}
+}
+
+func TestUpsertChunks_StripCommentsTransform(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Embedding.Transforms = map[string][]string{"go": {"strip_comments"}}
+	idx, emb, vdb := newTestIndexer(t, cfg)
+
+	content := "// Login authenticates a user.\nfunc Login() {\n\t/* check creds */\n\turl := \"http://x\" // trailing\n}"
+	chunks := []chunker.Chunk{{
+		ID:          "proj:auth.go:Login:abcd1234",
+		Content:     content,
+		Symbol:      "Login",
+		SymbolType:  "function",
+		Language:    "go",
+		ContentHash: "abcd1234",
+		FilePath:    "auth.go",
+		ProjectID:   "proj",
+	}}
+
+	if err := idx.upsertChunks(context.Background(), chunks); err != nil {
+		t.Fatalf("upsertChunks failed: %v", err)
+	}
+
+	text := emb.texts[0]
+	if strings.Contains(text, "Login authenticates") || strings.Contains(text, "check creds") || strings.Contains(text, "trailing") {
+		t.Errorf("Expected comments stripped from embedded text, got %q", text)
+	}
+	if !strings.Contains(text, "func Login()") || !strings.Contains(text, `"http://x"`) {
+		t.Errorf("Expected code and string literals kept, got %q", text)
+	}
+
+	p := vdb.points["proj:auth.go:Login:abcd1234"]
+	if p.Payload.Content != content {
+		t.Errorf("Expected payload content to keep comments, got %q", p.Payload.Content)
+	}
+
+	if fp := idx.embeddingFingerprint(); !strings.Contains(fp, "transforms=go=strip_comments") {
+		t.Errorf("Expected fingerprint to reflect transforms, got %q", fp)
+	}
+}
+
+func TestIndexProject_AccountsEmbeddingTokens(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		data := make([]map[string]any, len(req.Input))
+		for i := range req.Input {
+			data[i] = map[string]any{"index": i, "embedding": []float32{0.1, 0.2}}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"data":  data,
+			"usage": map[string]int{"total_tokens": 250 * len(req.Input)},
+		})
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Embedding.BatchSize = 1
+	cfg.Embedding.PricePer1KTokens = 0.02
+	idx, _, _ := newTestIndexer(t, cfg)
+	emb, err := embedder.NewOpenAIEmbedder(embedder.Config{Endpoint: srv.URL, APIKey: "test", Dimensions: 2})
+	if err != nil {
+		t.Fatalf("NewOpenAIEmbedder failed: %v", err)
+	}
+	idx.embedder = emb
+
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"a.go": "package main\n\nfunc A() {}\n",
+		"b.go": "package main\n\nfunc B() {}\n",
+	})
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if result.ChunksCreated == 0 {
+		t.Fatalf("Expected chunks to be created")
+	}
+
+	wantTokens := 250 * result.ChunksCreated
+	if result.EmbeddingTokens != wantTokens {
+		t.Errorf("Expected %d embedding tokens, got %d", wantTokens, result.EmbeddingTokens)
+	}
+	wantCost := float64(wantTokens) / 1000 * 0.02
+	if math.Abs(result.EstimatedCost-wantCost) > 1e-9 {
+		t.Errorf("Expected estimated cost %f, got %f", wantCost, result.EstimatedCost)
+	}
+}
+
+const testCodeowners = `# Default owners
+*                 @org/platform
+
+/auth/            @org/identity @alice
+*.md              @org/docs
+/billing/**/*.go  @org/payments # inline comment
+`
+
+func TestParseCodeowners(t *testing.T) {
+	co, err := parseCodeowners([]byte(testCodeowners))
+	if err != nil {
+		t.Fatalf("parseCodeowners failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"main.go":                 "@org/platform",
+		"auth/login.go":           "@org/identity",
+		"auth/docs/README.md":     "@org/docs", // later rule wins
+		"pkg/auth/login.go":       "@org/platform",
+		"billing/invoice/calc.go": "@org/payments",
+		"billing/calc.go":         "@org/payments",
+		"billing/notes.txt":       "@org/platform",
+	}
+	for path, want := range tests {
+		if got := co.Owner(path); got != want {
+			t.Errorf("Owner(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestIndexProject_AttachesCodeowners(t *testing.T) {
+	cfg := &config.Config{}
+	_, emb, _ := newTestIndexer(t, cfg)
+	vdb := vectordb.NewMemoryProvider()
+	idx := NewIndexer(cfg, emb, vdb, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		".github/CODEOWNERS": testCodeowners,
+		"main.go":            "package main\n\nfunc Main() {}\n",
+		"auth/login.go":      "package auth\n\nfunc Login() {}\n",
+	})
+	projectCfg.Ownership = "codeowners"
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	results, err := vdb.Scroll(context.Background(), vectordb.Filter{ProjectID: "proj", Owner: "@org/identity"}, 100)
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatalf("Expected chunks owned by @org/identity")
+	}
+	for _, r := range results {
+		if r.Payload.FilePath != "auth/login.go" || r.Payload.Owner != "@org/identity" {
+			t.Errorf("Unexpected chunk for owner filter: %s (owner %q)", r.Payload.FilePath, r.Payload.Owner)
+		}
+	}
+
+	all, _ := vdb.Scroll(context.Background(), vectordb.Filter{ProjectID: "proj"}, 100)
+	for _, r := range all {
+		if r.Payload.FilePath == "main.go" && r.Payload.Owner != "@org/platform" {
+			t.Errorf("Expected main.go owned by @org/platform, got %q", r.Payload.Owner)
+		}
+	}
+}
+
+func TestIndexProject_StoresCustomMetadata(t *testing.T) {
+	cfg := &config.Config{}
+	_, emb, _ := newTestIndexer(t, cfg)
+	vdb := vectordb.NewMemoryProvider()
+	idx := NewIndexer(cfg, emb, vdb, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"main.go": "package main\n\nfunc Main() {}\n",
+	})
+	projectCfg.Metadata.Custom = map[string]string{"service": "billing", "criticality": "high"}
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	query := vectordb.SearchQuery{
+		Vector: make([]float32, 4),
+		TopK:   10,
+		Filter: vectordb.Filter{ProjectID: "proj", Custom: map[string]string{"service": "billing"}},
+	}
+	results, err := vdb.Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("Expected chunks matching service=billing")
+	}
+	if got := results[0].Payload.Custom["criticality"]; got != "high" {
+		t.Errorf("Expected custom metadata stored on chunks, got %v", results[0].Payload.Custom)
+	}
+
+	query.Filter.Custom = map[string]string{"service": "checkout"}
+	if results, _ := vdb.Search(context.Background(), query); len(results) != 0 {
+		t.Errorf("Expected no chunks for service=checkout, got %d", len(results))
+	}
+}
+
+func TestIndexProject_UpdatesShiftedLinesWithoutReembedding(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Indexing.UpdateShiftedLines = true
+	_, emb, _ := newTestIndexer(t, cfg)
+	vdb := vectordb.NewMemoryProvider()
+	idx := NewIndexer(cfg, emb, vdb, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	source := "package main\n\nfunc Main() {\n\tprintln(\"main\")\n}\n\nfunc Helper() int {\n\treturn 42\n}\n"
+	projectCfg := writeTestProject(t, cfg, map[string]string{"main.go": source})
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	before, _ := vdb.Scroll(context.Background(), vectordb.Filter{ProjectID: "proj"}, 100)
+	if len(before) == 0 {
+		t.Fatal("Expected indexed chunks")
+	}
+	indexed := make(map[string]vectordb.Payload)
+	for _, p := range before {
+		indexed[p.ID] = p.Payload
+	}
+	embedded := len(emb.texts)
+
+	// Insert a leading comment line: every symbol moves down by one
+	path := filepath.Join(cfg.Projects.SourceBasePath, "proj", "main.go")
+	if err := os.WriteFile(path, []byte("// Package main is an example.\n"+source), 0644); err != nil {
+		t.Fatalf("Failed to rewrite main.go: %v", err)
+	}
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+
+	if len(emb.texts) != embedded {
+		t.Errorf("Expected no new embeddings, got %d", len(emb.texts)-embedded)
+	}
+	after, _ := vdb.Scroll(context.Background(), vectordb.Filter{ProjectID: "proj"}, 100)
+	relocated := 0
+	for _, p := range after {
+		old, ok := indexed[p.ID]
+		if !ok {
+			continue
+		}
+		if p.Payload.StartLine != old.StartLine+1 || p.Payload.EndLine != old.EndLine+1 {
+			t.Errorf("%s: expected lines %d-%d, got %d-%d", p.Payload.Symbol,
+				old.StartLine+1, old.EndLine+1, p.Payload.StartLine, p.Payload.EndLine)
+		}
+		// Only the line fields are rewritten
+		if p.Payload.Content != old.Content || p.Payload.IndexedAt != old.IndexedAt {
+			t.Errorf("%s: expected content and indexed_at kept", p.Payload.Symbol)
+		}
+		relocated++
+	}
+	if relocated == 0 || result.ChunksRelocated != relocated {
+		t.Errorf("Expected %d relocated chunks reported, got %d", relocated, result.ChunksRelocated)
+	}
+}
+
+func TestGitOwnerResolver_UsesTopContributor(t *testing.T) {
+	r := &gitOwnerResolver{
+		root: "/repo",
+		shortlog: func(ctx context.Context, root, relPath string) ([]byte, error) {
+			return []byte("    12\tJane Doe\n     3\tJohn Roe\n"), nil
+		},
+	}
+	if got := r.Owner("main.go"); got != "Jane Doe" {
+		t.Errorf("Expected top contributor Jane Doe, got %q", got)
+	}
+}
+
+func TestIndexProject_MaxChunksPerProject(t *testing.T) {
+	files := map[string]string{
+		"main.go":                "package main\n\nfunc Main() {}\n",
+		"node_modules/a/a.go":    "package a\n\nfunc A() {}\n",
+		"node_modules/b/b.go":    "package b\n\nfunc B() {}\n",
+		"node_modules/c/c.go":    "package c\n\nfunc C() {}\n",
+		"node_modules/d/util.go": "package d\n\nfunc D() {}\n",
+	}
+
+	t.Run("exceeded", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Indexing.MaxChunksPerProject = 2
+		idx, _, vdb := newTestIndexer(t, cfg)
+		projectCfg := writeTestProject(t, cfg, files)
+
+		_, err := idx.IndexProject(context.Background(), projectCfg, false)
+		if err == nil {
+			t.Fatalf("Expected run to abort when chunk limit is exceeded")
+		}
+		if !strings.Contains(err.Error(), "max_chunks_per_project") || !strings.Contains(err.Error(), "node_modules/") {
+			t.Errorf("Expected error to name the limit and likely directory, got %v", err)
+		}
+		if len(vdb.points) != 0 {
+			t.Errorf("Expected nothing stored after abort, got %d points", len(vdb.points))
+		}
+	})
+
+	t.Run("under limit", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Indexing.MaxChunksPerProject = 100
+		idx, _, vdb := newTestIndexer(t, cfg)
+		projectCfg := writeTestProject(t, cfg, files)
+
+		result, err := idx.IndexProject(context.Background(), projectCfg, false)
+		if err != nil {
+			t.Fatalf("IndexProject failed: %v", err)
+		}
+		if result.FilesIndexed != len(files) || len(vdb.points) == 0 {
+			t.Errorf("Expected all files indexed, got %d files and %d points", result.FilesIndexed, len(vdb.points))
+		}
+	})
+
+	t.Run("incremental counts cached chunks", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.Indexing.MaxChunksPerProject = len(files)
+		idx, _, vdb := newTestIndexer(t, cfg)
+		projectCfg := writeTestProject(t, cfg, files)
+		if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+			t.Fatalf("IndexProject failed: %v", err)
+		}
+		stored := len(vdb.points)
+
+		// One new file is within the limit on its own, but not with the rest
+		writeTestProject(t, cfg, map[string]string{"extra.go": "package main\n\nfunc Extra() {}\n"})
+		_, err := idx.IndexProject(context.Background(), projectCfg, false)
+		if err == nil || !strings.Contains(err.Error(), "max_chunks_per_project") {
+			t.Fatalf("Expected the project total to exceed the limit, got %v", err)
+		}
+		if len(vdb.points) != stored {
+			t.Errorf("Expected nothing stored after abort, got %d points (was %d)", len(vdb.points), stored)
+		}
+	})
+}
+
+// panicChunker is a chunker stub that always panics.
+type panicChunker struct{}
+
+func (panicChunker) Chunk(content []byte, metadata chunker.FileMetadata) ([]chunker.Chunk, error) {
+	panic("unbalanced braces")
+}
+
+func (panicChunker) Name() string { return "panic" }
+
+func TestIndexProject_ChunkerPanicFallsBackToWholeFile(t *testing.T) {
+	orig := chunkerForFile
+	chunkerForFile = func(f *chunker.Factory, relPath string) chunker.Chunker {
+		if relPath == "bad.go" {
+			return panicChunker{}
+		}
+		return orig(f, relPath)
+	}
+	t.Cleanup(func() { chunkerForFile = orig })
+
+	cfg := &config.Config{}
+	idx, _, vdb := newTestIndexer(t, cfg)
+	// Well over max_tokens, so fixed-size chunking would split it
+	bad := "package main\n\nfunc Bad() {\n" + strings.Repeat("\tprintln(\"unbalanced braces ahead\")\n", 60)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"bad.go":  bad,
+		"good.go": "package main\n\nfunc Good() {}\n",
+	})
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Error(), "bad.go") ||
+		!strings.Contains(result.Errors[0].Error(), "unbalanced braces") {
+		t.Fatalf("Expected recovered panic recorded for bad.go, got %v", result.Errors)
+	}
+	if result.FilesIndexed != 2 {
+		t.Errorf("Expected both files indexed, got %d", result.FilesIndexed)
+	}
+
+	var fallback []vectordb.Payload
+	for _, p := range vdb.points {
+		if p.Payload.FilePath == "bad.go" {
+			fallback = append(fallback, p.Payload)
+		}
+	}
+	if len(fallback) != 1 || fallback[0].SymbolType != "file" || fallback[0].Content != bad {
+		t.Errorf("Expected bad.go indexed as one whole-file chunk, got %d chunks", len(fallback))
+	}
+}
+
+func TestIndexProject_TranscodesLatin1(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		// "Café résumé" in ISO-8859-1
+		"notes.txt": "Caf\xe9 r\xe9sum\xe9\n",
+	})
+	projectCfg.Encodings = []string{"iso-8859-1"}
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	if len(vdb.points) != 1 {
+		t.Fatalf("Expected 1 point, got %d", len(vdb.points))
+	}
+	for _, p := range vdb.points {
+		if !utf8.ValidString(p.Payload.Content) || !strings.Contains(p.Payload.Content, "Café résumé") {
+			t.Errorf("Expected transcoded UTF-8 content, got %q", p.Payload.Content)
+		}
+	}
+}
+
+func TestTranscodeToUTF8(t *testing.T) {
+	utf := []byte("héllo")
+	if got, err := transcodeToUTF8(utf, []string{"windows-1252"}); err != nil || string(got) != "héllo" {
+		t.Errorf("Expected valid UTF-8 unchanged, got %q, %v", got, err)
+	}
+
+	// 0x81 0x20 is an incomplete Shift_JIS sequence
+	if _, err := transcodeToUTF8([]byte{0x81, 0x20}, []string{"shift_jis"}); err == nil {
+		t.Errorf("Expected undecodable content to fail")
+	}
+}
+
+func TestIndexProject_FailedFullReindexKeepsPreviousIndex(t *testing.T) {
+	cfg := &config.Config{}
+	idx, emb, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"a.go": "package main\n\nfunc A() {}\n",
+		"b.go": "package main\n\nfunc B() {}\n",
+	})
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	before := len(vdb.points)
+	if before == 0 {
+		t.Fatal("Expected initial index to contain points")
+	}
+
+	// Full reindex fails while embedding
+	emb.err = fmt.Errorf("embedding backend down")
+	result, err := idx.IndexProject(context.Background(), projectCfg, true)
+	if err != nil {
+		t.Fatalf("IndexProject returned error: %v", err)
+	}
+	if len(result.Errors) == 0 {
+		t.Error("Expected errors from failed full reindex")
+	}
+	if len(vdb.points) != before {
+		t.Errorf("Expected previous %d points to remain, got %d", before, len(vdb.points))
+	}
+
+	// Cache was not replaced: an incremental run sees nothing to do
+	emb.err = nil
+	result, _ = idx.IndexProject(context.Background(), projectCfg, false)
+	if result.FilesIndexed != 0 {
+		t.Errorf("Expected previous cache to be kept, got %d files reindexed", result.FilesIndexed)
+	}
+}
+
+func TestIndexProject_FullReindexDropsPreviousGeneration(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"a.go": "package main\n\nfunc A() {}\n",
+	})
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, true); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	// A stale point from an older generation that no longer maps to source
+	vdb.points["proj:gone.go:Gone:deadbeef"] = vectordb.Point{
+		ID:      "proj:gone.go:Gone:deadbeef",
+		Payload: vectordb.Payload{ProjectID: "proj", FilePath: "gone.go", Generation: "old"},
+	}
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, true); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if _, ok := vdb.points["proj:gone.go:Gone:deadbeef"]; ok {
+		t.Error("Expected superseded generation to be deleted after successful full reindex")
+	}
+
+	var generation string
+	for _, p := range vdb.points {
+		if generation == "" {
+			generation = p.Payload.Generation
+		}
+		if p.Payload.Generation == "" || p.Payload.Generation != generation {
+			t.Errorf("Expected all points in one non-empty generation, got %q", p.Payload.Generation)
+		}
+	}
+	if len(vdb.points) == 0 {
+		t.Error("Expected new generation points to remain")
+	}
+}
+
+func TestReadGitRef(t *testing.T) {
+	root := t.TempDir()
+	gitDir := filepath.Join(root, ".git")
+	os.MkdirAll(filepath.Join(gitDir, "refs", "heads"), 0755)
+
+	// Loose branch ref
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+	os.WriteFile(filepath.Join(gitDir, "refs", "heads", "main"), []byte("aaa111\n"), 0644)
+	if got := readGitRef(root); got != "aaa111" {
+		t.Errorf("Expected loose ref commit, got %q", got)
+	}
+
+	// Packed branch ref
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/release\n"), 0644)
+	os.WriteFile(filepath.Join(gitDir, "packed-refs"),
+		[]byte("# pack-refs with: peeled fully-peeled sorted\nbbb222 refs/heads/release\n"), 0644)
+	if got := readGitRef(root); got != "bbb222" {
+		t.Errorf("Expected packed ref commit, got %q", got)
+	}
+
+	// Detached HEAD
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ccc333\n"), 0644)
+	if got := readGitRef(root); got != "ccc333" {
+		t.Errorf("Expected detached commit, got %q", got)
+	}
+
+	if got := readGitRef(""); got != "" {
+		t.Errorf("Expected empty ref outside a repository, got %q", got)
+	}
+}
+
+// slowChunker delays before delegating, to make file timings deterministic.
+type slowChunker struct {
+	chunker.Chunker
+	delay time.Duration
+}
+
+func (s slowChunker) Chunk(content []byte, metadata chunker.FileMetadata) ([]chunker.Chunk, error) {
+	time.Sleep(s.delay)
+	return s.Chunker.Chunk(content, metadata)
+}
+
+func TestIndexProject_SlowestFiles(t *testing.T) {
+	delays := map[string]time.Duration{
+		"slow.go":   80 * time.Millisecond,
+		"medium.go": 40 * time.Millisecond,
+	}
+	orig := chunkerForFile
+	chunkerForFile = func(f *chunker.Factory, relPath string) chunker.Chunker {
+		return slowChunker{Chunker: orig(f, relPath), delay: delays[relPath]}
+	}
+	t.Cleanup(func() { chunkerForFile = orig })
+
+	cfg := &config.Config{}
+	idx, _, _ := newTestIndexer(t, cfg)
+	idx.SetSlowestFiles(2)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"slow.go":   "package main\n\nfunc Slow() {}\n",
+		"medium.go": "package main\n\nfunc Medium() {}\n",
+		"fast.go":   "package main\n\nfunc Fast() {}\n",
+	})
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	if len(result.SlowestFiles) != 2 {
+		t.Fatalf("Expected 2 slowest files, got %+v", result.SlowestFiles)
+	}
+	if result.SlowestFiles[0].FilePath != "slow.go" || result.SlowestFiles[1].FilePath != "medium.go" {
+		t.Errorf("Expected slow.go then medium.go, got %+v", result.SlowestFiles)
+	}
+	if result.SlowestFiles[0].Duration < result.SlowestFiles[1].Duration {
+		t.Errorf("Expected descending durations, got %+v", result.SlowestFiles)
+	}
+	if result.SlowestFiles[0].Duration < delays["slow.go"] || result.SlowestFiles[0].Chunks == 0 {
+		t.Errorf("Expected slow.go timing and chunk count recorded, got %+v", result.SlowestFiles[0])
+	}
+}
+
+func TestIndexProject_ReportsProgress(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, _ := newTestIndexer(t, cfg)
+	var events []ProgressEvent
+	idx.SetProgressFunc(func(e ProgressEvent) { events = append(events, e) })
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"a.go": "package main\n\nfunc A() {}\n",
+		"b.go": "package main\n\nfunc B() {}\n",
+		"c.go": "package main\n\nfunc C() {}\n",
+	})
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 progress events, got %+v", events)
+	}
+	seen := make(map[string]bool)
+	for i, e := range events {
+		if e.ProjectID != "proj" || e.FilesTotal != 3 || e.FilesProcessed != i+1 {
+			t.Errorf("event %d: unexpected %+v", i, e)
+		}
+		seen[e.File] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected one event per file, got %+v", events)
+	}
+	if last := events[len(events)-1]; last.ETA != 0 || last.FilesProcessed != result.FilesIndexed {
+		t.Errorf("Expected final event to cover all indexed files, got %+v", last)
+	}
+}
+
+func TestIndexProject_EditedFunctionReembedsOneChunk(t *testing.T) {
+	idx, emb, vdb := newTestIndexer(t, nil)
+	body := func(name, stmt string) string {
+		return "func " + name + "(values []string) int {\n\ttotal := 0\n\tfor _, v := range values {\n\t\t" + stmt + "\n\t}\n\treturn total\n}\n"
+	}
+	source := func(bStmt string) string {
+		return "package calc\n\n" + body("SumA", "total += len(v)") + "\n" + body("SumB", bStmt) + "\n" + body("SumC", "total += 2 * len(v)")
+	}
+	projectCfg := writeTestProject(t, idx.cfg, map[string]string{"calc.go": source("total += len(v) + 1")})
+	ctx := context.Background()
+
+	first, err := idx.IndexProject(ctx, projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if first.ChunksCreated != 3 || len(vdb.points) != 3 {
+		t.Fatalf("Expected one chunk per function, got %d created, %d stored", first.ChunksCreated, len(vdb.points))
+	}
+	var oldB string
+	for id, p := range vdb.points {
+		if p.Payload.Symbol == "SumB" {
+			oldB = id
+		}
+	}
+
+	// Only the edited function is re-embedded; its old chunk is deleted
+	emb.texts = nil
+	path := filepath.Join(idx.cfg.Projects.SourceBasePath, "proj", "calc.go")
+	if err := os.WriteFile(path, []byte(source("total += len(v) - 1")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := idx.IndexProject(ctx, projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(emb.texts) != 1 || !strings.Contains(emb.texts[0], "len(v) - 1") {
+		t.Errorf("Expected exactly the edited function re-embedded, got %q", emb.texts)
+	}
+	if second.ChunksCreated != 1 || second.ChunksDeleted != 1 {
+		t.Errorf("Expected 1 chunk created and 1 deleted, got %d and %d", second.ChunksCreated, second.ChunksDeleted)
+	}
+	if _, ok := vdb.points[oldB]; ok || len(vdb.points) != 3 {
+		t.Errorf("Expected the old SumB chunk replaced, got %d points", len(vdb.points))
+	}
+
+	// A full reindex overwrites unchanged chunks in place and reports the
+	// superseded generation's leftovers as deleted
+	if err := os.WriteFile(path, []byte(source("total += len(v) * 3")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	full, err := idx.IndexProject(ctx, projectCfg, true)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if full.ChunksDeleted != 1 || len(vdb.points) != 3 {
+		t.Errorf("Expected the previous SumB chunk deleted, got %d deleted, %d stored", full.ChunksDeleted, len(vdb.points))
+	}
+}
+
+func TestIndexProject_EmbeddingCacheSkipsUnchangedChunks(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Cache.EmbeddingCache = true
+	idx, emb, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"a.go": "package main\n\nfunc A() {}\n",
+		"b.go": "package main\n\nfunc B() {}\n",
+	})
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, true); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	firstRun := len(emb.texts)
+	if firstRun == 0 {
+		t.Fatal("Expected chunks to be embedded on the first run")
+	}
+
+	// A full reindex of unchanged files is served entirely from the cache
+	emb.texts = nil
+	if _, err := idx.IndexProject(context.Background(), projectCfg, true); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(emb.texts) != 0 {
+		t.Errorf("Expected no embedding calls for unchanged content, got %q", emb.texts)
+	}
+	for id, p := range vdb.points {
+		if len(p.Vector) == 0 {
+			t.Errorf("point %s stored without a vector", id)
+		}
+	}
+
+	// Changed content misses the cache; the unchanged file still hits it
+	path := filepath.Join(cfg.Projects.SourceBasePath, "proj", "b.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc B() { println(1) }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.IndexProject(context.Background(), projectCfg, true); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(emb.texts) == 0 || len(emb.texts) >= firstRun {
+		t.Fatalf("Expected only b.go's changed chunks to be embedded, got %q", emb.texts)
+	}
+	for _, text := range emb.texts {
+		if strings.Contains(text, "func A()") {
+			t.Errorf("Unchanged chunk re-embedded: %q", text)
+		}
+	}
+
+	// The cache survives a new indexer (it lives on disk)
+	idx2, emb2, _ := newTestIndexer(t, cfg)
+	if _, err := idx2.IndexProject(context.Background(), projectCfg, true); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(emb2.texts) != 0 {
+		t.Errorf("Expected cached vectors to be loaded from disk, got %q", emb2.texts)
+	}
+}
+
+func TestIndexProject_SkipsEmptyChunks(t *testing.T) {
+	cfg := &config.Config{}
+	idx, emb, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"comments.ts": "// TODO: port the legacy client\n/*\n * Nothing here yet.\n */\n",
+		"empty.php":   "<?php\n\n",
+		"main.go":     "package main\n\nfunc main() {}\n",
+	})
+	projectCfg.IncludeExtensions = []string{".go", ".ts", ".php"}
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	if result.ChunksSkipped != 2 {
+		t.Errorf("Expected 2 skipped chunks, got %d", result.ChunksSkipped)
+	}
+	for _, p := range vdb.points {
+		if p.Payload.FilePath != "main.go" {
+			t.Errorf("Expected no vector for %s", p.Payload.FilePath)
+		}
+	}
+	if len(vdb.points) == 0 {
+		t.Errorf("Expected main.go to be indexed")
+	}
+	for _, text := range emb.texts {
+		if strings.Contains(text, "TODO") || strings.Contains(text, "<?php") {
+			t.Errorf("Expected empty chunks not to be embedded, got %q", text)
+		}
+	}
+}
+
+func TestIndexProject_MinChunkChars(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Indexing.MinChunkChars = 20
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"tiny.txt": "ok\n",
+		"note.txt": "This note has enough content to be embedded.\n",
+	})
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	if result.ChunksSkipped != 1 {
+		t.Errorf("Expected tiny.txt to be skipped, got %d skipped", result.ChunksSkipped)
+	}
+	if len(vdb.points) != 1 {
+		t.Fatalf("Expected 1 vector, got %d", len(vdb.points))
+	}
+	for _, p := range vdb.points {
+		if p.Payload.FilePath != "note.txt" {
+			t.Errorf("Expected only note.txt indexed, got %s", p.Payload.FilePath)
+		}
+	}
+}
+
+func TestIndexProject_ExcludeSymbols(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"user.go": `package models
+
+func getName() string {
+	return "generated accessor for the name field"
+}
+
+func setName(name string) {
+	println("generated mutator for the name field", name)
+}
+
+func Process() {
+	println("hand-written business logic lives here")
+}
+`,
+	})
+
+	symbols := func() map[string]bool {
+		found := make(map[string]bool)
+		for _, p := range vdb.points {
+			found[p.Payload.Symbol] = true
+		}
+		return found
+	}
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if !symbols()["getName"] {
+		t.Fatalf("Expected getName to be indexed before exclusion, got %v", symbols())
+	}
+
+	// Adding the pattern prunes already indexed matches on an unchanged file
+	projectCfg.ExcludeSymbols = []string{"^get[A-Z]"}
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if got := symbols(); got["getName"] || !got["setName"] || !got["Process"] {
+		t.Errorf("Expected only getName to be excluded, got %v", got)
+	}
+	if result.ChunksExcluded != 1 || result.FilesIndexed != 1 {
+		t.Errorf("Expected 1 excluded chunk from a reprocessed file, got excluded=%d indexed=%d",
+			result.ChunksExcluded, result.FilesIndexed)
+	}
+
+	// Once pruned, the file is skipped again
+	result, err = idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if result.FilesSkipped != 1 {
+		t.Errorf("Expected unchanged file to be skipped after pruning, got %d skipped", result.FilesSkipped)
+	}
+}
+
+func TestIndexProject_RepoOverridesExcludePaths(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"main.go":                "package main\n\nfunc main() {}\n",
+		"generated/api.go":       "package generated\n\nfunc Generated() {}\n",
+		"vendor/lib/lib.go":      "package lib\n\nfunc Lib() {}\n",
+		config.RepoOverridesFile: "exclude_paths:\n  - \"generated/\"\n",
+	})
+	projectCfg.ExcludePaths = []string{"vendor/"}
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	indexed := make(map[string]bool)
+	for _, p := range vdb.points {
+		indexed[p.Payload.FilePath] = true
+	}
+	if indexed["generated/api.go"] {
+		t.Errorf("Expected generated/ excluded by the in-repo file, got %v", indexed)
+	}
+	// The in-repo list replaces the central one
+	if !indexed["main.go"] || !indexed["vendor/lib/lib.go"] {
+		t.Errorf("Expected main.go and vendor/ indexed, got %v", indexed)
+	}
+}
+
+func TestIndexProject_RespectGitignore(t *testing.T) {
+	files := map[string]string{
+		".gitignore":          "# build output\ndist/\n*.log\n!keep.log\n/root-only.txt\n",
+		"main.go":             "package main\n\nfunc main() {}\n",
+		"debug.log":           "debug output\n",
+		"keep.log":            "kept on purpose\n",
+		"root-only.txt":       "ignored at the top only\n",
+		"dist/bundle.go":      "package dist\n\nfunc Bundle() {}\n",
+		"pkg/root-only.txt":   "anchored pattern doesn't apply here\n",
+		"pkg/trace.log":       "trace output\n",
+		"pkg/dist":            "a file, not a directory\n",
+		"pkg/.gitignore":      "*.txt\n!notes.txt\n",
+		"pkg/notes.txt":       "re-included by the nested file\n",
+		"pkg/lib.go":          "package pkg\n\nfunc Lib() {}\n",
+		"pkg/sub/scratch.txt": "ignored by the nested file\n",
+	}
+
+	discovered := func(t *testing.T, respect bool) map[string]bool {
+		cfg := &config.Config{}
+		idx, _, _ := newTestIndexer(t, cfg)
+		projectCfg := writeTestProject(t, cfg, files)
+		projectCfg.IncludeExtensions = append(projectCfg.IncludeExtensions, ".log", "")
+		projectCfg.RespectGitignore = respect
+
+		found, err := idx.discoverFiles(filepath.Join(cfg.Projects.SourceBasePath, "proj"), projectCfg)
+		if err != nil {
+			t.Fatalf("discoverFiles failed: %v", err)
+		}
+		paths := make(map[string]bool)
+		for _, f := range found {
+			paths[f.relPath] = true
+		}
+		return paths
+	}
+
+	got := discovered(t, true)
+	want := map[string]bool{
+		"main.go":       true,
+		"keep.log":      true,
+		"pkg/dist":      true, // dist/ only matches directories
+		"pkg/notes.txt": true,
+		"pkg/lib.go":    true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Discovered %v, want %v", got, want)
+	}
+
+	// Off by default
+	if got := discovered(t, false); !got["dist/bundle.go"] || !got["debug.log"] || !got["pkg/sub/scratch.txt"] {
+		t.Errorf("Expected .gitignore ignored without respect_gitignore, got %v", got)
+	}
+}
+
+func TestGitignoreMatcher_WalksUpToGitRoot(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create .git: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".gitignore"), []byte("services/api/generated/\n**/*.tmp\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+	root := filepath.Join(repo, "services", "api")
+
+	m := newGitignoreMatcher(root)
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"generated", true, true},
+		{"handler.go", false, false},
+		{"internal/cache.tmp", false, true},
+	}
+	for _, tt := range tests {
+		got, err := m.Ignored(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir)
+		if err != nil {
+			t.Fatalf("Ignored(%q) failed: %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("Ignored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIndexProject_PeriodicCommits(t *testing.T) {
+	files := map[string]string{
+		"a.txt": "First file with enough content.\n",
+		"b.txt": "Second file with enough content.\n",
+		"c.txt": "Third file with enough content.\n",
+	}
+
+	cfg := &config.Config{}
+	cfg.Cache.FlushEveryN = 1
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, files)
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	if vdb.upserts != 3 {
+		t.Errorf("Expected one commit per file, got %d upserts", vdb.upserts)
+	}
+	if len(vdb.points) != 3 {
+		t.Errorf("Expected 3 vectors, got %d", len(vdb.points))
+	}
+	cache, _ := NewCache(cfg.Cache.Dir, "proj")
+	if cached := cache.GetAllFiles(); len(cached) != 3 {
+		t.Errorf("Expected all files in the saved cache, got %v", cached)
+	}
+
+	// Full reindexes keep storing everything at the end
+	vdb.upserts = 0
+	if _, err := idx.IndexProject(context.Background(), projectCfg, true); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if vdb.upserts != 1 {
+		t.Errorf("Expected a single store for a full reindex, got %d upserts", vdb.upserts)
+	}
+}
+
+func TestIndexProject_GiganticFunctionFitsWindow(t *testing.T) {
+	// Generated code: a 10,000-line function with one huge table line
+	var body strings.Builder
+	body.WriteString("package gen\n\nfunc Generated() []int {\n\tt := []int{")
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&body, "%d, ", i)
+	}
+	body.WriteString("}\n")
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&body, "\tt = append(t, value%d*%d)\n", i, i)
+	}
+	body.WriteString("\treturn t\n}\n")
+
+	const window = 512
+	cfg := &config.Config{}
+	cfg.Chunking = config.ChunkingConfig{MinTokens: 10, IdealTokens: 50, MaxTokens: 100,
+		MaxTokensByType: map[string]int{"function": window}}
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{"gen.go": body.String()})
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(result.OversizedChunks) != 0 {
+		t.Errorf("Expected no oversized chunks, got %d", len(result.OversizedChunks))
+	}
+
+	parts := 0
+	for _, p := range vdb.points {
+		if !strings.HasPrefix(p.Payload.Symbol, "Generated#") {
+			continue
+		}
+		parts++
+		if tokens := chunker.EstimateTokens(p.Payload.Content); tokens > window {
+			t.Errorf("%s (lines %d-%d) has %d tokens, over the %d window",
+				p.Payload.Symbol, p.Payload.StartLine, p.Payload.EndLine, tokens, window)
+		}
+	}
+	if parts < 2 {
+		t.Fatalf("Expected the function to be split, got %d parts", parts)
+	}
+}
+
+func TestIndexProject_SplitsOversizedChunks(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("package huge\n\nfunc Huge(a, b, c int) int {\n")
+	for i := 0; i < 600; i++ {
+		fmt.Fprintf(&body, "\ta = compute(a, b, c) + offset%d\n", i)
+	}
+	body.WriteString("\treturn a\n}\n")
+	if tokens := chunker.EstimateTokens(body.String()); tokens < 5000 {
+		t.Fatalf("fixture too small: %d tokens", tokens)
+	}
+
+	cfg := &config.Config{}
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{"huge.go": body.String()})
+
+	result, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if result.ChunksSplit != 1 || len(result.OversizedChunks) != 0 {
+		t.Errorf("Expected 1 split chunk and no oversized report, got split=%d oversized=%d",
+			result.ChunksSplit, len(result.OversizedChunks))
+	}
+
+	var parts []vectordb.Point
+	for _, p := range vdb.points {
+		if strings.HasPrefix(p.Payload.Symbol, "Huge#") {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) < 3 {
+		t.Fatalf("Expected the function to be split into at least 3 sub-chunks, got %d", len(parts))
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Payload.StartLine < parts[j].Payload.StartLine })
+	for i, p := range parts {
+		if tokens := chunker.EstimateTokens(p.Payload.Content); tokens > 2048 {
+			t.Errorf("%s has %d tokens, over the 2048 limit", p.Payload.Symbol, tokens)
+		}
+		if p.Payload.FilePath != "huge.go" {
+			t.Errorf("%s lost its file path: %q", p.Payload.Symbol, p.Payload.FilePath)
+		}
+		if i > 0 && p.Payload.StartLine != parts[i-1].Payload.EndLine+1 {
+			t.Errorf("sub-chunks are not contiguous: %d-%d then %d-%d",
+				parts[i-1].Payload.StartLine, parts[i-1].Payload.EndLine, p.Payload.StartLine, p.Payload.EndLine)
+		}
+	}
+}
+
+// wordEmbedder embeds texts as hashed bag-of-words vectors, so texts sharing
+// words score as similar.
+type wordEmbedder struct{ fakeEmbedder }
+
+func (w *wordEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	v := make([]float32, 64)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		v[h.Sum32()%64]++
+	}
+	return v, nil
+}
+
+func (w *wordEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = w.Embed(ctx, text)
+	}
+	return vectors, nil
+}
+
+func TestIndexProject_IndexPathsFindsFileByPathFragment(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Chunking = config.ChunkingConfig{MinTokens: 10, IdealTokens: 50, MaxTokens: 100, IndexPaths: true}
+	cfg.Cache.Dir = t.TempDir()
+	cfg.Embedding.BatchSize = 8
+	projectCfg := writeTestProject(t, cfg, map[string]string{
+		"internal/vectordb/qdrant_client.go": "package vectordb\n\nfunc Connect(url string) error { return nil }\n",
+		"internal/api/server.go":             "package api\n\nfunc Start(client string) error { return nil }\n",
+	})
+
+	emb := &wordEmbedder{}
+	vdb := vectordb.NewMemoryProvider()
+	idx := NewIndexer(cfg, emb, vdb, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+
+	query, _ := emb.Embed(context.Background(), "the qdrant client file")
+	results, err := vdb.Search(context.Background(), vectordb.SearchQuery{Vector: query, TopK: 1, Filter: vectordb.Filter{ProjectID: "proj"}})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected a result, got none")
+	}
+	top := results[0].Payload
+	if top.SymbolType != chunker.PathSymbolType || top.FilePath != "internal/vectordb/qdrant_client.go" {
+		t.Errorf("Expected the qdrant client path chunk first, got %+v", top)
+	}
+	if top.Content != "internal vectordb qdrant client" {
+		t.Errorf("Expected humanized path content, got %q", top.Content)
+	}
+
+	if n, _ := vdb.Count(context.Background(), vectordb.Filter{ProjectID: "proj", SymbolType: chunker.PathSymbolType}); n != 2 {
+		t.Errorf("Expected one path chunk per file, got %d", n)
+	}
+}
+
+func TestIndexProject_MergeSiblingFiles(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Chunking = config.ChunkingConfig{MinTokens: 40, IdealTokens: 80, MaxTokens: 120, MergeSiblingFiles: true}
+	cfg.Cache.Dir = t.TempDir()
+	cfg.Embedding.BatchSize = 8
+	files := map[string]string{"cmd/main.go": "package main\n\nfunc main() {}\n"}
+	for _, name := range []string{"Reader", "Writer", "Closer", "Seeker", "Flusher", "Syncer"} {
+		files["pkg/io/"+strings.ToLower(name)+".go"] = "package io\n\n// " + name + " wraps one method.\ntype " + name + " interface {\n\t" + name[:len(name)-2] + "() error\n}\n"
+	}
+	projectCfg := writeTestProject(t, cfg, files)
+	root := filepath.Join(cfg.Projects.SourceBasePath, "proj")
+
+	vdb := vectordb.NewMemoryProvider()
+	idx := NewIndexer(cfg, &fakeEmbedder{}, vdb, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	// points returns the stored points by the files they cover
+	points := func() map[string]vectordb.Payload {
+		t.Helper()
+		all, err := vdb.Scroll(ctx, vectordb.Filter{ProjectID: "proj"}, 100)
+		if err != nil {
+			t.Fatalf("Scroll failed: %v", err)
+		}
+		byFile := make(map[string]vectordb.Payload)
+		for _, p := range all {
+			covered := p.Payload.FilePaths
+			if len(covered) == 0 {
+				covered = []string{p.Payload.FilePath}
+			}
+			for _, f := range covered {
+				if _, dup := byFile[f]; dup {
+					t.Errorf("File %s stored in more than one chunk", f)
+				}
+				byFile[f] = p.Payload
+			}
+		}
+		if len(byFile) != len(files) {
+			t.Errorf("Expected every file stored once, got %d of %d", len(byFile), len(files))
+		}
+		return byFile
+	}
+	index := func() {
+		t.Helper()
+		if _, err := idx.IndexProject(ctx, projectCfg, false); err != nil {
+			t.Fatalf("IndexProject failed: %v", err)
+		}
+	}
+
+	index()
+	byFile := points()
+	n, _ := vdb.Count(ctx, vectordb.Filter{ProjectID: "proj"})
+	if n >= len(files)-1 {
+		t.Fatalf("Expected the six interface files merged into fewer chunks, got %d points", n)
+	}
+	reader := byFile["pkg/io/reader.go"]
+	if len(reader.FilePaths) < 2 || reader.SymbolType != "combined" || !strings.Contains(reader.Content, "type Reader interface") {
+		t.Errorf("Expected reader.go in a combined chunk, got %+v", reader)
+	}
+	if len(byFile["cmd/main.go"].FilePaths) != 0 {
+		t.Errorf("Expected main.go alone in its directory to stay unmerged")
+	}
+
+	// Editing one file rebuilds its merge without leaving stale chunks
+	if err := os.WriteFile(filepath.Join(root, "pkg/io/reader.go"), []byte("package io\n\n// Reader reads.\ntype Reader interface {\n\tReadAll() error\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to edit reader.go: %v", err)
+	}
+	index()
+	byFile = points()
+	if !strings.Contains(byFile["pkg/io/reader.go"].Content, "ReadAll") {
+		t.Errorf("Expected the edited interface in its merged chunk, got %q", byFile["pkg/io/reader.go"].Content)
+	}
+	if after, _ := vdb.Count(ctx, vectordb.Filter{ProjectID: "proj"}); after != n {
+		t.Errorf("Expected %d points after the edit, got %d", n, after)
+	}
+
+	// Deleting a file re-merges its siblings
+	if err := os.Remove(filepath.Join(root, "pkg/io/syncer.go")); err != nil {
+		t.Fatalf("Failed to delete syncer.go: %v", err)
+	}
+	delete(files, "pkg/io/syncer.go")
+	index()
+	for _, p := range points() {
+		if strings.Contains(p.Content, "Syncer") {
+			t.Errorf("Expected the deleted interface gone, found in %v", p.FilePaths)
+		}
+	}
+}
+
+func TestIndexProject_MinFreeBytes(t *testing.T) {
+	orig := freeDiskSpace
+	t.Cleanup(func() { freeDiskSpace = orig })
+	var checkedDir string
+	freeDiskSpace = func(dir string) (uint64, bool, error) {
+		checkedDir = dir
+		return 1 << 20, true, nil
+	}
+
+	cfg := &config.Config{}
+	cfg.Cache.MinFreeBytes = 2 << 20
+	idx, emb, _ := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	_, err := idx.IndexProject(context.Background(), projectCfg, false)
+	if err == nil || !strings.Contains(err.Error(), "cache.min_free_bytes") {
+		t.Fatalf("Expected insufficient disk space error, got %v", err)
+	}
+	if checkedDir != cfg.Cache.Dir {
+		t.Errorf("Expected the cache dir to be checked, got %q", checkedDir)
+	}
+	if len(emb.texts) != 0 {
+		t.Errorf("Expected nothing embedded, got %d texts", len(emb.texts))
+	}
+
+	// Enough space lets the run proceed
+	cfg.Cache.MinFreeBytes = 1 << 20
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Errorf("Expected run to proceed, got %v", err)
+	}
+}
+
+func TestIndexProject_StoresLastModified(t *testing.T) {
+	cfg := &config.Config{}
+	idx, _, vdb := newTestIndexer(t, cfg)
+	projectCfg := writeTestProject(t, cfg, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	modTime := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	path := filepath.Join(cfg.Projects.SourceBasePath, "proj", "main.go")
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	if _, err := idx.IndexProject(context.Background(), projectCfg, false); err != nil {
+		t.Fatalf("IndexProject failed: %v", err)
+	}
+	if len(vdb.points) == 0 {
+		t.Fatal("Expected points to be upserted")
+	}
+	for _, p := range vdb.points {
+		if p.Payload.LastModified != "2024-03-15T10:30:00Z" {
+			t.Errorf("Expected last_modified from the file mod-time, got %q", p.Payload.LastModified)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package indexer provides payload schema migration for stored points.
+// Points written before a payload field existed don't match filters on it;
+// migration back-fills such fields so old and new points behave alike.
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iasik/project-indexer/internal/chunker"
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+// migrateBatchSize is the number of payloads written per update request.
+const migrateBatchSize = 256
+
+// migratePageSize is the number of points read per scroll request.
+const migratePageSize = 1000
+
+// MigrateResult summarizes a payload migration.
+type MigrateResult struct {
+	Scanned  int // points read
+	Migrated int // points upgraded to the current payload version
+	Skipped  int // points already at the current version
+}
+
+// MigratePayloads upgrades stored payloads of a project (all projects when
+// projectID is empty) to vectordb.PayloadVersion, back-filling fields that
+// are derivable from the payload itself. Up-to-date points are skipped.
+// Points are read page by page and each page is written before the next is
+// read, so memory use doesn't grow with the collection.
+func (idx *Indexer) MigratePayloads(ctx context.Context, projectID string) (*MigrateResult, error) {
+	caps := idx.vectorDB.Capabilities()
+	if !caps.Count || !caps.Scroll {
+		return nil, fmt.Errorf("vector database provider does not support counting and scrolling points")
+	}
+
+	filter := vectordb.Filter{ProjectID: projectID, IncludeDeleted: true}
+	total, err := idx.vectorDB.Count(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("count points: %w", err)
+	}
+	result := &MigrateResult{}
+	if total == 0 {
+		return result, nil
+	}
+
+	var batch []vectordb.Point
+	var updateErr error
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := idx.vectorDB.UpdatePayloads(ctx, batch); err != nil {
+			updateErr = fmt.Errorf("update payloads: %w", err)
+			return updateErr
+		}
+		result.Migrated += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	query := vectordb.ScrollQuery{Filter: filter, Limit: migratePageSize}
+	err = vectordb.ScrollAll(ctx, idx.vectorDB, query, func(points []vectordb.SearchResult) error {
+		for _, p := range points {
+			result.Scanned++
+			if p.Payload.PayloadVersion >= vectordb.PayloadVersion {
+				result.Skipped++
+				continue
+			}
+			payload := p.Payload
+			idx.migratePayload(&payload)
+			batch = append(batch, vectordb.Point{ID: p.ID, Payload: payload})
+			if len(batch) >= migrateBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		// Flush the page before reading the next one
+		return flush()
+	})
+	if updateErr != nil {
+		return result, updateErr
+	}
+	if err != nil {
+		return result, fmt.Errorf("scroll points: %w", err)
+	}
+	return result, nil
+}
+
+// migratePayload applies each schema step from the payload's version up to
+// vectordb.PayloadVersion.
+func (idx *Indexer) migratePayload(p *vectordb.Payload) {
+	if p.PayloadVersion < 1 {
+		// token_count is derivable from stored content; metadata-only
+		// indexes (no content) keep it unset
+		if p.TokenCount == 0 && p.Content != "" {
+			p.TokenCount = idx.tokenizer.CountTokens(p.Content)
+		}
+		if p.Language == "" {
+			p.Language = chunker.DetectLanguage(p.FilePath)
+		}
+	}
+	if p.PayloadVersion < 2 {
+		p.ParentSymbol = chunker.ParentSymbol(p.Symbol, p.SymbolType)
+	}
+	p.PayloadVersion = vectordb.PayloadVersion
+}
@@ -0,0 +1,132 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/iasik/project-indexer/internal/config"
+	"github.com/iasik/project-indexer/internal/vectordb"
+)
+
+func TestMigratePayloads(t *testing.T) {
+	vdb := vectordb.NewMemoryProvider()
+	old := []vectordb.Point{
+		{ID: "proj:a.go:A:1", Vector: []float32{1, 0}, Payload: vectordb.Payload{
+			ProjectID: "proj", FilePath: "a.go", Content: "func A() { return }"}},
+		{ID: "proj:b.py:b:2", Vector: []float32{0, 1}, Payload: vectordb.Payload{
+			ProjectID: "proj", FilePath: "b.py", Language: "python"}}, // metadata-only
+		{ID: "proj:c.go:C:3", Vector: []float32{1, 1}, Payload: vectordb.Payload{
+			ProjectID: "proj", FilePath: "c.go", Language: "go", Content: "x", TokenCount: 7,
+			PayloadVersion: vectordb.PayloadVersion}},
+		{ID: "proj:e.go:Server.Handle:5", Vector: []float32{0, 1}, Payload: vectordb.Payload{
+			ProjectID: "proj", FilePath: "e.go", Language: "go", Symbol: "Server.Handle", SymbolType: "method",
+			PayloadVersion: 1}},
+		{ID: "other:d.go:D:4", Vector: []float32{1, 1}, Payload: vectordb.Payload{
+			ProjectID: "other", FilePath: "d.go", Content: "func D() {}"}},
+	}
+	if err := vdb.Upsert(context.Background(), old); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	idx := NewIndexer(&config.Config{}, nil, vdb, logger)
+
+	result, err := idx.MigratePayloads(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("MigratePayloads failed: %v", err)
+	}
+	if result.Scanned != 4 || result.Migrated != 3 || result.Skipped != 1 {
+		t.Errorf("Expected 4 scanned, 3 migrated, 1 skipped, got %+v", result)
+	}
+
+	points, _ := vdb.Scroll(context.Background(), vectordb.Filter{}, 0)
+	byID := make(map[string]vectordb.SearchResult)
+	for _, p := range points {
+		byID[p.ID] = p
+	}
+
+	a := byID["proj:a.go:A:1"].Payload
+	if a.PayloadVersion != vectordb.PayloadVersion || a.TokenCount == 0 || a.Language != "go" {
+		t.Errorf("Expected a.go back-filled, got %+v", a)
+	}
+	if b := byID["proj:b.py:b:2"].Payload; b.PayloadVersion != vectordb.PayloadVersion || b.TokenCount != 0 {
+		t.Errorf("Expected b.py versioned without a token count, got %+v", b)
+	}
+	if c := byID["proj:c.go:C:3"].Payload; c.TokenCount != 7 {
+		t.Errorf("Expected already-migrated c.go untouched, got %+v", c)
+	}
+	if e := byID["proj:e.go:Server.Handle:5"].Payload; e.ParentSymbol != "Server" || e.PayloadVersion != vectordb.PayloadVersion {
+		t.Errorf("Expected e.go method linked to its type, got %+v", e)
+	}
+	if d := byID["other:d.go:D:4"].Payload; d.PayloadVersion != 0 {
+		t.Errorf("Expected other project untouched, got %+v", d)
+	}
+
+	// Vectors survive and a second run has nothing to do
+	if results, _ := vdb.Search(context.Background(), vectordb.SearchQuery{Vector: []float32{1, 0}, TopK: 1}); len(results) == 0 || results[0].ID != "proj:a.go:A:1" {
+		t.Errorf("Expected a.go vector preserved, got %+v", results)
+	}
+	result, err = idx.MigratePayloads(context.Background(), "proj")
+	if err != nil || result.Migrated != 0 || result.Skipped != 4 {
+		t.Errorf("Expected second run to skip all points, got %+v, %v", result, err)
+	}
+}
+
+// pagedMemoryDB records the order of page reads and payload writes.
+type pagedMemoryDB struct {
+	*vectordb.MemoryProvider
+	calls []string
+}
+
+func (p *pagedMemoryDB) ScrollPage(ctx context.Context, query vectordb.ScrollQuery) ([]vectordb.SearchResult, string, error) {
+	p.calls = append(p.calls, "scroll")
+	return p.MemoryProvider.ScrollPage(ctx, query)
+}
+
+func (p *pagedMemoryDB) UpdatePayloads(ctx context.Context, points []vectordb.Point) error {
+	p.calls = append(p.calls, "update")
+	return p.MemoryProvider.UpdatePayloads(ctx, points)
+}
+
+func TestMigratePayloads_Pages(t *testing.T) {
+	vdb := &pagedMemoryDB{MemoryProvider: vectordb.NewMemoryProvider()}
+	total := 2*migratePageSize + 10
+	points := make([]vectordb.Point, total)
+	for i := range points {
+		points[i] = vectordb.Point{ID: fmt.Sprintf("proj:f%04d.go:F:1", i), Vector: []float32{1, 0},
+			Payload: vectordb.Payload{ProjectID: "proj", FilePath: fmt.Sprintf("f%04d.go", i), Language: "go"}}
+	}
+	if err := vdb.Upsert(context.Background(), points); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	idx := NewIndexer(&config.Config{}, nil, vdb, logger)
+
+	result, err := idx.MigratePayloads(context.Background(), "")
+	if err != nil {
+		t.Fatalf("MigratePayloads failed: %v", err)
+	}
+	if result.Scanned != total || result.Migrated != total {
+		t.Errorf("Expected %d points scanned and migrated, got %+v", total, result)
+	}
+
+	// Each page is written before the next one is read
+	var scrolls int
+	for i, call := range vdb.calls {
+		if call != "scroll" {
+			continue
+		}
+		scrolls++
+		if i > 0 && vdb.calls[i-1] != "update" {
+			t.Errorf("Expected page %d to be read after the previous page was written, calls %v", scrolls, vdb.calls)
+			break
+		}
+	}
+	if scrolls != 3 {
+		t.Errorf("Expected 3 pages, got %d", scrolls)
+	}
+}
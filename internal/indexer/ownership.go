@@ -0,0 +1,170 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// OwnerResolver returns the owner of a file given its stored (path-root
+// relative, slash-separated) path. An empty string means no owner.
+type OwnerResolver interface {
+	Owner(relPath string) string
+}
+
+// codeownersLocations are the CODEOWNERS paths GitHub recognises, in lookup order.
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// newOwnerResolver builds the resolver for a project's ownership mode
+// (swappable in tests). Returns nil when ownership capture is off.
+var newOwnerResolver = func(mode, root string) (OwnerResolver, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case "codeowners":
+		for _, loc := range codeownersLocations {
+			data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(loc)))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", loc, err)
+			}
+			return parseCodeowners(data)
+		}
+		return nil, fmt.Errorf("no CODEOWNERS file found under %s", root)
+	case "git":
+		return &gitOwnerResolver{root: root, shortlog: gitShortlog}, nil
+	default:
+		return nil, fmt.Errorf("unknown ownership mode: %s", mode)
+	}
+}
+
+// codeownersRule is a single CODEOWNERS line.
+type codeownersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// codeowners resolves owners from parsed CODEOWNERS rules.
+// As on GitHub, the last matching rule wins; the first listed owner is used.
+type codeowners struct {
+	rules []codeownersRule
+}
+
+// parseCodeowners parses CODEOWNERS content. Blank lines, comments and
+// rules without owners are ignored.
+func parseCodeowners(data []byte) (*codeowners, error) {
+	co := &codeowners{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		re, err := codeownersPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("CODEOWNERS line %d: %w", lineNo, err)
+		}
+		co.rules = append(co.rules, codeownersRule{pattern: re, owners: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return co, nil
+}
+
+// Owner returns the first owner of the last rule matching relPath.
+func (c *codeowners) Owner(relPath string) string {
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(relPath) {
+			return c.rules[i].owners[0]
+		}
+	}
+	return ""
+}
+
+// codeownersPattern converts a gitignore-style CODEOWNERS pattern into a
+// regexp over slash-separated relative paths. Patterns containing a slash
+// (other than a trailing one) are anchored to the root; others match at any
+// depth. A match on a directory covers everything below it.
+func codeownersPattern(pattern string) (*regexp.Regexp, error) {
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			b.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(trimmed[i : i+1]))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+
+	return regexp.Compile(b.String())
+}
+
+// gitShortlogFunc runs `git shortlog` for a file under root and returns its output.
+type gitShortlogFunc func(ctx context.Context, root, relPath string) ([]byte, error)
+
+// gitShortlog runs `git shortlog -sn HEAD -- <file>` in root.
+func gitShortlog(ctx context.Context, root, relPath string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "shortlog", "-sn", "HEAD", "--", relPath)
+	return cmd.Output()
+}
+
+// gitOwnerResolver uses the top contributor by commit count as the owner.
+type gitOwnerResolver struct {
+	root     string
+	shortlog gitShortlogFunc
+}
+
+// Owner returns the top contributor of relPath, or "" if git fails or the
+// file has no history.
+func (g *gitOwnerResolver) Owner(relPath string) string {
+	out, err := g.shortlog(context.Background(), g.root, relPath)
+	if err != nil {
+		return ""
+	}
+	return parseShortlogTop(out)
+}
+
+// parseShortlogTop returns the author on the first line of
+// `git shortlog -sn` output ("   12\tJane Doe").
+func parseShortlogTop(out []byte) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	_, author, ok := strings.Cut(line, "\t")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(author)
+}
@@ -0,0 +1,115 @@
+package indexer
+
+import (
+	"path"
+
+	"github.com/iasik/project-indexer/internal/chunker"
+)
+
+// mergeSiblingResults waits for every file's chunks, then merges tiny ones
+// across sibling files (chunking.merge_sibling_files). A merged chunk is
+// listed in the cache entry of each of its files but embedded and deleted
+// only once.
+func (idx *Indexer) mergeSiblingResults(in <-chan fileResult, cache *Cache, updateLines bool) <-chan fileResult {
+	var results []fileResult
+	for res := range in {
+		results = append(results, res)
+	}
+
+	// Candidates are files with a single chunk besides their path chunk
+	var candidates []chunker.Chunk
+	for _, res := range results {
+		if res.err != nil {
+			continue
+		}
+		if own := withoutPathChunks(res.produced); len(own) == 1 {
+			candidates = append(candidates, own[0])
+		}
+	}
+	mergedByFile := make(map[string]chunker.Chunk)
+	for _, c := range idx.chunkerFactory.MergeSiblingChunks(candidates) {
+		for _, filePath := range c.FilePaths {
+			mergedByFile[filePath] = c
+		}
+	}
+
+	out := make(chan fileResult, len(results))
+	stored := make(map[string]bool)
+	deleted := make(map[string]bool)
+	for _, res := range results {
+		if merged, ok := mergedByFile[res.relPath]; ok {
+			chunks := []chunker.Chunk{merged}
+			for _, c := range res.produced {
+				if c.SymbolType == chunker.PathSymbolType {
+					chunks = append(chunks, c)
+				}
+			}
+			diff := diffChunks(cache, res.relPath, chunks, updateLines)
+			res.chunks, res.shifted, res.deletedChunks = diff.changed, diff.shifted, diff.deleted
+			res.chunkIDs, res.chunkHashes, res.chunkLines = diff.ids, diff.hashes, diff.lines
+		}
+		res.chunks = dropSeenChunks(res.chunks, stored)
+		res.shifted = dropSeenChunks(res.shifted, stored)
+		res.deletedChunks = dropSeenIDs(res.deletedChunks, deleted)
+		out <- res
+	}
+	close(out)
+	return out
+}
+
+// withoutPathChunks returns the chunks that aren't path chunks.
+func withoutPathChunks(chunks []chunker.Chunk) []chunker.Chunk {
+	var own []chunker.Chunk
+	for _, c := range chunks {
+		if c.SymbolType != chunker.PathSymbolType {
+			own = append(own, c)
+		}
+	}
+	return own
+}
+
+// dropSeenChunks drops chunks whose ID is in seen and adds the rest to it.
+func dropSeenChunks(chunks []chunker.Chunk, seen map[string]bool) []chunker.Chunk {
+	kept := chunks[:0]
+	for _, c := range chunks {
+		if !seen[c.ID] {
+			seen[c.ID] = true
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// dropSeenIDs drops IDs in seen and adds the rest to it.
+func dropSeenIDs(ids []string, seen map[string]bool) []string {
+	kept := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// siblingFiles returns the discovered files not yet in toProcess that share
+// a directory with a file in toProcess or a deleted file.
+func siblingFiles(files []discoveredFile, toProcess []fileToProcess, deleted []string) []discoveredFile {
+	queued := make(map[string]bool, len(toProcess))
+	dirs := make(map[string]bool)
+	for _, f := range toProcess {
+		queued[f.relPath] = true
+		dirs[path.Dir(f.relPath)] = true
+	}
+	for _, relPath := range deleted {
+		dirs[path.Dir(relPath)] = true
+	}
+
+	var siblings []discoveredFile
+	for _, f := range files {
+		if !queued[f.relPath] && dirs[path.Dir(f.relPath)] {
+			siblings = append(siblings, f)
+		}
+	}
+	return siblings
+}
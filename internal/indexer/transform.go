@@ -0,0 +1,149 @@
+package indexer
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// contentTransform rewrites chunk text before it is embedded.
+type contentTransform func(text, language string) string
+
+// contentTransforms maps config names to transform functions.
+var contentTransforms = map[string]contentTransform{
+	"strip_comments":      stripComments,
+	"collapse_whitespace": collapseWhitespace,
+	"lowercase":           func(text, _ string) string { return strings.ToLower(text) },
+}
+
+// applyTransforms runs the named transforms over text in order.
+// Unknown names are ignored (config validation rejects them).
+func applyTransforms(text, language string, names []string) string {
+	for _, name := range names {
+		if fn, ok := contentTransforms[name]; ok {
+			text = fn(text, language)
+		}
+	}
+	return text
+}
+
+// commentStyle describes the comment syntax of a language.
+type commentStyle struct {
+	slash bool // "//" line and "/* */" block comments
+	hash  bool // "#" line comments
+}
+
+// commentStyles lists languages whose comments strip_comments understands.
+var commentStyles = map[string]commentStyle{
+	"go":         {slash: true},
+	"typescript": {slash: true},
+	"javascript": {slash: true},
+	"java":       {slash: true},
+	"rust":       {slash: true},
+	"c":          {slash: true},
+	"cpp":        {slash: true},
+	"csharp":     {slash: true},
+	"swift":      {slash: true},
+	"kotlin":     {slash: true},
+	"scala":      {slash: true},
+	"php":        {slash: true, hash: true},
+	"python":     {hash: true},
+	"ruby":       {hash: true},
+	"shell":      {hash: true},
+	"yaml":       {hash: true},
+}
+
+// stripComments removes comments from source code, leaving string literals
+// intact. Languages without a known comment style are returned unchanged.
+func stripComments(text, language string) string {
+	style, ok := commentStyles[language]
+	if !ok {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			end := skipString(text, i)
+			b.WriteString(text[i:end])
+			i = end
+		case style.slash && strings.HasPrefix(text[i:], "//"), style.hash && c == '#':
+			// Keep the newline so line structure survives
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case style.slash && strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				i = len(text)
+			} else {
+				i += end + 4
+			}
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// skipString returns the index just past the string literal starting at i.
+// Backslash escapes are honoured except in backtick (raw) strings.
+func skipString(text string, i int) int {
+	quote := text[i]
+	for j := i + 1; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			if quote != '`' {
+				j++
+			}
+		case quote:
+			return j + 1
+		case '\n':
+			if quote != '`' {
+				return j
+			}
+		}
+	}
+	return len(text)
+}
+
+// collapseWhitespace joins runs of whitespace into single spaces.
+func collapseWhitespace(text, _ string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// meaningfulLength counts the characters of text that are neither comments,
+// whitespace nor PHP open/close tags.
+func meaningfulLength(text, language string) int {
+	text = stripComments(text, language)
+	text = strings.NewReplacer("<?php", "", "?>", "").Replace(text)
+	n := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// transformsFingerprint renders a transform config deterministically,
+// e.g. "*=lowercase;go=strip_comments,collapse_whitespace".
+func transformsFingerprint(transforms map[string][]string) string {
+	languages := make([]string, 0, len(transforms))
+	for language := range transforms {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	parts := make([]string, len(languages))
+	for i, language := range languages {
+		parts[i] = language + "=" + strings.Join(transforms[language], ",")
+	}
+	return strings.Join(parts, ";")
+}
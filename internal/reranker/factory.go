@@ -0,0 +1,29 @@
+package reranker
+
+import (
+	"fmt"
+
+	"github.com/iasik/project-indexer/internal/config"
+)
+
+// NewReranker creates a reranker based on configuration. An empty provider
+// disables reranking and returns Noop.
+func NewReranker(cfg config.RerankConfig) (Reranker, error) {
+	rerankerCfg := Config{
+		Provider:       cfg.Provider,
+		Endpoint:       cfg.Endpoint,
+		Model:          cfg.Model,
+		APIKey:         cfg.GetAPIKey(),
+		TopN:           cfg.TopN,
+		TimeoutSeconds: int(cfg.GetTimeout().Seconds()),
+	}
+
+	switch cfg.Provider {
+	case "":
+		return Noop{}, nil
+	case "tei", "cohere":
+		return NewHTTPReranker(rerankerCfg)
+	default:
+		return nil, fmt.Errorf("unknown rerank provider: %s (supported: tei, cohere)", cfg.Provider)
+	}
+}
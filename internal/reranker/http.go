@@ -0,0 +1,164 @@
+package reranker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultCohereEndpoint is used when the cohere provider has no endpoint.
+const defaultCohereEndpoint = "https://api.cohere.com"
+
+// HTTPReranker calls a rerank endpoint over HTTP. It speaks either the
+// text-embeddings-inference (TEI) /rerank API or the Cohere /v1/rerank API.
+type HTTPReranker struct {
+	client   *http.Client
+	provider string
+	endpoint string
+	model    string
+	apiKey   string
+	topN     int
+}
+
+// rerankRanking is one scored document; index refers to the request order.
+type rerankRanking struct {
+	Index          int     `json:"index"`
+	Score          float32 `json:"score"`
+	RelevanceScore float32 `json:"relevance_score"`
+}
+
+// teiRerankRequest is the request body for the TEI /rerank API.
+type teiRerankRequest struct {
+	Query string   `json:"query"`
+	Texts []string `json:"texts"`
+}
+
+// cohereRerankRequest is the request body for the Cohere /v1/rerank API.
+type cohereRerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// cohereRerankResponse is the response body of the Cohere /v1/rerank API.
+type cohereRerankResponse struct {
+	Results []rerankRanking `json:"results"`
+}
+
+// NewHTTPReranker creates a reranker for a TEI or Cohere endpoint.
+func NewHTTPReranker(cfg Config) (*HTTPReranker, error) {
+	endpoint := cfg.Endpoint
+	switch cfg.Provider {
+	case "tei":
+		if endpoint == "" {
+			return nil, fmt.Errorf("TEI rerank endpoint is required")
+		}
+	case "cohere":
+		if endpoint == "" {
+			endpoint = defaultCohereEndpoint
+		}
+	default:
+		return nil, fmt.Errorf("unknown rerank provider: %s", cfg.Provider)
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	topN := cfg.TopN
+	if topN <= 0 {
+		topN = 20
+	}
+
+	return &HTTPReranker{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		provider: cfg.Provider,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		model:    cfg.Model,
+		apiKey:   cfg.APIKey,
+		topN:     topN,
+	}, nil
+}
+
+// TopN returns the number of leading candidates to rerank.
+func (h *HTTPReranker) TopN() int {
+	return h.topN
+}
+
+// Rerank scores documents against query and returns the scores in input order.
+func (h *HTTPReranker) Rerank(ctx context.Context, query string, documents []string) ([]float32, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	var body interface{}
+	var path string
+	if h.provider == "cohere" {
+		path = "/v1/rerank"
+		body = cohereRerankRequest{Model: h.model, Query: query, Documents: documents, TopN: len(documents)}
+	} else {
+		path = "/rerank"
+		body = teiRerankRequest{Query: query, Texts: documents}
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint+path, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rerank request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("rerank request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var rankings []rerankRanking
+	if h.provider == "cohere" {
+		var cohereResp cohereRerankResponse
+		if err := json.NewDecoder(resp.Body).Decode(&cohereResp); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		rankings = cohereResp.Results
+		for i := range rankings {
+			rankings[i].Score = rankings[i].RelevanceScore
+		}
+	} else if err := json.NewDecoder(resp.Body).Decode(&rankings); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(rankings) != len(documents) {
+		return nil, fmt.Errorf("expected %d rerank scores, got %d", len(documents), len(rankings))
+	}
+	scores := make([]float32, len(documents))
+	seen := make([]bool, len(documents))
+	for _, r := range rankings {
+		if r.Index < 0 || r.Index >= len(documents) || seen[r.Index] {
+			return nil, fmt.Errorf("invalid rerank result index %d", r.Index)
+		}
+		seen[r.Index] = true
+		scores[r.Index] = r.Score
+	}
+	return scores, nil
+}
@@ -0,0 +1,127 @@
+package reranker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/iasik/project-indexer/internal/config"
+)
+
+func TestHTTPReranker_TEI(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rerank" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		var req teiRerankRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query != "auth" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// TEI returns rankings sorted by score, not in input order
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"index": 1, "score": 0.9},
+			{"index": 0, "score": 0.2},
+		})
+	}))
+	defer srv.Close()
+
+	rr, err := NewHTTPReranker(Config{Provider: "tei", Endpoint: srv.URL + "/", APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewHTTPReranker failed: %v", err)
+	}
+	scores, err := rr.Rerank(context.Background(), "auth", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Rerank failed: %v", err)
+	}
+	if !reflect.DeepEqual(scores, []float32{0.2, 0.9}) {
+		t.Errorf("Expected scores in input order, got %v", scores)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected bearer auth, got %q", auth)
+	}
+	if rr.TopN() != 20 {
+		t.Errorf("Expected default top_n 20, got %d", rr.TopN())
+	}
+}
+
+func TestHTTPReranker_Cohere(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/rerank" {
+			http.NotFound(w, r)
+			return
+		}
+		var req cohereRerankRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "rerank-v3.5" || req.TopN != 3 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{
+				{"index": 2, "relevance_score": 0.8},
+				{"index": 0, "relevance_score": 0.5},
+				{"index": 1, "relevance_score": 0.1},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	rr, err := NewHTTPReranker(Config{Provider: "cohere", Endpoint: srv.URL, Model: "rerank-v3.5", TopN: 3})
+	if err != nil {
+		t.Fatalf("NewHTTPReranker failed: %v", err)
+	}
+	scores, err := rr.Rerank(context.Background(), "q", []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("Rerank failed: %v", err)
+	}
+	if !reflect.DeepEqual(scores, []float32{0.5, 0.1, 0.8}) {
+		t.Errorf("Expected scores in input order, got %v", scores)
+	}
+}
+
+func TestHTTPReranker_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{{"index": 5, "score": 1}})
+	}))
+	defer srv.Close()
+
+	rr, _ := NewHTTPReranker(Config{Provider: "tei", Endpoint: srv.URL})
+	if _, err := rr.Rerank(context.Background(), "q", []string{"a"}); err == nil {
+		t.Error("Expected error for out-of-range index")
+	}
+	if _, err := rr.Rerank(context.Background(), "q", []string{"a", "b"}); err == nil {
+		t.Error("Expected error for missing scores")
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model loading", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	rr, _ = NewHTTPReranker(Config{Provider: "tei", Endpoint: failing.URL})
+	if _, err := rr.Rerank(context.Background(), "q", []string{"a"}); err == nil {
+		t.Error("Expected error for non-200 status")
+	}
+}
+
+func TestNewReranker(t *testing.T) {
+	rr, err := NewReranker(config.RerankConfig{})
+	if err != nil {
+		t.Fatalf("NewReranker failed: %v", err)
+	}
+	if _, ok := rr.(Noop); !ok {
+		t.Errorf("Expected Noop reranker without provider, got %T", rr)
+	}
+	if _, err := NewReranker(config.RerankConfig{Provider: "tei"}); err == nil {
+		t.Error("Expected error for tei without endpoint")
+	}
+	if _, err := NewReranker(config.RerankConfig{Provider: "bogus"}); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+}
@@ -0,0 +1,38 @@
+// Package reranker provides optional cross-encoder reranking of retrieval results.
+package reranker
+
+import "context"
+
+// Reranker scores documents against a query with a cross-encoder model.
+type Reranker interface {
+	// Rerank returns one relevance score per document, in input order.
+	// A nil result means the reranker is disabled and the original order stands.
+	Rerank(ctx context.Context, query string, documents []string) ([]float32, error)
+
+	// TopN returns how many leading candidates should be sent to Rerank
+	// (0 = reranking disabled).
+	TopN() int
+}
+
+// Config holds reranker settings.
+type Config struct {
+	Provider       string
+	Endpoint       string
+	Model          string
+	APIKey         string
+	TopN           int
+	TimeoutSeconds int
+}
+
+// Noop is the default reranker; it leaves result order unchanged.
+type Noop struct{}
+
+// Rerank returns nil scores.
+func (Noop) Rerank(ctx context.Context, query string, documents []string) ([]float32, error) {
+	return nil, nil
+}
+
+// TopN returns 0; no candidates are reranked.
+func (Noop) TopN() int {
+	return 0
+}
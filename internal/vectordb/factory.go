@@ -3,6 +3,7 @@ package vectordb
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/iasik/project-indexer/internal/config"
 )
@@ -15,22 +16,34 @@ func NewProvider(cfg config.VectorDBConfig) (Provider, error) {
 		Endpoint:       cfg.Endpoint,
 		CollectionName: cfg.CollectionName,
 		TimeoutSeconds: int(cfg.GetTimeout().Seconds()),
+		Headers:        cfg.Headers,
+		APIKey:         cfg.GetAPIKey(),
+		SoftDelete:     cfg.SoftDelete.Enabled,
+
+		UpsertBatchSize: cfg.UpsertBatchSize,
 	}
 
 	switch cfg.Provider {
 	case "qdrant":
 		return NewQdrantClient(providerCfg)
 
+	case "memory":
+		provider := NewMemoryProvider()
+		provider.softDelete = providerCfg.SoftDelete
+		return provider, nil
+
 	case "milvus":
 		// TODO: Implement Milvus client
 		return nil, fmt.Errorf("milvus provider not yet implemented")
 
 	case "weaviate":
-		// TODO: Implement Weaviate client
-		return nil, fmt.Errorf("weaviate provider not yet implemented")
+		return NewWeaviateClient(providerCfg)
+
+	case "pgvector":
+		return NewPgVectorClient(providerCfg)
 
 	default:
-		return nil, fmt.Errorf("unknown vectordb provider: %s (supported: qdrant, milvus, weaviate)", cfg.Provider)
+		return nil, fmt.Errorf("unknown vectordb provider: %s (supported: qdrant, memory, milvus, weaviate, pgvector)", cfg.Provider)
 	}
 }
 
@@ -43,3 +56,11 @@ func MustNewProvider(cfg config.VectorDBConfig) Provider {
 	}
 	return provider
 }
+
+// setHeaders attaches configured extra headers to a provider request.
+// They are applied last, so they override built-in headers of the same name.
+func setHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
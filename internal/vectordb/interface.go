@@ -4,8 +4,18 @@ package vectordb
 
 import (
 	"context"
+	"strings"
+	"time"
 )
 
+// PayloadVersion is the schema version stamped on newly written payloads.
+// Payloads written before versioning read as version 0.
+//
+//	1: payload_version and token_count
+//	2: parent_symbol
+//	3: file_dirs (Qdrant directory prefix filters)
+const PayloadVersion = 3
+
 // Provider defines the interface for vector database providers.
 // All vector database implementations must satisfy this interface.
 type Provider interface {
@@ -15,22 +25,78 @@ type Provider interface {
 	// Search performs similarity search with optional filters.
 	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
 
+	// SearchBatch runs several searches in one round trip where supported.
+	// Results are returned in query order.
+	SearchBatch(ctx context.Context, queries []SearchQuery) ([][]SearchResult, error)
+
+	// Scroll returns up to limit points matching a filter, without scoring.
+	// Only available when Capabilities().Scroll is true.
+	Scroll(ctx context.Context, filter Filter, limit int) ([]SearchResult, error)
+
+	// ScrollPage returns one page of points matching query.Filter, starting
+	// at query.Cursor, and the cursor of the next page ("" after the last).
+	// Use it (or ScrollAll) to walk more points than fit in one request.
+	// Only available when Capabilities().Scroll is true.
+	ScrollPage(ctx context.Context, query ScrollQuery) ([]SearchResult, string, error)
+
+	// Count returns the number of points matching a filter.
+	// Only available when Capabilities().Count is true.
+	Count(ctx context.Context, filter Filter) (int, error)
+
+	// UpdatePayloads writes whole payloads of existing points in one batch,
+	// keeping their vectors (payload migrations). Point IDs are as returned by
+	// Scroll; vectors are ignored.
+	UpdatePayloads(ctx context.Context, points []Point) error
+
+	// UpdatePayload merges a few fields into the payload of one existing
+	// point without resending its vector (e.g. moved line numbers). Keys are
+	// payload field names as stored (e.g. "start_line"); fields not listed are
+	// left unchanged.
+	UpdatePayload(ctx context.Context, id string, fields map[string]interface{}) error
+
 	// Delete removes vectors by their IDs.
+	// In soft-delete mode the points are tombstoned with deleted_at instead.
 	Delete(ctx context.Context, ids []string) error
 
 	// DeleteByFilter removes vectors matching a filter.
+	// In soft-delete mode the points are tombstoned with deleted_at instead.
 	DeleteByFilter(ctx context.Context, filter Filter) error
 
+	// PurgeDeleted hard-deletes tombstones whose deleted_at is before olderThan.
+	PurgeDeleted(ctx context.Context, olderThan time.Time) error
+
 	// EnsureCollection creates the collection if it doesn't exist.
 	EnsureCollection(ctx context.Context, dimensions int) error
 
 	// Health checks if the provider is available.
 	Health(ctx context.Context) error
 
+	// Capabilities declares which optional features the provider supports.
+	Capabilities() ProviderCapabilities
+
 	// Close releases any resources held by the provider.
 	Close() error
 }
 
+// ProviderCapabilities declares optional features supported by a provider,
+// so callers can advertise or guard features instead of trial-and-error.
+type ProviderCapabilities struct {
+	// Full-text (keyword) search over payload fields, needed for hybrid search
+	FullTextSearch bool `json:"full_text_search"`
+
+	// Paginated iteration over points matching a filter
+	Scroll bool `json:"scroll"`
+
+	// Counting points matching a filter
+	Count bool `json:"count"`
+
+	// Collection aliases (e.g. for zero-downtime reindex)
+	Aliases bool `json:"aliases"`
+
+	// Indexes on payload fields for faster filtering
+	PayloadIndexing bool `json:"payload_indexing"`
+}
+
 // Point represents a vector with its metadata.
 type Point struct {
 	// Unique identifier for this vector
@@ -75,8 +141,47 @@ type Payload struct {
 	// Hash of the content for change detection
 	ContentHash string `json:"content_hash"`
 
+	// Hash of the exact content, set when it differs from ContentHash
+	// (chunking.normalize_hash)
+	ExactHash string `json:"exact_hash,omitempty"`
+
 	// When this chunk was indexed
 	IndexedAt string `json:"indexed_at"`
+
+	// Every file of a chunk merged from sibling files (first is FilePath);
+	// empty for single-file chunks
+	FilePaths []string `json:"file_paths,omitempty"`
+
+	// Modification time of the file when the chunk was indexed (RFC3339)
+	LastModified string `json:"last_modified,omitempty"`
+
+	// Index generation this chunk belongs to (set by full reindex)
+	Generation string `json:"generation,omitempty"`
+
+	// Owner of the file (CODEOWNERS entry or top git contributor), if captured
+	Owner string `json:"owner,omitempty"`
+
+	// Enclosing type of a method chunk (e.g. the class), if any
+	ParentSymbol string `json:"parent_symbol,omitempty"`
+
+	// Custom project metadata (project config metadata.custom)
+	Custom map[string]string `json:"custom,omitempty"`
+
+	// Git commit (or ref) checked out when the chunk was indexed, if any
+	GitRef string `json:"git_ref,omitempty"`
+
+	// When this chunk was soft-deleted (RFC3339); empty for live chunks
+	DeletedAt string `json:"deleted_at,omitempty"`
+
+	// Import paths used and functions called by the symbol (Go, when extracted)
+	Imports    []string `json:"imports,omitempty"`
+	References []string `json:"references,omitempty"`
+
+	// Estimated token count of the content
+	TokenCount int `json:"token_count,omitempty"`
+
+	// Schema version of this payload (see PayloadVersion)
+	PayloadVersion int `json:"payload_version,omitempty"`
 }
 
 // SearchQuery defines parameters for a similarity search.
@@ -92,6 +197,52 @@ type SearchQuery struct {
 
 	// Minimum similarity score (0.0 to 1.0)
 	ScoreThreshold float32
+
+	// Return stored vectors with the results (e.g. for query-time dedup)
+	WithVectors bool
+}
+
+// ScrollQuery selects one page of points for ScrollPage.
+type ScrollQuery struct {
+	// Optional filters
+	Filter Filter
+
+	// Maximum points in the page
+	Limit int
+
+	// Opaque cursor returned with the previous page ("" for the first page)
+	Cursor string
+
+	// Payload keys to return (e.g. "language"); nil returns the whole
+	// payload. Providers may return more keys than asked for.
+	Fields []string
+}
+
+// scrollPageSize is the page size ScrollAll uses when the query sets none.
+const scrollPageSize = 1000
+
+// ScrollAll calls fn with each page of points matching query.Filter, from
+// query.Cursor to the last page, so callers never hold more than one page.
+// The provider must support Scroll.
+func ScrollAll(ctx context.Context, p Provider, query ScrollQuery, fn func([]SearchResult) error) error {
+	if query.Limit <= 0 {
+		query.Limit = scrollPageSize
+	}
+	for {
+		page, next, err := p.ScrollPage(ctx, query)
+		if err != nil {
+			return err
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		query.Cursor = next
+	}
 }
 
 // Filter defines conditions for filtering search results.
@@ -107,6 +258,36 @@ type Filter struct {
 
 	// Optional: filter by symbol type
 	SymbolType string
+
+	// Optional: filter by exact symbol name
+	Symbol string
+
+	// Optional: filter by relative file path; a trailing "/" matches every
+	// file under that directory (e.g. "src/auth/")
+	FilePath string
+
+	// Optional: filter by file owner
+	Owner string
+
+	// Optional: match only points whose custom metadata has all these key/values
+	Custom map[string]string
+
+	// Optional: match only chunks starting at or after this line (0: no bound)
+	StartLineGte int
+
+	// Optional: match only chunks ending at or before this line (0: no bound)
+	EndLineLte int
+
+	// Optional: match only points NOT in this generation (drops superseded generations)
+	ExcludeGeneration string
+
+	// Optional: also match soft-deleted tombstones (excluded by default)
+	IncludeDeleted bool
+}
+
+// IsPathPrefix reports whether a FilePath filter is a directory prefix.
+func IsPathPrefix(filePath string) bool {
+	return strings.HasSuffix(filePath, "/")
 }
 
 // SearchResult represents a single search result.
@@ -119,6 +300,9 @@ type SearchResult struct {
 
 	// The payload/metadata
 	Payload Payload
+
+	// The stored vector; only set when SearchQuery.WithVectors is true
+	Vector []float32
 }
 
 // Config holds common configuration for vector database providers.
@@ -134,4 +318,16 @@ type Config struct {
 
 	// Request timeout in seconds
 	TimeoutSeconds int
+
+	// Extra HTTP headers attached to every request
+	Headers map[string]string
+
+	// API key for secured instances (Qdrant api-key header, Weaviate bearer token)
+	APIKey string
+
+	// Tombstone deleted points with deleted_at instead of removing them
+	SoftDelete bool
+
+	// Maximum points per upsert request (0: provider default)
+	UpsertBatchSize int
 }
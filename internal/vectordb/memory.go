@@ -0,0 +1,349 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryProvider is an in-process vector store for tests and local development.
+// Data is lost when the process exits.
+type MemoryProvider struct {
+	mu         sync.RWMutex
+	points     map[string]Point
+	softDelete bool
+}
+
+// NewMemoryProvider creates an empty in-memory provider.
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		points: make(map[string]Point),
+	}
+}
+
+// Upsert inserts or updates vectors with metadata.
+func (m *MemoryProvider) Upsert(ctx context.Context, points []Point) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range points {
+		m.points[p.ID] = p
+	}
+	return nil
+}
+
+// Search performs brute-force cosine similarity search with optional filters.
+func (m *MemoryProvider) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]SearchResult, 0)
+	for _, p := range m.points {
+		if !m.visible(p.Payload, query.Filter) {
+			continue
+		}
+		score := CosineSimilarity(query.Vector, p.Vector)
+		if score < query.ScoreThreshold {
+			continue
+		}
+		result := SearchResult{ID: p.ID, Score: score, Payload: p.Payload}
+		if query.WithVectors {
+			result.Vector = p.Vector
+		}
+		results = append(results, result)
+	}
+
+	// Sort by score, then ID for deterministic ties
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if query.TopK > 0 && len(results) > query.TopK {
+		results = results[:query.TopK]
+	}
+	return results, nil
+}
+
+// SearchBatch runs each query in turn.
+func (m *MemoryProvider) SearchBatch(ctx context.Context, queries []SearchQuery) ([][]SearchResult, error) {
+	results := make([][]SearchResult, len(queries))
+	for i, query := range queries {
+		res, err := m.Search(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+// Scroll returns up to limit points matching a filter, ordered by file and start line.
+func (m *MemoryProvider) Scroll(ctx context.Context, filter Filter, limit int) ([]SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := m.scrolled(filter)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// ScrollPage returns a page of Scroll's order; the cursor is the offset of
+// the next page.
+func (m *MemoryProvider) ScrollPage(ctx context.Context, query ScrollQuery) ([]SearchResult, string, error) {
+	offset := 0
+	if query.Cursor != "" {
+		n, err := strconv.Atoi(query.Cursor)
+		if err != nil || n < 0 {
+			return nil, "", fmt.Errorf("invalid scroll cursor %q", query.Cursor)
+		}
+		offset = n
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := m.scrolled(query.Filter)
+	if offset >= len(results) {
+		return []SearchResult{}, "", nil
+	}
+	results = results[offset:]
+	if query.Limit <= 0 || len(results) <= query.Limit {
+		return results, "", nil
+	}
+	return results[:query.Limit], strconv.Itoa(offset + query.Limit), nil
+}
+
+// scrolled returns the points matching a filter, ordered by file and start
+// line. The caller must hold m.mu.
+func (m *MemoryProvider) scrolled(filter Filter) []SearchResult {
+	results := make([]SearchResult, 0)
+	for _, p := range m.points {
+		if m.visible(p.Payload, filter) {
+			results = append(results, SearchResult{ID: p.ID, Payload: p.Payload})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i].Payload, results[j].Payload
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
+
+// UpdatePayloads replaces the payloads of existing points, keeping their vectors.
+func (m *MemoryProvider) UpdatePayloads(ctx context.Context, points []Point) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range points {
+		if existing, ok := m.points[p.ID]; ok {
+			existing.Payload = p.Payload
+			m.points[p.ID] = existing
+		}
+	}
+	return nil
+}
+
+// UpdatePayload merges fields into the payload of an existing point,
+// keeping its vector. Field names are those of the stored payload.
+func (m *MemoryProvider) UpdatePayload(ctx context.Context, id string, fields map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.points[id]
+	if !ok {
+		return nil
+	}
+
+	// Round-trip through JSON so field values get the types the parser expects
+	merged := buildQdrantPayload(existing)
+	for key, value := range fields {
+		merged[key] = value
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	existing.Payload = parseQdrantPayload(payload)
+	m.points[id] = existing
+	return nil
+}
+
+// Count returns the number of points matching a filter.
+func (m *MemoryProvider) Count(ctx context.Context, filter Filter) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, p := range m.points {
+		if m.visible(p.Payload, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Delete removes vectors by their IDs, or tombstones them in soft-delete mode.
+func (m *MemoryProvider) Delete(ctx context.Context, ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deletedAt := time.Now().UTC().Format(time.RFC3339)
+	for _, id := range ids {
+		p, ok := m.points[id]
+		if !ok {
+			continue
+		}
+		if !m.softDelete {
+			delete(m.points, id)
+		} else if p.Payload.DeletedAt == "" {
+			p.Payload.DeletedAt = deletedAt
+			m.points[id] = p
+		}
+	}
+	return nil
+}
+
+// DeleteByFilter removes vectors matching a filter, or tombstones them in
+// soft-delete mode. Existing tombstones keep their original deleted_at.
+func (m *MemoryProvider) DeleteByFilter(ctx context.Context, filter Filter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deletedAt := time.Now().UTC().Format(time.RFC3339)
+	for id, p := range m.points {
+		if !m.visible(p.Payload, filter) {
+			continue
+		}
+		if !m.softDelete {
+			delete(m.points, id)
+		} else if p.Payload.DeletedAt == "" {
+			p.Payload.DeletedAt = deletedAt
+			m.points[id] = p
+		}
+	}
+	return nil
+}
+
+// PurgeDeleted hard-deletes tombstones whose deleted_at is before olderThan.
+func (m *MemoryProvider) PurgeDeleted(ctx context.Context, olderThan time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, p := range m.points {
+		if p.Payload.DeletedAt == "" {
+			continue
+		}
+		deletedAt, err := time.Parse(time.RFC3339, p.Payload.DeletedAt)
+		if err == nil && deletedAt.Before(olderThan) {
+			delete(m.points, id)
+		}
+	}
+	return nil
+}
+
+// EnsureCollection is a no-op for the in-memory provider.
+func (m *MemoryProvider) EnsureCollection(ctx context.Context, dimensions int) error {
+	return nil
+}
+
+// Health always succeeds for the in-memory provider.
+func (m *MemoryProvider) Health(ctx context.Context) error {
+	return nil
+}
+
+// Capabilities returns the optional features supported in memory.
+func (m *MemoryProvider) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{
+		Scroll: true,
+		Count:  true,
+	}
+}
+
+// Close releases resources (no-op for the in-memory provider).
+func (m *MemoryProvider) Close() error {
+	return nil
+}
+
+// visible reports whether a point matches a filter, hiding tombstones in
+// soft-delete mode unless the filter includes them.
+func (m *MemoryProvider) visible(p Payload, f Filter) bool {
+	if m.softDelete && !f.IncludeDeleted && p.DeletedAt != "" {
+		return false
+	}
+	return matchesFilter(p, f)
+}
+
+// matchesFilter reports whether a payload satisfies all set filter fields.
+func matchesFilter(p Payload, f Filter) bool {
+	if f.ProjectID != "" && p.ProjectID != f.ProjectID {
+		return false
+	}
+	if f.Module != "" && p.Module != f.Module {
+		return false
+	}
+	if f.Language != "" && p.Language != f.Language {
+		return false
+	}
+	if f.SymbolType != "" && p.SymbolType != f.SymbolType {
+		return false
+	}
+	if f.Symbol != "" && p.Symbol != f.Symbol {
+		return false
+	}
+	if f.FilePath != "" && p.FilePath != f.FilePath &&
+		!(IsPathPrefix(f.FilePath) && strings.HasPrefix(p.FilePath, f.FilePath)) {
+		return false
+	}
+	if f.Owner != "" && p.Owner != f.Owner {
+		return false
+	}
+	for key, value := range f.Custom {
+		if p.Custom[key] != value {
+			return false
+		}
+	}
+	if f.StartLineGte > 0 && p.StartLine < f.StartLineGte {
+		return false
+	}
+	if f.EndLineLte > 0 && p.EndLine > f.EndLineLte {
+		return false
+	}
+	if f.ExcludeGeneration != "" && p.Generation == f.ExcludeGeneration {
+		return false
+	}
+	return true
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors
+// (0 for mismatched lengths or zero vectors).
+func CosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
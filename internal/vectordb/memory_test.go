@@ -0,0 +1,198 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryProvider_Capabilities(t *testing.T) {
+	want := ProviderCapabilities{Scroll: true, Count: true}
+	if got := NewMemoryProvider().Capabilities(); got != want {
+		t.Errorf("Capabilities() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMemoryProvider_SearchAndDelete(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryProvider()
+	m.Upsert(ctx, []Point{
+		{ID: "a", Vector: []float32{1, 0}, Payload: Payload{ProjectID: "p1", Language: "go"}},
+		{ID: "b", Vector: []float32{0.6, 0.8}, Payload: Payload{ProjectID: "p1", Language: "php"}},
+		{ID: "c", Vector: []float32{1, 0}, Payload: Payload{ProjectID: "p2", Language: "go"}},
+	})
+
+	results, _ := m.Search(ctx, SearchQuery{Vector: []float32{1, 0}, TopK: 5, Filter: Filter{ProjectID: "p1"}})
+	if len(results) != 2 || results[0].ID != "a" || results[1].ID != "b" {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+
+	if results[0].Vector != nil {
+		t.Errorf("Expected no vectors unless requested, got %v", results[0].Vector)
+	}
+	results, _ = m.Search(ctx, SearchQuery{Vector: []float32{1, 0}, TopK: 1, Filter: Filter{ProjectID: "p1"}, WithVectors: true})
+	if len(results) != 1 || len(results[0].Vector) != 2 {
+		t.Errorf("Expected the stored vector with WithVectors, got %+v", results)
+	}
+
+	results, _ = m.Search(ctx, SearchQuery{Vector: []float32{1, 0}, TopK: 5, ScoreThreshold: 0.9})
+	if len(results) != 2 {
+		t.Errorf("Expected threshold to drop the low-score point, got %d results", len(results))
+	}
+
+	m.DeleteByFilter(ctx, Filter{ProjectID: "p1"})
+	m.Delete(ctx, []string{"c"})
+	results, _ = m.Search(ctx, SearchQuery{Vector: []float32{1, 0}, TopK: 5})
+	if len(results) != 0 {
+		t.Errorf("Expected empty store after deletes, got %d results", len(results))
+	}
+}
+
+func TestMemoryProvider_UpdatePayload(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryProvider()
+	m.Upsert(ctx, []Point{
+		{ID: "a", Vector: []float32{1, 0}, Payload: Payload{ProjectID: "p1", Symbol: "A", StartLine: 3, EndLine: 5}},
+	})
+
+	err := m.UpdatePayload(ctx, "a", map[string]interface{}{"start_line": 4, "end_line": 6, "owner": "team-a"})
+	if err != nil {
+		t.Fatalf("UpdatePayload failed: %v", err)
+	}
+	if err := m.UpdatePayload(ctx, "missing", map[string]interface{}{"owner": "x"}); err != nil {
+		t.Errorf("Expected missing points to be ignored, got %v", err)
+	}
+
+	results, _ := m.Search(ctx, SearchQuery{Vector: []float32{1, 0}, TopK: 5, WithVectors: true})
+	if len(results) != 1 {
+		t.Fatalf("Expected one point, got %d", len(results))
+	}
+	got := results[0]
+	if got.Payload.StartLine != 4 || got.Payload.EndLine != 6 || got.Payload.Owner != "team-a" {
+		t.Errorf("Expected merged fields, got %+v", got.Payload)
+	}
+	if got.Payload.Symbol != "A" || got.Payload.ProjectID != "p1" {
+		t.Errorf("Expected other fields kept, got %+v", got.Payload)
+	}
+	if len(got.Vector) != 2 || got.Vector[0] != 1 || got.Vector[1] != 0 {
+		t.Errorf("Expected vector unchanged, got %v", got.Vector)
+	}
+}
+
+func TestMemoryProvider_LineRangeFilter(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryProvider()
+	m.Upsert(ctx, []Point{
+		{ID: "top", Payload: Payload{ProjectID: "p1", StartLine: 1, EndLine: 20}},
+		{ID: "middle", Payload: Payload{ProjectID: "p1", StartLine: 390, EndLine: 410}},
+		{ID: "bottom", Payload: Payload{ProjectID: "p1", StartLine: 800, EndLine: 850}},
+	})
+
+	results, _ := m.Scroll(ctx, Filter{ProjectID: "p1", StartLineGte: 300, EndLineLte: 500}, 10)
+	if len(results) != 1 || results[0].ID != "middle" {
+		t.Errorf("Expected only the middle chunk, got %+v", results)
+	}
+	if n, _ := m.Count(ctx, Filter{StartLineGte: 300}); n != 2 {
+		t.Errorf("Expected 2 chunks from line 300, got %d", n)
+	}
+}
+
+func TestMemoryProvider_FilePathPrefix(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryProvider()
+	m.Upsert(ctx, []Point{
+		{ID: "login", Payload: Payload{ProjectID: "p1", FilePath: "src/auth/login.go"}},
+		{ID: "token", Payload: Payload{ProjectID: "p1", FilePath: "src/auth/jwt/token.go"}},
+		{ID: "authz", Payload: Payload{ProjectID: "p1", FilePath: "src/authz/policy.go"}},
+	})
+
+	if n, _ := m.Count(ctx, Filter{FilePath: "src/auth/"}); n != 2 {
+		t.Errorf("Expected 2 files under src/auth/, got %d", n)
+	}
+	if n, _ := m.Count(ctx, Filter{FilePath: "src/auth"}); n != 0 {
+		t.Errorf("Expected no exact match for a directory without trailing slash, got %d", n)
+	}
+}
+
+func TestMemoryProvider_ScrollByFile(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryProvider()
+	m.Upsert(ctx, []Point{
+		{ID: "2", Payload: Payload{ProjectID: "p", FilePath: "a.go", StartLine: 20}},
+		{ID: "1", Payload: Payload{ProjectID: "p", FilePath: "a.go", StartLine: 1}},
+		{ID: "3", Payload: Payload{ProjectID: "p", FilePath: "b.go", StartLine: 1}},
+	})
+
+	results, _ := m.Scroll(ctx, Filter{ProjectID: "p", FilePath: "a.go"}, 10)
+	if len(results) != 2 || results[0].ID != "1" || results[1].ID != "2" {
+		t.Errorf("Expected a.go chunks ordered by start line, got %+v", results)
+	}
+}
+
+func TestScrollAll_Pages(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryProvider()
+	for i := 0; i < 7; i++ {
+		m.Upsert(ctx, []Point{{ID: fmt.Sprintf("p%d", i), Payload: Payload{ProjectID: "p", StartLine: i}}})
+	}
+	m.Upsert(ctx, []Point{{ID: "other", Payload: Payload{ProjectID: "q"}}})
+
+	var pages []int
+	seen := make(map[string]bool)
+	err := ScrollAll(ctx, m, ScrollQuery{Filter: Filter{ProjectID: "p"}, Limit: 3}, func(page []SearchResult) error {
+		pages = append(pages, len(page))
+		for _, r := range page {
+			if seen[r.ID] {
+				t.Errorf("Point %s returned twice", r.ID)
+			}
+			seen[r.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScrollAll failed: %v", err)
+	}
+	if !reflect.DeepEqual(pages, []int{3, 3, 1}) || len(seen) != 7 {
+		t.Errorf("Expected pages of 3, 3 and 1 covering 7 points, got %v (%d points)", pages, len(seen))
+	}
+
+	if _, _, err := m.ScrollPage(ctx, ScrollQuery{Cursor: "bogus"}); err == nil {
+		t.Error("Expected an invalid cursor to fail")
+	}
+}
+
+func TestMemoryProvider_SoftDeleteAndPurge(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryProvider()
+	m.softDelete = true
+	m.Upsert(ctx, []Point{
+		{ID: "a", Vector: []float32{1, 0}, Payload: Payload{ProjectID: "p", FilePath: "a.go"}},
+		{ID: "b", Vector: []float32{1, 0}, Payload: Payload{ProjectID: "p", FilePath: "b.go"}},
+	})
+
+	m.Delete(ctx, []string{"a"})
+
+	results, _ := m.Search(ctx, SearchQuery{Vector: []float32{1, 0}, TopK: 5, Filter: Filter{ProjectID: "p"}})
+	if len(results) != 1 || results[0].ID != "b" {
+		t.Fatalf("Expected soft-deleted chunk excluded from search, got %+v", results)
+	}
+
+	tombstones, _ := m.Scroll(ctx, Filter{ProjectID: "p", FilePath: "a.go", IncludeDeleted: true}, 10)
+	if len(tombstones) != 1 || tombstones[0].Payload.DeletedAt == "" {
+		t.Fatalf("Expected tombstone with deleted_at until purged, got %+v", tombstones)
+	}
+
+	// Tombstones within the retention period survive a purge
+	m.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	if tombstones, _ = m.Scroll(ctx, Filter{IncludeDeleted: true}, 10); len(tombstones) != 2 {
+		t.Fatalf("Expected recent tombstone kept, got %d points", len(tombstones))
+	}
+
+	m.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+	remaining, _ := m.Scroll(ctx, Filter{IncludeDeleted: true}, 10)
+	if len(remaining) != 1 || remaining[0].ID != "b" {
+		t.Errorf("Expected only the live chunk after purge, got %+v", remaining)
+	}
+}
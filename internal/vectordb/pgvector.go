@@ -0,0 +1,452 @@
+// Package vectordb provides PostgreSQL pgvector implementation.
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgVectorClient implements the Provider interface for PostgreSQL with the
+// pgvector extension. Each point is a row keyed by its chunk ID; the payload
+// is stored as jsonb, and the fields filters use are generated columns of it.
+type PgVectorClient struct {
+	pool       *pgxpool.Pool
+	table      string // quoted table identifier
+	tableName  string
+	softDelete bool
+}
+
+// pgFilterColumns maps Filter fields to their generated columns.
+var pgFilterColumns = []string{"project_id", "module", "language", "symbol_type", "owner"}
+
+// NewPgVectorClient creates a new pgvector client. Endpoint is a PostgreSQL
+// connection string and CollectionName the table name; connections are
+// opened lazily.
+func NewPgVectorClient(cfg Config) (*PgVectorClient, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("pgvector endpoint (connection string) is required")
+	}
+
+	poolCfg, err := pgxpool.ParseConfig(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pgvector connection string: %w", err)
+	}
+	if cfg.TimeoutSeconds > 0 {
+		poolCfg.ConnConfig.ConnectTimeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pgvector pool: %w", err)
+	}
+
+	return &PgVectorClient{
+		pool:       pool,
+		table:      pgx.Identifier{cfg.CollectionName}.Sanitize(),
+		tableName:  cfg.CollectionName,
+		softDelete: cfg.SoftDelete,
+	}, nil
+}
+
+// EnsureCollection creates the table, an HNSW cosine index on the embedding
+// and btree indexes on project_id and language if they don't exist.
+func (p *PgVectorClient) EnsureCollection(ctx context.Context, dimensions int) error {
+	index := func(suffix string) string {
+		return pgx.Identifier{p.tableName + "_" + suffix}.Sanitize()
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id          text PRIMARY KEY,
+			embedding   vector(%d) NOT NULL,
+			payload     jsonb NOT NULL,
+			project_id  text GENERATED ALWAYS AS (coalesce(payload->>'project_id', '')) STORED,
+			module      text GENERATED ALWAYS AS (coalesce(payload->>'module', '')) STORED,
+			language    text GENERATED ALWAYS AS (coalesce(payload->>'language', '')) STORED,
+			symbol_type text GENERATED ALWAYS AS (coalesce(payload->>'symbol_type', '')) STORED,
+			file_path   text GENERATED ALWAYS AS (coalesce(payload->>'file_path', '')) STORED,
+			owner       text GENERATED ALWAYS AS (coalesce(payload->>'owner', '')) STORED,
+			generation  text GENERATED ALWAYS AS (coalesce(payload->>'generation', '')) STORED,
+			deleted     boolean GENERATED ALWAYS AS (coalesce((payload->>'deleted')::boolean, false)) STORED,
+			deleted_at  text GENERATED ALWAYS AS (payload->>'deleted_at') STORED
+		)`, p.table, dimensions),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING hnsw (embedding vector_cosine_ops)`, index("embedding_idx"), p.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (project_id)`, index("project_id_idx"), p.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (language)`, index("language_idx"), p.table),
+	}
+
+	for _, stmt := range statements {
+		if _, err := p.pool.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create collection: %w", err)
+		}
+	}
+	return nil
+}
+
+// Upsert inserts or updates rows in a single batch round trip.
+func (p *PgVectorClient) Upsert(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (id, embedding, payload) VALUES ($1, $2::vector, $3)
+		ON CONFLICT (id) DO UPDATE SET embedding = EXCLUDED.embedding, payload = EXCLUDED.payload`, p.table)
+
+	batch := &pgx.Batch{}
+	for _, point := range points {
+		payload, err := json.Marshal(buildQdrantPayload(point))
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		batch.Queue(query, point.ID, formatPgVector(point.Vector), payload)
+	}
+
+	if err := p.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+	return nil
+}
+
+// Search performs cosine similarity search with optional filters.
+func (p *PgVectorClient) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	where := &pgWhere{}
+	vector := where.arg(formatPgVector(query.Vector))
+	p.readWhere(where, query.Filter)
+	if query.ScoreThreshold > 0 {
+		where.add(fmt.Sprintf("1 - (embedding <=> %s::vector) >= %s", vector, where.arg(query.ScoreThreshold)))
+	}
+
+	columns := fmt.Sprintf("id, payload, 1 - (embedding <=> %s::vector)", vector)
+	if query.WithVectors {
+		columns += ", embedding::text"
+	}
+	sql := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY embedding <=> %s::vector",
+		columns, p.table, where.sql(), vector)
+	if query.TopK > 0 {
+		sql += " LIMIT " + where.arg(query.TopK)
+	}
+
+	return p.query(ctx, sql, where.args, query.WithVectors)
+}
+
+// SearchBatch runs the searches one after another.
+func (p *PgVectorClient) SearchBatch(ctx context.Context, queries []SearchQuery) ([][]SearchResult, error) {
+	results := make([][]SearchResult, len(queries))
+	for i, query := range queries {
+		r, err := p.Search(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = r
+	}
+	return results, nil
+}
+
+// Scroll returns up to limit rows matching a filter, without scoring.
+func (p *PgVectorClient) Scroll(ctx context.Context, filter Filter, limit int) ([]SearchResult, error) {
+	results, _, err := p.ScrollPage(ctx, ScrollQuery{Filter: filter, Limit: limit})
+	return results, err
+}
+
+// ScrollPage returns one page of rows in ID order. The cursor is the last
+// ID of the page (keyset paging), so pages stay cheap however deep.
+func (p *PgVectorClient) ScrollPage(ctx context.Context, query ScrollQuery) ([]SearchResult, string, error) {
+	where := &pgWhere{}
+	p.readWhere(where, query.Filter)
+	if query.Cursor != "" {
+		where.add("id > " + where.arg(query.Cursor))
+	}
+
+	payload := "payload"
+	if query.Fields != nil {
+		payload = "COALESCE((SELECT jsonb_object_agg(key, value) FROM jsonb_each(payload) WHERE key = ANY(" +
+			where.arg(query.Fields) + "::text[])), '{}'::jsonb)"
+	}
+
+	sql := fmt.Sprintf("SELECT id, %s, 0::float8 FROM %s%s ORDER BY id", payload, p.table, where.sql())
+	if query.Limit > 0 {
+		sql += " LIMIT " + where.arg(query.Limit)
+	}
+
+	results, err := p.query(ctx, sql, where.args, false)
+	if err != nil {
+		return nil, "", err
+	}
+	if query.Limit <= 0 || len(results) < query.Limit {
+		return results, "", nil
+	}
+	return results, results[len(results)-1].ID, nil
+}
+
+// query runs a select of (id, payload, score[, embedding]) rows.
+func (p *PgVectorClient) query(ctx context.Context, sql string, args []any, withVectors bool) ([]SearchResult, error) {
+	rows, err := p.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var (
+			id      string
+			payload map[string]interface{}
+			score   float64
+			vector  string
+		)
+		dest := []any{&id, &payload, &score}
+		if withVectors {
+			dest = append(dest, &vector)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		result := SearchResult{
+			ID:      id,
+			Score:   float32(score),
+			Payload: parseQdrantPayload(payload),
+		}
+		if withVectors {
+			if result.Vector, err = parsePgVector(vector); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return results, nil
+}
+
+// Count returns the number of rows matching a filter.
+func (p *PgVectorClient) Count(ctx context.Context, filter Filter) (int, error) {
+	where := &pgWhere{}
+	p.readWhere(where, filter)
+
+	var count int
+	err := p.pool.QueryRow(ctx,
+		fmt.Sprintf("SELECT count(*) FROM %s%s", p.table, where.sql()),
+		where.args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count failed: %w", err)
+	}
+	return count, nil
+}
+
+// UpdatePayloads merges payload fields into existing rows; fields left empty
+// keep their stored value. Vectors are not changed.
+func (p *PgVectorClient) UpdatePayloads(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET payload = payload || $2 WHERE id = $1`, p.table)
+	batch := &pgx.Batch{}
+	for _, point := range points {
+		payload := buildQdrantPayload(point)
+		delete(payload, "original_id")
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		batch.Queue(query, point.ID, data)
+	}
+
+	if err := p.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("payload update failed: %w", err)
+	}
+	return nil
+}
+
+// UpdatePayload merges fields into one row's payload.
+func (p *PgVectorClient) UpdatePayload(ctx context.Context, id string, fields map[string]interface{}) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	query := fmt.Sprintf(`UPDATE %s SET payload = payload || $2 WHERE id = $1`, p.table)
+	if _, err := p.pool.Exec(ctx, query, id, data); err != nil {
+		return fmt.Errorf("payload update failed: %w", err)
+	}
+	return nil
+}
+
+// Delete removes rows by their IDs.
+func (p *PgVectorClient) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	where := &pgWhere{}
+	where.add("id = ANY(" + where.arg(ids) + ")")
+	return p.delete(ctx, where)
+}
+
+// DeleteByFilter removes rows matching a filter; an empty filter matches all.
+func (p *PgVectorClient) DeleteByFilter(ctx context.Context, filter Filter) error {
+	where := &pgWhere{}
+	where.filter(filter)
+	return p.delete(ctx, where)
+}
+
+// delete removes the selected rows, or tombstones them in soft-delete mode.
+func (p *PgVectorClient) delete(ctx context.Context, where *pgWhere) error {
+	var sql string
+	if p.softDelete {
+		// Skip existing tombstones so their deleted_at (and retention) is kept
+		where.add("NOT deleted")
+		deletedAt := where.arg(time.Now().UTC().Format(time.RFC3339))
+		sql = fmt.Sprintf(`UPDATE %s SET payload = payload || jsonb_build_object('deleted', true, 'deleted_at', %s::text)%s`,
+			p.table, deletedAt, where.sql())
+	} else {
+		sql = fmt.Sprintf("DELETE FROM %s%s", p.table, where.sql())
+	}
+
+	if _, err := p.pool.Exec(ctx, sql, where.args...); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeleted hard-deletes tombstones whose deleted_at is before olderThan.
+// deleted_at is always written as UTC RFC3339, so it compares as text.
+func (p *PgVectorClient) PurgeDeleted(ctx context.Context, olderThan time.Time) error {
+	where := &pgWhere{}
+	where.add("deleted")
+	where.add("deleted_at < " + where.arg(olderThan.UTC().Format(time.RFC3339)))
+
+	if _, err := p.pool.Exec(ctx, fmt.Sprintf("DELETE FROM %s%s", p.table, where.sql()), where.args...); err != nil {
+		return fmt.Errorf("purge failed: %w", err)
+	}
+	return nil
+}
+
+// Health checks if PostgreSQL is reachable.
+func (p *PgVectorClient) Health(ctx context.Context) error {
+	if err := p.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("pgvector health check failed: %w", err)
+	}
+	return nil
+}
+
+// Capabilities returns the optional features supported by pgvector.
+func (p *PgVectorClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{
+		Scroll:          true,
+		Count:           true,
+		PayloadIndexing: true,
+	}
+}
+
+// Close closes the connection pool.
+func (p *PgVectorClient) Close() error {
+	p.pool.Close()
+	return nil
+}
+
+// readWhere adds the filter conditions for searches and scrolls. In
+// soft-delete mode tombstones are excluded unless the filter asks for them.
+func (p *PgVectorClient) readWhere(where *pgWhere, f Filter) {
+	where.filter(f)
+	if p.softDelete && !f.IncludeDeleted {
+		where.add("NOT deleted")
+	}
+}
+
+// pgWhere accumulates WHERE conditions and their positional arguments.
+type pgWhere struct {
+	conditions []string
+	args       []any
+}
+
+// arg appends a query argument and returns its placeholder.
+func (w *pgWhere) arg(value any) string {
+	w.args = append(w.args, value)
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+// add appends a condition.
+func (w *pgWhere) add(condition string) {
+	w.conditions = append(w.conditions, condition)
+}
+
+// filter adds a condition per set Filter field.
+func (w *pgWhere) filter(f Filter) {
+	values := []string{f.ProjectID, f.Module, f.Language, f.SymbolType, f.Owner}
+	for i, value := range values {
+		if value != "" {
+			w.add(pgFilterColumns[i] + " = " + w.arg(value))
+		}
+	}
+	if IsPathPrefix(f.FilePath) {
+		w.add("starts_with(file_path, " + w.arg(f.FilePath) + ")")
+	} else if f.FilePath != "" {
+		w.add("file_path = " + w.arg(f.FilePath))
+	}
+	if f.Symbol != "" {
+		w.add("payload->>'symbol' = " + w.arg(f.Symbol))
+	}
+	if len(f.Custom) > 0 {
+		custom, _ := json.Marshal(map[string]interface{}{"custom": f.Custom})
+		w.add("payload @> " + w.arg(string(custom)) + "::jsonb")
+	}
+	if f.StartLineGte > 0 {
+		w.add("(payload->>'start_line')::int >= " + w.arg(f.StartLineGte))
+	}
+	if f.EndLineLte > 0 {
+		w.add("(payload->>'end_line')::int <= " + w.arg(f.EndLineLte))
+	}
+	if f.ExcludeGeneration != "" {
+		w.add("generation <> " + w.arg(f.ExcludeGeneration))
+	}
+}
+
+// sql returns the WHERE clause, or "" without conditions.
+func (w *pgWhere) sql() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+// formatPgVector renders a vector in pgvector's text form: [1,2,3].
+func formatPgVector(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parsePgVector parses pgvector's text form.
+func parsePgVector(s string) ([]float32, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if s == "" {
+		return []float32{}, nil
+	}
+	parts := strings.Split(s, ",")
+	v := make([]float32, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector %q: %w", s, err)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
@@ -0,0 +1,170 @@
+//go:build integration
+
+package vectordb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// newTestPgVectorClient starts a throwaway Postgres with pgvector (requires Docker):
+//
+//	go test -tags integration ./internal/vectordb/
+func newTestPgVectorClient(t *testing.T, softDelete bool) *PgVectorClient {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := postgres.RunContainer(ctx,
+		testcontainers.WithImage("pgvector/pgvector:pg16"),
+		postgres.WithDatabase("indexer"),
+		postgres.WithUsername("indexer"),
+		postgres.WithPassword("indexer"),
+		testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").
+			WithOccurrence(2).WithStartupTimeout(time.Minute)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start postgres: %v", err)
+	}
+	t.Cleanup(func() { container.Terminate(context.Background()) })
+
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("Failed to get connection string: %v", err)
+	}
+	client, err := NewPgVectorClient(Config{Endpoint: dsn, CollectionName: "code_chunks", SoftDelete: softDelete})
+	if err != nil {
+		t.Fatalf("NewPgVectorClient failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	if err := client.Health(ctx); err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if err := client.EnsureCollection(ctx, 3); err != nil {
+		t.Fatalf("EnsureCollection failed: %v", err)
+	}
+	// Creating an existing collection is a no-op
+	if err := client.EnsureCollection(ctx, 3); err != nil {
+		t.Fatalf("second EnsureCollection failed: %v", err)
+	}
+	return client
+}
+
+var pgTestPoints = []Point{
+	{ID: "proj:a.go:A", Vector: []float32{1, 0, 0}, Payload: Payload{ProjectID: "proj", FilePath: "a.go", Symbol: "A", Language: "go"}},
+	{ID: "proj:b.py:B", Vector: []float32{0, 1, 0}, Payload: Payload{ProjectID: "proj", FilePath: "b.py", Symbol: "B", Language: "python"}},
+	{ID: "other:c.go:C", Vector: []float32{1, 0, 0}, Payload: Payload{ProjectID: "other", FilePath: "c.go", Symbol: "C", Language: "go"}},
+}
+
+func TestPgVectorClient_Integration(t *testing.T) {
+	client := newTestPgVectorClient(t, false)
+	ctx := context.Background()
+
+	if err := client.Upsert(ctx, pgTestPoints); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	// Upserting again updates in place
+	if err := client.Upsert(ctx, pgTestPoints[:1]); err != nil {
+		t.Fatalf("second Upsert failed: %v", err)
+	}
+
+	results, err := client.Search(ctx, SearchQuery{
+		Vector:      []float32{1, 0, 0},
+		TopK:        5,
+		Filter:      Filter{ProjectID: "proj"},
+		WithVectors: true,
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "proj:a.go:A" {
+		t.Fatalf("Expected A first among 2 proj results, got %+v", results)
+	}
+	if results[0].Score < 0.99 || len(results[0].Vector) != 3 {
+		t.Errorf("Expected exact match with its vector, got %+v", results[0])
+	}
+
+	thresholded, err := client.Search(ctx, SearchQuery{Vector: []float32{1, 0, 0}, TopK: 5, ScoreThreshold: 0.5})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(thresholded) != 2 {
+		t.Errorf("Expected 2 results above threshold, got %d", len(thresholded))
+	}
+
+	if n, err := client.Count(ctx, Filter{Language: "go"}); err != nil || n != 2 {
+		t.Errorf("Count(go) = %d, %v; want 2", n, err)
+	}
+
+	if err := client.UpdatePayloads(ctx, []Point{{ID: "proj:b.py:B", Payload: Payload{Owner: "team-a"}}}); err != nil {
+		t.Fatalf("UpdatePayloads failed: %v", err)
+	}
+	owned, err := client.Scroll(ctx, Filter{Owner: "team-a"}, 10)
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(owned) != 1 || owned[0].Payload.Symbol != "B" {
+		t.Errorf("Expected B to keep its payload with the new owner, got %+v", owned)
+	}
+
+	if err := client.UpdatePayload(ctx, "proj:b.py:B", map[string]interface{}{"start_line": 7}); err != nil {
+		t.Fatalf("UpdatePayload failed: %v", err)
+	}
+	moved, err := client.Search(ctx, SearchQuery{Vector: []float32{0, 1, 0}, TopK: 1, WithVectors: true})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(moved) != 1 || moved[0].Payload.StartLine != 7 || moved[0].Score < 0.99 {
+		t.Errorf("Expected B moved with its vector unchanged, got %+v", moved)
+	}
+
+	if err := client.DeleteByFilter(ctx, Filter{ProjectID: "proj", FilePath: "a.go"}); err != nil {
+		t.Fatalf("DeleteByFilter failed: %v", err)
+	}
+	if err := client.Delete(ctx, []string{"other:c.go:C"}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	remaining, err := client.Scroll(ctx, Filter{}, 10)
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != "proj:b.py:B" {
+		t.Errorf("Expected only B to remain, got %+v", remaining)
+	}
+}
+
+func TestPgVectorClient_SoftDeleteIntegration(t *testing.T) {
+	client := newTestPgVectorClient(t, true)
+	ctx := context.Background()
+
+	if err := client.Upsert(ctx, pgTestPoints); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if err := client.DeleteByFilter(ctx, Filter{ProjectID: "proj"}); err != nil {
+		t.Fatalf("DeleteByFilter failed: %v", err)
+	}
+
+	if n, _ := client.Count(ctx, Filter{}); n != 1 {
+		t.Errorf("Expected tombstones hidden from Count, got %d", n)
+	}
+	tombstones, err := client.Scroll(ctx, Filter{ProjectID: "proj", IncludeDeleted: true}, 10)
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(tombstones) != 2 || tombstones[0].Payload.DeletedAt == "" {
+		t.Errorf("Expected 2 tombstones with deleted_at, got %+v", tombstones)
+	}
+
+	if err := client.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeDeleted failed: %v", err)
+	}
+	if n, _ := client.Count(ctx, Filter{IncludeDeleted: true}); n != 1 {
+		t.Errorf("Expected tombstones purged, got %d points", n)
+	}
+}
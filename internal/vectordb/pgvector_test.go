@@ -0,0 +1,96 @@
+package vectordb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPgWhere_Filter(t *testing.T) {
+	where := &pgWhere{}
+	where.filter(Filter{})
+	if got := where.sql(); got != "" {
+		t.Errorf("Expected no WHERE clause for empty filter, got %q", got)
+	}
+
+	where = &pgWhere{}
+	vector := where.arg("[1,0]")
+	where.filter(Filter{ProjectID: "proj", Language: "go", ExcludeGeneration: "g2"})
+	if vector != "$1" {
+		t.Errorf("Expected first placeholder $1, got %s", vector)
+	}
+	want := " WHERE project_id = $2 AND language = $3 AND generation <> $4"
+	if got := where.sql(); got != want {
+		t.Errorf("sql() = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(where.args, []any{"[1,0]", "proj", "go", "g2"}) {
+		t.Errorf("Unexpected args %v", where.args)
+	}
+}
+
+func TestPgWhere_LineRange(t *testing.T) {
+	where := &pgWhere{}
+	where.filter(Filter{StartLineGte: 10, EndLineLte: 20})
+	want := " WHERE (payload->>'start_line')::int >= $1 AND (payload->>'end_line')::int <= $2"
+	if got := where.sql(); got != want {
+		t.Errorf("sql() = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(where.args, []any{10, 20}) {
+		t.Errorf("Unexpected args %v", where.args)
+	}
+}
+
+func TestPgWhere_FilePathPrefix(t *testing.T) {
+	where := &pgWhere{}
+	where.filter(Filter{FilePath: "src/auth/"})
+	if got, want := where.sql(), " WHERE starts_with(file_path, $1)"; got != want {
+		t.Errorf("sql() = %q, want %q", got, want)
+	}
+}
+
+func TestPgVectorClient_ReadWhereExcludesTombstones(t *testing.T) {
+	client := &PgVectorClient{softDelete: true}
+
+	where := &pgWhere{}
+	client.readWhere(where, Filter{ProjectID: "proj"})
+	if got, want := where.sql(), " WHERE project_id = $1 AND NOT deleted"; got != want {
+		t.Errorf("sql() = %q, want %q", got, want)
+	}
+
+	where = &pgWhere{}
+	client.readWhere(where, Filter{ProjectID: "proj", IncludeDeleted: true})
+	if got, want := where.sql(), " WHERE project_id = $1"; got != want {
+		t.Errorf("sql() = %q, want %q", got, want)
+	}
+}
+
+func TestPgVectorFormat(t *testing.T) {
+	v := []float32{0.5, -0.25, 3}
+	text := formatPgVector(v)
+	if text != "[0.5,-0.25,3]" {
+		t.Errorf("formatPgVector = %s", text)
+	}
+	parsed, err := parsePgVector(text)
+	if err != nil {
+		t.Fatalf("parsePgVector failed: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, v) {
+		t.Errorf("parsePgVector = %v, want %v", parsed, v)
+	}
+	if _, err := parsePgVector("[1,x]"); err == nil {
+		t.Error("Expected error for malformed vector")
+	}
+}
+
+func TestNewPgVectorClient_RequiresConnectionString(t *testing.T) {
+	if _, err := NewPgVectorClient(Config{CollectionName: "c"}); err == nil {
+		t.Error("Expected error without endpoint")
+	}
+	client, err := NewPgVectorClient(Config{Endpoint: "postgres://u:p@localhost:5432/db", CollectionName: "code_chunks"})
+	if err != nil {
+		t.Fatalf("NewPgVectorClient failed: %v", err)
+	}
+	defer client.Close()
+	if client.table != `"code_chunks"` {
+		t.Errorf("Expected quoted table name, got %s", client.table)
+	}
+}
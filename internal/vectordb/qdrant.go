@@ -6,9 +6,12 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"sort"
 	"time"
 )
 
@@ -17,8 +20,16 @@ type QdrantClient struct {
 	client         *http.Client
 	endpoint       string
 	collectionName string
+	apiKey         string
+	headers        map[string]string
+	softDelete     bool
+	upsertBatch    int
 }
 
+// defaultUpsertBatchSize is the number of points sent per upsert request,
+// keeping request bodies well below Qdrant's payload limit.
+const defaultUpsertBatchSize = 256
+
 // Qdrant API types
 
 type qdrantCreateCollectionRequest struct {
@@ -39,45 +50,115 @@ type qdrantPoint struct {
 }
 
 type qdrantSearchRequest struct {
-	Vector      []float32              `json:"vector"`
-	Limit       int                    `json:"limit"`
-	WithPayload bool                   `json:"with_payload"`
-	Filter      *qdrantFilter          `json:"filter,omitempty"`
-	ScoreThreshold float32             `json:"score_threshold,omitempty"`
+	Vector         []float32     `json:"vector"`
+	Limit          int           `json:"limit"`
+	WithPayload    bool          `json:"with_payload"`
+	Filter         *qdrantFilter `json:"filter,omitempty"`
+	ScoreThreshold float32       `json:"score_threshold,omitempty"`
+	WithVector     bool          `json:"with_vector,omitempty"`
 }
 
 type qdrantFilter struct {
-	Must []qdrantCondition `json:"must,omitempty"`
+	Must    []qdrantCondition `json:"must,omitempty"`
+	MustNot []qdrantCondition `json:"must_not,omitempty"`
 }
 
 type qdrantCondition struct {
-	Key   string           `json:"key"`
-	Match qdrantMatchValue `json:"match"`
+	Key   string            `json:"key"`
+	Match *qdrantMatchValue `json:"match,omitempty"`
+	Range *qdrantRange      `json:"range,omitempty"`
 }
 
 type qdrantMatchValue struct {
-	Value string `json:"value"`
+	Value interface{} `json:"value"`
+}
+
+// qdrantRange is a range condition; RFC3339 bounds compare as datetimes.
+type qdrantRange struct {
+	Lt  string `json:"lt,omitempty"`
+	Gte *int   `json:"gte,omitempty"`
+	Lte *int   `json:"lte,omitempty"`
+}
+
+type qdrantScoredPoint struct {
+	ID      string                 `json:"id"`
+	Score   float32                `json:"score"`
+	Payload map[string]interface{} `json:"payload"`
+	Vector  []float32              `json:"vector,omitempty"`
 }
 
 type qdrantSearchResponse struct {
-	Result []struct {
-		ID      string                 `json:"id"`
-		Score   float32                `json:"score"`
-		Payload map[string]interface{} `json:"payload"`
+	Result []qdrantScoredPoint `json:"result"`
+}
+
+type qdrantSearchBatchRequest struct {
+	Searches []qdrantSearchRequest `json:"searches"`
+}
+
+type qdrantSearchBatchResponse struct {
+	Result [][]qdrantScoredPoint `json:"result"`
+}
+
+type qdrantScrollRequest struct {
+	Filter      *qdrantFilter   `json:"filter,omitempty"`
+	Limit       int             `json:"limit"`
+	Offset      json.RawMessage `json:"offset,omitempty"`
+	WithPayload interface{}     `json:"with_payload"` // true or a list of keys
+}
+
+type qdrantScrollResponse struct {
+	Result struct {
+		Points []struct {
+			ID      string                 `json:"id"`
+			Payload map[string]interface{} `json:"payload"`
+		} `json:"points"`
+		NextPageOffset json.RawMessage `json:"next_page_offset"`
+	} `json:"result"`
+}
+
+type qdrantCountRequest struct {
+	Filter *qdrantFilter `json:"filter,omitempty"`
+	Exact  bool          `json:"exact"`
+}
+
+type qdrantCountResponse struct {
+	Result struct {
+		Count int `json:"count"`
 	} `json:"result"`
 }
 
+type qdrantBatchRequest struct {
+	Operations []qdrantBatchOperation `json:"operations"`
+}
+
+type qdrantBatchOperation struct {
+	SetPayload *qdrantSetPayloadRequest `json:"set_payload,omitempty"`
+}
+
 type qdrantDeleteRequest struct {
 	Points []string      `json:"points,omitempty"`
 	Filter *qdrantFilter `json:"filter,omitempty"`
 }
 
+type qdrantSetPayloadRequest struct {
+	Payload map[string]interface{} `json:"payload"`
+	Points  []string               `json:"points,omitempty"`
+	Filter  *qdrantFilter          `json:"filter,omitempty"`
+}
+
+// deletedCondition matches soft-deleted tombstones.
+var deletedCondition = qdrantCondition{Key: "deleted", Match: &qdrantMatchValue{Value: true}}
+
 // NewQdrantClient creates a new Qdrant vector database client.
 func NewQdrantClient(cfg Config) (*QdrantClient, error) {
 	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
+	upsertBatch := cfg.UpsertBatchSize
+	if upsertBatch <= 0 {
+		upsertBatch = defaultUpsertBatchSize
+	}
 
 	return &QdrantClient{
 		client: &http.Client{
@@ -85,36 +166,48 @@ func NewQdrantClient(cfg Config) (*QdrantClient, error) {
 		},
 		endpoint:       cfg.Endpoint,
 		collectionName: cfg.CollectionName,
+		apiKey:         cfg.APIKey,
+		headers:        cfg.Headers,
+		softDelete:     cfg.SoftDelete,
+		upsertBatch:    upsertBatch,
 	}, nil
 }
 
 // Upsert inserts or updates vectors with metadata.
+// Points are sent in sequential batches of the configured upsert batch size;
+// a failed batch does not stop the remaining ones, and the returned error
+// names every failed batch.
 func (q *QdrantClient) Upsert(ctx context.Context, points []Point) error {
 	if len(points) == 0 {
 		return nil
 	}
 
+	batches := (len(points) + q.upsertBatch - 1) / q.upsertBatch
+	var errs []error
+	for b := 0; b < batches; b++ {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		start := b * q.upsertBatch
+		end := min(start+q.upsertBatch, len(points))
+		if err := q.upsertBatchRequest(ctx, points[start:end]); err != nil {
+			errs = append(errs, fmt.Errorf("upsert batch %d/%d: %w", b+1, batches, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// upsertBatchRequest sends a single points upsert request.
+func (q *QdrantClient) upsertBatchRequest(ctx context.Context, points []Point) error {
 	qdrantPoints := make([]qdrantPoint, len(points))
 	for i, p := range points {
 		// Convert string ID to UUID format (Qdrant requires UUID or uint64)
 		uuid := stringToUUID(p.ID)
 		qdrantPoints[i] = qdrantPoint{
-			ID:     uuid,
-			Vector: p.Vector,
-			Payload: map[string]interface{}{
-				"original_id":  p.ID, // Store original ID for reference
-				"project_id":   p.Payload.ProjectID,
-				"file_path":    p.Payload.FilePath,
-				"symbol":       p.Payload.Symbol,
-				"symbol_type":  p.Payload.SymbolType,
-				"language":     p.Payload.Language,
-				"module":       p.Payload.Module,
-				"start_line":   p.Payload.StartLine,
-				"end_line":     p.Payload.EndLine,
-				"content":      p.Payload.Content,
-				"content_hash": p.Payload.ContentHash,
-				"indexed_at":   p.Payload.IndexedAt,
-			},
+			ID:      uuid,
+			Vector:  p.Vector,
+			Payload: qdrantPointPayload(p),
 		}
 	}
 
@@ -126,76 +219,363 @@ func (q *QdrantClient) Upsert(ctx context.Context, points []Point) error {
 
 // Search performs similarity search with optional filters.
 func (q *QdrantClient) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
-	reqBody := qdrantSearchRequest{
+	var resp qdrantSearchResponse
+	err := q.doRequest(ctx, http.MethodPost,
+		fmt.Sprintf("/collections/%s/points/search", q.collectionName),
+		q.searchRequest(query), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return toSearchResults(resp.Result), nil
+}
+
+// SearchBatch runs several searches with a single points/search/batch request.
+func (q *QdrantClient) SearchBatch(ctx context.Context, queries []SearchQuery) ([][]SearchResult, error) {
+	if len(queries) == 0 {
+		return [][]SearchResult{}, nil
+	}
+
+	reqBody := qdrantSearchBatchRequest{Searches: make([]qdrantSearchRequest, len(queries))}
+	for i, query := range queries {
+		reqBody.Searches[i] = q.searchRequest(query)
+	}
+
+	var resp qdrantSearchBatchResponse
+	err := q.doRequest(ctx, http.MethodPost,
+		fmt.Sprintf("/collections/%s/points/search/batch", q.collectionName),
+		reqBody, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Result) != len(queries) {
+		return nil, fmt.Errorf("batch search returned %d result sets for %d queries", len(resp.Result), len(queries))
+	}
+
+	results := make([][]SearchResult, len(resp.Result))
+	for i, points := range resp.Result {
+		results[i] = toSearchResults(points)
+	}
+	return results, nil
+}
+
+// searchRequest builds the Qdrant request body for a search query.
+func (q *QdrantClient) searchRequest(query SearchQuery) qdrantSearchRequest {
+	return qdrantSearchRequest{
 		Vector:         query.Vector,
 		Limit:          query.TopK,
 		WithPayload:    true,
 		ScoreThreshold: query.ScoreThreshold,
+		WithVector:     query.WithVectors,
+		Filter:         q.readFilter(query.Filter),
 	}
+}
 
-	// Build filter
-	if query.Filter.ProjectID != "" || query.Filter.Module != "" ||
-		query.Filter.Language != "" || query.Filter.SymbolType != "" {
-		reqBody.Filter = &qdrantFilter{
-			Must: make([]qdrantCondition, 0),
+// toSearchResults converts scored Qdrant points into search results.
+func toSearchResults(points []qdrantScoredPoint) []SearchResult {
+	results := make([]SearchResult, len(points))
+	for i, r := range points {
+		results[i] = SearchResult{
+			ID:      r.ID,
+			Score:   r.Score,
+			Payload: parseQdrantPayload(r.Payload),
+			Vector:  r.Vector,
 		}
+	}
+	return results
+}
 
-		if query.Filter.ProjectID != "" {
-			reqBody.Filter.Must = append(reqBody.Filter.Must, qdrantCondition{
-				Key:   "project_id",
-				Match: qdrantMatchValue{Value: query.Filter.ProjectID},
-			})
-		}
-		if query.Filter.Module != "" {
-			reqBody.Filter.Must = append(reqBody.Filter.Must, qdrantCondition{
-				Key:   "module",
-				Match: qdrantMatchValue{Value: query.Filter.Module},
-			})
-		}
-		if query.Filter.Language != "" {
-			reqBody.Filter.Must = append(reqBody.Filter.Must, qdrantCondition{
-				Key:   "language",
-				Match: qdrantMatchValue{Value: query.Filter.Language},
-			})
-		}
-		if query.Filter.SymbolType != "" {
-			reqBody.Filter.Must = append(reqBody.Filter.Must, qdrantCondition{
-				Key:   "symbol_type",
-				Match: qdrantMatchValue{Value: query.Filter.SymbolType},
-			})
-		}
+// Scroll returns up to limit points matching a filter, without scoring.
+func (q *QdrantClient) Scroll(ctx context.Context, filter Filter, limit int) ([]SearchResult, error) {
+	results, _, err := q.ScrollPage(ctx, ScrollQuery{Filter: filter, Limit: limit})
+	return results, err
+}
+
+// ScrollPage returns one page of points in ID order. The cursor is Qdrant's
+// next_page_offset (a point ID) as raw JSON.
+func (q *QdrantClient) ScrollPage(ctx context.Context, query ScrollQuery) ([]SearchResult, string, error) {
+	reqBody := qdrantScrollRequest{
+		Filter:      q.readFilter(query.Filter),
+		Limit:       query.Limit,
+		WithPayload: true,
+	}
+	if query.Fields != nil {
+		reqBody.WithPayload = query.Fields
+	}
+	if query.Cursor != "" {
+		reqBody.Offset = json.RawMessage(query.Cursor)
 	}
 
-	var resp qdrantSearchResponse
+	var resp qdrantScrollResponse
 	err := q.doRequest(ctx, http.MethodPost,
-		fmt.Sprintf("/collections/%s/points/search", q.collectionName),
+		fmt.Sprintf("/collections/%s/points/scroll", q.collectionName),
 		reqBody, &resp)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	results := make([]SearchResult, len(resp.Result))
-	for i, r := range resp.Result {
+	results := make([]SearchResult, len(resp.Result.Points))
+	for i, p := range resp.Result.Points {
 		results[i] = SearchResult{
-			ID:    r.ID,
-			Score: r.Score,
-			Payload: Payload{
-				ProjectID:   getString(r.Payload, "project_id"),
-				FilePath:    getString(r.Payload, "file_path"),
-				Symbol:      getString(r.Payload, "symbol"),
-				SymbolType:  getString(r.Payload, "symbol_type"),
-				Language:    getString(r.Payload, "language"),
-				Module:      getString(r.Payload, "module"),
-				StartLine:   getInt(r.Payload, "start_line"),
-				EndLine:     getInt(r.Payload, "end_line"),
-				Content:     getString(r.Payload, "content"),
-				ContentHash: getString(r.Payload, "content_hash"),
-				IndexedAt:   getString(r.Payload, "indexed_at"),
-			},
+			ID:      p.ID,
+			Payload: parseQdrantPayload(p.Payload),
 		}
 	}
 
-	return results, nil
+	next := string(resp.Result.NextPageOffset)
+	if next == "null" {
+		next = ""
+	}
+	return results, next, nil
+}
+
+// Count returns the exact number of points matching a filter.
+func (q *QdrantClient) Count(ctx context.Context, filter Filter) (int, error) {
+	reqBody := qdrantCountRequest{
+		Filter: q.readFilter(filter),
+		Exact:  true,
+	}
+
+	var resp qdrantCountResponse
+	err := q.doRequest(ctx, http.MethodPost,
+		fmt.Sprintf("/collections/%s/points/count", q.collectionName),
+		reqBody, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Result.Count, nil
+}
+
+// buildQdrantFilter converts a Filter into Qdrant must-conditions.
+// Returns nil when no filter field is set.
+func buildQdrantFilter(f Filter) *qdrantFilter {
+	filePathKey := "file_path"
+	if IsPathPrefix(f.FilePath) {
+		// Directory filters match the stored ancestor directories instead
+		filePathKey = "file_dirs"
+	}
+
+	fields := []struct{ key, value string }{
+		{"project_id", f.ProjectID},
+		{"module", f.Module},
+		{"language", f.Language},
+		{"symbol_type", f.SymbolType},
+		{"symbol", f.Symbol},
+		{filePathKey, f.FilePath},
+		{"owner", f.Owner},
+	}
+
+	var must []qdrantCondition
+	for _, field := range fields {
+		if field.value != "" {
+			must = append(must, qdrantCondition{
+				Key:   field.key,
+				Match: &qdrantMatchValue{Value: field.value},
+			})
+		}
+	}
+
+	for _, key := range sortedKeys(f.Custom) {
+		must = append(must, qdrantCondition{
+			Key:   "custom." + key,
+			Match: &qdrantMatchValue{Value: f.Custom[key]},
+		})
+	}
+
+	if f.StartLineGte > 0 {
+		must = append(must, qdrantCondition{
+			Key:   "start_line",
+			Range: &qdrantRange{Gte: &f.StartLineGte},
+		})
+	}
+	if f.EndLineLte > 0 {
+		must = append(must, qdrantCondition{
+			Key:   "end_line",
+			Range: &qdrantRange{Lte: &f.EndLineLte},
+		})
+	}
+
+	var mustNot []qdrantCondition
+	if f.ExcludeGeneration != "" {
+		mustNot = append(mustNot, qdrantCondition{
+			Key:   "generation",
+			Match: &qdrantMatchValue{Value: f.ExcludeGeneration},
+		})
+	}
+
+	if len(must) == 0 && len(mustNot) == 0 {
+		return nil
+	}
+	return &qdrantFilter{Must: must, MustNot: mustNot}
+}
+
+// readFilter builds the filter for searches and scrolls. In soft-delete mode
+// tombstones are excluded with a must_not deleted condition unless the
+// filter asks for them.
+func (q *QdrantClient) readFilter(f Filter) *qdrantFilter {
+	qf := buildQdrantFilter(f)
+	if !q.softDelete || f.IncludeDeleted {
+		return qf
+	}
+	if qf == nil {
+		qf = &qdrantFilter{}
+	}
+	qf.MustNot = append(qf.MustNot, deletedCondition)
+	return qf
+}
+
+// parseQdrantPayload converts a Qdrant payload map into a Payload.
+func parseQdrantPayload(m map[string]interface{}) Payload {
+	return Payload{
+		ProjectID:   getString(m, "project_id"),
+		FilePath:    getString(m, "file_path"),
+		Symbol:      getString(m, "symbol"),
+		SymbolType:  getString(m, "symbol_type"),
+		Language:    getString(m, "language"),
+		Module:      getString(m, "module"),
+		StartLine:   getInt(m, "start_line"),
+		EndLine:     getInt(m, "end_line"),
+		Content:     getString(m, "content"),
+		ContentHash: getString(m, "content_hash"),
+		ExactHash:   getString(m, "exact_hash"),
+		IndexedAt:   getString(m, "indexed_at"),
+		Generation:  getString(m, "generation"),
+		Owner:       getString(m, "owner"),
+		Custom:      getStringMap(m, "custom"),
+		GitRef:      getString(m, "git_ref"),
+		DeletedAt:   getString(m, "deleted_at"),
+		Imports:     getStringSlice(m, "imports"),
+		References:  getStringSlice(m, "references"),
+		TokenCount:  getInt(m, "token_count"),
+
+		ParentSymbol:   getString(m, "parent_symbol"),
+		LastModified:   getString(m, "last_modified"),
+		FilePaths:      getStringSlice(m, "file_paths"),
+		PayloadVersion: getInt(m, "payload_version"),
+	}
+}
+
+// qdrantPointPayload is buildQdrantPayload plus file_dirs, the ancestor
+// directories of file_path. Qdrant has no prefix match on keywords, so
+// directory filters match against this list.
+func qdrantPointPayload(p Point) map[string]interface{} {
+	payload := buildQdrantPayload(p)
+	if dirs := fileDirs(p.Payload.FilePath); len(dirs) > 0 {
+		payload["file_dirs"] = dirs
+	}
+	return payload
+}
+
+// fileDirs returns the ancestor directories of a slash-separated path, each
+// with a trailing slash: "src/auth/login.go" -> ["src/", "src/auth/"].
+func fileDirs(path string) []string {
+	var dirs []string
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 {
+			dirs = append(dirs, path[:i+1])
+		}
+	}
+	return dirs
+}
+
+// buildQdrantPayload converts a point's metadata into a Qdrant payload map.
+// Content is omitted when empty so metadata-only indexes don't store source.
+func buildQdrantPayload(p Point) map[string]interface{} {
+	payload := map[string]interface{}{
+		"original_id":  p.ID, // Store original ID for reference
+		"project_id":   p.Payload.ProjectID,
+		"file_path":    p.Payload.FilePath,
+		"symbol":       p.Payload.Symbol,
+		"symbol_type":  p.Payload.SymbolType,
+		"language":     p.Payload.Language,
+		"module":       p.Payload.Module,
+		"start_line":   p.Payload.StartLine,
+		"end_line":     p.Payload.EndLine,
+		"content_hash": p.Payload.ContentHash,
+		"indexed_at":   p.Payload.IndexedAt,
+	}
+	if p.Payload.Content != "" {
+		payload["content"] = p.Payload.Content
+	}
+	if p.Payload.ExactHash != "" {
+		payload["exact_hash"] = p.Payload.ExactHash
+	}
+	if p.Payload.Generation != "" {
+		payload["generation"] = p.Payload.Generation
+	}
+	if p.Payload.Owner != "" {
+		payload["owner"] = p.Payload.Owner
+	}
+	if p.Payload.ParentSymbol != "" {
+		payload["parent_symbol"] = p.Payload.ParentSymbol
+	}
+	if p.Payload.LastModified != "" {
+		payload["last_modified"] = p.Payload.LastModified
+	}
+	if len(p.Payload.Custom) > 0 {
+		payload["custom"] = p.Payload.Custom
+	}
+	if p.Payload.GitRef != "" {
+		payload["git_ref"] = p.Payload.GitRef
+	}
+	if len(p.Payload.FilePaths) > 0 {
+		payload["file_paths"] = p.Payload.FilePaths
+	}
+	if len(p.Payload.Imports) > 0 {
+		payload["imports"] = p.Payload.Imports
+	}
+	if len(p.Payload.References) > 0 {
+		payload["references"] = p.Payload.References
+	}
+	if p.Payload.TokenCount > 0 {
+		payload["token_count"] = p.Payload.TokenCount
+	}
+	if p.Payload.PayloadVersion > 0 {
+		payload["payload_version"] = p.Payload.PayloadVersion
+	}
+	if p.Payload.DeletedAt != "" {
+		payload["deleted"] = true
+		payload["deleted_at"] = p.Payload.DeletedAt
+	}
+	return payload
+}
+
+// UpdatePayloads sets the payload fields of existing points in one batch
+// request. IDs may be original chunk IDs or the Qdrant UUIDs Scroll returns;
+// fields left empty keep their stored value.
+func (q *QdrantClient) UpdatePayloads(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	ops := make([]qdrantBatchOperation, len(points))
+	for i, p := range points {
+		id, payload := p.ID, qdrantPointPayload(p)
+		if uuidPattern.MatchString(id) {
+			// Scroll IDs are already UUIDs; keep the stored original_id
+			delete(payload, "original_id")
+		} else {
+			id = stringToUUID(id)
+		}
+		ops[i] = qdrantBatchOperation{SetPayload: &qdrantSetPayloadRequest{
+			Payload: payload,
+			Points:  []string{id},
+		}}
+	}
+
+	return q.doRequest(ctx, http.MethodPost,
+		fmt.Sprintf("/collections/%s/points/batch", q.collectionName),
+		qdrantBatchRequest{Operations: ops}, nil)
+}
+
+// UpdatePayload merges fields into one point's payload via points/payload.
+func (q *QdrantClient) UpdatePayload(ctx context.Context, id string, fields map[string]interface{}) error {
+	if !uuidPattern.MatchString(id) {
+		id = stringToUUID(id)
+	}
+	return q.doRequest(ctx, http.MethodPost,
+		fmt.Sprintf("/collections/%s/points/payload", q.collectionName),
+		qdrantSetPayloadRequest{Payload: fields, Points: []string{id}}, nil)
 }
 
 // Delete removes vectors by their IDs.
@@ -210,6 +590,10 @@ func (q *QdrantClient) Delete(ctx context.Context, ids []string) error {
 		uuids[i] = stringToUUID(id)
 	}
 
+	if q.softDelete {
+		return q.tombstone(ctx, qdrantSetPayloadRequest{Points: uuids})
+	}
+
 	reqBody := qdrantDeleteRequest{Points: uuids}
 	return q.doRequest(ctx, http.MethodPost,
 		fmt.Sprintf("/collections/%s/points/delete", q.collectionName),
@@ -218,18 +602,41 @@ func (q *QdrantClient) Delete(ctx context.Context, ids []string) error {
 
 // DeleteByFilter removes vectors matching a filter.
 func (q *QdrantClient) DeleteByFilter(ctx context.Context, filter Filter) error {
-	qdrantFilter := &qdrantFilter{
-		Must: make([]qdrantCondition, 0),
+	// An empty filter keeps the previous match-all semantics
+	deleteFilter := buildQdrantFilter(filter)
+	if deleteFilter == nil {
+		deleteFilter = &qdrantFilter{}
 	}
 
-	if filter.ProjectID != "" {
-		qdrantFilter.Must = append(qdrantFilter.Must, qdrantCondition{
-			Key:   "project_id",
-			Match: qdrantMatchValue{Value: filter.ProjectID},
-		})
+	if q.softDelete {
+		// Skip existing tombstones so their deleted_at (and retention) is kept
+		deleteFilter.MustNot = append(deleteFilter.MustNot, deletedCondition)
+		return q.tombstone(ctx, qdrantSetPayloadRequest{Filter: deleteFilter})
 	}
 
-	reqBody := qdrantDeleteRequest{Filter: qdrantFilter}
+	reqBody := qdrantDeleteRequest{Filter: deleteFilter}
+	return q.doRequest(ctx, http.MethodPost,
+		fmt.Sprintf("/collections/%s/points/delete", q.collectionName),
+		reqBody, nil)
+}
+
+// tombstone marks the selected points as deleted without removing them.
+func (q *QdrantClient) tombstone(ctx context.Context, req qdrantSetPayloadRequest) error {
+	req.Payload = map[string]interface{}{
+		"deleted":    true,
+		"deleted_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	return q.doRequest(ctx, http.MethodPost,
+		fmt.Sprintf("/collections/%s/points/payload", q.collectionName),
+		req, nil)
+}
+
+// PurgeDeleted hard-deletes tombstones whose deleted_at is before olderThan.
+func (q *QdrantClient) PurgeDeleted(ctx context.Context, olderThan time.Time) error {
+	reqBody := qdrantDeleteRequest{Filter: &qdrantFilter{Must: []qdrantCondition{
+		deletedCondition,
+		{Key: "deleted_at", Range: &qdrantRange{Lt: olderThan.UTC().Format(time.RFC3339)}},
+	}}}
 	return q.doRequest(ctx, http.MethodPost,
 		fmt.Sprintf("/collections/%s/points/delete", q.collectionName),
 		reqBody, nil)
@@ -238,7 +645,14 @@ func (q *QdrantClient) DeleteByFilter(ctx context.Context, filter Filter) error
 // EnsureCollection creates the collection if it doesn't exist.
 func (q *QdrantClient) EnsureCollection(ctx context.Context, dimensions int) error {
 	// Check if collection exists
-	resp, err := q.client.Get(fmt.Sprintf("%s/collections/%s", q.endpoint, q.collectionName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/collections/%s", q.endpoint, q.collectionName), nil)
+	if err != nil {
+		return err
+	}
+	q.setAuth(req)
+
+	resp, err := q.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to check collection: %w", err)
 	}
@@ -265,6 +679,7 @@ func (q *QdrantClient) Health(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	q.setAuth(req)
 
 	resp, err := q.client.Do(req)
 	if err != nil {
@@ -279,6 +694,17 @@ func (q *QdrantClient) Health(ctx context.Context) error {
 	return nil
 }
 
+// Capabilities returns the optional features supported by Qdrant.
+func (q *QdrantClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{
+		FullTextSearch:  true,
+		Scroll:          true,
+		Count:           true,
+		Aliases:         true,
+		PayloadIndexing: true,
+	}
+}
+
 // Close releases resources (no-op for Qdrant HTTP client).
 func (q *QdrantClient) Close() error {
 	return nil
@@ -300,6 +726,7 @@ func (q *QdrantClient) doRequest(ctx context.Context, method, path string, body
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	q.setAuth(req)
 
 	resp, err := q.client.Do(req)
 	if err != nil {
@@ -321,6 +748,14 @@ func (q *QdrantClient) doRequest(ctx context.Context, method, path string, body
 	return nil
 }
 
+// setAuth attaches the api-key header, when configured, and extra headers.
+func (q *QdrantClient) setAuth(req *http.Request) {
+	if q.apiKey != "" {
+		req.Header.Set("api-key", q.apiKey)
+	}
+	setHeaders(req, q.headers)
+}
+
 // Helper functions
 
 func getString(m map[string]interface{}, key string) string {
@@ -332,6 +767,44 @@ func getString(m map[string]interface{}, key string) string {
 	return ""
 }
 
+func getStringSlice(m map[string]interface{}, key string) []string {
+	items, ok := m[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func getStringMap(m map[string]interface{}, key string) map[string]string {
+	items, ok := m[key].(map[string]interface{})
+	if !ok || len(items) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(items))
+	for k, v := range items {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
+
+// sortedKeys returns the keys of m in order, for deterministic requests.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func getInt(m map[string]interface{}, key string) int {
 	if v, ok := m[key]; ok {
 		switch n := v.(type) {
@@ -344,12 +817,15 @@ func getInt(m map[string]interface{}, key string) int {
 	return 0
 }
 
+// uuidPattern matches point IDs already in Qdrant's UUID form.
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
 // stringToUUID converts a string ID to a deterministic UUID v5 format.
 // This is needed because Qdrant requires point IDs to be UUIDs or uint64.
 func stringToUUID(s string) string {
 	// Generate SHA-256 hash of the string
 	hash := sha256.Sum256([]byte(s))
-	
+
 	// Format as UUID v4-like string (using first 16 bytes of hash)
 	// Format: xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
 	return fmt.Sprintf("%08x-%04x-%04x-%04x-%012x",
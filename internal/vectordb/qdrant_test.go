@@ -0,0 +1,531 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildQdrantPayload_OmitsEmptyContent(t *testing.T) {
+	point := Point{
+		ID: "proj:main.go:main:abcd1234",
+		Payload: Payload{
+			ProjectID:   "proj",
+			FilePath:    "main.go",
+			Symbol:      "main",
+			SymbolType:  "function",
+			StartLine:   3,
+			EndLine:     10,
+			ContentHash: "abcd1234",
+		},
+	}
+
+	payload := buildQdrantPayload(point)
+	if _, ok := payload["content"]; ok {
+		t.Error("Expected payload to exclude content when empty")
+	}
+	if payload["symbol"] != "main" {
+		t.Errorf("Expected symbol 'main', got %v", payload["symbol"])
+	}
+	if payload["start_line"] != 3 || payload["end_line"] != 10 {
+		t.Errorf("Expected lines 3-10, got %v-%v", payload["start_line"], payload["end_line"])
+	}
+	if payload["content_hash"] != "abcd1234" {
+		t.Errorf("Expected content_hash to be kept, got %v", payload["content_hash"])
+	}
+
+	point.Payload.Content = "func main() {}"
+	payload = buildQdrantPayload(point)
+	if payload["content"] != "func main() {}" {
+		t.Errorf("Expected content to be stored, got %v", payload["content"])
+	}
+}
+
+func TestQdrantClient_Capabilities(t *testing.T) {
+	client, err := NewQdrantClient(Config{Endpoint: "http://localhost:6333", CollectionName: "c"})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+	want := ProviderCapabilities{FullTextSearch: true, Scroll: true, Count: true, Aliases: true, PayloadIndexing: true}
+	if got := client.Capabilities(); got != want {
+		t.Errorf("Capabilities() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildQdrantFilter(t *testing.T) {
+	if f := buildQdrantFilter(Filter{}); f != nil {
+		t.Errorf("Expected nil filter for empty Filter, got %+v", f)
+	}
+
+	f := buildQdrantFilter(Filter{ProjectID: "proj", FilePath: "a.go"})
+	if f == nil || len(f.Must) != 2 {
+		t.Fatalf("Expected 2 conditions, got %+v", f)
+	}
+	if f.Must[0].Key != "project_id" || f.Must[1].Key != "file_path" || f.Must[1].Match.Value != "a.go" {
+		t.Errorf("Unexpected conditions: %+v", f.Must)
+	}
+}
+
+func TestBuildQdrantFilter_FilePathPrefix(t *testing.T) {
+	exact := buildQdrantFilter(Filter{FilePath: "src/auth/login.go"})
+	if exact.Must[0].Key != "file_path" || exact.Must[0].Match.Value != "src/auth/login.go" {
+		t.Errorf("Expected exact file_path match, got %+v", exact.Must)
+	}
+
+	prefix := buildQdrantFilter(Filter{ProjectID: "proj", FilePath: "src/auth/"})
+	if len(prefix.Must) != 2 || prefix.Must[1].Key != "file_dirs" || prefix.Must[1].Match.Value != "src/auth/" {
+		t.Errorf("Expected a file_dirs match for a directory, got %+v", prefix.Must)
+	}
+
+	payload := qdrantPointPayload(Point{ID: "a", Payload: Payload{FilePath: "src/auth/login.go"}})
+	dirs, _ := payload["file_dirs"].([]string)
+	if len(dirs) != 2 || dirs[0] != "src/" || dirs[1] != "src/auth/" {
+		t.Errorf("Expected ancestor directories stored, got %v", payload["file_dirs"])
+	}
+	if payload := qdrantPointPayload(Point{ID: "b", Payload: Payload{FilePath: "main.go"}}); payload["file_dirs"] != nil {
+		t.Errorf("Expected no file_dirs for a root file, got %v", payload["file_dirs"])
+	}
+}
+
+func TestBuildQdrantFilter_ExcludeGeneration(t *testing.T) {
+	f := buildQdrantFilter(Filter{ProjectID: "proj", ExcludeGeneration: "g2"})
+	if f == nil || len(f.Must) != 1 || len(f.MustNot) != 1 {
+		t.Fatalf("Expected 1 must and 1 must_not condition, got %+v", f)
+	}
+	if f.MustNot[0].Key != "generation" || f.MustNot[0].Match.Value != "g2" {
+		t.Errorf("Unexpected must_not condition: %+v", f.MustNot[0])
+	}
+}
+
+func TestBuildQdrantFilter_Custom(t *testing.T) {
+	f := buildQdrantFilter(Filter{Custom: map[string]string{"tier": "1", "service": "billing"}})
+	if f == nil || len(f.Must) != 2 {
+		t.Fatalf("Expected 2 must conditions, got %+v", f)
+	}
+	if f.Must[0].Key != "custom.service" || f.Must[0].Match.Value != "billing" || f.Must[1].Key != "custom.tier" {
+		t.Errorf("Expected sorted custom.<key> conditions, got %+v", f.Must)
+	}
+
+	payload := buildQdrantPayload(Point{ID: "a", Payload: Payload{Custom: map[string]string{"service": "billing"}}})
+	data, _ := json.Marshal(payload)
+	var decoded map[string]interface{}
+	json.Unmarshal(data, &decoded)
+	if got := parseQdrantPayload(decoded).Custom["service"]; got != "billing" {
+		t.Errorf("Expected custom metadata to round-trip, got %q", got)
+	}
+}
+
+func TestBuildQdrantFilter_LineRange(t *testing.T) {
+	data, _ := json.Marshal(buildQdrantFilter(Filter{ProjectID: "proj", FilePath: "server.go"}))
+	if strings.Contains(string(data), "range") {
+		t.Errorf("Expected no range clause without line bounds, got %s", data)
+	}
+
+	data, _ = json.Marshal(buildQdrantFilter(Filter{ProjectID: "proj", StartLineGte: 380, EndLineLte: 420}))
+	want := `{"must":[{"key":"project_id","match":{"value":"proj"}},` +
+		`{"key":"start_line","range":{"gte":380}},{"key":"end_line","range":{"lte":420}}]}`
+	if string(data) != want {
+		t.Errorf("filter JSON = %s, want %s", data, want)
+	}
+
+	data, _ = json.Marshal(buildQdrantFilter(Filter{EndLineLte: 50}))
+	if want := `{"must":[{"key":"end_line","range":{"lte":50}}]}`; string(data) != want {
+		t.Errorf("filter JSON = %s, want %s", data, want)
+	}
+}
+
+func TestQdrantClient_SendsConfiguredHeaders(t *testing.T) {
+	var seen []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Clone())
+		w.Write([]byte(`{"result":{"points":[]}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{
+		Endpoint:       srv.URL,
+		CollectionName: "code_chunks",
+		Headers:        map[string]string{"X-Tenant-ID": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+
+	if err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if _, err := client.Scroll(context.Background(), Filter{ProjectID: "proj"}, 10); err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+
+	if len(seen) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(seen))
+	}
+	for i, h := range seen {
+		if h.Get("X-Tenant-ID") != "acme" {
+			t.Errorf("Request %d missing X-Tenant-ID header: %v", i, h)
+		}
+	}
+}
+
+func TestQdrantClient_ScrollPage(t *testing.T) {
+	var offsets []interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		offsets = append(offsets, body["offset"])
+		if body["offset"] == nil {
+			w.Write([]byte(`{"result":{"points":[{"id":"a","payload":{}},{"id":"b","payload":{}}],"next_page_offset":"c"}}`))
+			return
+		}
+		w.Write([]byte(`{"result":{"points":[{"id":"c","payload":{}}],"next_page_offset":null}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks"})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+
+	var ids []string
+	err = ScrollAll(context.Background(), client, ScrollQuery{Filter: Filter{ProjectID: "proj"}, Limit: 2}, func(page []SearchResult) error {
+		for _, r := range page {
+			ids = append(ids, r.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScrollAll failed: %v", err)
+	}
+	if strings.Join(ids, ",") != "a,b,c" {
+		t.Errorf("Expected points a,b,c, got %v", ids)
+	}
+	if len(offsets) != 2 || offsets[0] != nil || offsets[1] != "c" {
+		t.Errorf("Expected the second request to continue at next_page_offset, got offsets %v", offsets)
+	}
+}
+
+func TestQdrantClient_ScrollPageFields(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"result":{"points":[]}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks"})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+	if _, _, err := client.ScrollPage(context.Background(), ScrollQuery{Limit: 10, Fields: []string{"language"}}); err != nil {
+		t.Fatalf("ScrollPage failed: %v", err)
+	}
+	if got, _ := json.Marshal(body["with_payload"]); string(got) != `["language"]` {
+		t.Errorf("Expected with_payload limited to the fields, got %s", got)
+	}
+}
+
+func TestQdrantClient_APIKey(t *testing.T) {
+	for _, apiKey := range []string{"secret", ""} {
+		var seen []http.Header
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = append(seen, r.Header.Clone())
+			w.Write([]byte(`{"result":{"points":[]}}`))
+		}))
+
+		client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks", APIKey: apiKey})
+		if err != nil {
+			t.Fatalf("NewQdrantClient failed: %v", err)
+		}
+		ctx := context.Background()
+		if err := client.Health(ctx); err != nil {
+			t.Fatalf("Health failed: %v", err)
+		}
+		if err := client.EnsureCollection(ctx, 4); err != nil {
+			t.Fatalf("EnsureCollection failed: %v", err)
+		}
+		if _, err := client.Scroll(ctx, Filter{ProjectID: "proj"}, 10); err != nil {
+			t.Fatalf("Scroll failed: %v", err)
+		}
+		srv.Close()
+
+		if len(seen) != 3 {
+			t.Fatalf("Expected 3 requests, got %d", len(seen))
+		}
+		for i, h := range seen {
+			if got := h.Get("api-key"); got != apiKey {
+				t.Errorf("Request %d: api-key = %q, want %q", i, got, apiKey)
+			}
+			if _, ok := h["Api-Key"]; apiKey == "" && ok {
+				t.Errorf("Request %d: expected no api-key header without a key", i)
+			}
+		}
+	}
+}
+
+func TestQdrantClient_UpsertBatches(t *testing.T) {
+	var calls, points int
+	failCall := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req qdrantUpsertRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		points += len(req.Points)
+		if calls == failCall {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write([]byte(`{"result":{}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks"})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+	batch := make([]Point, 1000)
+	for i := range batch {
+		batch[i] = Point{ID: fmt.Sprintf("proj:f.go:S%d", i), Vector: []float32{1}}
+	}
+
+	if err := client.Upsert(context.Background(), batch); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if calls != 4 || points != 1000 {
+		t.Errorf("Expected 1000 points in 4 requests, got %d in %d", points, calls)
+	}
+
+	// A failing batch is reported by index; the others are still sent
+	calls, points, failCall = 0, 0, 2
+	err = client.Upsert(context.Background(), batch)
+	if err == nil || !strings.Contains(err.Error(), "upsert batch 2/4") {
+		t.Errorf("Expected error naming batch 2/4, got %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("Expected remaining batches sent after a failure, got %d requests", calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls, failCall = 0, 0
+	if err := client.Upsert(ctx, batch); !errors.Is(err, context.Canceled) || calls != 0 {
+		t.Errorf("Expected cancellation before any request, got %v after %d requests", err, calls)
+	}
+}
+
+func TestQdrantClient_SoftDelete(t *testing.T) {
+	type request struct {
+		path string
+		body map[string]interface{}
+	}
+	var seen []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		seen = append(seen, request{r.URL.Path, body})
+		w.Write([]byte(`{"result":{"points":[]}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks", SoftDelete: true})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+	ctx := context.Background()
+
+	client.Delete(ctx, []string{"proj:a.go:main:1234"})
+	client.Scroll(ctx, Filter{ProjectID: "proj"}, 10)
+	client.PurgeDeleted(ctx, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	if len(seen) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(seen))
+	}
+
+	// Delete tombstones instead of removing
+	if seen[0].path != "/collections/code_chunks/points/payload" {
+		t.Errorf("Expected set-payload request, got %s", seen[0].path)
+	}
+	payload, _ := seen[0].body["payload"].(map[string]interface{})
+	if payload["deleted"] != true || payload["deleted_at"] == nil {
+		t.Errorf("Expected deleted tombstone payload, got %v", seen[0].body)
+	}
+
+	// Reads exclude tombstones with a must_not deleted condition
+	filter, _ := json.Marshal(seen[1].body["filter"])
+	if !strings.Contains(string(filter), `"must_not":[{"key":"deleted","match":{"value":true}}]`) {
+		t.Errorf("Expected must_not deleted condition, got %s", filter)
+	}
+
+	// Purge hard-deletes tombstones older than the cutoff
+	filter, _ = json.Marshal(seen[2].body["filter"])
+	if seen[2].path != "/collections/code_chunks/points/delete" ||
+		!strings.Contains(string(filter), `{"key":"deleted_at","range":{"lt":"2026-01-01T00:00:00Z"}}`) {
+		t.Errorf("Expected purge delete with deleted_at range, got %s %s", seen[2].path, filter)
+	}
+}
+
+func TestQdrantClient_Count(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"result":{"count":42}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks"})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+
+	count, err := client.Count(context.Background(), Filter{ProjectID: "proj"})
+	if err != nil || count != 42 {
+		t.Fatalf("Count = %d, %v; want 42", count, err)
+	}
+	filter, _ := json.Marshal(body["filter"])
+	if path != "/collections/code_chunks/points/count" || body["exact"] != true ||
+		!strings.Contains(string(filter), `{"key":"project_id","match":{"value":"proj"}}`) {
+		t.Errorf("Unexpected count request: %s %v", path, body)
+	}
+}
+
+func TestQdrantClient_UpdatePayload(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"result":{}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks"})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+
+	err = client.UpdatePayload(context.Background(), "proj:a.go:A:1", map[string]interface{}{"start_line": 4})
+	if err != nil {
+		t.Fatalf("UpdatePayload failed: %v", err)
+	}
+	if path != "/collections/code_chunks/points/payload" {
+		t.Errorf("Unexpected path %s", path)
+	}
+	if _, ok := body["vector"]; ok {
+		t.Errorf("Expected no vector in payload update, got %v", body)
+	}
+	payload, _ := body["payload"].(map[string]interface{})
+	if len(payload) != 1 || payload["start_line"] != float64(4) {
+		t.Errorf("Expected only the given fields, got %v", body["payload"])
+	}
+	points, _ := body["points"].([]interface{})
+	if len(points) != 1 || points[0] != stringToUUID("proj:a.go:A:1") {
+		t.Errorf("Expected the converted point ID, got %v", body["points"])
+	}
+}
+
+func TestQdrantClient_UpdatePayloads(t *testing.T) {
+	var path string
+	var body struct {
+		Operations []struct {
+			SetPayload struct {
+				Payload map[string]interface{} `json:"payload"`
+				Points  []string               `json:"points"`
+			} `json:"set_payload"`
+		} `json:"operations"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"result":[]}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks"})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+
+	scrolled := stringToUUID("proj:a.go:A:1")
+	err = client.UpdatePayloads(context.Background(), []Point{
+		{ID: scrolled, Payload: Payload{ProjectID: "proj", TokenCount: 5, PayloadVersion: 1}},
+		{ID: "proj:b.go:B:2", Payload: Payload{ProjectID: "proj", DeletedAt: "2026-01-01T00:00:00Z"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePayloads failed: %v", err)
+	}
+	if path != "/collections/code_chunks/points/batch" || len(body.Operations) != 2 {
+		t.Fatalf("Unexpected request: %s %+v", path, body)
+	}
+
+	// Scrolled UUIDs are used as-is and keep their stored original_id
+	first := body.Operations[0].SetPayload
+	if first.Points[0] != scrolled || first.Payload["original_id"] != nil {
+		t.Errorf("Expected UUID kept and original_id left alone, got %+v", first)
+	}
+	if first.Payload["token_count"] != float64(5) || first.Payload["payload_version"] != float64(1) {
+		t.Errorf("Expected versioned fields, got %v", first.Payload)
+	}
+
+	// Chunk IDs are converted, and tombstones stay tombstones
+	second := body.Operations[1].SetPayload
+	if second.Points[0] != stringToUUID("proj:b.go:B:2") || second.Payload["deleted"] != true {
+		t.Errorf("Expected converted ID and preserved tombstone, got %+v", second)
+	}
+}
+
+func TestQdrantClient_SearchBatch(t *testing.T) {
+	type request struct {
+		path string
+		body map[string]interface{}
+	}
+	var seen []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		seen = append(seen, request{r.URL.Path, body})
+		w.Write([]byte(`{"result":[` +
+			`[{"id":"a","score":0.9,"payload":{"project_id":"proj","file_path":"a.go"}}],` +
+			`[{"id":"b","score":0.8,"payload":{"project_id":"proj","file_path":"b.go"}},` +
+			`{"id":"c","score":0.7,"payload":{"project_id":"proj","file_path":"c.go"}}]]}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewQdrantClient(Config{Endpoint: srv.URL, CollectionName: "code_chunks"})
+	if err != nil {
+		t.Fatalf("NewQdrantClient failed: %v", err)
+	}
+
+	results, err := client.SearchBatch(context.Background(), []SearchQuery{
+		{Vector: []float32{0.1, 0.2}, TopK: 5, Filter: Filter{ProjectID: "proj"}},
+		{Vector: []float32{0.3, 0.4}, TopK: 3, Filter: Filter{ProjectID: "proj"}, WithVectors: true},
+	})
+	if err != nil {
+		t.Fatalf("SearchBatch failed: %v", err)
+	}
+
+	if len(seen) != 1 || seen[0].path != "/collections/code_chunks/points/search/batch" {
+		t.Fatalf("Expected a single batch search request, got %+v", seen)
+	}
+	searches, _ := seen[0].body["searches"].([]interface{})
+	if len(searches) != 2 {
+		t.Fatalf("Expected 2 searches in the batch body, got %v", seen[0].body)
+	}
+	if second, _ := searches[1].(map[string]interface{}); second["limit"] != float64(3) || second["with_vector"] != true {
+		t.Errorf("Expected per-query limit and with_vector, got %v", second)
+	}
+
+	if len(results) != 2 || len(results[0]) != 1 || len(results[1]) != 2 {
+		t.Fatalf("Expected result sets of 1 and 2 hits, got %+v", results)
+	}
+	if results[1][0].ID != "b" || results[1][0].Payload.FilePath != "b.go" {
+		t.Errorf("Expected parsed hits in query order, got %+v", results[1])
+	}
+}
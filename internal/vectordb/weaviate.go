@@ -0,0 +1,872 @@
+// Package vectordb provides Weaviate vector database implementation.
+package vectordb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// weaviateMaxResults is Weaviate's default QUERY_MAXIMUM_RESULTS: the most
+// objects a single query or batch delete touches.
+const weaviateMaxResults = 10000
+
+// errWeaviateNotFound is returned by doRequest for 404 responses.
+var errWeaviateNotFound = errors.New("not found")
+
+// WeaviateClient implements the Provider interface for Weaviate.
+// Points are stored as objects of one class; their properties use the same
+// names as the Qdrant payload.
+type WeaviateClient struct {
+	client     *http.Client
+	endpoint   string
+	className  string
+	apiKey     string
+	headers    map[string]string
+	softDelete bool
+}
+
+// weaviateProperty describes one class property.
+type weaviateProperty struct {
+	Name         string   `json:"name"`
+	DataType     []string `json:"dataType"`
+	Tokenization string   `json:"tokenization,omitempty"`
+}
+
+// weaviateProperties are the class properties created by EnsureCollection
+// and selected by queries. Identifier-like text uses field tokenization so
+// Equal filters match whole values.
+var weaviateProperties = []weaviateProperty{
+	{Name: "original_id", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "project_id", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "file_path", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "symbol", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "symbol_type", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "language", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "module", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "start_line", DataType: []string{"int"}},
+	{Name: "end_line", DataType: []string{"int"}},
+	{Name: "content", DataType: []string{"text"}, Tokenization: "word"},
+	{Name: "content_hash", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "exact_hash", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "indexed_at", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "last_modified", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "generation", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "owner", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "parent_symbol", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "git_ref", DataType: []string{"text"}, Tokenization: "field"},
+	{Name: "file_paths", DataType: []string{"text[]"}, Tokenization: "field"},
+	{Name: "imports", DataType: []string{"text[]"}, Tokenization: "field"},
+	{Name: "references", DataType: []string{"text[]"}, Tokenization: "field"},
+	{Name: "custom", DataType: []string{"text[]"}, Tokenization: "field"},
+	{Name: "token_count", DataType: []string{"int"}},
+	{Name: "payload_version", DataType: []string{"int"}},
+	{Name: "deleted", DataType: []string{"boolean"}},
+	{Name: "deleted_at", DataType: []string{"date"}},
+}
+
+// Weaviate API types
+
+type weaviateClass struct {
+	Class             string             `json:"class"`
+	Vectorizer        string             `json:"vectorizer"`
+	VectorIndexConfig map[string]string  `json:"vectorIndexConfig"`
+	Properties        []weaviateProperty `json:"properties"`
+}
+
+type weaviateObject struct {
+	Class      string                 `json:"class"`
+	ID         string                 `json:"id"`
+	Vector     []float32              `json:"vector,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type weaviateBatchObjectsRequest struct {
+	Objects []weaviateObject `json:"objects"`
+}
+
+type weaviateBatchObjectResult struct {
+	ID     string `json:"id"`
+	Result struct {
+		Errors *struct {
+			Error []struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"errors"`
+	} `json:"result"`
+}
+
+type weaviateBatchDeleteRequest struct {
+	Match struct {
+		Class string         `json:"class"`
+		Where *weaviateWhere `json:"where"`
+	} `json:"match"`
+	Output string `json:"output"`
+}
+
+type weaviateBatchDeleteResponse struct {
+	Results struct {
+		Matches    int `json:"matches"`
+		Limit      int `json:"limit"`
+		Successful int `json:"successful"`
+		Failed     int `json:"failed"`
+	} `json:"results"`
+}
+
+type weaviateGraphQLRequest struct {
+	Query string `json:"query"`
+}
+
+type weaviateGraphQLResponse struct {
+	Data   map[string]map[string][]map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// weaviateWhere is a Weaviate where filter. It is sent as JSON to the REST
+// API and rendered with graphQL for queries.
+type weaviateWhere struct {
+	Operator       string          `json:"operator"`
+	Path           []string        `json:"path,omitempty"`
+	ValueText      string          `json:"valueText,omitempty"`
+	ValueBoolean   *bool           `json:"valueBoolean,omitempty"`
+	ValueInt       *int            `json:"valueInt,omitempty"`
+	ValueDate      string          `json:"valueDate,omitempty"`
+	ValueTextArray []string        `json:"valueTextArray,omitempty"`
+	Operands       []weaviateWhere `json:"operands,omitempty"`
+}
+
+// weaviateDeletedTrue matches soft-deleted tombstones.
+var weaviateDeletedTrue = true
+
+// NewWeaviateClient creates a new Weaviate vector database client.
+func NewWeaviateClient(cfg Config) (*WeaviateClient, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("weaviate endpoint is required")
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &WeaviateClient{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		endpoint:   strings.TrimRight(cfg.Endpoint, "/"),
+		className:  weaviateClassName(cfg.CollectionName),
+		apiKey:     cfg.APIKey,
+		headers:    cfg.Headers,
+		softDelete: cfg.SoftDelete,
+	}, nil
+}
+
+// weaviateClassName converts a collection name into a Weaviate class name,
+// which must start with an upper-case letter: "code_chunks" -> "CodeChunks".
+func weaviateClassName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Upsert inserts or updates objects with the batch objects endpoint.
+func (w *WeaviateClient) Upsert(ctx context.Context, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	objects := make([]weaviateObject, len(points))
+	for i, p := range points {
+		// Weaviate object IDs must be UUIDs
+		objects[i] = weaviateObject{
+			Class:      w.className,
+			ID:         stringToUUID(p.ID),
+			Vector:     p.Vector,
+			Properties: buildWeaviateProperties(p),
+		}
+	}
+
+	var results []weaviateBatchObjectResult
+	err := w.doRequest(ctx, http.MethodPost, "/v1/batch/objects",
+		weaviateBatchObjectsRequest{Objects: objects}, &results)
+	if err != nil {
+		return err
+	}
+
+	// The batch endpoint answers 200 and reports failures per object
+	for _, r := range results {
+		if r.Result.Errors != nil && len(r.Result.Errors.Error) > 0 {
+			return fmt.Errorf("failed to upsert object %s: %s", r.ID, r.Result.Errors.Error[0].Message)
+		}
+	}
+	return nil
+}
+
+// buildWeaviateProperties converts a point's metadata into object properties.
+// Custom metadata is flattened to "key=value" entries of a text[] property,
+// since Weaviate can't filter on nested object fields.
+func buildWeaviateProperties(p Point) map[string]interface{} {
+	props := buildQdrantPayload(p)
+	if custom := p.Payload.Custom; len(custom) > 0 {
+		entries := make([]string, 0, len(custom))
+		for _, key := range sortedKeys(custom) {
+			entries = append(entries, key+"="+custom[key])
+		}
+		props["custom"] = entries
+	}
+	return props
+}
+
+// parseWeaviateProperties converts object properties into a Payload.
+func parseWeaviateProperties(props map[string]interface{}) Payload {
+	payload := parseQdrantPayload(props)
+	for _, entry := range getStringSlice(props, "custom") {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			if payload.Custom == nil {
+				payload.Custom = make(map[string]string)
+			}
+			payload.Custom[key] = value
+		}
+	}
+	return payload
+}
+
+// Search performs similarity search with optional filters.
+func (w *WeaviateClient) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	results, err := w.SearchBatch(ctx, []SearchQuery{query})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// SearchBatch runs several nearVector searches as aliased queries of a
+// single GraphQL request.
+func (w *WeaviateClient) SearchBatch(ctx context.Context, queries []SearchQuery) ([][]SearchResult, error) {
+	if len(queries) == 0 {
+		return [][]SearchResult{}, nil
+	}
+
+	var query strings.Builder
+	query.WriteString("{ Get {")
+	for i, q := range queries {
+		fmt.Fprintf(&query, " q%d: %s", i, w.searchQuery(q))
+	}
+	query.WriteString(" } }")
+
+	data, err := w.graphQL(ctx, query.String())
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]SearchResult, len(queries))
+	for i := range queries {
+		objects := data["Get"][fmt.Sprintf("q%d", i)]
+		results[i] = toWeaviateResults(objects)
+	}
+	return results, nil
+}
+
+// searchQuery builds the GraphQL Get selection for one search query.
+// A score threshold becomes a maximum cosine distance.
+func (w *WeaviateClient) searchQuery(query SearchQuery) string {
+	var near strings.Builder
+	near.WriteString("{vector: [")
+	for i, v := range query.Vector {
+		if i > 0 {
+			near.WriteString(", ")
+		}
+		near.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	near.WriteString("]")
+	if query.ScoreThreshold > 0 {
+		near.WriteString(", distance: ")
+		near.WriteString(strconv.FormatFloat(float64(1-query.ScoreThreshold), 'g', -1, 32))
+	}
+	near.WriteString("}")
+
+	args := []string{"nearVector: " + near.String()}
+	if query.TopK > 0 {
+		args = append(args, fmt.Sprintf("limit: %d", query.TopK))
+	}
+	if where := w.readWhere(query.Filter); where != nil {
+		args = append(args, "where: "+where.graphQL())
+	}
+
+	additional := "id distance"
+	if query.WithVectors {
+		additional += " vector"
+	}
+	return fmt.Sprintf("%s(%s) { %s _additional { %s } }",
+		w.className, strings.Join(args, ", "), weaviateSelection(), additional)
+}
+
+// weaviateSelection lists the class properties for a GraphQL selection set.
+func weaviateSelection() string {
+	names := make([]string, len(weaviateProperties))
+	for i, p := range weaviateProperties {
+		names[i] = p.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// toWeaviateResults converts GraphQL Get objects into search results.
+// Cosine distance is converted back to a similarity score.
+func toWeaviateResults(objects []map[string]interface{}) []SearchResult {
+	results := make([]SearchResult, len(objects))
+	for i, obj := range objects {
+		additional, _ := obj["_additional"].(map[string]interface{})
+		result := SearchResult{
+			ID:      getString(additional, "id"),
+			Payload: parseWeaviateProperties(obj),
+		}
+		if distance, ok := additional["distance"].(float64); ok {
+			result.Score = float32(1 - distance)
+		}
+		if vector, ok := additional["vector"].([]interface{}); ok {
+			result.Vector = make([]float32, 0, len(vector))
+			for _, v := range vector {
+				if f, ok := v.(float64); ok {
+					result.Vector = append(result.Vector, float32(f))
+				}
+			}
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// Scroll returns up to limit objects matching a filter (all when limit is
+// 0), without scoring. Limits above weaviateMaxResults are fetched in pages.
+func (w *WeaviateClient) Scroll(ctx context.Context, filter Filter, limit int) ([]SearchResult, error) {
+	results := []SearchResult{}
+	query := ScrollQuery{Filter: filter}
+	for {
+		query.Limit = weaviateMaxResults
+		if remaining := limit - len(results); limit > 0 && remaining < query.Limit {
+			query.Limit = remaining
+		}
+		page, next, err := w.ScrollPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, page...)
+		if next == "" || (limit > 0 && len(results) >= limit) {
+			return results, nil
+		}
+		query.Cursor = next
+	}
+}
+
+// ScrollPage returns one page of at most weaviateMaxResults objects.
+// Unfiltered pages follow Weaviate's after cursor (UUID order). The cursor
+// API rejects where filters, so filtered pages are sorted by original_id
+// and continue after the page's last one instead.
+func (w *WeaviateClient) ScrollPage(ctx context.Context, query ScrollQuery) ([]SearchResult, string, error) {
+	limit := query.Limit
+	if limit <= 0 || limit > weaviateMaxResults {
+		limit = weaviateMaxResults
+	}
+
+	where := w.readWhere(query.Filter)
+	var extra []string
+	if where == nil {
+		if query.Cursor != "" {
+			extra = append(extra, "after: "+graphQLString(query.Cursor))
+		}
+	} else {
+		if query.Cursor != "" {
+			where = weaviateAnd(*where, weaviateWhere{
+				Operator:  "GreaterThan",
+				Path:      []string{"original_id"},
+				ValueText: query.Cursor,
+			})
+		}
+		extra = append(extra, `sort: [{path: ["original_id"], order: asc}]`)
+	}
+
+	selection := weaviateSelection()
+	if query.Fields != nil {
+		// original_id is the cursor of filtered pages
+		selection = strings.Join(append([]string{"original_id"}, query.Fields...), " ")
+	}
+
+	data, err := w.graphQL(ctx, w.scrollQuery(where, limit, selection, extra...))
+	if err != nil {
+		return nil, "", err
+	}
+	objects := data["Get"][w.className]
+	if len(objects) < limit {
+		return toWeaviateResults(objects), "", nil
+	}
+
+	last := objects[len(objects)-1]
+	if where == nil {
+		additional, _ := last["_additional"].(map[string]interface{})
+		return toWeaviateResults(objects), getString(additional, "id"), nil
+	}
+	return toWeaviateResults(objects), getString(last, "original_id"), nil
+}
+
+// scrollQuery builds a GraphQL Get query without a vector search. Extra
+// arguments (e.g. a cursor or sort) are appended as given.
+func (w *WeaviateClient) scrollQuery(where *weaviateWhere, limit int, selection string, extra ...string) string {
+	var args []string
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("limit: %d", limit))
+	}
+	if where != nil {
+		args = append(args, "where: "+where.graphQL())
+	}
+	args = append(args, extra...)
+
+	class := w.className
+	if len(args) > 0 {
+		class += "(" + strings.Join(args, ", ") + ")"
+	}
+	return fmt.Sprintf("{ Get { %s { %s _additional { id } } } }", class, selection)
+}
+
+// Count returns the number of objects matching a filter via an Aggregate query.
+func (w *WeaviateClient) Count(ctx context.Context, filter Filter) (int, error) {
+	class := w.className
+	if where := w.readWhere(filter); where != nil {
+		class += "(where: " + where.graphQL() + ")"
+	}
+
+	data, err := w.graphQL(ctx, fmt.Sprintf("{ Aggregate { %s { meta { count } } } }", class))
+	if err != nil {
+		return 0, err
+	}
+
+	groups := data["Aggregate"][w.className]
+	if len(groups) == 0 {
+		return 0, nil
+	}
+	meta, _ := groups[0]["meta"].(map[string]interface{})
+	return getInt(meta, "count"), nil
+}
+
+// buildWeaviateWhere converts a Filter into a Weaviate where clause.
+// Returns nil when no filter field is set.
+func buildWeaviateWhere(f Filter) *weaviateWhere {
+	fields := []struct{ key, value string }{
+		{"project_id", f.ProjectID},
+		{"module", f.Module},
+		{"language", f.Language},
+		{"symbol_type", f.SymbolType},
+		{"symbol", f.Symbol},
+		{"owner", f.Owner},
+	}
+
+	var operands []weaviateWhere
+	if f.FilePath != "" {
+		where := weaviateWhere{Operator: "Equal", Path: []string{"file_path"}, ValueText: f.FilePath}
+		if IsPathPrefix(f.FilePath) {
+			where.Operator, where.ValueText = "Like", f.FilePath+"*"
+		}
+		operands = append(operands, where)
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			operands = append(operands, weaviateWhere{
+				Operator:  "Equal",
+				Path:      []string{field.key},
+				ValueText: field.value,
+			})
+		}
+	}
+	for _, key := range sortedKeys(f.Custom) {
+		operands = append(operands, weaviateWhere{
+			Operator:  "Equal",
+			Path:      []string{"custom"},
+			ValueText: key + "=" + f.Custom[key],
+		})
+	}
+	if f.StartLineGte > 0 {
+		operands = append(operands, weaviateWhere{
+			Operator: "GreaterThanEqual",
+			Path:     []string{"start_line"},
+			ValueInt: &f.StartLineGte,
+		})
+	}
+	if f.EndLineLte > 0 {
+		operands = append(operands, weaviateWhere{
+			Operator: "LessThanEqual",
+			Path:     []string{"end_line"},
+			ValueInt: &f.EndLineLte,
+		})
+	}
+	if f.ExcludeGeneration != "" {
+		operands = append(operands, weaviateWhere{
+			Operator:  "NotEqual",
+			Path:      []string{"generation"},
+			ValueText: f.ExcludeGeneration,
+		})
+	}
+
+	return weaviateAnd(operands...)
+}
+
+// weaviateAnd combines where clauses, unwrapping a single operand.
+// Returns nil when there are none.
+func weaviateAnd(operands ...weaviateWhere) *weaviateWhere {
+	switch len(operands) {
+	case 0:
+		return nil
+	case 1:
+		return &operands[0]
+	default:
+		return &weaviateWhere{Operator: "And", Operands: operands}
+	}
+}
+
+// notDeleted excludes soft-deleted tombstones. NotEqual also matches
+// objects that never had the deleted property set.
+func notDeleted() weaviateWhere {
+	return weaviateWhere{Operator: "NotEqual", Path: []string{"deleted"}, ValueBoolean: &weaviateDeletedTrue}
+}
+
+// readWhere builds the where clause for searches and scrolls. In soft-delete
+// mode tombstones are excluded unless the filter asks for them.
+func (w *WeaviateClient) readWhere(f Filter) *weaviateWhere {
+	where := buildWeaviateWhere(f)
+	if !w.softDelete || f.IncludeDeleted {
+		return where
+	}
+	if where == nil {
+		return weaviateAnd(notDeleted())
+	}
+	return weaviateAnd(*where, notDeleted())
+}
+
+// graphQL renders the where clause as a GraphQL input object.
+func (wh weaviateWhere) graphQL() string {
+	var fields []string
+	if len(wh.Path) > 0 {
+		fields = append(fields, "path: "+graphQLStrings(wh.Path))
+	}
+	fields = append(fields, "operator: "+wh.Operator)
+	switch {
+	case wh.ValueBoolean != nil:
+		fields = append(fields, "valueBoolean: "+strconv.FormatBool(*wh.ValueBoolean))
+	case wh.ValueInt != nil:
+		fields = append(fields, "valueInt: "+strconv.Itoa(*wh.ValueInt))
+	case wh.ValueDate != "":
+		fields = append(fields, "valueDate: "+graphQLString(wh.ValueDate))
+	case wh.ValueTextArray != nil:
+		fields = append(fields, "valueTextArray: "+graphQLStrings(wh.ValueTextArray))
+	case len(wh.Path) > 0:
+		fields = append(fields, "valueText: "+graphQLString(wh.ValueText))
+	}
+	if len(wh.Operands) > 0 {
+		operands := make([]string, len(wh.Operands))
+		for i, op := range wh.Operands {
+			operands[i] = op.graphQL()
+		}
+		fields = append(fields, "operands: ["+strings.Join(operands, ", ")+"]")
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// graphQLString quotes s as a GraphQL string literal; JSON escaping is valid GraphQL.
+func graphQLString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// graphQLStrings renders a list of GraphQL string literals.
+func graphQLStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = graphQLString(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// UpdatePayloads merges the properties of existing objects, one PATCH per
+// object. IDs may be original chunk IDs or the UUIDs Scroll returns; fields
+// left empty keep their stored value.
+func (w *WeaviateClient) UpdatePayloads(ctx context.Context, points []Point) error {
+	for _, p := range points {
+		id, props := p.ID, buildWeaviateProperties(p)
+		if uuidPattern.MatchString(id) {
+			// Scroll IDs are already UUIDs; keep the stored original_id
+			delete(props, "original_id")
+		} else {
+			id = stringToUUID(id)
+		}
+		if err := w.patchObject(ctx, id, props); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdatePayload merges fields into one object's properties.
+func (w *WeaviateClient) UpdatePayload(ctx context.Context, id string, fields map[string]interface{}) error {
+	if !uuidPattern.MatchString(id) {
+		id = stringToUUID(id)
+	}
+	props := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		props[key] = value
+	}
+	if custom, ok := fields["custom"].(map[string]string); ok {
+		// Custom metadata is stored flattened, as in buildWeaviateProperties
+		props["custom"] = buildWeaviateProperties(Point{Payload: Payload{Custom: custom}})["custom"]
+	}
+	return w.patchObject(ctx, id, props)
+}
+
+// patchObject merges properties into one object.
+func (w *WeaviateClient) patchObject(ctx context.Context, id string, props map[string]interface{}) error {
+	return w.doRequest(ctx, http.MethodPatch,
+		fmt.Sprintf("/v1/objects/%s/%s", w.className, id),
+		weaviateObject{Class: w.className, ID: id, Properties: props}, nil)
+}
+
+// Delete removes objects by their IDs.
+func (w *WeaviateClient) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	uuids := make([]string, len(ids))
+	for i, id := range ids {
+		uuids[i] = stringToUUID(id)
+	}
+
+	if w.softDelete {
+		return w.tombstone(ctx, uuids)
+	}
+
+	return w.deleteWhere(ctx, &weaviateWhere{
+		Operator:       "ContainsAny",
+		Path:           []string{"id"},
+		ValueTextArray: uuids,
+	})
+}
+
+// DeleteByFilter removes objects matching a filter.
+func (w *WeaviateClient) DeleteByFilter(ctx context.Context, filter Filter) error {
+	where := buildWeaviateWhere(filter)
+
+	if w.softDelete {
+		// Skip existing tombstones so their deleted_at (and retention) is kept.
+		// Tombstoned objects drop out of the filter, so each pass makes progress.
+		if where == nil {
+			where = weaviateAnd(notDeleted())
+		} else {
+			where = weaviateAnd(*where, notDeleted())
+		}
+		for {
+			data, err := w.graphQL(ctx, w.scrollQuery(where, weaviateMaxResults, "original_id"))
+			if err != nil {
+				return err
+			}
+			objects := data["Get"][w.className]
+			ids := make([]string, len(objects))
+			for i, obj := range objects {
+				additional, _ := obj["_additional"].(map[string]interface{})
+				ids[i] = getString(additional, "id")
+			}
+			if err := w.tombstone(ctx, ids); err != nil {
+				return err
+			}
+			if len(objects) < weaviateMaxResults {
+				return nil
+			}
+		}
+	}
+
+	if where == nil {
+		// An empty filter keeps the match-all semantics of the other providers
+		where = &weaviateWhere{Operator: "Like", Path: []string{"original_id"}, ValueText: "*"}
+	}
+	return w.deleteWhere(ctx, where)
+}
+
+// tombstone marks the given objects as deleted without removing them.
+// Objects that no longer exist are skipped.
+func (w *WeaviateClient) tombstone(ctx context.Context, uuids []string) error {
+	props := map[string]interface{}{
+		"deleted":    true,
+		"deleted_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, id := range uuids {
+		if err := w.patchObject(ctx, id, props); err != nil && !errors.Is(err, errWeaviateNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeDeleted hard-deletes tombstones whose deleted_at is before olderThan.
+func (w *WeaviateClient) PurgeDeleted(ctx context.Context, olderThan time.Time) error {
+	return w.deleteWhere(ctx, weaviateAnd(
+		weaviateWhere{Operator: "Equal", Path: []string{"deleted"}, ValueBoolean: &weaviateDeletedTrue},
+		weaviateWhere{Operator: "LessThan", Path: []string{"deleted_at"}, ValueDate: olderThan.UTC().Format(time.RFC3339)},
+	))
+}
+
+// deleteWhere batch-deletes all objects matching where. A batch delete
+// touches at most the server's query limit, so it repeats until a pass
+// matches fewer objects than that.
+func (w *WeaviateClient) deleteWhere(ctx context.Context, where *weaviateWhere) error {
+	req := weaviateBatchDeleteRequest{Output: "minimal"}
+	req.Match.Class = w.className
+	req.Match.Where = where
+
+	for {
+		var resp weaviateBatchDeleteResponse
+		if err := w.doRequest(ctx, http.MethodDelete, "/v1/batch/objects", req, &resp); err != nil {
+			return err
+		}
+		r := resp.Results
+		if r.Failed > 0 {
+			return fmt.Errorf("failed to delete %d of %d objects", r.Failed, r.Matches)
+		}
+		if r.Limit == 0 || r.Matches < r.Limit || r.Successful == 0 {
+			return nil
+		}
+	}
+}
+
+// EnsureCollection creates the class if it doesn't exist. Weaviate takes the
+// vector dimensions from the first object, so dimensions is not sent.
+func (w *WeaviateClient) EnsureCollection(ctx context.Context, dimensions int) error {
+	err := w.doRequest(ctx, http.MethodGet, "/v1/schema/"+w.className, nil, nil)
+	if err == nil {
+		return nil // Class exists
+	}
+	if !errors.Is(err, errWeaviateNotFound) {
+		return fmt.Errorf("failed to check class: %w", err)
+	}
+
+	reqBody := weaviateClass{
+		Class:             w.className,
+		Vectorizer:        "none",
+		VectorIndexConfig: map[string]string{"distance": "cosine"},
+		Properties:        weaviateProperties,
+	}
+	return w.doRequest(ctx, http.MethodPost, "/v1/schema", reqBody, nil)
+}
+
+// Health checks if Weaviate is ready to serve requests.
+func (w *WeaviateClient) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		w.endpoint+"/v1/.well-known/ready", nil)
+	if err != nil {
+		return err
+	}
+	w.setAuth(req)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("weaviate health check failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("weaviate returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Capabilities returns the optional features supported by the Weaviate client.
+func (w *WeaviateClient) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{
+		Scroll: true,
+		Count:  true,
+	}
+}
+
+// Close releases resources (no-op for Weaviate HTTP client).
+func (w *WeaviateClient) Close() error {
+	return nil
+}
+
+// graphQL runs a GraphQL query and returns its data. Weaviate reports query
+// errors in the response body with status 200.
+func (w *WeaviateClient) graphQL(ctx context.Context, query string) (map[string]map[string][]map[string]interface{}, error) {
+	var resp weaviateGraphQLResponse
+	if err := w.doRequest(ctx, http.MethodPost, "/v1/graphql", weaviateGraphQLRequest{Query: query}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("graphql query failed: %s", resp.Errors[0].Message)
+	}
+	return resp.Data, nil
+}
+
+// doRequest performs an HTTP request to Weaviate.
+func (w *WeaviateClient) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		bodyReader = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, w.endpoint+path, bodyReader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w.setAuth(req)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("request failed with status %d: %w", resp.StatusCode, errWeaviateNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// setAuth attaches the API key as a bearer token, when configured, and extra headers.
+func (w *WeaviateClient) setAuth(req *http.Request) {
+	if w.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+w.apiKey)
+	}
+	setHeaders(req, w.headers)
+}
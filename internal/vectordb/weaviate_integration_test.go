@@ -0,0 +1,79 @@
+//go:build integration
+
+package vectordb
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// TestWeaviateClient_Integration runs against a live Weaviate instance:
+//
+//	WEAVIATE_ENDPOINT=http://localhost:8080 go test -tags integration ./internal/vectordb/
+func TestWeaviateClient_Integration(t *testing.T) {
+	endpoint := os.Getenv("WEAVIATE_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("WEAVIATE_ENDPOINT not set")
+	}
+
+	client, err := NewWeaviateClient(Config{
+		Endpoint:       endpoint,
+		CollectionName: "indexer_integration_test",
+		APIKey:         os.Getenv("WEAVIATE_API_KEY"),
+	})
+	if err != nil {
+		t.Fatalf("NewWeaviateClient failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := client.Health(ctx); err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if err := client.EnsureCollection(ctx, 3); err != nil {
+		t.Fatalf("EnsureCollection failed: %v", err)
+	}
+	t.Cleanup(func() {
+		client.doRequest(context.Background(), "DELETE", "/v1/schema/"+client.className, nil, nil)
+	})
+
+	points := []Point{
+		{ID: "proj:a.go:A", Vector: []float32{1, 0, 0}, Payload: Payload{ProjectID: "proj", FilePath: "a.go", Symbol: "A", Language: "go"}},
+		{ID: "proj:b.py:B", Vector: []float32{0, 1, 0}, Payload: Payload{ProjectID: "proj", FilePath: "b.py", Symbol: "B", Language: "python"}},
+		{ID: "other:c.go:C", Vector: []float32{1, 0, 0}, Payload: Payload{ProjectID: "other", FilePath: "c.go", Symbol: "C", Language: "go"}},
+	}
+	if err := client.Upsert(ctx, points); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	results, err := client.Search(ctx, SearchQuery{
+		Vector: []float32{1, 0, 0},
+		TopK:   5,
+		Filter: Filter{ProjectID: "proj"},
+	})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Payload.Symbol != "A" {
+		t.Fatalf("Expected A first among 2 proj results, got %+v", results)
+	}
+
+	if n, err := client.Count(ctx, Filter{Language: "go"}); err != nil || n != 2 {
+		t.Errorf("Count(go) = %d, %v; want 2", n, err)
+	}
+
+	if err := client.DeleteByFilter(ctx, Filter{ProjectID: "proj", FilePath: "a.go"}); err != nil {
+		t.Fatalf("DeleteByFilter failed: %v", err)
+	}
+	if err := client.Delete(ctx, []string{"other:c.go:C"}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	remaining, err := client.Scroll(ctx, Filter{}, 10)
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].Payload.Symbol != "B" {
+		t.Errorf("Expected only B to remain, got %+v", remaining)
+	}
+}
@@ -0,0 +1,431 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWeaviateClient(t *testing.T, endpoint string, softDelete bool) *WeaviateClient {
+	t.Helper()
+	client, err := NewWeaviateClient(Config{
+		Endpoint:       endpoint,
+		CollectionName: "code_chunks",
+		APIKey:         "secret",
+		SoftDelete:     softDelete,
+	})
+	if err != nil {
+		t.Fatalf("NewWeaviateClient failed: %v", err)
+	}
+	return client
+}
+
+func TestWeaviateClassName(t *testing.T) {
+	cases := map[string]string{
+		"code_chunks": "CodeChunks",
+		"chunks":      "Chunks",
+		"my-index-2":  "MyIndex2",
+	}
+	for name, want := range cases {
+		if got := weaviateClassName(name); got != want {
+			t.Errorf("weaviateClassName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestBuildWeaviateWhere(t *testing.T) {
+	if w := buildWeaviateWhere(Filter{}); w != nil {
+		t.Errorf("Expected nil where for empty filter, got %+v", w)
+	}
+
+	single := buildWeaviateWhere(Filter{ProjectID: "proj"})
+	if got, want := single.graphQL(), `{path: ["project_id"], operator: Equal, valueText: "proj"}`; got != want {
+		t.Errorf("graphQL() = %s, want %s", got, want)
+	}
+
+	where := buildWeaviateWhere(Filter{ProjectID: "proj", Language: "go", ExcludeGeneration: "g2"})
+	want := `{operator: And, operands: [` +
+		`{path: ["project_id"], operator: Equal, valueText: "proj"}, ` +
+		`{path: ["language"], operator: Equal, valueText: "go"}, ` +
+		`{path: ["generation"], operator: NotEqual, valueText: "g2"}]}`
+	if got := where.graphQL(); got != want {
+		t.Errorf("graphQL() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The REST API takes the same clause as JSON
+	data, err := json.Marshal(single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"operator":"Equal","path":["project_id"],"valueText":"proj"}`; got != want {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
+
+func TestWeaviateCustomMetadata(t *testing.T) {
+	where := buildWeaviateWhere(Filter{Custom: map[string]string{"service": "billing"}})
+	if got, want := where.graphQL(), `{path: ["custom"], operator: Equal, valueText: "service=billing"}`; got != want {
+		t.Errorf("graphQL() = %s, want %s", got, want)
+	}
+
+	props := buildWeaviateProperties(Point{ID: "a", Payload: Payload{Custom: map[string]string{"service": "billing", "tier": "1"}}})
+	data, _ := json.Marshal(props)
+	var decoded map[string]interface{}
+	json.Unmarshal(data, &decoded)
+	custom := parseWeaviateProperties(decoded).Custom
+	if len(custom) != 2 || custom["service"] != "billing" || custom["tier"] != "1" {
+		t.Errorf("Expected custom metadata to round-trip, got %v", custom)
+	}
+}
+
+func TestBuildWeaviateWhere_LineRange(t *testing.T) {
+	where := buildWeaviateWhere(Filter{StartLineGte: 10, EndLineLte: 20})
+	want := `{operator: And, operands: [` +
+		`{path: ["start_line"], operator: GreaterThanEqual, valueInt: 10}, ` +
+		`{path: ["end_line"], operator: LessThanEqual, valueInt: 20}]}`
+	if got := where.graphQL(); got != want {
+		t.Errorf("graphQL() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWeaviateWhere_EscapesStrings(t *testing.T) {
+	where := buildWeaviateWhere(Filter{FilePath: `a "quoted"\path.go`})
+	want := `{path: ["file_path"], operator: Equal, valueText: "a \"quoted\"\\path.go"}`
+	if got := where.graphQL(); got != want {
+		t.Errorf("graphQL() = %s, want %s", got, want)
+	}
+}
+
+func TestWeaviateClient_ReadWhereExcludesTombstones(t *testing.T) {
+	client := newTestWeaviateClient(t, "http://localhost:8080", true)
+
+	got := client.readWhere(Filter{}).graphQL()
+	if want := `{path: ["deleted"], operator: NotEqual, valueBoolean: true}`; got != want {
+		t.Errorf("readWhere(empty) = %s, want %s", got, want)
+	}
+
+	got = client.readWhere(Filter{ProjectID: "proj"}).graphQL()
+	if !strings.HasPrefix(got, "{operator: And") || !strings.Contains(got, `path: ["deleted"]`) {
+		t.Errorf("Expected project filter combined with tombstone exclusion, got %s", got)
+	}
+
+	if w := client.readWhere(Filter{IncludeDeleted: true}); w != nil {
+		t.Errorf("Expected no where clause with IncludeDeleted, got %s", w.graphQL())
+	}
+}
+
+func TestWeaviateClient_SearchQuery(t *testing.T) {
+	client := newTestWeaviateClient(t, "http://localhost:8080", false)
+
+	got := client.searchQuery(SearchQuery{
+		Vector:         []float32{0.5, -0.25},
+		TopK:           3,
+		ScoreThreshold: 0.75,
+		Filter:         Filter{ProjectID: "proj"},
+		WithVectors:    true,
+	})
+	for _, want := range []string{
+		`CodeChunks(nearVector: {vector: [0.5, -0.25], distance: 0.25}, limit: 3, where: {path: ["project_id"], operator: Equal, valueText: "proj"}) {`,
+		" project_id file_path ",
+		"_additional { id distance vector } }",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("searchQuery() missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWeaviateClient_Search(t *testing.T) {
+	var body struct{ Query string }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/graphql" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want bearer API key", got)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, `{"data": {"Get": {
+			"q0": [{"project_id": "proj", "file_path": "main.go", "start_line": 3,
+				"_additional": {"id": "0000-id", "distance": 0.2, "vector": [0.1, 0.2]}}],
+			"q1": []}}}`)
+	}))
+	defer srv.Close()
+
+	client := newTestWeaviateClient(t, srv.URL, false)
+	results, err := client.SearchBatch(context.Background(), []SearchQuery{
+		{Vector: []float32{1}, TopK: 1},
+		{Vector: []float32{2}, TopK: 1},
+	})
+	if err != nil {
+		t.Fatalf("SearchBatch failed: %v", err)
+	}
+	if !strings.Contains(body.Query, "q0: CodeChunks(") || !strings.Contains(body.Query, "q1: CodeChunks(") {
+		t.Errorf("Expected aliased queries, got %s", body.Query)
+	}
+	if len(results) != 2 || len(results[0]) != 1 || len(results[1]) != 0 {
+		t.Fatalf("Unexpected result shape: %+v", results)
+	}
+
+	r := results[0][0]
+	if r.ID != "0000-id" || r.Payload.FilePath != "main.go" || r.Payload.StartLine != 3 {
+		t.Errorf("Unexpected result %+v", r)
+	}
+	if r.Score < 0.79 || r.Score > 0.81 {
+		t.Errorf("Expected score 1-distance = 0.8, got %v", r.Score)
+	}
+	if len(r.Vector) != 2 {
+		t.Errorf("Expected stored vector, got %v", r.Vector)
+	}
+}
+
+func TestWeaviateClient_ScrollPage(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Query string }
+		json.NewDecoder(r.Body).Decode(&body)
+		queries = append(queries, body.Query)
+		io.WriteString(w, `{"data": {"Get": {"CodeChunks": [
+			{"original_id": "proj:a.go:A:1", "_additional": {"id": "uuid-1"}},
+			{"original_id": "proj:b.go:B:1", "_additional": {"id": "uuid-2"}}]}}}`)
+	}))
+	defer srv.Close()
+
+	client := newTestWeaviateClient(t, srv.URL, false)
+	ctx := context.Background()
+
+	// Unfiltered pages follow the after cursor
+	_, next, err := client.ScrollPage(ctx, ScrollQuery{Limit: 2})
+	if err != nil || next != "uuid-2" {
+		t.Fatalf("Expected cursor uuid-2, got %q (%v)", next, err)
+	}
+	client.ScrollPage(ctx, ScrollQuery{Limit: 2, Cursor: next})
+	if want := `CodeChunks(limit: 2, after: "uuid-2")`; !strings.Contains(queries[1], want) {
+		t.Errorf("Expected %s in query:\n%s", want, queries[1])
+	}
+
+	// Filtered pages continue after the last original_id
+	_, next, _ = client.ScrollPage(ctx, ScrollQuery{Filter: Filter{ProjectID: "proj"}, Limit: 2})
+	if next != "proj:b.go:B:1" {
+		t.Fatalf("Expected cursor proj:b.go:B:1, got %q", next)
+	}
+	client.ScrollPage(ctx, ScrollQuery{Filter: Filter{ProjectID: "proj"}, Limit: 2, Cursor: next})
+	for _, want := range []string{
+		`{path: ["original_id"], operator: GreaterThan, valueText: "proj:b.go:B:1"}`,
+		`sort: [{path: ["original_id"], order: asc}]`,
+	} {
+		if !strings.Contains(queries[3], want) {
+			t.Errorf("Expected %s in query:\n%s", want, queries[3])
+		}
+	}
+	if strings.Contains(queries[3], "after:") {
+		t.Errorf("Expected no after cursor with a where filter:\n%s", queries[3])
+	}
+
+	// A short page is the last one
+	if _, next, _ := client.ScrollPage(ctx, ScrollQuery{Limit: 3}); next != "" {
+		t.Errorf("Expected no cursor after a short page, got %q", next)
+	}
+}
+
+func TestWeaviateClient_ScrollAboveMaxResults(t *testing.T) {
+	const stored = 25000
+	limitRe := regexp.MustCompile(`limit: (\d+)`)
+	afterRe := regexp.MustCompile(`after: "uuid-(\d+)"`)
+
+	var limits []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Query string }
+		json.NewDecoder(r.Body).Decode(&body)
+		limit, _ := strconv.Atoi(limitRe.FindStringSubmatch(body.Query)[1])
+		limits = append(limits, limit)
+		if limit > weaviateMaxResults {
+			io.WriteString(w, `{"errors": [{"message": "query maximum results exceeded"}]}`)
+			return
+		}
+		start := 0
+		if m := afterRe.FindStringSubmatch(body.Query); m != nil {
+			start, _ = strconv.Atoi(m[1])
+			start++
+		}
+		objects := []string{}
+		for i := start; i < stored && i < start+limit; i++ {
+			objects = append(objects, fmt.Sprintf(`{"_additional": {"id": "uuid-%05d"}}`, i))
+		}
+		fmt.Fprintf(w, `{"data": {"Get": {"CodeChunks": [%s]}}}`, strings.Join(objects, ","))
+	}))
+	defer srv.Close()
+
+	client := newTestWeaviateClient(t, srv.URL, false)
+	results, err := client.Scroll(context.Background(), Filter{}, 12000)
+	if err != nil {
+		t.Fatalf("Scroll failed: %v", err)
+	}
+	if len(results) != 12000 || results[11999].ID != "uuid-11999" {
+		t.Errorf("Expected the first 12000 objects, got %d", len(results))
+	}
+	if !reflect.DeepEqual(limits, []int{weaviateMaxResults, 2000}) {
+		t.Errorf("Expected pages capped at %d, got limits %v", weaviateMaxResults, limits)
+	}
+
+	// Without a limit every object is returned
+	limits = nil
+	if results, _ = client.Scroll(context.Background(), Filter{}, 0); len(results) != stored {
+		t.Errorf("Expected all %d objects, got %d", stored, len(results))
+	}
+}
+
+func TestWeaviateClient_GraphQLErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"errors": [{"message": "no such class"}]}`)
+	}))
+	defer srv.Close()
+
+	client := newTestWeaviateClient(t, srv.URL, false)
+	if _, err := client.Count(context.Background(), Filter{}); err == nil || !strings.Contains(err.Error(), "no such class") {
+		t.Errorf("Expected GraphQL error to be returned, got %v", err)
+	}
+}
+
+func TestWeaviateClient_EnsureCollection(t *testing.T) {
+	var created weaviateClass
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/schema/CodeChunks":
+			http.NotFound(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/schema":
+			json.NewDecoder(r.Body).Decode(&created)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client := newTestWeaviateClient(t, srv.URL, false)
+	if err := client.EnsureCollection(context.Background(), 768); err != nil {
+		t.Fatalf("EnsureCollection failed: %v", err)
+	}
+	if created.Class != "CodeChunks" || created.Vectorizer != "none" {
+		t.Errorf("Unexpected class definition %+v", created)
+	}
+	if len(created.Properties) != len(weaviateProperties) {
+		t.Errorf("Expected %d properties, got %d", len(weaviateProperties), len(created.Properties))
+	}
+}
+
+func TestWeaviateClient_UpsertAndDelete(t *testing.T) {
+	var requests []string
+	var upserted weaviateBatchObjectsRequest
+	var deleted weaviateBatchDeleteRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodPost:
+			json.NewDecoder(r.Body).Decode(&upserted)
+			io.WriteString(w, `[{"id": "x", "result": {}}]`)
+		case http.MethodDelete:
+			json.NewDecoder(r.Body).Decode(&deleted)
+			io.WriteString(w, `{"results": {"matches": 1, "limit": 10000, "successful": 1}}`)
+		}
+	}))
+	defer srv.Close()
+
+	client := newTestWeaviateClient(t, srv.URL, false)
+	ctx := context.Background()
+
+	err := client.Upsert(ctx, []Point{{
+		ID:      "proj:main.go:main",
+		Vector:  []float32{0.1},
+		Payload: Payload{ProjectID: "proj", FilePath: "main.go"},
+	}})
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	obj := upserted.Objects[0]
+	if obj.Class != "CodeChunks" || obj.ID != stringToUUID("proj:main.go:main") {
+		t.Errorf("Unexpected object %+v", obj)
+	}
+	if obj.Properties["original_id"] != "proj:main.go:main" || obj.Properties["project_id"] != "proj" {
+		t.Errorf("Unexpected properties %v", obj.Properties)
+	}
+
+	if err := client.Delete(ctx, []string{"proj:main.go:main"}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	where := deleted.Match.Where
+	if deleted.Match.Class != "CodeChunks" || where.Operator != "ContainsAny" ||
+		len(where.ValueTextArray) != 1 || where.ValueTextArray[0] != stringToUUID("proj:main.go:main") {
+		t.Errorf("Unexpected delete by ID %+v", deleted.Match)
+	}
+
+	if err := client.DeleteByFilter(ctx, Filter{ProjectID: "proj"}); err != nil {
+		t.Fatalf("DeleteByFilter failed: %v", err)
+	}
+	if where := deleted.Match.Where; where.Operator != "Equal" || where.ValueText != "proj" {
+		t.Errorf("Unexpected delete by filter %+v", where)
+	}
+
+	want := []string{"POST /v1/batch/objects", "DELETE /v1/batch/objects", "DELETE /v1/batch/objects"}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
+
+func TestWeaviateClient_UpsertReportsObjectErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"id": "x", "result": {"errors": {"error": [{"message": "vector lengths don't match"}]}}}]`)
+	}))
+	defer srv.Close()
+
+	client := newTestWeaviateClient(t, srv.URL, false)
+	err := client.Upsert(context.Background(), []Point{{ID: "a", Vector: []float32{1}}})
+	if err == nil || !strings.Contains(err.Error(), "vector lengths") {
+		t.Errorf("Expected per-object error, got %v", err)
+	}
+}
+
+func TestWeaviateClient_SoftDelete(t *testing.T) {
+	var patched []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPatch:
+			var obj weaviateObject
+			json.NewDecoder(r.Body).Decode(&obj)
+			patched = append(patched, obj.Properties)
+		case r.Method == http.MethodDelete:
+			var req weaviateBatchDeleteRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			data, _ := json.Marshal(req.Match.Where)
+			if !strings.Contains(string(data), `"valueDate":"2026-01-01T00:00:00Z"`) {
+				t.Errorf("Expected purge to compare deleted_at, got %s", data)
+			}
+			io.WriteString(w, `{"results": {}}`)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client := newTestWeaviateClient(t, srv.URL, true)
+	ctx := context.Background()
+
+	if err := client.Delete(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(patched) != 2 || patched[0]["deleted"] != true || patched[0]["deleted_at"] == "" {
+		t.Errorf("Expected two tombstones, got %v", patched)
+	}
+
+	if err := client.PurgeDeleted(ctx, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("PurgeDeleted failed: %v", err)
+	}
+}